@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"backend/internal/models"
+	"backend/internal/repository"
+	"backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ArticleHandler serves the published articles
+type ArticleHandler struct {
+	articleService services.IArticleService
+}
+
+// NewArticleHandler creates a new instance of ArticleHandler
+func NewArticleHandler(articleService services.IArticleService) *ArticleHandler {
+	return &ArticleHandler{
+		articleService: articleService,
+	}
+}
+
+// HandleList handles GET /articles
+// Optional query parameters: tag, limit, offset. Articles are listed newest
+// first, without their body.
+func (h *ArticleHandler) HandleList(c *gin.Context) {
+	limit, offset := pagination(c)
+	filter := models.ArticleFilter{
+		Tag:    c.Query("tag"),
+		Limit:  limit,
+		Offset: offset,
+	}
+
+	articles, total, err := h.articleService.List(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list articles"})
+		return
+	}
+	writeCachedJSON(c, gin.H{"articles": articles, "total": total, "limit": limit, "offset": offset})
+}
+
+// HandleGet handles GET /articles/:slug
+// The article includes its rendered HTML and its headings.
+func (h *ArticleHandler) HandleGet(c *gin.Context) {
+	article, err := h.articleService.Get(c.Request.Context(), c.Param("slug"))
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Article not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get article"})
+		return
+	}
+	writeCachedJSON(c, gin.H{"article": article})
+}
+
+// HandleTags handles GET /tags
+// Lists the tags of published articles with their article counts.
+func (h *ArticleHandler) HandleTags(c *gin.Context) {
+	tags, err := h.articleService.Tags(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list tags"})
+		return
+	}
+	writeCachedJSON(c, gin.H{"tags": tags})
+}
+
+// HandleAdminList handles GET /admin/articles
+// Lists every loaded article, drafts and scheduled ones included. Same
+// query parameters as GET /articles.
+func (h *ArticleHandler) HandleAdminList(c *gin.Context) {
+	limit, offset := pagination(c)
+	filter := models.ArticleFilter{
+		Tag:    c.Query("tag"),
+		Drafts: true,
+		Limit:  limit,
+		Offset: offset,
+	}
+
+	articles, total, err := h.articleService.List(c.Request.Context(), filter)
+	if err != nil {
+		writeArticleError(c, err, "Failed to list articles")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"articles": articles, "total": total, "limit": limit, "offset": offset})
+}
+
+// HandleAdminGet handles GET /admin/articles/:slug
+// Returns the stored Markdown source of an article
+func (h *ArticleHandler) HandleAdminGet(c *gin.Context) {
+	source, err := h.articleService.Source(c.Request.Context(), c.Param("slug"))
+	if err != nil {
+		writeArticleError(c, err, "Failed to get article")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"source": source})
+}
+
+// HandleSave handles PUT /admin/articles/:slug
+// Body: {"markdown": "---\ntitle: ...\n---\n..."}; creates or replaces the article
+func (h *ArticleHandler) HandleSave(c *gin.Context) {
+	var input models.ArticleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	article, err := h.articleService.Save(c.Request.Context(), c.Param("slug"), input.Markdown)
+	if err != nil {
+		writeArticleError(c, err, "Failed to save article")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"article": article})
+}
+
+// HandlePublish handles POST /admin/articles/:slug/publish
+// Optional body: {"at": "<RFC 3339>"}; a future date schedules the article
+func (h *ArticleHandler) HandlePublish(c *gin.Context) {
+	var publication models.ArticlePublication
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&publication); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	article, err := h.articleService.Publish(c.Request.Context(), c.Param("slug"), publication)
+	if err != nil {
+		writeArticleError(c, err, "Failed to publish article")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"article": article})
+}
+
+// HandleUnpublish handles POST /admin/articles/:slug/unpublish
+func (h *ArticleHandler) HandleUnpublish(c *gin.Context) {
+	article, err := h.articleService.Unpublish(c.Request.Context(), c.Param("slug"))
+	if err != nil {
+		writeArticleError(c, err, "Failed to unpublish article")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"article": article})
+}
+
+// HandleDelete handles DELETE /admin/articles/:slug
+func (h *ArticleHandler) HandleDelete(c *gin.Context) {
+	if err := h.articleService.Delete(c.Request.Context(), c.Param("slug")); err != nil {
+		writeArticleError(c, err, "Failed to delete article")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// writeArticleError maps article service errors to HTTP statuses
+func writeArticleError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Article not found"})
+	case errors.Is(err, services.ErrReadOnlyArticles):
+		c.JSON(http.StatusConflict, gin.H{"error": "Articles are read from ARTICLES_DIR and cannot be edited here"})
+	case errors.Is(err, services.ErrInvalidArticle):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"backend/internal/models"
+	"backend/internal/repository"
+	"backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BlocklistHandler lets admins manage block rules and review automatic bans
+type BlocklistHandler struct {
+	blocklistService services.IBlocklistService
+}
+
+// NewBlocklistHandler creates a new instance of BlocklistHandler
+func NewBlocklistHandler(blocklistService services.IBlocklistService) *BlocklistHandler {
+	return &BlocklistHandler{
+		blocklistService: blocklistService,
+	}
+}
+
+// HandleList handles GET /admin/blocklist
+// Expired rules and bans are only listed with include_expired=true
+func (h *BlocklistHandler) HandleList(c *gin.Context) {
+	includeExpired := c.Query("include_expired") == "true"
+
+	rules, err := h.blocklistService.List(c.Request.Context(), includeExpired)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list block rules"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"rules": rules})
+}
+
+// HandleCreate handles POST /admin/blocklist
+// Adding a rule that exists (same kind and value) updates its reason and expiry
+func (h *BlocklistHandler) HandleCreate(c *gin.Context) {
+	var input models.BlockRuleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule, err := h.blocklistService.Create(c.Request.Context(), input)
+	if errors.Is(err, services.ErrInvalidBlockRule) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save block rule"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"rule": rule})
+}
+
+// HandleDelete handles DELETE /admin/blocklist/:id
+// Deleting an automatic ban lifts it immediately
+func (h *BlocklistHandler) HandleDelete(c *gin.Context) {
+	id, ok := idParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid rule id"})
+		return
+	}
+
+	err := h.blocklistService.Delete(c.Request.Context(), id)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Block rule not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete block rule"})
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -1,52 +1,108 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"net/http"
 
 	"backend/internal/models"
 	"backend/internal/services"
 
 	"github.com/gin-gonic/gin"
-	"github.com/go-playground/validator/v10"
 )
 
 // ContactHandler handles contact form submissions
-// This struct now includes a validator for input validation
 type ContactHandler struct {
 	contactService services.IContactService
-	validator      *validator.Validate
 }
 
 // NewContactHandler creates a new instance of ContactHandler
-// It is a constructor that initializes the email service and validator
+// It is a constructor that initializes the contact service
 func NewContactHandler(contactService services.IContactService) *ContactHandler {
 	return &ContactHandler{
 		contactService: contactService,
-		validator:      validator.New(),
 	}
 }
 
+// contactRequest is the body of POST /contact: the form, plus the page it
+// was sent from and the UTM parameters of the visitor's landing page
+type contactRequest struct {
+	models.ContactForm
+	Referrer string           `json:"referrer"`
+	UTM      models.UTMParams `json:"utm"`
+}
+
 // SendEmail handles the POST /contact endpoint
-// It validates the input and uses the email service to send the contact email
+// It normalizes and validates the input before handing it to the contact service
 func (h *ContactHandler) HandleSendContactForm(c *gin.Context) {
-	var form models.ContactForm
-
-	if err := c.ShouldBindJSON(&form); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-	if err := h.validator.Struct(form); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	req, ok := bindContactRequest(c)
+	if !ok {
 		return
 	}
 
-	if err := h.contactService.SubmitContactForm(c.Request.Context(), form); err != nil {
+	ctx := clientContext(c, req.Referrer, req.UTM)
+	if err := h.contactService.SubmitContactForm(ctx, req.ContactForm); err != nil {
 		// Ensure sensitive POST responses are not cached
 		c.Header("Cache-Control", "no-store")
+		if errors.Is(err, models.ErrInvalidContactForm) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to submit contact form"})
 		return
 	}
+	writeContactSent(c)
+}
+
+// HandleBlocked answers POST /contact for banned clients exactly like a
+// successful submission, without submitting anything, so they cannot tell
+// they are banned
+func (h *ContactHandler) HandleBlocked(c *gin.Context) {
+	if _, ok := bindContactRequest(c); !ok {
+		return
+	}
+	writeContactSent(c)
+}
+
+// bindContactRequest reads, normalizes and validates the request body,
+// writing the error response when it is invalid
+func bindContactRequest(c *gin.Context) (contactRequest, bool) {
+	var req contactRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Request body too large"})
+			return req, false
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return req, false
+	}
+	req.ContactForm.Normalize()
+	if err := req.ContactForm.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return req, false
+	}
+	return req, true
+}
+
+// clientContext attaches the metadata of the submitting client to the
+// request context. The frontend knows the form page; the Referer header is
+// the fallback.
+func clientContext(c *gin.Context, referrer string, utm models.UTMParams) context.Context {
+	if referrer == "" {
+		referrer = c.Request.Referer()
+	}
+	return services.WithClientMetadata(c.Request.Context(), models.ClientMetadata{
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		Language:  c.GetHeader("Accept-Language"),
+		Referrer:  referrer,
+		UTM:       utm,
+	})
+}
 
+func writeContactSent(c *gin.Context) {
 	// Ensure successful POST responses are not cached
 	c.Header("Cache-Control", "no-store")
 	c.JSON(http.StatusOK, gin.H{"message": "Successfully sent contact form"})
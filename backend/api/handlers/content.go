@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"backend/internal/models"
+	"backend/internal/repository"
+	"backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ContentHandler serves the admin tools shared by every editable content
+// type: revision history, scheduled publishing and preview links
+type ContentHandler struct {
+	revisions services.IRevisionService
+	restorers map[string]services.ContentRestorer
+	schedules services.IScheduleService
+	previews  *services.PreviewSigner
+	siteURL   string
+}
+
+// NewContentHandler creates a new instance of ContentHandler. Restorers
+// save revisions back, by content type; preview links are built on siteURL.
+func NewContentHandler(revisions services.IRevisionService, restorers map[string]services.ContentRestorer,
+	schedules services.IScheduleService, previews *services.PreviewSigner, siteURL string) *ContentHandler {
+	return &ContentHandler{
+		revisions: revisions,
+		restorers: restorers,
+		schedules: schedules,
+		previews:  previews,
+		siteURL:   siteURL,
+	}
+}
+
+// HandleListRevisions handles GET /admin/revisions
+// Required query parameters: content_type (project or article), content_key
+// (project id or article slug). Revisions are listed newest first, without
+// their body.
+func (h *ContentHandler) HandleListRevisions(c *gin.Context) {
+	revisions, err := h.revisions.List(c.Request.Context(), c.Query("content_type"), c.Query("content_key"))
+	if err != nil {
+		writeContentError(c, err, "Failed to list revisions")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"revisions": revisions})
+}
+
+// HandleGetRevision handles GET /admin/revisions/:id
+func (h *ContentHandler) HandleGetRevision(c *gin.Context) {
+	id, ok := idParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid revision id"})
+		return
+	}
+
+	revision, err := h.revisions.Get(c.Request.Context(), id)
+	if err != nil {
+		writeContentError(c, err, "Failed to get revision")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"revision": revision})
+}
+
+// HandleDiffRevisions handles GET /admin/revisions/diff?from=<id>&to=<id>
+// Both revisions must belong to the same content item
+func (h *ContentHandler) HandleDiffRevisions(c *gin.Context) {
+	from, errFrom := strconv.ParseInt(c.Query("from"), 10, 64)
+	to, errTo := strconv.ParseInt(c.Query("to"), 10, 64)
+	if errFrom != nil || errTo != nil || from <= 0 || to <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from and to must be revision ids"})
+		return
+	}
+
+	diff, err := h.revisions.Diff(c.Request.Context(), from, to)
+	if err != nil {
+		writeContentError(c, err, "Failed to compare revisions")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"diff": diff})
+}
+
+// HandleRestoreRevision handles POST /admin/revisions/:id/restore
+// Saves the revision as the current content, which records a new revision.
+// Optional body: {"version": 3}, checked against the current project version.
+func (h *ContentHandler) HandleRestoreRevision(c *gin.Context) {
+	id, ok := idParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid revision id"})
+		return
+	}
+	var body models.RevisionRestore
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	revision, err := h.revisions.Get(c.Request.Context(), id)
+	if err != nil {
+		writeContentError(c, err, "Failed to restore revision")
+		return
+	}
+	restorer := h.restorers[revision.ContentType]
+	if restorer == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Revisions of this content cannot be restored"})
+		return
+	}
+	if err := restorer.RestoreRevision(c.Request.Context(), revision, body.Version); err != nil {
+		writeContentError(c, err, "Failed to restore revision")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// HandleListSchedules handles GET /admin/schedules
+// Optional query parameters: content_type, content_key, pending (true to
+// hide schedules that already ran), limit, offset.
+func (h *ContentHandler) HandleListSchedules(c *gin.Context) {
+	limit, offset := pagination(c)
+	filter := models.ScheduleFilter{
+		ContentType: c.Query("content_type"),
+		ContentKey:  c.Query("content_key"),
+		Pending:     c.Query("pending") == "true",
+		Limit:       limit,
+		Offset:      offset,
+	}
+
+	schedules, total, err := h.schedules.List(c.Request.Context(), filter)
+	if err != nil {
+		writeContentError(c, err, "Failed to list schedules")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"schedules": schedules, "total": total, "limit": limit, "offset": offset})
+}
+
+// HandleCreateSchedule handles POST /admin/schedules
+// Body: {"content_type": "article", "content_key": "proxmox", "action": "unpublish", "run_at": "<RFC 3339>"}
+func (h *ContentHandler) HandleCreateSchedule(c *gin.Context) {
+	var input models.Schedule
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	schedule, err := h.schedules.Schedule(c.Request.Context(), models.Schedule{
+		ContentType: input.ContentType,
+		ContentKey:  input.ContentKey,
+		Action:      input.Action,
+		RunAt:       input.RunAt,
+	})
+	if err != nil {
+		writeContentError(c, err, "Failed to create schedule")
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"schedule": schedule})
+}
+
+// HandleDeleteSchedule handles DELETE /admin/schedules/:id
+// Only schedules that did not run yet can be cancelled
+func (h *ContentHandler) HandleDeleteSchedule(c *gin.Context) {
+	id, ok := idParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid schedule id"})
+		return
+	}
+
+	if err := h.schedules.Cancel(c.Request.Context(), id); err != nil {
+		writeContentError(c, err, "Failed to delete schedule")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// HandleCreatePreview handles POST /admin/previews
+// Body: {"content_type": "project", "content_key": "12"}; returns a signed
+// link showing the content even while unpublished, until expires_at
+func (h *ContentHandler) HandleCreatePreview(c *gin.Context) {
+	var body struct {
+		ContentType string `json:"content_type" binding:"required"`
+		ContentKey  string `json:"content_key" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !slices.Contains(models.DraftContentTypes, body.ContentType) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown content type"})
+		return
+	}
+
+	path, expires, err := h.previews.Path(body.ContentType, body.ContentKey, time.Now())
+	if errors.Is(err, services.ErrPreviewDisabled) {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Preview links are disabled, set PREVIEW_SECRET"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create preview link"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"url": h.siteURL + path, "expires_at": expires})
+}
+
+// writeContentError maps revision, schedule and content errors to HTTP statuses
+func writeContentError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrInvalidRevision), errors.Is(err, services.ErrInvalidSchedule), errors.Is(err, services.ErrInvalidProfile):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, repository.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
+	case errors.Is(err, repository.ErrVersionConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": "Content was modified since this version, reload it and try again"})
+	case errors.Is(err, repository.ErrSlugTaken), errors.Is(err, services.ErrInvalidProject):
+		writeProjectError(c, err, fallback)
+	case errors.Is(err, services.ErrInvalidArticle), errors.Is(err, services.ErrReadOnlyArticles):
+		writeArticleError(c, err, fallback)
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
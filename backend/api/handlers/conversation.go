@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"backend/internal/models"
+	"backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ConversationHandler lets admins reply to submissions and read the history
+type ConversationHandler struct {
+	conversationService services.IConversationService
+}
+
+// NewConversationHandler creates a new instance of ConversationHandler
+func NewConversationHandler(conversationService services.IConversationService) *ConversationHandler {
+	return &ConversationHandler{
+		conversationService: conversationService,
+	}
+}
+
+// HandleReply handles POST /admin/contacts/:id/replies
+// The reply is emailed to the visitor and stored in the conversation
+func (h *ConversationHandler) HandleReply(c *gin.Context) {
+	id, ok := idParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contact id"})
+		return
+	}
+	var req models.ReplyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	message, err := h.conversationService.Reply(c.Request.Context(), id, req)
+	if errors.Is(err, services.ErrReplyNotSent) {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Failed to send reply"})
+		return
+	}
+	if err != nil {
+		writeInboxError(c, err, "Failed to send reply")
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": message})
+}
+
+// HandleListMessages handles GET /admin/contacts/:id/messages
+func (h *ConversationHandler) HandleListMessages(c *gin.Context) {
+	id, ok := idParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contact id"})
+		return
+	}
+
+	messages, err := h.conversationService.Messages(c.Request.Context(), id)
+	if err != nil {
+		writeInboxError(c, err, "Failed to list messages")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"messages": messages})
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// writeCachedJSON writes body as JSON with an ETag derived from its content.
+// Clients revalidate on every use (no-cache) and get 304 Not Modified while
+// the content is unchanged.
+func writeCachedJSON(c *gin.Context, body any) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encode response"})
+		return
+	}
+	writeCached(c, "application/json; charset=utf-8", data)
+}
+
+// writeCached is writeCachedJSON for an already encoded body
+func writeCached(c *gin.Context, contentType string, data []byte) {
+	sum := sha256.Sum256(data)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "public, no-cache")
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, contentType, data)
+}
+
+// etagMatches applies the weak comparison of If-None-Match (RFC 9110 13.1.2)
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
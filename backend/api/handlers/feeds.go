@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FeedHandler serves the sitemap, the Atom and RSS feeds and robots.txt
+type FeedHandler struct {
+	feedService services.IFeedService
+}
+
+// NewFeedHandler creates a new instance of FeedHandler
+func NewFeedHandler(feedService services.IFeedService) *FeedHandler {
+	return &FeedHandler{
+		feedService: feedService,
+	}
+}
+
+// HandleSitemap handles GET /sitemap.xml
+func (h *FeedHandler) HandleSitemap(c *gin.Context) {
+	h.write(c, "application/xml; charset=utf-8", h.feedService.Sitemap)
+}
+
+// HandleAtom handles GET /feed.atom
+func (h *FeedHandler) HandleAtom(c *gin.Context) {
+	h.write(c, "application/atom+xml; charset=utf-8", h.feedService.Atom)
+}
+
+// HandleRSS handles GET /feed.rss
+func (h *FeedHandler) HandleRSS(c *gin.Context) {
+	h.write(c, "application/rss+xml; charset=utf-8", h.feedService.RSS)
+}
+
+// HandleRobots handles GET /robots.txt
+func (h *FeedHandler) HandleRobots(c *gin.Context) {
+	writeCached(c, "text/plain; charset=utf-8", h.feedService.Robots())
+}
+
+func (h *FeedHandler) write(c *gin.Context, contentType string, generate func(context.Context) ([]byte, error)) {
+	data, err := generate(c.Request.Context())
+	if err != nil {
+		log.Printf("Error generating %s: %v", c.Request.URL.Path, err)
+		c.String(http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	writeCached(c, contentType, data)
+}
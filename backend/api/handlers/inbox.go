@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"backend/internal/models"
+	"backend/internal/repository"
+	"backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// InboxHandler exposes the stored submissions to admins
+type InboxHandler struct {
+	inboxService services.IInboxService
+}
+
+// NewInboxHandler creates a new instance of InboxHandler
+func NewInboxHandler(inboxService services.IInboxService) *InboxHandler {
+	return &InboxHandler{
+		inboxService: inboxService,
+	}
+}
+
+// HandleList handles GET /admin/contacts
+// Optional query parameters: q, status, tag, assignee, ip_hash, from, to, limit, offset.
+// With q the results are ranked by relevance and carry a highlighted snippet.
+func (h *InboxHandler) HandleList(c *gin.Context) {
+	limit, offset := pagination(c)
+	filter := models.ContactFilter{
+		Status:   c.Query("status"),
+		Tag:      c.Query("tag"),
+		Assignee: c.Query("assignee"),
+		IPHash:   c.Query("ip_hash"),
+		Query:    c.Query("q"),
+		Limit:    limit,
+		Offset:   offset,
+	}
+	var ok bool
+	if filter.Since, ok = dateParam(c, "from"); !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from date"})
+		return
+	}
+	if filter.Until, ok = dateParam(c, "to"); !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to date"})
+		return
+	}
+
+	if filter.Query != "" {
+		results, err := h.inboxService.Search(c.Request.Context(), filter)
+		if err != nil {
+			writeInboxError(c, err, "Failed to search contacts")
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"contacts": results, "limit": limit, "offset": offset})
+		return
+	}
+
+	submissions, err := h.inboxService.List(c.Request.Context(), filter)
+	if err != nil {
+		writeInboxError(c, err, "Failed to list contacts")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"contacts": submissions, "limit": limit, "offset": offset})
+}
+
+// HandleSources handles GET /admin/contacts/sources
+// Query parameters: by (referrer, utm_source, utm_medium, utm_campaign,
+// language, ip_hash, country or as_org), from, to, limit.
+func (h *InboxHandler) HandleSources(c *gin.Context) {
+	limit, _ := pagination(c)
+	filter := models.SourceFilter{By: c.Query("by"), Limit: limit}
+	var ok bool
+	if filter.Since, ok = dateParam(c, "from"); !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from date"})
+		return
+	}
+	if filter.Until, ok = dateParam(c, "to"); !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to date"})
+		return
+	}
+
+	sources, err := h.inboxService.Sources(c.Request.Context(), filter)
+	if err != nil {
+		writeInboxError(c, err, "Failed to count sources")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"by": filter.By, "sources": sources})
+}
+
+// HandleGet handles GET /admin/contacts/:id
+func (h *InboxHandler) HandleGet(c *gin.Context) {
+	id, ok := idParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contact id"})
+		return
+	}
+
+	detail, err := h.inboxService.Get(c.Request.Context(), id)
+	if err != nil {
+		writeInboxError(c, err, "Failed to load contact")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"contact": detail})
+}
+
+// HandleUpdate handles PATCH /admin/contacts/:id
+// Only the fields present in the body are changed
+func (h *InboxHandler) HandleUpdate(c *gin.Context) {
+	id, ok := idParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contact id"})
+		return
+	}
+	var update models.ContactUpdate
+	if err := c.ShouldBindJSON(&update); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	detail, err := h.inboxService.Update(c.Request.Context(), id, update)
+	if err != nil {
+		writeInboxError(c, err, "Failed to update contact")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"contact": detail})
+}
+
+// HandleDelete handles DELETE /admin/contacts/:id
+// The submission is moved to the trash and purged after the retention window
+func (h *InboxHandler) HandleDelete(c *gin.Context) {
+	id, ok := idParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contact id"})
+		return
+	}
+
+	submission, err := h.inboxService.Trash(c.Request.Context(), id)
+	if err != nil {
+		writeInboxError(c, err, "Failed to delete contact")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"contact": submission})
+}
+
+// HandleAddNote handles POST /admin/contacts/:id/notes
+func (h *InboxHandler) HandleAddNote(c *gin.Context) {
+	id, ok := idParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid contact id"})
+		return
+	}
+	var body struct {
+		Author string `json:"author"`
+		Body   string `json:"body" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	note, err := h.inboxService.AddNote(c.Request.Context(), id, body.Author, body.Body)
+	if err != nil {
+		writeInboxError(c, err, "Failed to add note")
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"note": note})
+}
+
+// HandleBulkUpdate handles POST /admin/contacts/bulk
+// Per-id failures are reported in the response, not as an error status
+func (h *InboxHandler) HandleBulkUpdate(c *gin.Context) {
+	var bulk models.ContactBulkUpdate
+	if err := c.ShouldBindJSON(&bulk); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	result, err := h.inboxService.BulkUpdate(c.Request.Context(), bulk)
+	if err != nil {
+		writeInboxError(c, err, "Failed to update contacts")
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// writeInboxError maps inbox service errors to HTTP statuses
+func writeInboxError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Contact not found"})
+	case errors.Is(err, services.ErrInvalidTransition):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidUpdate):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"backend/internal/media"
+	"backend/internal/repository"
+	"backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MediaHandler serves the media library and its files
+type MediaHandler struct {
+	mediaService services.IMediaService
+}
+
+// NewMediaHandler creates a new instance of MediaHandler
+func NewMediaHandler(mediaService services.IMediaService) *MediaHandler {
+	return &MediaHandler{
+		mediaService: mediaService,
+	}
+}
+
+// HandleUpload handles POST /admin/media
+// Body: multipart/form-data with the file in the "file" field. Returns 201
+// with the stored file, or 200 with the existing one when the same content
+// was already uploaded.
+func (h *MediaHandler) HandleUpload(c *gin.Context) {
+	header, err := c.FormFile("file")
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Request body too large"})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing file field"})
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unreadable file"})
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unreadable file"})
+		return
+	}
+
+	m, created, err := h.mediaService.Upload(c.Request.Context(), header.Filename, data)
+	if err != nil {
+		writeMediaError(c, err, "Failed to store file")
+		return
+	}
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	c.JSON(status, gin.H{"media": m})
+}
+
+// HandleList handles GET /admin/media
+// Optional query parameters: limit, offset. Files are listed newest first.
+func (h *MediaHandler) HandleList(c *gin.Context) {
+	limit, offset := pagination(c)
+	list, total, err := h.mediaService.List(c.Request.Context(), limit, offset)
+	if err != nil {
+		writeMediaError(c, err, "Failed to list media")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"media": list, "total": total, "limit": limit, "offset": offset})
+}
+
+// HandleGet handles GET /admin/media/:id
+func (h *MediaHandler) HandleGet(c *gin.Context) {
+	id, ok := idParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid media id"})
+		return
+	}
+
+	m, err := h.mediaService.Get(c.Request.Context(), id)
+	if err != nil {
+		writeMediaError(c, err, "Failed to get media")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"media": m})
+}
+
+// HandleDelete handles DELETE /admin/media/:id
+// Removes the file and its variants; pages still linking to them get 404s
+func (h *MediaHandler) HandleDelete(c *gin.Context) {
+	id, ok := idParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid media id"})
+		return
+	}
+
+	if err := h.mediaService.Delete(c.Request.Context(), id); err != nil {
+		writeMediaError(c, err, "Failed to delete media")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// HandleServe handles GET /media/:name
+// Names contain the content hash, so files are cached for a year as
+// immutable. Range requests are supported.
+func (h *MediaHandler) HandleServe(c *gin.Context) {
+	f, contentType, err := h.mediaService.Open(c.Param("name"))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read file"})
+		return
+	}
+	defer f.Close()
+
+	c.Header("Content-Type", contentType)
+	c.Header("Cache-Control", "public, max-age=31536000, immutable")
+	http.ServeContent(c.Writer, c.Request, "", time.Time{}, f)
+}
+
+// writeMediaError maps media errors to HTTP statuses
+func writeMediaError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Media not found"})
+	case errors.Is(err, media.ErrTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+	case errors.Is(err, media.ErrUnsupportedType):
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+	case errors.Is(err, media.ErrInvalidFile):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
@@ -0,0 +1,478 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"backend/internal/models"
+	"backend/internal/repository"
+	"backend/internal/services"
+	"backend/internal/site"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// featuredProjects is the number of projects shown on the home page
+	featuredProjects = 3
+	// projectsPerPage is the page size of the project list page
+	projectsPerPage = 12
+	// articlesPerPage is the page size of the article list page
+	articlesPerPage = 10
+)
+
+// PageHandler renders the public pages of the site server-side
+type PageHandler struct {
+	renderer       *site.Renderer
+	projectService services.IProjectService
+	articleService services.IArticleService
+	profileService services.IProfileService
+	contactService services.IContactService
+	privacyService services.IPrivacyService
+	previews       *services.PreviewSigner
+}
+
+// NewPageHandler creates a new instance of PageHandler. previews checks the
+// links of GET /preview.
+func NewPageHandler(renderer *site.Renderer, projectService services.IProjectService, articleService services.IArticleService,
+	profileService services.IProfileService, contactService services.IContactService, privacyService services.IPrivacyService,
+	previews *services.PreviewSigner) *PageHandler {
+	return &PageHandler{
+		renderer:       renderer,
+		projectService: projectService,
+		articleService: articleService,
+		profileService: profileService,
+		contactService: contactService,
+		privacyService: privacyService,
+		previews:       previews,
+	}
+}
+
+// HandleIndex handles GET /
+func (h *PageHandler) HandleIndex(c *gin.Context) {
+	projects, _, err := h.projectService.List(c.Request.Context(), models.ProjectFilter{Limit: featuredProjects})
+	if err != nil {
+		h.renderError(c, err)
+		return
+	}
+	profile, err := h.profileService.Get(c.Request.Context())
+	if err != nil {
+		h.renderError(c, err)
+		return
+	}
+	h.render(c, http.StatusOK, site.PageIndex, site.Page{
+		Path: "/",
+		Nav:  site.PageIndex,
+		Data: site.IndexData{Projects: projects, Skills: profile.Resume.Skills},
+	})
+}
+
+// HandleProjects handles GET /projects
+// Optional query parameters: category, technology, offset.
+func (h *PageHandler) HandleProjects(c *gin.Context) {
+	offset, err := strconv.Atoi(c.Query("offset"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+	filter := models.ProjectFilter{
+		Technology: c.Query("technology"),
+		Category:   c.Query("category"),
+		Limit:      projectsPerPage,
+		Offset:     offset,
+	}
+
+	projects, total, err := h.projectService.List(c.Request.Context(), filter)
+	if errors.Is(err, services.ErrInvalidProjectFilter) {
+		h.renderNotFound(c)
+		return
+	}
+	if err != nil {
+		h.renderError(c, err)
+		return
+	}
+	technologies, err := h.projectService.Technologies(c.Request.Context())
+	if err != nil {
+		h.renderError(c, err)
+		return
+	}
+
+	data := site.ProjectsData{
+		Projects:     projects,
+		Total:        total,
+		Categories:   site.Categories,
+		Technologies: technologies,
+		Category:     c.Query("category"),
+		Technology:   c.Query("technology"),
+	}
+	if offset > 0 {
+		data.PrevURL = projectsPageURL(filter, max(offset-projectsPerPage, 0))
+	}
+	if offset+len(projects) < total {
+		data.NextURL = projectsPageURL(filter, offset+projectsPerPage)
+	}
+	h.render(c, http.StatusOK, site.PageProjects, site.Page{
+		Title: "Projets",
+		Path:  "/projects",
+		Nav:   site.PageProjects,
+		Data:  data,
+	})
+}
+
+// HandleProject handles GET /projects/:slug
+func (h *PageHandler) HandleProject(c *gin.Context) {
+	project, err := h.projectService.Get(c.Request.Context(), c.Param("slug"))
+	if errors.Is(err, repository.ErrNotFound) {
+		h.renderNotFound(c)
+		return
+	}
+	if err != nil {
+		h.renderError(c, err)
+		return
+	}
+	h.render(c, http.StatusOK, site.PageProject, site.Page{
+		Title:       project.Title,
+		Description: project.Summary,
+		Path:        "/projects/" + project.Slug,
+		Nav:         site.PageProjects,
+		Data:        site.ProjectData{Project: project},
+	})
+}
+
+// HandleArticles handles GET /articles
+// Optional query parameters: tag, offset.
+func (h *PageHandler) HandleArticles(c *gin.Context) {
+	offset, err := strconv.Atoi(c.Query("offset"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+	filter := models.ArticleFilter{Tag: c.Query("tag"), Limit: articlesPerPage, Offset: offset}
+
+	articles, total, err := h.articleService.List(c.Request.Context(), filter)
+	if err != nil {
+		h.renderError(c, err)
+		return
+	}
+	if filter.Tag != "" && total == 0 {
+		h.renderNotFound(c)
+		return
+	}
+	tags, err := h.articleService.Tags(c.Request.Context())
+	if err != nil {
+		h.renderError(c, err)
+		return
+	}
+
+	data := site.ArticlesData{Articles: articles, Total: total, Tags: tags, Tag: filter.Tag}
+	if offset > 0 {
+		data.PrevURL = articlesPageURL(filter.Tag, max(offset-articlesPerPage, 0))
+	}
+	if offset+len(articles) < total {
+		data.NextURL = articlesPageURL(filter.Tag, offset+articlesPerPage)
+	}
+	h.render(c, http.StatusOK, site.PageArticles, site.Page{
+		Title: "Articles",
+		Path:  "/articles",
+		Nav:   site.PageArticles,
+		Data:  data,
+	})
+}
+
+// HandleArticle handles GET /articles/:slug
+func (h *PageHandler) HandleArticle(c *gin.Context) {
+	article, err := h.articleService.Get(c.Request.Context(), c.Param("slug"))
+	if errors.Is(err, repository.ErrNotFound) {
+		h.renderNotFound(c)
+		return
+	}
+	if err != nil {
+		h.renderError(c, err)
+		return
+	}
+	h.render(c, http.StatusOK, site.PageArticle, site.Page{
+		Title:       article.Title,
+		Description: article.Summary,
+		Path:        "/articles/" + article.Slug,
+		Nav:         site.PageArticles,
+		Data:        site.ArticleData{Article: article},
+	})
+}
+
+// HandlePreview handles GET /preview/:type/:key?expires=...&sig=...
+// Shows a project (by id) or an article (by slug), published or not, to
+// holders of a signed link. Invalid or expired links get the 404 page.
+func (h *PageHandler) HandlePreview(c *gin.Context) {
+	contentType, key := c.Param("type"), c.Param("key")
+	c.Header("X-Robots-Tag", "noindex, nofollow")
+	if !h.previews.Verify(contentType, key, c.Query("expires"), c.Query("sig"), time.Now()) {
+		h.renderNotFound(c)
+		return
+	}
+
+	var name string
+	var page site.Page
+	var err error
+	switch contentType {
+	case models.ContentProject:
+		var project *models.Project
+		id, parseErr := strconv.ParseInt(key, 10, 64)
+		if parseErr != nil {
+			h.renderNotFound(c)
+			return
+		}
+		if project, err = h.projectService.GetByID(c.Request.Context(), id); err == nil {
+			name = site.PageProject
+			page = site.Page{Title: project.Title, Description: project.Summary, Path: "/projects/" + project.Slug,
+				Nav: site.PageProjects, Data: site.ProjectData{Project: project}}
+		}
+	case models.ContentArticle:
+		var article *models.Article
+		if article, err = h.articleService.Preview(c.Request.Context(), key); err == nil {
+			name = site.PageArticle
+			page = site.Page{Title: article.Title, Description: article.Summary, Path: "/articles/" + article.Slug,
+				Nav: site.PageArticles, Data: site.ArticleData{Article: article}}
+		}
+	default:
+		err = repository.ErrNotFound
+	}
+	if errors.Is(err, repository.ErrNotFound) {
+		h.renderNotFound(c)
+		return
+	}
+	if err != nil {
+		h.renderError(c, err)
+		return
+	}
+
+	// Previews are private: never stored by shared caches, never revalidated
+	page.Preview = true
+	var buf bytes.Buffer
+	if err := h.renderer.Render(&buf, name, page); err != nil {
+		h.renderError(c, err)
+		return
+	}
+	c.Header("Cache-Control", "private, no-store")
+	c.Data(http.StatusOK, "text/html; charset=utf-8", buf.Bytes())
+}
+
+// HandleAbout handles GET /about, rendered from the profile
+func (h *PageHandler) HandleAbout(c *gin.Context) {
+	profile, err := h.profileService.Get(c.Request.Context())
+	if err != nil {
+		h.renderError(c, err)
+		return
+	}
+	h.render(c, http.StatusOK, site.PageAbout, site.Page{
+		Title:       "À propos",
+		Description: profile.Resume.Basics.Label,
+		Path:        "/about",
+		Nav:         site.PageAbout,
+		Data:        site.AboutData{Resume: profile.Resume, CVURL: CVPath},
+	})
+}
+
+// HandleContact handles GET /contact
+// ?sent=1 shows the confirmation of a submission.
+func (h *PageHandler) HandleContact(c *gin.Context) {
+	h.renderContact(c, http.StatusOK, site.ContactData{Sent: c.Query("sent") == "1"})
+}
+
+// HandleContactSubmit handles POST /contact, the form of the contact page
+// posted without JavaScript. A valid submission redirects to the
+// confirmation (Post/Redirect/Get); an invalid one shows the form again.
+func (h *PageHandler) HandleContactSubmit(c *gin.Context) {
+	form, ok := h.bindContactForm(c)
+	if !ok {
+		return
+	}
+
+	err := h.contactService.SubmitContactForm(clientContext(c, "", models.UTMParams{}), form)
+	if errors.Is(err, models.ErrInvalidContactForm) {
+		h.renderContact(c, http.StatusBadRequest, site.ContactData{Form: form, Error: err.Error()})
+		return
+	}
+	if err != nil {
+		log.Printf("Error submitting contact page form: %v", err)
+		h.renderContact(c, http.StatusInternalServerError, site.ContactData{
+			Form:  form,
+			Error: "L'envoi a échoué, merci de réessayer dans quelques instants.",
+		})
+		return
+	}
+	c.Redirect(http.StatusSeeOther, "/contact?sent=1")
+}
+
+// HandleContactBlocked answers POST /contact for banned clients exactly like
+// a successful submission, without submitting anything
+func (h *PageHandler) HandleContactBlocked(c *gin.Context) {
+	if _, ok := h.bindContactForm(c); !ok {
+		return
+	}
+	c.Redirect(http.StatusSeeOther, "/contact?sent=1")
+}
+
+// HandlePrivacy handles GET /privacy?token=..., the page of privacy
+// verification links: it offers the export downloads and the erasure form.
+// ?erased=1 shows the confirmation of an erasure.
+func (h *PageHandler) HandlePrivacy(c *gin.Context) {
+	if c.Query("erased") == "1" {
+		h.renderPrivacy(c, http.StatusOK, site.PrivacyData{Erased: true})
+		return
+	}
+	token := c.Query("token")
+	err := h.privacyService.CheckToken(c.Request.Context(), token)
+	if errors.Is(err, services.ErrInvalidPrivacyToken) {
+		h.renderPrivacy(c, http.StatusGone, site.PrivacyData{Error: privacyLinkExpired})
+		return
+	}
+	if err != nil {
+		h.renderError(c, err)
+		return
+	}
+	h.renderPrivacy(c, http.StatusOK, site.PrivacyData{Token: token})
+}
+
+// HandlePrivacyErase handles POST /privacy, the erasure form of the privacy
+// page. The token is consumed and the visitor redirected to the confirmation.
+func (h *PageHandler) HandlePrivacyErase(c *gin.Context) {
+	token := c.PostForm("token")
+	if c.PostForm("confirm") != "1" {
+		h.renderPrivacy(c, http.StatusBadRequest, site.PrivacyData{Token: token, Error: "Cochez la case pour confirmer la suppression."})
+		return
+	}
+
+	_, err := h.privacyService.EraseByToken(c.Request.Context(), token)
+	if errors.Is(err, services.ErrInvalidPrivacyToken) {
+		h.renderPrivacy(c, http.StatusGone, site.PrivacyData{Error: privacyLinkExpired})
+		return
+	}
+	if err != nil {
+		log.Printf("Error erasing privacy data: %v", err)
+		h.renderPrivacy(c, http.StatusInternalServerError, site.PrivacyData{
+			Token: token,
+			Error: "La suppression a échoué, merci de réessayer dans quelques instants.",
+		})
+		return
+	}
+	c.Redirect(http.StatusSeeOther, "/privacy?erased=1")
+}
+
+// privacyLinkExpired is shown for unknown, used or expired verification links
+const privacyLinkExpired = "Ce lien est invalide, a déjà servi à une suppression ou a expiré. Faites une nouvelle demande pour en recevoir un autre."
+
+// renderPrivacy writes the privacy page. It carries a personal token: it is
+// neither cached nor indexed.
+func (h *PageHandler) renderPrivacy(c *gin.Context, status int, data site.PrivacyData) {
+	var buf bytes.Buffer
+	err := h.renderer.Render(&buf, site.PagePrivacy, site.Page{
+		Title: "Vos données",
+		Path:  "/privacy",
+		Data:  data,
+	})
+	if err != nil {
+		h.renderError(c, err)
+		return
+	}
+	c.Header("Cache-Control", "private, no-store")
+	c.Header("X-Robots-Tag", "noindex, nofollow")
+	c.Data(status, "text/html; charset=utf-8", buf.Bytes())
+}
+
+// bindContactForm reads, normalizes and validates the urlencoded form,
+// rendering the form again with the error when it is invalid
+func (h *PageHandler) bindContactForm(c *gin.Context) (models.ContactForm, bool) {
+	var form models.ContactForm
+	var maxErr *http.MaxBytesError
+	if err := c.Request.ParseForm(); errors.As(err, &maxErr) {
+		h.renderContact(c, http.StatusRequestEntityTooLarge, site.ContactData{Error: "Le message est trop long."})
+		return form, false
+	}
+	form = models.ContactForm{
+		Name:    c.PostForm("name"),
+		Email:   c.PostForm("email"),
+		Subject: c.PostForm("subject"),
+		Message: c.PostForm("message"),
+	}
+
+	form.Normalize()
+	if err := form.Validate(); err != nil {
+		h.renderContact(c, http.StatusBadRequest, site.ContactData{
+			Form:  form,
+			Error: "Le formulaire est incomplet ou invalide : " + err.Error(),
+		})
+		return form, false
+	}
+	return form, true
+}
+
+func (h *PageHandler) renderContact(c *gin.Context, status int, data site.ContactData) {
+	data.Subjects = site.Subjects
+	h.render(c, status, site.PageContact, site.Page{
+		Title: "Contact",
+		Path:  "/contact",
+		Nav:   site.PageContact,
+		Data:  data,
+	})
+}
+
+func (h *PageHandler) renderNotFound(c *gin.Context) {
+	h.render(c, http.StatusNotFound, site.PageNotFound, site.Page{Title: "Page introuvable", Path: c.Request.URL.Path})
+}
+
+func (h *PageHandler) renderError(c *gin.Context, err error) {
+	log.Printf("Error loading page %s: %v", c.Request.URL.Path, err)
+	c.String(http.StatusInternalServerError, "Internal server error")
+}
+
+// render writes a page. Successful GET pages carry an ETag like the JSON
+// API; error pages and form responses are written as is.
+func (h *PageHandler) render(c *gin.Context, status int, name string, page site.Page) {
+	var buf bytes.Buffer
+	if err := h.renderer.Render(&buf, name, page); err != nil {
+		h.renderError(c, err)
+		return
+	}
+	const contentType = "text/html; charset=utf-8"
+	if status == http.StatusOK && c.Request.Method == http.MethodGet {
+		writeCached(c, contentType, buf.Bytes())
+		return
+	}
+	c.Data(status, contentType, buf.Bytes())
+}
+
+// projectsPageURL is the link to another page of the filtered project list
+func projectsPageURL(filter models.ProjectFilter, offset int) string {
+	query := url.Values{}
+	if filter.Category != "" {
+		query.Set("category", filter.Category)
+	}
+	if filter.Technology != "" {
+		query.Set("technology", filter.Technology)
+	}
+	if offset > 0 {
+		query.Set("offset", strconv.Itoa(offset))
+	}
+	if len(query) == 0 {
+		return "/projects"
+	}
+	return "/projects?" + query.Encode()
+}
+
+// articlesPageURL is the link to another page of the article list
+func articlesPageURL(tag string, offset int) string {
+	query := url.Values{}
+	if tag != "" {
+		query.Set("tag", tag)
+	}
+	if offset > 0 {
+		query.Set("offset", strconv.Itoa(offset))
+	}
+	if len(query) == 0 {
+		return "/articles"
+	}
+	return "/articles?" + query.Encode()
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 200
+)
+
+// pagination reads the limit/offset query parameters, clamping them to sane values
+func pagination(c *gin.Context) (limit, offset int) {
+	limit, err := strconv.Atoi(c.Query("limit"))
+	if err != nil || limit <= 0 {
+		limit = defaultPageLimit
+	}
+	if limit > maxPageLimit {
+		limit = maxPageLimit
+	}
+	offset, err = strconv.Atoi(c.Query("offset"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+	return limit, offset
+}
+
+// idParam parses a positive integer path parameter
+func idParam(c *gin.Context, name string) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param(name), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, false
+	}
+	return id, true
+}
+
+// dateParam parses an optional RFC 3339 timestamp or YYYY-MM-DD date (UTC midnight)
+func dateParam(c *gin.Context, name string) (*time.Time, bool) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, true
+	}
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"backend/internal/models"
+	"backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PrivacyHandler exposes the GDPR access and erasure rights to visitors
+// (through emailed verification links) and to admins
+type PrivacyHandler struct {
+	privacyService services.IPrivacyService
+}
+
+// NewPrivacyHandler creates a new instance of PrivacyHandler
+func NewPrivacyHandler(privacyService services.IPrivacyService) *PrivacyHandler {
+	return &PrivacyHandler{
+		privacyService: privacyService,
+	}
+}
+
+// HandleRequest handles POST /privacy/requests
+// The answer is the same whether or not data is stored for the address
+func (h *PrivacyHandler) HandleRequest(c *gin.Context) {
+	var body struct {
+		Email string `json:"email" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	if err := h.privacyService.RequestAccess(c.Request.Context(), body.Email); err != nil {
+		writePrivacyError(c, err, "Failed to process privacy request")
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": "If data is stored for this address, a verification link has been sent to it"})
+}
+
+// HandleExport handles GET /privacy/export?token=&format=json|zip
+func (h *PrivacyHandler) HandleExport(c *gin.Context) {
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "zip" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format: use json or zip"})
+		return
+	}
+
+	export, err := h.privacyService.ExportByToken(c.Request.Context(), c.Query("token"))
+	if err != nil {
+		writePrivacyError(c, err, "Failed to export data")
+		return
+	}
+	writeExport(c, export, format)
+}
+
+// HandleErase handles POST /privacy/erase
+// The token is consumed: the link cannot be used afterwards
+func (h *PrivacyHandler) HandleErase(c *gin.Context) {
+	var body struct {
+		Token string `json:"token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	count, err := h.privacyService.EraseByToken(c.Request.Context(), body.Token)
+	if err != nil {
+		writePrivacyError(c, err, "Failed to erase data")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"erased": count})
+}
+
+// HandleAdminExport handles GET /admin/privacy/export?email=&format=json|zip
+func (h *PrivacyHandler) HandleAdminExport(c *gin.Context) {
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "zip" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format: use json or zip"})
+		return
+	}
+
+	export, err := h.privacyService.Export(c.Request.Context(), c.Query("email"), adminActor(c.Query("actor")))
+	if err != nil {
+		writePrivacyError(c, err, "Failed to export data")
+		return
+	}
+	writeExport(c, export, format)
+}
+
+// HandleAdminErase handles POST /admin/privacy/erase
+func (h *PrivacyHandler) HandleAdminErase(c *gin.Context) {
+	var body struct {
+		Email string `json:"email" binding:"required"`
+		Actor string `json:"actor"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	count, err := h.privacyService.Erase(c.Request.Context(), body.Email, adminActor(body.Actor))
+	if err != nil {
+		writePrivacyError(c, err, "Failed to erase data")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"erased": count})
+}
+
+// HandleAuditLog handles GET /admin/privacy/audit
+func (h *PrivacyHandler) HandleAuditLog(c *gin.Context) {
+	limit, offset := pagination(c)
+	entries, err := h.privacyService.AuditLog(c.Request.Context(), limit, offset)
+	if err != nil {
+		writePrivacyError(c, err, "Failed to list privacy audit log")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"entries": entries, "limit": limit, "offset": offset})
+}
+
+// writeExport sends the export as a JSON or ZIP attachment
+func writeExport(c *gin.Context, export *models.PrivacyExport, format string) {
+	name := "personal-data-" + export.GeneratedAt.Format("20060102")
+	c.Header("Cache-Control", "no-store")
+	if format == "zip" {
+		var buf bytes.Buffer
+		if err := services.WritePrivacyArchive(&buf, export); err != nil {
+			log.Printf("Error building privacy archive: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export data"})
+			return
+		}
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.zip"`, name))
+		c.Data(http.StatusOK, "application/zip", buf.Bytes())
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.json"`, name))
+	c.IndentedJSON(http.StatusOK, export)
+}
+
+func adminActor(actor string) string {
+	if actor == "" {
+		return "admin"
+	}
+	if r := []rune(actor); len(r) > 100 {
+		return string(r[:100])
+	}
+	return actor
+}
+
+// writePrivacyError maps privacy service errors to HTTP statuses
+func writePrivacyError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrInvalidPrivacyToken):
+		c.JSON(http.StatusGone, gin.H{"error": "This link is invalid or has expired"})
+	case errors.Is(err, services.ErrInvalidPrivacyRequest):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"backend/internal/models"
+	"backend/internal/repository"
+	"backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CVPath is where the PDF rendering of the profile is served
+const CVPath = "/cv.pdf"
+
+// ProfileHandler serves the profile as JSON Resume and as a PDF, and lets
+// admins edit it
+type ProfileHandler struct {
+	profileService services.IProfileService
+}
+
+// NewProfileHandler creates a new instance of ProfileHandler
+func NewProfileHandler(profileService services.IProfileService) *ProfileHandler {
+	return &ProfileHandler{
+		profileService: profileService,
+	}
+}
+
+// HandleGet handles GET /profile
+// Returns the JSON Resume document itself, so it can be fed to any JSON
+// Resume tool.
+func (h *ProfileHandler) HandleGet(c *gin.Context) {
+	profile, err := h.profileService.Get(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get profile"})
+		return
+	}
+	writeCachedJSON(c, profile.Resume)
+}
+
+// HandleCV handles GET /cv.pdf
+func (h *ProfileHandler) HandleCV(c *gin.Context) {
+	pdf, name, err := h.profileService.PDF(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render CV"})
+		return
+	}
+	c.Header("Content-Disposition", `inline; filename="`+name+`"`)
+	writeCached(c, "application/pdf", pdf)
+}
+
+// HandleAdminGet handles GET /admin/profile
+// Returns the profile with its version, 0 while the default one is served.
+func (h *ProfileHandler) HandleAdminGet(c *gin.Context) {
+	profile, err := h.profileService.Get(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get profile"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"profile": profile})
+}
+
+// HandleUpdate handles PUT /admin/profile
+// Body: {"resume": {...}, "version": 3}; version is the one read with GET.
+func (h *ProfileHandler) HandleUpdate(c *gin.Context) {
+	var input models.ProfileInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	profile, err := h.profileService.Update(c.Request.Context(), input)
+	if err != nil {
+		writeProfileError(c, err, "Failed to update profile")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"profile": profile})
+}
+
+// writeProfileError maps profile errors to HTTP statuses
+func writeProfileError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, repository.ErrVersionConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": "Profile was modified since this version, reload it and try again"})
+	case errors.Is(err, services.ErrInvalidProfile):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
@@ -0,0 +1,248 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"backend/internal/models"
+	"backend/internal/repository"
+	"backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ProjectHandler serves the public project catalog
+type ProjectHandler struct {
+	projectService services.IProjectService
+}
+
+// NewProjectHandler creates a new instance of ProjectHandler
+func NewProjectHandler(projectService services.IProjectService) *ProjectHandler {
+	return &ProjectHandler{
+		projectService: projectService,
+	}
+}
+
+// HandleList handles GET /projects
+// Optional query parameters: technology, category, sort (position, recent,
+// updated or title), limit, offset.
+func (h *ProjectHandler) HandleList(c *gin.Context) {
+	limit, offset := pagination(c)
+	filter := models.ProjectFilter{
+		Technology: c.Query("technology"),
+		Category:   c.Query("category"),
+		Sort:       c.Query("sort"),
+		Limit:      limit,
+		Offset:     offset,
+	}
+
+	projects, total, err := h.projectService.List(c.Request.Context(), filter)
+	if errors.Is(err, services.ErrInvalidProjectFilter) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list projects"})
+		return
+	}
+	writeCachedJSON(c, gin.H{"projects": projects, "total": total, "limit": limit, "offset": offset})
+}
+
+// HandleGet handles GET /projects/:slug
+func (h *ProjectHandler) HandleGet(c *gin.Context) {
+	project, err := h.projectService.Get(c.Request.Context(), c.Param("slug"))
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get project"})
+		return
+	}
+	writeCachedJSON(c, gin.H{"project": project})
+}
+
+// HandleTechnologies handles GET /technologies
+// Lists the technologies of published projects with their project counts.
+func (h *ProjectHandler) HandleTechnologies(c *gin.Context) {
+	technologies, err := h.projectService.Technologies(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list technologies"})
+		return
+	}
+	writeCachedJSON(c, gin.H{"technologies": technologies})
+}
+
+// HandleAdminList handles GET /admin/projects
+// Lists every project, drafts and scheduled ones included. Same query
+// parameters as GET /projects.
+func (h *ProjectHandler) HandleAdminList(c *gin.Context) {
+	limit, offset := pagination(c)
+	filter := models.ProjectFilter{
+		Technology: c.Query("technology"),
+		Category:   c.Query("category"),
+		Sort:       c.Query("sort"),
+		Drafts:     true,
+		Limit:      limit,
+		Offset:     offset,
+	}
+
+	projects, total, err := h.projectService.List(c.Request.Context(), filter)
+	if err != nil {
+		writeProjectError(c, err, "Failed to list projects")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"projects": projects, "total": total, "limit": limit, "offset": offset})
+}
+
+// HandleAdminGet handles GET /admin/projects/:id
+func (h *ProjectHandler) HandleAdminGet(c *gin.Context) {
+	id, ok := idParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project id"})
+		return
+	}
+
+	project, err := h.projectService.GetByID(c.Request.Context(), id)
+	if err != nil {
+		writeProjectError(c, err, "Failed to get project")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"project": project})
+}
+
+// HandleCreate handles POST /admin/projects
+// New projects are unpublished and placed at the end of the list
+func (h *ProjectHandler) HandleCreate(c *gin.Context) {
+	var input models.ProjectInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	project, err := h.projectService.Create(c.Request.Context(), input)
+	if err != nil {
+		writeProjectError(c, err, "Failed to create project")
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"project": project})
+}
+
+// HandleUpdate handles PUT /admin/projects/:id
+// The body replaces the project and must carry the version it was edited from
+func (h *ProjectHandler) HandleUpdate(c *gin.Context) {
+	id, ok := idParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project id"})
+		return
+	}
+	var input models.ProjectInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	project, err := h.projectService.Update(c.Request.Context(), id, input)
+	if err != nil {
+		writeProjectError(c, err, "Failed to update project")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"project": project})
+}
+
+// HandlePublish handles POST /admin/projects/:id/publish
+// Optional body: {"at": "<RFC 3339>", "version": 3}; a future date schedules the project
+func (h *ProjectHandler) HandlePublish(c *gin.Context) {
+	id, ok := idParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project id"})
+		return
+	}
+	var publication models.ProjectPublication
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&publication); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	project, err := h.projectService.Publish(c.Request.Context(), id, publication)
+	if err != nil {
+		writeProjectError(c, err, "Failed to publish project")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"project": project})
+}
+
+// HandleUnpublish handles POST /admin/projects/:id/unpublish
+// Optional body: {"version": 3}
+func (h *ProjectHandler) HandleUnpublish(c *gin.Context) {
+	id, ok := idParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project id"})
+		return
+	}
+	var publication models.ProjectPublication
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&publication); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	project, err := h.projectService.Unpublish(c.Request.Context(), id, publication.Version)
+	if err != nil {
+		writeProjectError(c, err, "Failed to unpublish project")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"project": project})
+}
+
+// HandleReorder handles PUT /admin/projects/order
+// Body: {"ids": [4, 1, 7]}; the listed projects come first, in that order
+func (h *ProjectHandler) HandleReorder(c *gin.Context) {
+	var body struct {
+		IDs []int64 `json:"ids"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.projectService.Reorder(c.Request.Context(), body.IDs); err != nil {
+		writeProjectError(c, err, "Failed to reorder projects")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// HandleDelete handles DELETE /admin/projects/:id
+func (h *ProjectHandler) HandleDelete(c *gin.Context) {
+	id, ok := idParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid project id"})
+		return
+	}
+
+	if err := h.projectService.Delete(c.Request.Context(), id); err != nil {
+		writeProjectError(c, err, "Failed to delete project")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// writeProjectError maps project service errors to HTTP statuses
+func writeProjectError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Project not found"})
+	case errors.Is(err, repository.ErrVersionConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": "Project was modified since this version, reload it and try again"})
+	case errors.Is(err, repository.ErrSlugTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": "Slug already used by another project"})
+	case errors.Is(err, services.ErrInvalidProject), errors.Is(err, services.ErrInvalidProjectFilter):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
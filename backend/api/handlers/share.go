@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"backend/internal/models"
+	"backend/internal/repository"
+	"backend/internal/services"
+	"backend/internal/site"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ShareHandler redirects share links and lets admins manage them
+type ShareHandler struct {
+	shareService services.IShareService
+	renderer     *site.Renderer
+}
+
+// NewShareHandler creates a new instance of ShareHandler. renderer draws
+// the page of unknown, expired and revoked links.
+func NewShareHandler(shareService services.IShareService, renderer *site.Renderer) *ShareHandler {
+	return &ShareHandler{
+		shareService: shareService,
+		renderer:     renderer,
+	}
+}
+
+// HandleRedirect handles GET /s/:code
+// Records the visit (time, referrer, browser and OS family, device type,
+// country; no cookie and no IP) and redirects to the link target. Unknown
+// links get the 404 page, expired or revoked ones the same page with 410.
+func (h *ShareHandler) HandleRedirect(c *gin.Context) {
+	client := models.ClientMetadata{
+		IP:        c.ClientIP(),
+		UserAgent: c.GetHeader("User-Agent"),
+		Referrer:  c.GetHeader("Referer"),
+	}
+	target, err := h.shareService.Resolve(c.Request.Context(), c.Param("code"), client)
+	c.Header("Cache-Control", "no-store")
+	c.Header("X-Robots-Tag", "noindex")
+	switch {
+	case err == nil:
+		c.Redirect(http.StatusFound, target)
+	case errors.Is(err, repository.ErrNotFound):
+		h.renderUnavailable(c, http.StatusNotFound, "Ce lien n'existe pas.")
+	case errors.Is(err, services.ErrShareLinkGone):
+		h.renderUnavailable(c, http.StatusGone, "Ce lien a expiré ou a été désactivé.")
+	default:
+		log.Printf("Error resolving share link %s: %v", c.Param("code"), err)
+		c.String(http.StatusInternalServerError, "Internal server error")
+	}
+}
+
+// HandleList handles GET /admin/share-links
+// Optional query parameters: limit, offset. Links are listed newest first
+// with their visit counts.
+func (h *ShareHandler) HandleList(c *gin.Context) {
+	limit, offset := pagination(c)
+	links, total, err := h.shareService.List(c.Request.Context(), limit, offset)
+	if err != nil {
+		writeShareError(c, err, "Failed to list share links")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"links": links, "total": total, "limit": limit, "offset": offset})
+}
+
+// HandleCreate handles POST /admin/share-links
+// Body: {"target": "/cv.pdf", "label": "Recruiter at Acme", "code": "acme",
+// "expires_at": "<RFC 3339>"}; code and expires_at are optional.
+func (h *ShareHandler) HandleCreate(c *gin.Context) {
+	var input models.ShareLinkInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	link, err := h.shareService.Create(c.Request.Context(), input)
+	if err != nil {
+		writeShareError(c, err, "Failed to create share link")
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"link": link})
+}
+
+// HandleGet handles GET /admin/share-links/:id
+func (h *ShareHandler) HandleGet(c *gin.Context) {
+	id, ok := idParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid share link id"})
+		return
+	}
+
+	link, err := h.shareService.Get(c.Request.Context(), id)
+	if err != nil {
+		writeShareError(c, err, "Failed to get share link")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"link": link})
+}
+
+// HandleRevoke handles POST /admin/share-links/:id/revoke
+// The link stops redirecting; its visits are kept
+func (h *ShareHandler) HandleRevoke(c *gin.Context) {
+	id, ok := idParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid share link id"})
+		return
+	}
+
+	link, err := h.shareService.Revoke(c.Request.Context(), id)
+	if err != nil {
+		writeShareError(c, err, "Failed to revoke share link")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"link": link})
+}
+
+// HandleDelete handles DELETE /admin/share-links/:id
+// Removes the link and its visits
+func (h *ShareHandler) HandleDelete(c *gin.Context) {
+	id, ok := idParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid share link id"})
+		return
+	}
+
+	if err := h.shareService.Delete(c.Request.Context(), id); err != nil {
+		writeShareError(c, err, "Failed to delete share link")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// HandleHits handles GET /admin/share-links/:id/hits
+// Optional query parameters: limit, offset. Visits are listed newest first.
+func (h *ShareHandler) HandleHits(c *gin.Context) {
+	id, ok := idParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid share link id"})
+		return
+	}
+
+	limit, offset := pagination(c)
+	hits, total, err := h.shareService.Hits(c.Request.Context(), id, limit, offset)
+	if err != nil {
+		writeShareError(c, err, "Failed to list visits")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"hits": hits, "total": total, "limit": limit, "offset": offset})
+}
+
+// HandleStats handles GET /admin/share-links/stats
+// Counts the links and visits of each target, such as the CV
+func (h *ShareHandler) HandleStats(c *gin.Context) {
+	stats, err := h.shareService.Stats(c.Request.Context())
+	if err != nil {
+		writeShareError(c, err, "Failed to count visits")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"targets": stats})
+}
+
+// HandleQRCode handles GET /admin/share-links/:id/qr.png
+// Optional query parameter: size, the width in pixels (128 to 1024).
+func (h *ShareHandler) HandleQRCode(c *gin.Context) {
+	id, ok := idParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid share link id"})
+		return
+	}
+	size := 0
+	if raw := c.Query("size"); raw != "" {
+		var err error
+		if size, err = strconv.Atoi(raw); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid size"})
+			return
+		}
+	}
+
+	png, err := h.shareService.QRCode(c.Request.Context(), id, size)
+	if err != nil {
+		writeShareError(c, err, "Failed to generate QR code")
+		return
+	}
+	c.Data(http.StatusOK, "image/png", png)
+}
+
+// renderUnavailable writes the not-found page with status and message
+func (h *ShareHandler) renderUnavailable(c *gin.Context, status int, message string) {
+	var buf bytes.Buffer
+	page := site.Page{
+		Title: "Lien indisponible",
+		Path:  c.Request.URL.Path,
+		Data:  site.NotFoundData{Status: status, Message: message},
+	}
+	if err := h.renderer.Render(&buf, site.PageNotFound, page); err != nil {
+		log.Printf("Error loading page %s: %v", c.Request.URL.Path, err)
+		c.String(http.StatusInternalServerError, "Internal server error")
+		return
+	}
+	c.Data(status, "text/html; charset=utf-8", buf.Bytes())
+}
+
+// writeShareError maps share link errors to HTTP statuses
+func writeShareError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Share link not found"})
+	case errors.Is(err, repository.ErrCodeTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": "Code already used by another link"})
+	case errors.Is(err, services.ErrInvalidShareLink):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"backend/internal/repository"
+	"backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WebhookHandler exposes the webhook delivery log to admins
+type WebhookHandler struct {
+	webhookService services.IWebhookService
+}
+
+// NewWebhookHandler creates a new instance of WebhookHandler
+func NewWebhookHandler(webhookService services.IWebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+	}
+}
+
+// HandleListDeliveries handles GET /admin/webhooks/deliveries
+// The optional endpoint query parameter restricts the log to one webhook URL
+func (h *WebhookHandler) HandleListDeliveries(c *gin.Context) {
+	limit, offset := pagination(c)
+
+	deliveries, err := h.webhookService.ListDeliveries(c.Request.Context(), c.Query("endpoint"), limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list webhook deliveries"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"deliveries": deliveries, "limit": limit, "offset": offset})
+}
+
+// HandleRedeliver handles POST /admin/webhooks/deliveries/:id/redeliver
+// It sends the delivery once more and returns its updated state, or 409 for
+// a pending delivery that is being sent or waits for its retry
+func (h *WebhookHandler) HandleRedeliver(c *gin.Context) {
+	id, ok := idParam(c, "id")
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid delivery id"})
+		return
+	}
+
+	delivery, err := h.webhookService.Redeliver(c.Request.Context(), id)
+	if errors.Is(err, repository.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Delivery not found"})
+		return
+	}
+	if errors.Is(err, repository.ErrDeliveryLeased) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Delivery is pending and will be retried automatically"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to redeliver webhook"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"delivery": delivery})
+}
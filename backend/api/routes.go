@@ -8,13 +8,140 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func RegisterRoutes(router *gin.Engine, contactHandler *handlers.ContactHandler) {
+// Handlers groups the HTTP handlers mounted by RegisterRoutes
+type Handlers struct {
+	Contact      *handlers.ContactHandler
+	Webhook      *handlers.WebhookHandler
+	Inbox        *handlers.InboxHandler
+	Conversation *handlers.ConversationHandler
+	Privacy      *handlers.PrivacyHandler
+	Blocklist    *handlers.BlocklistHandler
+	Project      *handlers.ProjectHandler
+	Pages        *handlers.PageHandler
+	Feed         *handlers.FeedHandler
+	Article      *handlers.ArticleHandler
+	Content      *handlers.ContentHandler
+	Media        *handlers.MediaHandler
+	Profile      *handlers.ProfileHandler
+	Share        *handlers.ShareHandler
+}
+
+// Middlewares groups the route-specific middlewares used by RegisterRoutes
+type Middlewares struct {
+	Idempotency   gin.HandlerFunc // guards POST endpoints that clients may retry
+	AdminAuth     gin.HandlerFunc // protects the /api/v1/admin group
+	RateLimit     gin.HandlerFunc // limits public POST endpoints per client IP
+	Blocklist     gin.HandlerFunc // fakes success on POST /api/v1/contact for banned client IPs
+	PageBlocklist gin.HandlerFunc // same for the form of the contact page
+}
+
+func RegisterRoutes(router *gin.Engine, h Handlers, m Middlewares) {
 	router.GET("/health", func(c *gin.Context) {
 		c.JSON(http.StatusOK, gin.H{"status": "OK"})
 	})
 
+	// Server-rendered pages
+	router.GET("/", h.Pages.HandleIndex)
+	router.GET("/projects", h.Pages.HandleProjects)
+	router.GET("/projects/:slug", h.Pages.HandleProject)
+	router.GET("/articles", h.Pages.HandleArticles)
+	router.GET("/articles/:slug", h.Pages.HandleArticle)
+	router.GET("/preview/:type/:key", h.Pages.HandlePreview)
+	router.GET("/about", h.Pages.HandleAbout)
+	router.GET("/contact", h.Pages.HandleContact)
+	router.POST("/contact", m.RateLimit, m.PageBlocklist, h.Pages.HandleContactSubmit)
+	router.GET("/privacy", h.Pages.HandlePrivacy)
+	router.POST("/privacy", h.Pages.HandlePrivacyErase)
+	router.GET("/sitemap.xml", h.Feed.HandleSitemap)
+	router.GET("/feed.atom", h.Feed.HandleAtom)
+	router.GET("/feed.rss", h.Feed.HandleRSS)
+	router.GET("/robots.txt", h.Feed.HandleRobots)
+	router.GET("/media/:name", h.Media.HandleServe)
+	router.GET(handlers.CVPath, h.Profile.HandleCV)
+	router.GET("/s/:code", h.Share.HandleRedirect)
+
 	apiV1 := router.Group("/api/v1")
 	{
-		apiV1.POST("/contact", contactHandler.HandleSendContactForm)
+		apiV1.POST("/contact", m.RateLimit, m.Idempotency, m.Blocklist, h.Contact.HandleSendContactForm)
+
+		apiV1.POST("/privacy/requests", m.RateLimit, h.Privacy.HandleRequest)
+		apiV1.GET("/privacy/export", h.Privacy.HandleExport)
+		apiV1.POST("/privacy/erase", h.Privacy.HandleErase)
+
+		apiV1.GET("/projects", h.Project.HandleList)
+		apiV1.GET("/projects/:slug", h.Project.HandleGet)
+		apiV1.GET("/technologies", h.Project.HandleTechnologies)
+
+		apiV1.GET("/articles", h.Article.HandleList)
+		apiV1.GET("/articles/:slug", h.Article.HandleGet)
+		apiV1.GET("/tags", h.Article.HandleTags)
+
+		apiV1.GET("/profile", h.Profile.HandleGet)
+	}
+
+	admin := apiV1.Group("/admin", m.AdminAuth)
+	{
+		admin.GET("/webhooks/deliveries", h.Webhook.HandleListDeliveries)
+		admin.POST("/webhooks/deliveries/:id/redeliver", h.Webhook.HandleRedeliver)
+
+		admin.GET("/contacts", h.Inbox.HandleList)
+		admin.POST("/contacts/bulk", h.Inbox.HandleBulkUpdate)
+		admin.GET("/contacts/sources", h.Inbox.HandleSources)
+		admin.GET("/contacts/:id", h.Inbox.HandleGet)
+		admin.PATCH("/contacts/:id", h.Inbox.HandleUpdate)
+		admin.DELETE("/contacts/:id", h.Inbox.HandleDelete)
+		admin.POST("/contacts/:id/notes", h.Inbox.HandleAddNote)
+		admin.GET("/contacts/:id/messages", h.Conversation.HandleListMessages)
+		admin.POST("/contacts/:id/replies", h.Conversation.HandleReply)
+
+		admin.GET("/privacy/export", h.Privacy.HandleAdminExport)
+		admin.POST("/privacy/erase", h.Privacy.HandleAdminErase)
+		admin.GET("/privacy/audit", h.Privacy.HandleAuditLog)
+
+		admin.GET("/blocklist", h.Blocklist.HandleList)
+		admin.POST("/blocklist", h.Blocklist.HandleCreate)
+		admin.DELETE("/blocklist/:id", h.Blocklist.HandleDelete)
+
+		admin.GET("/projects", h.Project.HandleAdminList)
+		admin.POST("/projects", h.Project.HandleCreate)
+		admin.PUT("/projects/order", h.Project.HandleReorder)
+		admin.GET("/projects/:id", h.Project.HandleAdminGet)
+		admin.PUT("/projects/:id", h.Project.HandleUpdate)
+		admin.DELETE("/projects/:id", h.Project.HandleDelete)
+		admin.POST("/projects/:id/publish", h.Project.HandlePublish)
+		admin.POST("/projects/:id/unpublish", h.Project.HandleUnpublish)
+
+		admin.GET("/articles", h.Article.HandleAdminList)
+		admin.GET("/articles/:slug", h.Article.HandleAdminGet)
+		admin.PUT("/articles/:slug", h.Article.HandleSave)
+		admin.DELETE("/articles/:slug", h.Article.HandleDelete)
+		admin.POST("/articles/:slug/publish", h.Article.HandlePublish)
+		admin.POST("/articles/:slug/unpublish", h.Article.HandleUnpublish)
+
+		admin.GET("/revisions", h.Content.HandleListRevisions)
+		admin.GET("/revisions/diff", h.Content.HandleDiffRevisions)
+		admin.GET("/revisions/:id", h.Content.HandleGetRevision)
+		admin.POST("/revisions/:id/restore", h.Content.HandleRestoreRevision)
+		admin.GET("/schedules", h.Content.HandleListSchedules)
+		admin.POST("/schedules", h.Content.HandleCreateSchedule)
+		admin.DELETE("/schedules/:id", h.Content.HandleDeleteSchedule)
+		admin.POST("/previews", h.Content.HandleCreatePreview)
+
+		admin.GET("/media", h.Media.HandleList)
+		admin.POST("/media", h.Media.HandleUpload)
+		admin.GET("/media/:id", h.Media.HandleGet)
+		admin.DELETE("/media/:id", h.Media.HandleDelete)
+
+		admin.GET("/profile", h.Profile.HandleAdminGet)
+		admin.PUT("/profile", h.Profile.HandleUpdate)
+
+		admin.GET("/share-links", h.Share.HandleList)
+		admin.POST("/share-links", h.Share.HandleCreate)
+		admin.GET("/share-links/stats", h.Share.HandleStats)
+		admin.GET("/share-links/:id", h.Share.HandleGet)
+		admin.DELETE("/share-links/:id", h.Share.HandleDelete)
+		admin.POST("/share-links/:id/revoke", h.Share.HandleRevoke)
+		admin.GET("/share-links/:id/hits", h.Share.HandleHits)
+		admin.GET("/share-links/:id/qr.png", h.Share.HandleQRCode)
 	}
 }
@@ -1,8 +1,11 @@
 package config
 
 import (
+	"fmt"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 )
 
 type Config struct {
@@ -23,8 +26,132 @@ type Config struct {
 	DbPassword       string   // Database password
 	DbName           string   // Database name
 	TrustedProxies   []string // Trusted proxy IPs (used by Gin)
+	MaxBodyBytes     int64    // Maximum accepted request body size in bytes
+
+	IdempotencyTTL      time.Duration // How long Idempotency-Key responses are kept for replay
+	IdempotencyLease    time.Duration // How long a request holds its key before a retry may take it over
+	ContactDedupeWindow time.Duration // Window in which identical submissions are dropped (0 disables)
+	ContactPurgeAfter   time.Duration // How long trashed submissions are kept before being deleted
+
+	ReplyFrom            string // Sender of admin replies (defaults to SMTP_USER)
+	ReplyMessageIDDomain string // Domain used in reply Message-IDs (defaults to the sender domain)
+	ReplyTemplate        string // text/template for reply bodies
+	ReplyTokenSecret     string // Signs plus-addressed Reply-To tokens (empty disables them)
+
+	InboundMaildir      string        // Maildir receiving visitor answers (empty disables ingestion)
+	InboundPollInterval time.Duration // How often the maildir is scanned
+
+	RetentionPeriod time.Duration // Age after which submissions are purged (0, the default, keeps them forever)
+	RetentionMode   string        // anonymize or delete, for the retention policy
+	ErasureMode     string        // anonymize or delete, for erasure requests
+	PrivacyTokenTTL time.Duration // Validity of "request my data" verification links
+	PrivacyLinkURL  string        // URL emailed with ?token= (defaults to the /privacy page)
+	PrivacyAuditKey string        // Secret key of the subject hashes in the audit log (required)
+
+	ClientIPMode string // How submission IPs are stored: hash, truncate or none
+	ClientIPSalt string // Secret salt of the IP hashes (random per process when empty)
+
+	GeoIPDatabases      []string      // .mmdb files (country and/or ASN) used to locate client IPs
+	GeoIPReloadInterval time.Duration // How often the files are checked for updates
+
+	RateLimit                int           // Requests per RateLimitWindow and client IP on public POST endpoints (0 disables)
+	RateLimitWindow          time.Duration // Rate limit window
+	RateLimitStrict          int           // Limit for clients in RateLimitStrictASNs or RateLimitStrictCountries
+	RateLimitStrictASNs      []int64       // Networks held to the strict limit (hosting providers...)
+	RateLimitStrictCountries []string      // ISO country codes held to the strict limit
+
+	BanStrikes       int           // Strikes (blocked submissions, rate-limit rejections) that ban a client IP (0 disables bans)
+	BanWindow        time.Duration // Window in which the strikes are counted
+	BanDuration      time.Duration // How long an automatic ban lasts
+	BlocklistRefresh time.Duration // How often block rules are reloaded from the database
+
+	EmailCheckMode        string        // "reject" or "flag" submissions failing the email checks
+	EmailDisposableFile   string        // File listing disposable email domains, one per line
+	EmailDisposableReload time.Duration // How often the file is checked for updates
+	EmailCheckDNS         bool          // Require the sender domain to have an MX (or A/AAAA) record
+	EmailDNSServer        string        // DNS server (host:port) for the lookups; system resolver when empty
+	EmailDNSTimeout       time.Duration // Timeout of each lookup
+	EmailDNSCacheTTL      time.Duration // How long lookup results are cached
+
+	AdminAPIToken string // Bearer token for the /api/v1/admin endpoints (empty disables them)
+
+	EncryptionKeys         string // "version:base64key" entries encrypting submissions at rest (empty disables encryption)
+	EncryptionKeysFile     string // File with more entries, one per line
+	EncryptionActiveKey    int    // Key version used for new data (0 selects the highest)
+	EncryptionIndexKey     string // Base64 key of the blind indexes
+	EncryptionIndexKeyFile string // File holding the index key
+
+	WebhookURLs         []string      // Endpoints receiving signed submission events
+	WebhookSecret       string        // HMAC-SHA256 secret used to sign webhook payloads
+	WebhookMaxAttempts  int           // Delivery attempts before a webhook is marked failed
+	WebhookTimeout      time.Duration // Per-attempt HTTP timeout
+	WebhookBackoff      time.Duration // Delay before the first retry (doubled each time)
+	WebhookPollInterval time.Duration // How often due webhook retries are sent
+
+	// Notification channels; each one is enabled when its required settings are set.
+	// *Template fields hold optional text/template overrides for the message body.
+	NotifyTimeout       time.Duration // HTTP timeout for chat/push channels
+	MatrixHomeserverURL string
+	MatrixAccessToken   string
+	MatrixRoomID        string
+	MatrixTemplate      string
+	NtfyURL             string
+	NtfyTopic           string
+	NtfyToken           string
+	NtfyTemplate        string
+	GotifyURL           string
+	GotifyToken         string
+	GotifyTemplate      string
+	DiscordWebhookURL   string
+	DiscordTemplate     string
+	TelegramAPIURL      string
+	TelegramBotToken    string
+	TelegramChatID      string
+	TelegramTemplate    string
+
+	RoutingRulesFile    string        // JSON file with routing rules; rules are read from the DB when empty
+	RoutingRulesRefresh time.Duration // How often routing rules are reloaded
+
+	// Server-rendered pages
+	SiteURL          string // Public URL of the site, used in canonical links (defaults to FRONTEND_URL)
+	SiteName         string // Name shown in titles, header and footer
+	SiteTagline      string // Short role shown under the name in the footer
+	SiteDescription  string // Default meta description
+	SiteEmail        string // Public contact address shown in the footer
+	SiteGitHubURL    string
+	SiteLinkedInURL  string
+	SiteTemplatesDir string // Directory overriding the embedded page templates (empty uses them)
+
+	// Sitemap, feeds and robots.txt
+	SiteNoIndex    bool          // robots.txt disallows everything (staging sites)
+	RobotsDisallow []string      // Path prefixes disallowed in robots.txt
+	SitemapPages   []string      // Pages still served as static files by nginx, listed in the sitemap
+	FeedCacheTTL   time.Duration // How long the sitemap and feeds are cached without content changes
+	FeedSize       int           // Entries per Atom/RSS feed
+
+	// Articles
+	ArticlesDir     string        // Directory of Markdown articles; articles are read from the DB when empty
+	ArticlesRefresh time.Duration // How often articles are reloaded (new files, scheduled dates are checked per request)
+
+	// Revisions, previews and scheduled publishing
+	PreviewSecret     string        // Signs preview links of unpublished content (empty disables them)
+	PreviewTTL        time.Duration // Validity of preview links
+	SchedulerInterval time.Duration // How often due publications and unpublications are run
+
+	// Media library
+	MediaDir           string // Directory of uploaded files and their variants
+	MediaMaxBytes      int64  // Maximum size of an uploaded file
+	MediaMaxPixels     int64  // Maximum width × height of an uploaded image
+	MediaVariantWidths []int  // Widths of the resized copies generated for srcset
+
+	// Share links
+	ShareHitRetention time.Duration // Age after which share link visits are purged (0 keeps them forever)
 }
 
+// defaultSitemapPages are the project pages nginx still serves as static
+// files, which have no project in the database to list them
+const defaultSitemapPages = "/proxmox-project.html,/aquarium-project.html,/network-project.html,/portfolio-project.html,/gitops-project.html"
+
 func getEnv(key, fallback string) string {
 	if value, exists := os.LookupEnv(key); exists {
 		return value
@@ -32,6 +159,40 @@ func getEnv(key, fallback string) string {
 	return fallback
 }
 
+func getEnvInt64(key string, fallback int64) int64 {
+	if value, exists := os.LookupEnv(key); exists {
+		if parsed, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64); err == nil {
+			return parsed
+		}
+	}
+	return fallback
+}
+
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	if value, exists := os.LookupEnv(key); exists {
+		if parsed, err := time.ParseDuration(strings.TrimSpace(value)); err == nil {
+			return parsed
+		}
+	}
+	return fallback
+}
+
+// getEnvList reads a comma-separated list, dropping empty entries
+func getEnvList(key string) []string {
+	return splitList(getEnv(key, ""))
+}
+
+// splitList splits a comma-separated list, dropping empty items
+func splitList(value string) []string {
+	var list []string
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			list = append(list, part)
+		}
+	}
+	return list
+}
+
 func LoadConfig() (*Config, error) {
 	config := &Config{
 		Port:             getEnv("BACKEND_PORT", "8080"),
@@ -50,7 +211,142 @@ func LoadConfig() (*Config, error) {
 		DbUser:           getEnv("DB_BACKEND_USER", ""),
 		DbPassword:       getEnv("DB_BACKEND_PASSWORD", ""),
 		DbName:           getEnv("DB_NAME", ""),
+		MaxBodyBytes:     getEnvInt64("MAX_BODY_BYTES", 64*1024),
+
+		IdempotencyTTL:      getEnvDuration("IDEMPOTENCY_TTL", 24*time.Hour),
+		IdempotencyLease:    getEnvDuration("IDEMPOTENCY_LEASE", time.Minute),
+		ContactDedupeWindow: getEnvDuration("CONTACT_DEDUPE_WINDOW", 10*time.Minute),
+		ContactPurgeAfter:   getEnvDuration("CONTACT_PURGE_AFTER", 30*24*time.Hour),
+
+		ReplyFrom:            getEnv("REPLY_FROM", ""),
+		ReplyMessageIDDomain: getEnv("REPLY_MESSAGE_ID_DOMAIN", ""),
+		ReplyTemplate:        getEnv("REPLY_TEMPLATE", ""),
+		ReplyTokenSecret:     getEnv("REPLY_TOKEN_SECRET", ""),
+
+		InboundMaildir:      getEnv("INBOUND_MAILDIR", ""),
+		InboundPollInterval: getEnvDuration("INBOUND_POLL_INTERVAL", 30*time.Second),
+
+		RetentionPeriod: getEnvDuration("RETENTION_PERIOD", 0),
+		RetentionMode:   getEnv("RETENTION_MODE", "anonymize"),
+		ErasureMode:     getEnv("ERASURE_MODE", "delete"),
+		PrivacyTokenTTL: getEnvDuration("PRIVACY_TOKEN_TTL", time.Hour),
+		PrivacyLinkURL:  getEnv("PRIVACY_LINK_URL", ""),
+		PrivacyAuditKey: getEnv("PRIVACY_AUDIT_KEY", ""),
+
+		ClientIPMode: getEnv("CLIENT_IP_MODE", "hash"),
+		ClientIPSalt: getEnv("CLIENT_IP_SALT", ""),
+
+		GeoIPDatabases:      getEnvList("GEOIP_DATABASES"),
+		GeoIPReloadInterval: getEnvDuration("GEOIP_RELOAD_INTERVAL", time.Minute),
+
+		RateLimit:                int(getEnvInt64("RATE_LIMIT", 10)),
+		RateLimitWindow:          getEnvDuration("RATE_LIMIT_WINDOW", time.Hour),
+		RateLimitStrict:          int(getEnvInt64("RATE_LIMIT_STRICT", 3)),
+		RateLimitStrictCountries: getEnvList("RATE_LIMIT_STRICT_COUNTRIES"),
+
+		BanStrikes:       int(getEnvInt64("BAN_STRIKES", 5)),
+		BanWindow:        getEnvDuration("BAN_WINDOW", time.Hour),
+		BanDuration:      getEnvDuration("BAN_DURATION", 24*time.Hour),
+		BlocklistRefresh: getEnvDuration("BLOCKLIST_REFRESH", time.Minute),
+
+		EmailCheckMode:        getEnv("EMAIL_CHECK_MODE", "flag"),
+		EmailDisposableFile:   getEnv("EMAIL_DISPOSABLE_FILE", ""),
+		EmailDisposableReload: getEnvDuration("EMAIL_DISPOSABLE_RELOAD", 5*time.Minute),
+		EmailCheckDNS:         getEnv("EMAIL_CHECK_DNS", "false") == "true",
+		EmailDNSServer:        getEnv("EMAIL_DNS_SERVER", ""),
+		EmailDNSTimeout:       getEnvDuration("EMAIL_DNS_TIMEOUT", 3*time.Second),
+		EmailDNSCacheTTL:      getEnvDuration("EMAIL_DNS_CACHE_TTL", time.Hour),
+
+		AdminAPIToken: getEnv("ADMIN_API_TOKEN", ""),
+
+		EncryptionKeys:         getEnv("ENCRYPTION_KEYS", ""),
+		EncryptionKeysFile:     getEnv("ENCRYPTION_KEYS_FILE", ""),
+		EncryptionActiveKey:    int(getEnvInt64("ENCRYPTION_ACTIVE_KEY", 0)),
+		EncryptionIndexKey:     getEnv("ENCRYPTION_INDEX_KEY", ""),
+		EncryptionIndexKeyFile: getEnv("ENCRYPTION_INDEX_KEY_FILE", ""),
+
+		WebhookURLs:         getEnvList("WEBHOOK_URLS"),
+		WebhookSecret:       getEnv("WEBHOOK_SECRET", ""),
+		WebhookMaxAttempts:  int(getEnvInt64("WEBHOOK_MAX_ATTEMPTS", 6)),
+		WebhookTimeout:      getEnvDuration("WEBHOOK_TIMEOUT", 10*time.Second),
+		WebhookBackoff:      getEnvDuration("WEBHOOK_BACKOFF", 30*time.Second),
+		WebhookPollInterval: getEnvDuration("WEBHOOK_POLL_INTERVAL", 15*time.Second),
+
+		NotifyTimeout:       getEnvDuration("NOTIFY_TIMEOUT", 10*time.Second),
+		MatrixHomeserverURL: getEnv("MATRIX_HOMESERVER_URL", ""),
+		MatrixAccessToken:   getEnv("MATRIX_ACCESS_TOKEN", ""),
+		MatrixRoomID:        getEnv("MATRIX_ROOM_ID", ""),
+		MatrixTemplate:      getEnv("MATRIX_TEMPLATE", ""),
+		NtfyURL:             getEnv("NTFY_URL", ""),
+		NtfyTopic:           getEnv("NTFY_TOPIC", ""),
+		NtfyToken:           getEnv("NTFY_TOKEN", ""),
+		NtfyTemplate:        getEnv("NTFY_TEMPLATE", ""),
+		GotifyURL:           getEnv("GOTIFY_URL", ""),
+		GotifyToken:         getEnv("GOTIFY_TOKEN", ""),
+		GotifyTemplate:      getEnv("GOTIFY_TEMPLATE", ""),
+		DiscordWebhookURL:   getEnv("DISCORD_WEBHOOK_URL", ""),
+		DiscordTemplate:     getEnv("DISCORD_TEMPLATE", ""),
+		TelegramAPIURL:      getEnv("TELEGRAM_API_URL", ""),
+		TelegramBotToken:    getEnv("TELEGRAM_BOT_TOKEN", ""),
+		TelegramChatID:      getEnv("TELEGRAM_CHAT_ID", ""),
+		TelegramTemplate:    getEnv("TELEGRAM_TEMPLATE", ""),
+
+		RoutingRulesFile:    getEnv("ROUTING_RULES_FILE", ""),
+		RoutingRulesRefresh: getEnvDuration("ROUTING_RULES_REFRESH", 5*time.Minute),
+
+		SiteName:         getEnv("SITE_NAME", "Enzo Gaggiotti"),
+		SiteTagline:      getEnv("SITE_TAGLINE", "Développeur"),
+		SiteDescription:  getEnv("SITE_DESCRIPTION", "Explorateur de l’infrastructure moderne : réseau, système, devops."),
+		SiteEmail:        getEnv("SITE_EMAIL", "enzo.gaggiotti@epitech.eu"),
+		SiteGitHubURL:    getEnv("SITE_GITHUB_URL", "https://github.com/enzogagg"),
+		SiteLinkedInURL:  getEnv("SITE_LINKEDIN_URL", "https://www.linkedin.com/in/enzo-gaggiotti-867a0229a/"),
+		SiteTemplatesDir: getEnv("SITE_TEMPLATES_DIR", ""),
+
+		SiteNoIndex:    getEnv("SITE_NOINDEX", "false") == "true",
+		RobotsDisallow: getEnvList("ROBOTS_DISALLOW"),
+		SitemapPages:   splitList(getEnv("SITEMAP_STATIC_PAGES", defaultSitemapPages)),
+		FeedCacheTTL:   getEnvDuration("FEED_CACHE_TTL", time.Hour),
+		FeedSize:       int(getEnvInt64("FEED_SIZE", 20)),
+
+		ArticlesDir:     getEnv("ARTICLES_DIR", ""),
+		ArticlesRefresh: getEnvDuration("ARTICLES_REFRESH", 5*time.Minute),
+
+		PreviewSecret:     getEnv("PREVIEW_SECRET", ""),
+		PreviewTTL:        getEnvDuration("PREVIEW_TTL", 72*time.Hour),
+		SchedulerInterval: getEnvDuration("SCHEDULER_INTERVAL", time.Minute),
+
+		MediaDir:       getEnv("MEDIA_DIR", "media"),
+		MediaMaxBytes:  getEnvInt64("MEDIA_MAX_BYTES", 10<<20),
+		MediaMaxPixels: getEnvInt64("MEDIA_MAX_PIXELS", 40_000_000),
+
+		ShareHitRetention: getEnvDuration("SHARE_HIT_RETENTION", 365*24*time.Hour),
 	}
+	if len(config.RobotsDisallow) == 0 {
+		config.RobotsDisallow = []string{"/api/"}
+	}
+	config.SiteURL = strings.TrimSuffix(getEnv("SITE_URL", config.FrontendURL), "/")
+	for _, asn := range getEnvList("RATE_LIMIT_STRICT_ASNS") {
+		parsed, err := strconv.ParseInt(strings.TrimPrefix(strings.ToUpper(asn), "AS"), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ASN %q in RATE_LIMIT_STRICT_ASNS", asn)
+		}
+		config.RateLimitStrictASNs = append(config.RateLimitStrictASNs, parsed)
+	}
+	widths := getEnvList("MEDIA_VARIANT_WIDTHS")
+	if len(widths) == 0 {
+		widths = []string{"320", "640", "1024", "1600"}
+	}
+	for _, width := range widths {
+		parsed, err := strconv.Atoi(width)
+		if err != nil || parsed <= 0 {
+			return nil, fmt.Errorf("invalid width %q in MEDIA_VARIANT_WIDTHS", width)
+		}
+		config.MediaVariantWidths = append(config.MediaVariantWidths, parsed)
+	}
+	for i, country := range config.RateLimitStrictCountries {
+		config.RateLimitStrictCountries[i] = strings.ToUpper(country)
+	}
+
 	// Parse trusted proxies from env var (comma-separated). Default to localhost.
 	proxies := getEnv("TRUSTED_PROXIES", "127.0.0.1")
 	if proxies == "" {
@@ -0,0 +1,265 @@
+// Package encryption implements the envelope encryption of personal data at rest.
+//
+// Every record gets its own random data key (DEK) that encrypts its fields with
+// AES-256-GCM. The DEK is itself encrypted ("wrapped") with a versioned
+// key-encryption key (KEK) from the configuration and stored next to the
+// record. Rotating keys means adding a KEK version, making it active and
+// re-encrypting the records still tied to older versions.
+package encryption
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// KeySize is the size of every key: AES-256 and HMAC-SHA256
+const KeySize = 32
+
+// ErrUnknownKey is returned for data wrapped with a key version that is not configured
+var ErrUnknownKey = errors.New("unknown encryption key version")
+
+// Options tells LoadKeyring where to find the keys
+type Options struct {
+	Keys          string // "version:base64key" entries separated by commas or newlines
+	KeysFile      string // File with the same entries, one per line ('#' starts a comment)
+	ActiveVersion int    // Version used for new data (0 selects the highest)
+	IndexKey      string // Base64 key of the blind indexes
+	IndexKeyFile  string // File holding the base64 index key
+}
+
+// Keyring holds the key-encryption keys and the blind index key
+type Keyring struct {
+	keys     map[int]cipher.AEAD
+	active   int
+	indexKey []byte
+}
+
+// LoadKeyring builds a keyring from the configuration. It returns nil and no
+// error when no key is configured, which disables encryption.
+func LoadKeyring(opts Options) (*Keyring, error) {
+	spec := opts.Keys
+	if opts.KeysFile != "" {
+		content, err := os.ReadFile(opts.KeysFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read encryption keys: %w", err)
+		}
+		spec += "\n" + string(content)
+	}
+	keys, err := ParseKeys(spec)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	indexKey := opts.IndexKey
+	if opts.IndexKeyFile != "" {
+		content, err := os.ReadFile(opts.IndexKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read index key: %w", err)
+		}
+		indexKey = string(content)
+	}
+	index, err := decodeKey(strings.TrimSpace(indexKey))
+	if err != nil {
+		return nil, fmt.Errorf("invalid index key: %w", err)
+	}
+	return NewKeyring(keys, opts.ActiveVersion, index)
+}
+
+// ParseKeys reads "version:base64key" entries separated by commas or newlines
+func ParseKeys(spec string) (map[int][]byte, error) {
+	keys := map[int][]byte{}
+	scanner := bufio.NewScanner(strings.NewReader(strings.ReplaceAll(spec, ",", "\n")))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		if line = strings.TrimSpace(line); line == "" {
+			continue
+		}
+		v, k, ok := strings.Cut(line, ":")
+		version, err := strconv.Atoi(strings.TrimSpace(v))
+		if !ok || err != nil || version <= 0 {
+			return nil, fmt.Errorf("invalid encryption key entry: expected version:base64key")
+		}
+		if _, exists := keys[version]; exists {
+			return nil, fmt.Errorf("duplicate encryption key version %d", version)
+		}
+		key, err := decodeKey(strings.TrimSpace(k))
+		if err != nil {
+			return nil, fmt.Errorf("invalid encryption key %d: %w", version, err)
+		}
+		keys[version] = key
+	}
+	return keys, nil
+}
+
+func decodeKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errors.New("not valid base64")
+	}
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("must be %d bytes, got %d", KeySize, len(key))
+	}
+	return key, nil
+}
+
+// NewKeyring creates a keyring from raw keys. active selects the version
+// used for new data; 0 selects the highest one.
+func NewKeyring(keys map[int][]byte, active int, indexKey []byte) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("at least one encryption key is required")
+	}
+	if len(indexKey) != KeySize {
+		return nil, fmt.Errorf("index key must be %d bytes", KeySize)
+	}
+
+	k := &Keyring{keys: map[int]cipher.AEAD{}, indexKey: indexKey}
+	for version, key := range keys {
+		if version <= 0 || version > 32767 {
+			return nil, fmt.Errorf("invalid encryption key version %d", version)
+		}
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, err
+		}
+		k.keys[version] = aead
+		if active == 0 && version > k.active {
+			k.active = version
+		}
+	}
+	if active != 0 {
+		if _, ok := k.keys[active]; !ok {
+			return nil, fmt.Errorf("active encryption key %d is not configured", active)
+		}
+		k.active = active
+	}
+	return k, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("encryption keys must be %d bytes", KeySize)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// ActiveVersion returns the key version used for new data
+func (k *Keyring) ActiveVersion() int {
+	return k.active
+}
+
+// NewDataKey generates a data key wrapped with the active key
+func (k *Keyring) NewDataKey() (*DataKey, error) {
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	wrapped, err := seal(k.keys[k.active], key, wrapLabel(k.active))
+	if err != nil {
+		return nil, err
+	}
+	return &DataKey{Version: k.active, Wrapped: wrapped, aead: aead}, nil
+}
+
+// OpenDataKey unwraps a stored data key
+func (k *Keyring) OpenDataKey(version int, wrapped []byte) (*DataKey, error) {
+	kek, ok := k.keys[version]
+	if !ok {
+		return nil, fmt.Errorf("%w: %d", ErrUnknownKey, version)
+	}
+	key, err := open(kek, wrapped, wrapLabel(version))
+	if err != nil {
+		return nil, fmt.Errorf("unable to unwrap data key: %w", err)
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	return &DataKey{Version: version, Wrapped: wrapped, aead: aead}, nil
+}
+
+// BlindIndex returns a keyed HMAC of value, so equal values can be looked up
+// without storing them. purpose separates the indexes of different fields.
+// Callers normalize value first.
+func (k *Keyring) BlindIndex(purpose, value string) string {
+	mac := hmac.New(sha256.New, k.indexKey)
+	mac.Write([]byte(purpose))
+	mac.Write([]byte{0})
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func wrapLabel(version int) []byte {
+	return []byte("dek:v" + strconv.Itoa(version))
+}
+
+// DataKey encrypts the fields of one record
+type DataKey struct {
+	Version int    // Version of the key-encryption key that wrapped it
+	Wrapped []byte // Encrypted form, stored with the record
+	aead    cipher.AEAD
+}
+
+// Seal encrypts a field value. field is authenticated with the ciphertext so
+// values cannot be swapped between fields of the same record.
+func (d *DataKey) Seal(field, plaintext string) (string, error) {
+	sealed, err := seal(d.aead, []byte(plaintext), []byte(field))
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Open decrypts a value produced by Seal for the same field
+func (d *DataKey) Open(field, ciphertext string) (string, error) {
+	sealed, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return "", fmt.Errorf("unable to decrypt %s: %w", field, err)
+	}
+	plaintext, err := open(d.aead, sealed, []byte(field))
+	if err != nil {
+		return "", fmt.Errorf("unable to decrypt %s: %w", field, err)
+	}
+	return string(plaintext), nil
+}
+
+// seal returns nonce || ciphertext
+func seal(aead cipher.AEAD, plaintext, additional []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, additional), nil
+}
+
+func open(aead cipher.AEAD, sealed, additional []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, additional)
+}
@@ -0,0 +1,150 @@
+// Package geoip looks up the country and network (ASN) of client IPs in local
+// MaxMind DB (.mmdb) files, such as GeoLite2 or DB-IP Lite. Lookups never
+// leave the process: the files are read into memory and reloaded when they
+// change on disk.
+package geoip
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/netip"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"backend/internal/models"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// record holds the fields read from any of the supported databases:
+// country databases fill Country, ASN databases the autonomous system
+type record struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	RegisteredCountry struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"registered_country"`
+	ASN   int64  `maxminddb:"autonomous_system_number"`
+	ASOrg string `maxminddb:"autonomous_system_organization"`
+}
+
+// database is one loaded .mmdb file
+type database struct {
+	path    string
+	modTime time.Time
+	size    int64
+	reader  *maxminddb.Reader
+}
+
+// Locator resolves client IPs with one or more .mmdb files; each file fills
+// the fields it knows. A nil Locator resolves nothing.
+type Locator struct {
+	mu        sync.RWMutex
+	databases []*database
+}
+
+// Open loads the databases at paths. Every file must exist and be valid.
+func Open(paths ...string) (*Locator, error) {
+	l := &Locator{}
+	for _, path := range paths {
+		db, err := load(path)
+		if err != nil {
+			return nil, err
+		}
+		l.databases = append(l.databases, db)
+	}
+	return l, nil
+}
+
+func load(path string) (*database, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to open GeoIP database: %w", err)
+	}
+	// Read into memory rather than mmap: a file truncated in place while
+	// mapped would crash the process
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read GeoIP database: %w", err)
+	}
+	reader, err := maxminddb.FromBytes(data)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load GeoIP database %s: %w", path, err)
+	}
+	return &database{path: path, modTime: info.ModTime(), size: info.Size(), reader: reader}, nil
+}
+
+// Reload swaps in the databases whose file changed since they were loaded.
+// A file that is missing or invalid keeps its previous version, so an update
+// caught halfway through a copy is picked up on the next call.
+func (l *Locator) Reload(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.RLock()
+	current := append([]*database(nil), l.databases...)
+	l.mu.RUnlock()
+
+	var errs []string
+	for i, db := range current {
+		info, err := os.Stat(db.path)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		if info.ModTime().Equal(db.modTime) && info.Size() == db.size {
+			continue
+		}
+		fresh, err := load(db.path)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		l.mu.Lock()
+		l.databases[i] = fresh
+		l.mu.Unlock()
+		log.Printf("Reloaded GeoIP database %s (%s)", db.path, fresh.reader.Metadata.DatabaseType)
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("unable to reload GeoIP databases: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// Lookup returns the country and network of ip. Unknown, private or
+// malformed addresses give an empty location.
+func (l *Locator) Lookup(ip string) models.GeoLocation {
+	var loc models.GeoLocation
+	if l == nil {
+		return loc
+	}
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return loc
+	}
+	netIP := net.IP(addr.Unmap().WithZone("").AsSlice())
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	for _, db := range l.databases {
+		var rec record
+		if err := db.reader.Lookup(netIP, &rec); err != nil {
+			continue
+		}
+		if loc.Country == "" {
+			loc.Country = rec.Country.ISOCode
+			if loc.Country == "" {
+				loc.Country = rec.RegisteredCountry.ISOCode
+			}
+		}
+		if loc.ASN == 0 && rec.ASN != 0 {
+			loc.ASN, loc.ASOrg = rec.ASN, rec.ASOrg
+		}
+	}
+	return loc
+}
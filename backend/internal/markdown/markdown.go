@@ -0,0 +1,200 @@
+// Package markdown renders article sources to safe HTML: raw HTML and
+// dangerous link schemes are dropped, code blocks are highlighted and
+// headings get anchors.
+package markdown
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	"backend/internal/models"
+
+	"github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/yuin/goldmark"
+	highlighting "github.com/yuin/goldmark-highlighting/v2"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+	"gopkg.in/yaml.v3"
+)
+
+// WordsPerMinute is the reading speed used for reading time estimates
+const WordsPerMinute = 200
+
+// FrontMatter is the YAML header of an article, between "---" lines
+type FrontMatter struct {
+	Title   string     `yaml:"title"`
+	Slug    string     `yaml:"slug"`
+	Summary string     `yaml:"summary"`
+	Tags    []string   `yaml:"tags"`
+	Date    *time.Time `yaml:"date"` // publication date; in the future, the article is scheduled
+	Updated *time.Time `yaml:"updated"`
+	Draft   bool       `yaml:"draft"`
+}
+
+// Document is a rendered source
+type Document struct {
+	HTML           string
+	Headings       []models.Heading
+	ReadingMinutes int
+}
+
+// SplitFrontMatter separates the YAML front matter from the Markdown body.
+// Sources without front matter return a zero FrontMatter.
+func SplitFrontMatter(source string) (FrontMatter, string, error) {
+	var fm FrontMatter
+	source = strings.TrimPrefix(strings.ReplaceAll(source, "\r\n", "\n"), "\ufeff")
+	if !strings.HasPrefix(source, "---\n") {
+		return fm, source, nil
+	}
+	header, body, ok := strings.Cut(source[len("---\n"):], "\n---")
+	if !ok || (body != "" && body[0] != '\n') {
+		return fm, "", fmt.Errorf("unterminated front matter")
+	}
+	if err := yaml.Unmarshal([]byte(header), &fm); err != nil {
+		return fm, "", fmt.Errorf("invalid front matter: %w", err)
+	}
+	return fm, strings.TrimPrefix(body, "\n"), nil
+}
+
+// Field is a top-level front matter entry; Value is written as is, so it
+// must be valid YAML
+type Field struct {
+	Key   string
+	Value string
+}
+
+// SetFrontMatter sets fields in the front matter of source, replacing their
+// line or adding them before the closing "---". Other lines, comments
+// included, are kept as written. A front matter is added when missing.
+func SetFrontMatter(source string, fields ...Field) string {
+	source = strings.TrimPrefix(strings.ReplaceAll(source, "\r\n", "\n"), "\ufeff")
+	var header []string
+	body := source
+	if strings.HasPrefix(source, "---\n") {
+		if h, rest, ok := strings.Cut(source[len("---\n"):], "\n---"); ok && (rest == "" || rest[0] == '\n') {
+			header = strings.Split(h, "\n")
+			body = strings.TrimPrefix(rest, "\n")
+		}
+	}
+	for _, field := range fields {
+		line := field.Key + ": " + field.Value
+		found := false
+		for i, existing := range header {
+			if strings.HasPrefix(existing, field.Key+":") {
+				header[i], found = line, true
+				break
+			}
+		}
+		if !found {
+			header = append(header, line)
+		}
+	}
+	return "---\n" + strings.Join(header, "\n") + "\n---\n" + body
+}
+
+var renderer = goldmark.New(
+	goldmark.WithExtensions(
+		extension.GFM,
+		highlighting.NewHighlighting(
+			highlighting.WithStyle("monokai"),
+			highlighting.WithFormatOptions(html.TabWidth(4)),
+		),
+	),
+	goldmark.WithParserOptions(
+		parser.WithAutoHeadingID(),
+		parser.WithASTTransformers(util.Prioritized(headingAnchors{}, 100)),
+	),
+	// Without html.WithUnsafe, raw HTML is omitted and javascript: links are emptied
+)
+
+// Render converts a Markdown body to HTML
+func Render(body string) (*Document, error) {
+	source := []byte(body)
+	doc := renderer.Parser().Parse(text.NewReader(source))
+
+	var headings []models.Heading
+	err := ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		heading, ok := n.(*ast.Heading)
+		if !entering || !ok {
+			return ast.WalkContinue, nil
+		}
+		id, _ := heading.AttributeString("id")
+		idBytes, _ := id.([]byte)
+		headings = append(headings, models.Heading{Level: heading.Level, ID: string(idBytes), Text: headingText(heading, source)})
+		return ast.WalkSkipChildren, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := renderer.Renderer().Render(&buf, source, doc); err != nil {
+		return nil, fmt.Errorf("unable to render markdown: %w", err)
+	}
+	return &Document{HTML: buf.String(), Headings: headings, ReadingMinutes: ReadingMinutes(body)}, nil
+}
+
+// ReadingMinutes estimates the reading time of a body, at least one minute
+func ReadingMinutes(body string) int {
+	words := len(strings.Fields(body))
+	return max(1, (words+WordsPerMinute-1)/WordsPerMinute)
+}
+
+// headingText is the plain text of a heading, without the anchor link
+func headingText(heading *ast.Heading, source []byte) string {
+	var b strings.Builder
+	for child := heading.FirstChild(); child != nil; child = child.NextSibling() {
+		if isHeadingAnchor(child) {
+			continue
+		}
+		_ = ast.Walk(child, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+			if t, ok := n.(*ast.Text); ok && entering {
+				b.Write(t.Segment.Value(source))
+			} else if s, ok := n.(*ast.String); ok && entering {
+				b.Write(s.Value)
+			}
+			return ast.WalkContinue, nil
+		})
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// headingAnchors appends a "#" link to its own id to every heading
+type headingAnchors struct{}
+
+// anchorClass is the class of the links added by headingAnchors
+const anchorClass = "heading-anchor"
+
+func isHeadingAnchor(n ast.Node) bool {
+	if _, ok := n.(*ast.Link); !ok {
+		return false
+	}
+	class, _ := n.AttributeString("class")
+	b, _ := class.([]byte)
+	return string(b) == anchorClass
+}
+
+func (headingAnchors) Transform(doc *ast.Document, reader text.Reader, pc parser.Context) {
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		heading, ok := n.(*ast.Heading)
+		if !entering || !ok {
+			return ast.WalkContinue, nil
+		}
+		id, ok := heading.AttributeString("id")
+		if !ok {
+			return ast.WalkSkipChildren, nil
+		}
+		idBytes, _ := id.([]byte)
+		anchor := ast.NewLink()
+		anchor.Destination = append([]byte("#"), idBytes...)
+		anchor.SetAttributeString("class", []byte(anchorClass))
+		anchor.AppendChild(anchor, ast.NewString([]byte("#")))
+		heading.AppendChild(heading, anchor)
+		return ast.WalkSkipChildren, nil
+	})
+}
@@ -0,0 +1,209 @@
+// Package media checks and cleans uploaded files: the type comes from the
+// content (magic bytes), not the name; image metadata (EXIF, XMP, text
+// chunks) is removed and smaller variants are generated for srcset.
+package media
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif" // registers the GIF decoder
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"strings"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // registers the WebP decoder
+)
+
+var (
+	ErrUnsupportedType = errors.New("unsupported file type")
+	ErrTooLarge        = errors.New("file too large")
+	ErrInvalidFile     = errors.New("invalid file")
+)
+
+// Types maps the accepted content types to their file extension. SVG is
+// not accepted: it can carry scripts.
+var Types = map[string]string{
+	"image/jpeg":      "jpg",
+	"image/png":       "png",
+	"image/gif":       "gif",
+	"image/webp":      "webp",
+	"application/pdf": "pdf",
+}
+
+// TypeByExtension returns the content type stored under ext, "" when unknown
+func TypeByExtension(ext string) string {
+	for contentType, e := range Types {
+		if e == ext {
+			return contentType
+		}
+	}
+	return ""
+}
+
+// JPEGQuality is the quality of re-encoded and resized JPEG images
+const JPEGQuality = 85
+
+// Limits bounds the accepted uploads; zero means no limit
+type Limits struct {
+	MaxBytes  int64 // size of the upload
+	MaxPixels int64 // width × height of images, checked before decoding
+}
+
+// File is an accepted upload, without its metadata
+type File struct {
+	Data        []byte
+	ContentType string
+	Width       int // zero for documents
+	Height      int
+}
+
+// IsImage reports whether the file is an image
+func (f *File) IsImage() bool {
+	return strings.HasPrefix(f.ContentType, "image/")
+}
+
+// Prepare detects the type of data, checks it against limits and removes the
+// image metadata. JPEG images rotated by their EXIF orientation are rotated
+// for real, since the orientation tag goes away with the metadata.
+func Prepare(data []byte, limits Limits) (*File, error) {
+	if limits.MaxBytes > 0 && int64(len(data)) > limits.MaxBytes {
+		return nil, fmt.Errorf("%w: %d bytes, at most %d", ErrTooLarge, len(data), limits.MaxBytes)
+	}
+	contentType := http.DetectContentType(data)
+	if _, ok := Types[contentType]; !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedType, contentType)
+	}
+	file := &File{Data: data, ContentType: contentType}
+	if !file.IsImage() {
+		return file, nil
+	}
+
+	orientation := 1
+	var err error
+	switch contentType {
+	case "image/jpeg":
+		file.Data, orientation, err = stripJPEG(data)
+	case "image/png":
+		file.Data, err = stripPNG(data)
+	case "image/webp":
+		file.Data, err = stripWebP(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(file.Data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+	if limits.MaxPixels > 0 && int64(config.Width)*int64(config.Height) > limits.MaxPixels {
+		return nil, fmt.Errorf("%w: %dx%d pixels", ErrTooLarge, config.Width, config.Height)
+	}
+	file.Width, file.Height = config.Width, config.Height
+
+	if orientation > 1 && orientation <= 8 {
+		img, err := jpeg.Decode(bytes.NewReader(file.Data))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+		}
+		rotated := orient(img, orientation)
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, rotated, &jpeg.Options{Quality: JPEGQuality}); err != nil {
+			return nil, fmt.Errorf("unable to encode image: %w", err)
+		}
+		file.Data = buf.Bytes()
+		file.Width, file.Height = rotated.Bounds().Dx(), rotated.Bounds().Dy()
+	}
+	return file, nil
+}
+
+// Variant is a resized copy of an image
+type Variant struct {
+	Data        []byte
+	ContentType string
+	Width       int
+	Height      int
+}
+
+// Variants resizes an image to each width smaller than its own, keeping the
+// aspect ratio. Opaque images are encoded as JPEG, the others as PNG. GIFs
+// are left alone, a resize would drop their animation.
+func Variants(file *File, widths []int) ([]Variant, error) {
+	if !file.IsImage() || file.ContentType == "image/gif" {
+		return nil, nil
+	}
+	var img image.Image
+	var variants []Variant
+	for _, width := range widths {
+		if width <= 0 || width >= file.Width {
+			continue
+		}
+		if img == nil {
+			var err error
+			if img, _, err = image.Decode(bytes.NewReader(file.Data)); err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+			}
+		}
+		height := max(1, file.Height*width/file.Width)
+		resized := image.NewNRGBA(image.Rect(0, 0, width, height))
+		draw.CatmullRom.Scale(resized, resized.Bounds(), img, img.Bounds(), draw.Src, nil)
+
+		variant := Variant{Width: width, Height: height}
+		var buf bytes.Buffer
+		var err error
+		if resized.Opaque() {
+			variant.ContentType = "image/jpeg"
+			err = jpeg.Encode(&buf, resized, &jpeg.Options{Quality: JPEGQuality})
+		} else {
+			variant.ContentType = "image/png"
+			err = png.Encode(&buf, resized)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to encode image: %w", err)
+		}
+		variant.Data = buf.Bytes()
+		variants = append(variants, variant)
+	}
+	return variants, nil
+}
+
+// orient applies an EXIF orientation (2 to 8) to img
+func orient(img image.Image, orientation int) image.Image {
+	b := img.Bounds()
+	src := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(src, src.Bounds(), img, b.Min, draw.Src)
+	w, h := b.Dx(), b.Dy()
+
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2: // mirrored
+				sx, sy = w-1-x, y
+			case 3: // rotated 180°
+				sx, sy = w-1-x, h-1-y
+			case 4: // mirrored vertically
+				sx, sy = x, h-1-y
+			case 5: // transposed
+				sx, sy = y, x
+			case 6: // rotated 90° clockwise
+				sx, sy = y, h-1-x
+			case 7: // transversed
+				sx, sy = w-1-y, h-1-x
+			case 8: // rotated 90° counter-clockwise
+				sx, sy = w-1-y, x
+			}
+			copy(dst.Pix[dst.PixOffset(x, y):dst.PixOffset(x, y)+4], src.Pix[src.PixOffset(sx, sy):src.PixOffset(sx, sy)+4])
+		}
+	}
+	return dst
+}
@@ -0,0 +1,167 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+)
+
+var errTruncated = errors.New("truncated file")
+
+// stripJPEG drops the APP1 (EXIF, XMP), APP13 (IPTC) and comment segments
+// of a JPEG without re-encoding it, and returns the EXIF orientation (1 when
+// absent). APP0 (JFIF), APP2 (ICC profile) and APP14 (Adobe) are kept: they
+// affect how the image is displayed.
+func stripJPEG(data []byte) ([]byte, int, error) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil, 0, errors.New("missing JPEG header")
+	}
+	out := make([]byte, 0, len(data))
+	out = append(out, 0xFF, 0xD8)
+	orientation := 1
+	for i := 2; ; {
+		// Markers may be preceded by any number of 0xFF fill bytes
+		for i < len(data) && data[i] == 0xFF && i+1 < len(data) && data[i+1] == 0xFF {
+			i++
+		}
+		if i+1 >= len(data) || data[i] != 0xFF {
+			return nil, 0, errTruncated
+		}
+		marker := data[i+1]
+		if marker == 0xD9 || marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7) {
+			// Standalone markers: end of image, TEM, restarts
+			out = append(out, data[i:i+2]...)
+			i += 2
+			if marker == 0xD9 {
+				return out, orientation, nil
+			}
+			continue
+		}
+		if i+4 > len(data) {
+			return nil, 0, errTruncated
+		}
+		end := i + 2 + int(binary.BigEndian.Uint16(data[i+2:]))
+		if end > len(data) || end < i+4 {
+			return nil, 0, errTruncated
+		}
+		segment := data[i:end]
+		if marker == 0xDA {
+			// Start of scan: the entropy-coded data and the rest of the file
+			// are copied as is
+			return append(out, data[i:]...), orientation, nil
+		}
+		switch marker {
+		case 0xE1:
+			if payload := segment[4:]; bytes.HasPrefix(payload, []byte("Exif\x00\x00")) {
+				orientation = exifOrientation(payload[6:])
+			}
+		case 0xED, 0xFE:
+		default:
+			out = append(out, segment...)
+		}
+		i = end
+	}
+}
+
+// exifOrientation reads the orientation tag (0x0112) of the first IFD of a
+// TIFF structure, 1 when missing or malformed
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 1
+	}
+	count := int(order.Uint16(tiff[ifd:]))
+	for n := 0; n < count; n++ {
+		entry := ifd + 2 + n*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:]) == 0x0112 {
+			if v := int(order.Uint16(tiff[entry+8:])); v >= 1 && v <= 8 {
+				return v
+			}
+			return 1
+		}
+	}
+	return 1
+}
+
+// pngMetadataChunks are the PNG chunks removed by stripPNG
+var pngMetadataChunks = map[string]bool{"eXIf": true, "tEXt": true, "zTXt": true, "iTXt": true, "tIME": true}
+
+// stripPNG drops the EXIF, text and timestamp chunks of a PNG. Each chunk
+// carries its own CRC, so the others are copied unchanged.
+func stripPNG(data []byte) ([]byte, error) {
+	const signature = "\x89PNG\r\n\x1a\n"
+	if !bytes.HasPrefix(data, []byte(signature)) {
+		return nil, errors.New("missing PNG signature")
+	}
+	out := make([]byte, 0, len(data))
+	out = append(out, signature...)
+	for i := len(signature); i < len(data); {
+		if i+8 > len(data) {
+			return nil, errTruncated
+		}
+		end := i + 12 + int(binary.BigEndian.Uint32(data[i:]))
+		if end > len(data) || end < i+12 {
+			return nil, errTruncated
+		}
+		if !pngMetadataChunks[string(data[i+4:i+8])] {
+			out = append(out, data[i:end]...)
+		}
+		i = end
+	}
+	return out, nil
+}
+
+// VP8X flags announcing EXIF and XMP chunks
+const (
+	webpFlagEXIF = 0x08
+	webpFlagXMP  = 0x04
+)
+
+// stripWebP drops the EXIF and XMP chunks of a WebP file and clears their
+// flags in the VP8X header
+func stripWebP(data []byte) ([]byte, error) {
+	if len(data) < 12 || string(data[:4]) != "RIFF" || string(data[8:12]) != "WEBP" {
+		return nil, errors.New("missing WebP header")
+	}
+	out := make([]byte, 12, len(data))
+	copy(out, data[:12])
+	for i := 12; i < len(data); {
+		if i+8 > len(data) {
+			return nil, errTruncated
+		}
+		size := int(binary.LittleEndian.Uint32(data[i+4:]))
+		end := i + 8 + size + size%2 // chunks are padded to an even size
+		if end > len(data) || size < 0 {
+			return nil, errTruncated
+		}
+		switch fourCC := string(data[i : i+4]); fourCC {
+		case "EXIF", "XMP ":
+		case "VP8X":
+			start := len(out)
+			out = append(out, data[i:end]...)
+			if size > 0 {
+				out[start+8] &^= webpFlagEXIF | webpFlagXMP
+			}
+		default:
+			out = append(out, data[i:end]...)
+		}
+		i = end
+	}
+	binary.LittleEndian.PutUint32(out[4:], uint32(len(out)-8))
+	return out, nil
+}
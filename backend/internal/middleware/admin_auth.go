@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminAuth returns a middleware that only lets through requests carrying
+// "Authorization: Bearer <token>". An empty token disables the admin API.
+func AdminAuth(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		provided, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if token == "" || !ok || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			c.Header("WWW-Authenticate", `Bearer realm="admin"`)
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+		c.Header("Cache-Control", "no-store")
+		c.Next()
+	}
+}
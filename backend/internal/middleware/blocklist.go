@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Blocklist returns a middleware that hands requests from blocked or banned
+// client IPs to blocked instead of the route handler. blocked typically
+// answers like the real handler would, so clients cannot tell they are banned.
+// It belongs right before the route handler, after rate limiting and
+// idempotency, so banned clients go through the same steps as everyone else.
+// Since the real handler does more work, a successful fake reply is held back
+// until the average duration of the successful real ones has elapsed. The same
+// goes for submissions the route handler drops silently, such as those matching
+// a content rule (see services.WithSilentDrops).
+func Blocklist(blocklist services.IBlocklistService, blocked gin.HandlerFunc) gin.HandlerFunc {
+	var latency handlerLatency
+	return func(c *gin.Context) {
+		start := time.Now()
+		if rule := blocklist.BlockedIP(c.Request.Context(), c.ClientIP()); rule != nil {
+			log.Printf("Request to %s denied by block rule %d", c.FullPath(), rule.ID)
+			blocked(c)
+			c.Abort()
+			// Small replies stay buffered by net/http until the handler
+			// returns, so the client sees nothing before the wait is over
+			if c.Writer.Status() < http.StatusBadRequest {
+				waitFor(c, latency.average()-time.Since(start))
+			}
+			return
+		}
+		ctx, dropped := services.WithSilentDrops(c.Request.Context())
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+		if c.Writer.Status() >= http.StatusBadRequest {
+			return
+		}
+		if dropped.Load() {
+			waitFor(c, latency.average()-time.Since(start))
+			return
+		}
+		latency.observe(time.Since(start))
+	}
+}
+
+// handlerLatency is a moving average of the duration of a handler
+type handlerLatency struct {
+	mu  sync.Mutex
+	avg time.Duration
+}
+
+func (l *handlerLatency) observe(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.avg == 0 {
+		l.avg = d
+		return
+	}
+	l.avg += (d - l.avg) / 8
+}
+
+func (l *handlerLatency) average() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.avg
+}
+
+// waitFor waits for d, or until the client goes away
+func waitFor(c *gin.Context, d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-c.Request.Context().Done():
+	}
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RouteLimit overrides the body limit of one route, such as uploads.
+// Path is the route pattern as registered ("/api/v1/admin/media").
+type RouteLimit struct {
+	Method   string
+	Path     string
+	MaxBytes int64
+}
+
+// BodyLimit returns a middleware that caps the request body at maxBytes,
+// or at the limit of the matched route in routes. Reading past the limit
+// fails with *http.MaxBytesError; requests that announce a larger
+// Content-Length are rejected upfront.
+func BodyLimit(maxBytes int64, routes ...RouteLimit) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit := maxBytes
+		for _, route := range routes {
+			if route.Method == c.Request.Method && route.Path == c.FullPath() {
+				limit = route.MaxBytes
+				break
+			}
+		}
+		if c.Request.ContentLength > limit {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Request body too large"})
+			return
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+		c.Next()
+	}
+}
@@ -0,0 +1,131 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"backend/internal/repository"
+	"backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IdempotencyKeyHeader is the request header clients use to make a POST safe to retry
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// maxIdempotencyKeyLength bounds the client key so the scoped key fits in the DB column
+const maxIdempotencyKeyLength = 128
+
+// responseRecorder keeps a copy of the response body so it can be stored for replay
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Idempotency returns a middleware that replays the first response for a
+// repeated Idempotency-Key instead of running the handler again.
+// Keys are scoped to the route and expire after ttl. Server errors are not
+// stored so that the client can retry with the same key. A request holds
+// its key for lease: if it crashes or hangs without a response, retries
+// get 409 until the lease runs out, then take the key over.
+func Idempotency(store repository.IIdempotencyRepository, ttl, lease time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength || !isPrintableASCII(key) {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid Idempotency-Key header"})
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &maxErr) {
+				c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": "Request body too large"})
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Unable to read request body"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		scopedKey := c.Request.Method + " " + c.FullPath() + " " + key
+		sum := sha256.Sum256(body)
+		requestHash := hex.EncodeToString(sum[:])
+
+		ctx := c.Request.Context()
+		now := time.Now()
+		record, reserved, err := store.Reserve(ctx, scopedKey, requestHash, now.Add(-ttl), now.Add(lease))
+		if err != nil {
+			// Fail open: losing idempotency is better than rejecting the submission
+			log.Printf("Error reserving idempotency key: %v", err)
+			c.Next()
+			return
+		}
+
+		if !reserved {
+			switch {
+			case record.RequestHash != requestHash:
+				c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": "Idempotency-Key was already used with a different payload"})
+			case !record.Completed():
+				c.Header("Retry-After", "1")
+				c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "A request with this Idempotency-Key is already in progress"})
+			default:
+				c.Header("Idempotent-Replayed", "true")
+				c.Data(*record.StatusCode, "application/json; charset=utf-8", record.ResponseBody)
+				c.Abort()
+			}
+			return
+		}
+
+		c.Request = c.Request.WithContext(services.WithIdempotencyKey(ctx, key))
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+
+		c.Next()
+
+		// The request context may already be cancelled; the bookkeeping must still happen
+		storeCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		status := recorder.Status()
+		if status >= http.StatusInternalServerError {
+			if err := store.Release(storeCtx, scopedKey); err != nil {
+				log.Printf("Error releasing idempotency key: %v", err)
+			}
+			return
+		}
+		if err := store.Complete(storeCtx, scopedKey, status, recorder.body.Bytes()); err != nil {
+			log.Printf("Error storing idempotent response: %v", err)
+		}
+	}
+}
+
+func isPrintableASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < 0x21 || s[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,25 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+
+	"backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimit returns a middleware that rejects clients over their limit with
+// 429 Too Many Requests and a Retry-After header (in seconds)
+func RateLimit(limiter *services.RateLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		allowed, retryAfter := limiter.Allow(c.ClientIP())
+		if !allowed {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "Too many requests, please try again later"})
+			return
+		}
+		c.Next()
+	}
+}
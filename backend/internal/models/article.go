@@ -0,0 +1,66 @@
+package models
+
+import "time"
+
+// MaxArticleSlugLength matches the articles.slug column
+const MaxArticleSlugLength = 100
+
+// ArticleSource is the raw Markdown of an article, with its front matter, as
+// read from the articles directory or the articles table
+type ArticleSource struct {
+	Slug      string    `json:"slug"` // file name without .md, or the slug column; the front matter may override it
+	Markdown  string    `json:"markdown"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ArticleInput is the body of PUT /admin/articles/:slug
+type ArticleInput struct {
+	Markdown string `json:"markdown" binding:"required"`
+}
+
+// ArticlePublication is the optional body of POST /admin/articles/:slug/publish.
+// A future At schedules the article through its front matter date.
+type ArticlePublication struct {
+	At *time.Time `json:"at"`
+}
+
+// Heading is a section of an article, for its table of contents
+type Heading struct {
+	Level int    `json:"level"`
+	ID    string `json:"id"`
+	Text  string `json:"text"`
+}
+
+// Article is a write-up rendered from its Markdown source. Only articles
+// that are not drafts and have a PublishedAt in the past are public.
+type Article struct {
+	Slug           string     `json:"slug"`
+	Title          string     `json:"title"`
+	Summary        string     `json:"summary"`
+	Tags           []string   `json:"tags"`
+	ReadingMinutes int        `json:"reading_minutes"`
+	Draft          bool       `json:"draft,omitempty"`
+	PublishedAt    *time.Time `json:"published_at,omitempty"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	HTML           string     `json:"html,omitempty"` // rendered body, only in single-article responses
+	Headings       []Heading  `json:"headings,omitempty"`
+}
+
+// Published reports whether the article is public at now
+func (a *Article) Published(now time.Time) bool {
+	return !a.Draft && a.PublishedAt != nil && !a.PublishedAt.After(now)
+}
+
+// ArticleFilter selects articles; only published ones unless Drafts is set
+type ArticleFilter struct {
+	Tag    string
+	Drafts bool // include drafts and scheduled articles (admin list)
+	Limit  int
+	Offset int
+}
+
+// ArticleTag is a tag with the number of published articles using it
+type ArticleTag struct {
+	Tag      string `json:"tag"`
+	Articles int    `json:"articles"`
+}
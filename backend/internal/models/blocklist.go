@@ -0,0 +1,56 @@
+package models
+
+import "time"
+
+// Block rule kinds
+const (
+	BlockIP      = "ip"      // client IP address or CIDR range
+	BlockEmail   = "email"   // exact sender address (case-insensitive)
+	BlockDomain  = "domain"  // sender domain or any of its subdomains
+	BlockKeyword = "keyword" // case-insensitive text in subject or message
+	BlockRegex   = "regex"   // RE2 expression matched against subject and message
+)
+
+// BlockKinds lists the valid block rule kinds
+var BlockKinds = []string{BlockIP, BlockEmail, BlockDomain, BlockKeyword, BlockRegex}
+
+// Who created a block rule
+const (
+	BlockSourceAdmin = "admin" // added through the admin API
+	BlockSourceAuto  = "auto"  // temporary ban after repeated strikes
+)
+
+// Limits on block rules
+const (
+	MaxBlockValueLength  = 500
+	MaxBlockReasonLength = 200
+)
+
+// BlockRule denies submissions matching Value. Rules with an ExpiresAt in
+// the past no longer apply.
+type BlockRule struct {
+	ID        int64      `json:"id"`
+	Kind      string     `json:"kind"`
+	Value     string     `json:"value"`
+	Reason    string     `json:"reason,omitempty"`
+	Source    string     `json:"source"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Hits      int64      `json:"hits"`
+	LastHitAt *time.Time `json:"last_hit_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// Active reports whether the rule applies at now
+func (r BlockRule) Active(now time.Time) bool {
+	return r.ExpiresAt == nil || r.ExpiresAt.After(now)
+}
+
+// BlockRuleInput is the body of POST /admin/blocklist. ExpiresIn (a Go
+// duration such as "72h") is an alternative to ExpiresAt.
+type BlockRuleInput struct {
+	Kind      string     `json:"kind"`
+	Value     string     `json:"value"`
+	Reason    string     `json:"reason"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	ExpiresIn string     `json:"expires_in"`
+}
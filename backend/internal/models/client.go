@@ -0,0 +1,76 @@
+package models
+
+import (
+	"net/url"
+	"strings"
+	"unicode/utf8"
+)
+
+// Limits on stored client metadata; longer values are truncated
+const (
+	MaxUserAgentLength = 512
+	MaxLanguageLength  = 100
+	MaxReferrerLength  = 500
+	MaxUTMLength       = 100
+	MaxASOrgLength     = 200
+)
+
+// UTMParams are the campaign parameters of the visitor's landing page,
+// forwarded by the frontend
+type UTMParams struct {
+	Source   string `json:"source,omitempty"`
+	Medium   string `json:"medium,omitempty"`
+	Campaign string `json:"campaign,omitempty"`
+	Term     string `json:"term,omitempty"`
+	Content  string `json:"content,omitempty"`
+}
+
+// GeoLocation is where a client IP is registered, from the local GeoIP
+// databases. Empty when unknown.
+type GeoLocation struct {
+	Country string `json:"country,omitempty"` // ISO 3166-1 alpha-2 code
+	ASN     int64  `json:"asn,omitempty"`     // autonomous system number of the network
+	ASOrg   string `json:"as_org,omitempty"`  // organization owning the network
+}
+
+// ClientMetadata describes the request a submission came from. The raw IP
+// is never stored: only its salted hash or its network prefix.
+type ClientMetadata struct {
+	IP       string `json:"-"`
+	IPHash   string `json:"ip_hash,omitempty"`
+	IPPrefix string `json:"ip_prefix,omitempty"`
+	GeoLocation
+	UserAgent string    `json:"user_agent,omitempty"`
+	Language  string    `json:"language,omitempty"`
+	Referrer  string    `json:"referrer,omitempty"`
+	UTM       UTMParams `json:"utm"`
+}
+
+// Normalize cleans the metadata in place: single-line values, bounded
+// lengths, and referrers reduced to scheme, host and path since query
+// strings may carry personal data
+func (m *ClientMetadata) Normalize() {
+	m.UserAgent = truncate(normalizeLine(m.UserAgent), MaxUserAgentLength)
+	m.Language = truncate(normalizeLine(m.Language), MaxLanguageLength)
+	m.Referrer = truncate(cleanReferrer(normalizeLine(m.Referrer)), MaxReferrerLength)
+	m.ASOrg = truncate(normalizeLine(m.ASOrg), MaxASOrgLength)
+	for _, v := range []*string{&m.UTM.Source, &m.UTM.Medium, &m.UTM.Campaign, &m.UTM.Term, &m.UTM.Content} {
+		*v = truncate(normalizeLine(*v), MaxUTMLength)
+	}
+}
+
+func cleanReferrer(referrer string) string {
+	u, err := url.Parse(referrer)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ""
+	}
+	return u.Scheme + "://" + strings.ToLower(u.Host) + u.EscapedPath()
+}
+
+// truncate cuts s to at most n runes
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n])
+}
@@ -1,9 +1,54 @@
 package models
 
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+)
+
+// Field limits for contact submissions. Only the subject has a matching column
+// size in db/config/01-schema.sql: the other fields may be stored encrypted.
+const (
+	MaxNameLength    = 200
+	MaxEmailLength   = 254
+	MaxSubjectLength = 32
+	MaxMessageLength = 10000
+)
+
+// ContactSubjects lists the subjects offered by the frontend contact form
+var ContactSubjects = []string{"collaboration", "stage", "question", "other"}
+
 // ContactForm represents the structure of the contact form data
 type ContactForm struct {
-	Name    string `json:"name" binding:"required"`
-	Email   string `json:"email" binding:"required,email"`
-	Subject string `json:"subject" binding:"required"`
-	Message string `json:"message" binding:"required"`
+	Name    string `json:"name" binding:"required" validate:"required,max=200"`
+	Email   string `json:"email" binding:"required" validate:"required,contact_email,max=254"`
+	Subject string `json:"subject" binding:"required" validate:"required,max=32,contact_subject"`
+	Message string `json:"message" binding:"required" validate:"required,max=10000"`
+}
+
+// ContactSubmission is a contact form as stored in the database
+type ContactSubmission struct {
+	ID int64 `json:"id"`
+	ContactForm
+	Priority   string     `json:"priority"`
+	Tags       []string   `json:"tags"`
+	Status     string     `json:"status"`
+	Assignee   *string    `json:"assignee,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	ReadAt     *time.Time `json:"read_at,omitempty"`
+	RepliedAt  *time.Time `json:"replied_at,omitempty"`
+	ArchivedAt *time.Time `json:"archived_at,omitempty"`
+	DeletedAt  *time.Time `json:"deleted_at,omitempty"`
+
+	// Request the form was sent with
+	Client ClientMetadata `json:"client"`
+}
+
+// DedupeHash fingerprints the parts of a submission that identify a duplicate:
+// the same sender, subject and message. The form should be normalized first.
+func (f ContactForm) DedupeHash() string {
+	sum := sha256.Sum256([]byte(strings.ToLower(f.Email) + "\x00" + f.Subject + "\x00" + f.Message))
+	return hex.EncodeToString(sum[:])
 }
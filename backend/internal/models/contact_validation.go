@@ -0,0 +1,110 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/go-playground/validator/v10"
+	"golang.org/x/text/unicode/norm"
+)
+
+// ErrInvalidContactForm is returned (wrapped) when a contact form fails validation
+var ErrInvalidContactForm = errors.New("invalid contact form")
+
+// contactValidator is shared by every caller so the rules only live in one place
+var contactValidator = newContactValidator()
+
+func newContactValidator() *validator.Validate {
+	v := validator.New()
+	_ = v.RegisterValidation("contact_subject", func(fl validator.FieldLevel) bool {
+		return IsContactSubject(fl.Field().String())
+	})
+	_ = v.RegisterValidation("contact_email", func(fl validator.FieldLevel) bool {
+		return IsValidEmail(fl.Field().String())
+	})
+	return v
+}
+
+// IsContactSubject reports whether subject is one of ContactSubjects
+func IsContactSubject(subject string) bool {
+	for _, s := range ContactSubjects {
+		if s == subject {
+			return true
+		}
+	}
+	return false
+}
+
+// Normalize cleans user input in place: Unicode is converted to NFC,
+// control characters are stripped and surrounding whitespace is trimmed.
+// Line breaks and tabs are kept in the message only. The email gets its
+// canonical form (see CanonicalEmail).
+func (f *ContactForm) Normalize() {
+	f.Name = normalizeLine(f.Name)
+	f.Email = normalizeLine(f.Email)
+	if canonical, err := CanonicalEmail(f.Email); err == nil {
+		f.Email = canonical
+	}
+	f.Subject = strings.ToLower(normalizeLine(f.Subject))
+	f.Message = normalizeText(f.Message)
+}
+
+// Validate checks the form against the field rules declared on ContactForm.
+// It should be called after Normalize.
+func (f ContactForm) Validate() error {
+	if err := contactValidator.Struct(f); err != nil {
+		var verrs validator.ValidationErrors
+		if errors.As(err, &verrs) && len(verrs) > 0 {
+			return fmt.Errorf("%w: %s", ErrInvalidContactForm, describeFieldError(verrs[0]))
+		}
+		return fmt.Errorf("%w: %v", ErrInvalidContactForm, err)
+	}
+	return nil
+}
+
+// describeFieldError turns a validator error into a short message safe to return to clients
+func describeFieldError(fe validator.FieldError) string {
+	field := strings.ToLower(fe.Field())
+	switch fe.Tag() {
+	case "required":
+		return field + " is required"
+	case "max":
+		return fmt.Sprintf("%s must be at most %s characters", field, fe.Param())
+	case "contact_email":
+		return "email is not a valid address"
+	case "contact_subject":
+		return "subject must be one of: " + strings.Join(ContactSubjects, ", ")
+	default:
+		return field + " is invalid"
+	}
+}
+
+// normalizeLine normalizes a single-line value; all control characters are removed
+func normalizeLine(s string) string {
+	s = norm.NFC.String(s)
+	s = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, s)
+	return strings.TrimSpace(s)
+}
+
+// normalizeText normalizes a multi-line value; CRLF becomes LF and only LF/TAB survive
+func normalizeText(s string) string {
+	s = norm.NFC.String(s)
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = strings.Map(func(r rune) rune {
+		if r == '\n' || r == '\t' {
+			return r
+		}
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, s)
+	return strings.TrimSpace(s)
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// ContentEntry is a piece of published content (a project, an article...)
+// as listed in the sitemap and the feeds
+type ContentEntry struct {
+	Title       string
+	Path        string // page path, such as /projects/proxmox
+	Summary     string
+	Tags        []string
+	PublishedAt time.Time
+	UpdatedAt   time.Time
+}
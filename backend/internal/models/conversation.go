@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// Direction of a conversation message
+const (
+	MessageOutbound = "outbound" // sent by the admin to the visitor
+	MessageInbound  = "inbound"  // received from the visitor by email
+)
+
+// MaxReplyLength bounds the body of an admin reply
+const MaxReplyLength = 20000
+
+// ContactMessage is one email exchanged about a submission. Together with the
+// submission itself they form the conversation history.
+type ContactMessage struct {
+	ID           int64     `json:"id"`
+	SubmissionID int64     `json:"submission_id"`
+	Direction    string    `json:"direction"`
+	MessageID    string    `json:"message_id"`
+	InReplyTo    string    `json:"in_reply_to,omitempty"`
+	References   []string  `json:"references"`
+	From         string    `json:"from"`
+	To           string    `json:"to"`
+	Subject      string    `json:"subject"`
+	Body         string    `json:"body"`
+	Author       string    `json:"author,omitempty"` // admin who sent an outbound message
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// ReplyRequest is the body of POST /admin/contacts/:id/replies
+type ReplyRequest struct {
+	Body    string `json:"body" binding:"required"`
+	Subject string `json:"subject"` // defaults to "Re: <submission subject>"
+	Author  string `json:"author"`
+}
+
+// OutgoingEmail is a plain-text email handed to the mail transport
+type OutgoingEmail struct {
+	From       string
+	To         []string
+	ReplyTo    string
+	Subject    string
+	Text       string
+	MessageID  string
+	InReplyTo  string
+	References []string
+}
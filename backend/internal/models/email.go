@@ -0,0 +1,98 @@
+package models
+
+import (
+	"errors"
+	"net/mail"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/idna"
+)
+
+// Limits of RFC 5321 on the parts of an address, in bytes
+const (
+	maxLocalPartLength = 64
+	maxDomainLength    = 253
+)
+
+// ErrNonASCIILocalPart is returned when an address can only be sent
+// through a relay supporting SMTPUTF8
+var ErrNonASCIILocalPart = errors.New("email local part is not ASCII")
+
+// ASCIIDomain returns the lowercase ASCII form of domain: internationalized
+// labels are converted to punycode (IDNA 2008 lookup rules)
+func ASCIIDomain(domain string) (string, error) {
+	ascii, err := idna.Lookup.ToASCII(strings.TrimSuffix(strings.TrimSpace(domain), "."))
+	if err != nil {
+		return "", err
+	}
+	if ascii == "" {
+		return "", errors.New("empty domain")
+	}
+	return strings.ToLower(ascii), nil
+}
+
+// splitEmail returns the local part and domain of a bare address
+func splitEmail(address string) (local, domain string, ok bool) {
+	at := strings.LastIndex(address, "@")
+	if at <= 0 || at == len(address)-1 {
+		return "", "", false
+	}
+	return address[:at], address[at+1:], true
+}
+
+// IsValidEmail reports whether address is a bare address (no display name)
+// that can receive mail. Internationalized addresses (RFC 6531) are
+// accepted: UTF-8 local parts and IDN domains, in Unicode or punycode.
+func IsValidEmail(address string) bool {
+	parsed, err := mail.ParseAddress(address)
+	if err != nil || parsed.Name != "" || parsed.Address != address {
+		return false
+	}
+	local, domain, ok := splitEmail(address)
+	if !ok || len(local) > maxLocalPartLength || !utf8.ValidString(local) {
+		return false
+	}
+	ascii, err := ASCIIDomain(domain)
+	return err == nil && len(ascii) <= maxDomainLength && strings.Contains(ascii, ".")
+}
+
+// CanonicalEmail returns the form in which addresses are stored and
+// compared: the domain lowercased and in Unicode, so "José@XN--EXMPLE-CVA.fr"
+// becomes "José@exémple.fr". The local part keeps its case. The address
+// should be NFC-normalized first.
+func CanonicalEmail(address string) (string, error) {
+	local, domain, ok := splitEmail(strings.TrimSpace(address))
+	if !ok {
+		return "", errors.New("email address has no domain")
+	}
+	ascii, err := ASCIIDomain(domain)
+	if err != nil {
+		return "", err
+	}
+	unicode, err := idna.Lookup.ToUnicode(ascii)
+	if err != nil {
+		return "", err
+	}
+	return local + "@" + unicode, nil
+}
+
+// ASCIIEmail returns address with its domain in punycode, the form relays
+// without SMTPUTF8 accept. It fails with ErrNonASCIILocalPart when the
+// local part itself is internationalized.
+func ASCIIEmail(address string) (string, error) {
+	local, domain, ok := splitEmail(address)
+	if !ok {
+		return "", errors.New("email address has no domain")
+	}
+	for i := 0; i < len(local); i++ {
+		if local[i] >= utf8.RuneSelf {
+			return "", ErrNonASCIILocalPart
+		}
+	}
+	ascii, err := ASCIIDomain(domain)
+	if err != nil {
+		return "", err
+	}
+	return local + "@" + ascii, nil
+}
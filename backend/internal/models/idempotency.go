@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// IdempotencyRecord is the stored outcome of a request sent with an Idempotency-Key header.
+// StatusCode is nil while the first request is still being processed.
+type IdempotencyRecord struct {
+	Key          string
+	RequestHash  string
+	StatusCode   *int
+	ResponseBody []byte
+	CreatedAt    time.Time
+}
+
+// Completed reports whether the original request finished and its response can be replayed
+func (r IdempotencyRecord) Completed() bool {
+	return r.StatusCode != nil
+}
@@ -0,0 +1,147 @@
+package models
+
+import "time"
+
+// Submission statuses
+const (
+	ContactStatusNew      = "new"
+	ContactStatusRead     = "read"
+	ContactStatusReplied  = "replied"
+	ContactStatusArchived = "archived"
+	ContactStatusSpam     = "spam"
+	ContactStatusTrashed  = "trashed"
+)
+
+// contactTransitions lists the statuses reachable from each status.
+// Trashed submissions are soft-deleted and purged after a grace period
+// unless they are restored first.
+var contactTransitions = map[string][]string{
+	ContactStatusNew:      {ContactStatusRead, ContactStatusReplied, ContactStatusArchived, ContactStatusSpam, ContactStatusTrashed},
+	ContactStatusRead:     {ContactStatusNew, ContactStatusReplied, ContactStatusArchived, ContactStatusSpam, ContactStatusTrashed},
+	ContactStatusReplied:  {ContactStatusRead, ContactStatusArchived, ContactStatusSpam, ContactStatusTrashed},
+	ContactStatusArchived: {ContactStatusRead, ContactStatusReplied, ContactStatusTrashed},
+	ContactStatusSpam:     {ContactStatusNew, ContactStatusRead, ContactStatusTrashed},
+	ContactStatusTrashed:  {ContactStatusNew, ContactStatusRead, ContactStatusArchived},
+}
+
+// IsContactStatus reports whether s is a known submission status
+func IsContactStatus(s string) bool {
+	_, ok := contactTransitions[s]
+	return ok
+}
+
+// CanTransition reports whether a submission may move from one status to another.
+// Staying in the same status is always allowed.
+func CanTransition(from, to string) bool {
+	if from == to {
+		return IsContactStatus(to)
+	}
+	for _, s := range contactTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// MaxSearchQueryLength bounds the full-text search query
+const MaxSearchQueryLength = 200
+
+// ContactFilter selects submissions in the admin inbox
+type ContactFilter struct {
+	Status   string // empty lists every status except trashed
+	Tag      string
+	Assignee string
+	IPHash   string     // submissions sent from the same client IP
+	Query    string     // full-text search (websearch syntax: words, "phrases", -excluded, or)
+	Since    *time.Time // received at or after
+	Until    *time.Time // received before
+	Limit    int
+	Offset   int
+}
+
+// Dimensions of the sources report (GET /admin/contacts/sources)
+const (
+	SourceReferrer    = "referrer"
+	SourceUTMSource   = "utm_source"
+	SourceUTMMedium   = "utm_medium"
+	SourceUTMCampaign = "utm_campaign"
+	SourceLanguage    = "language"
+	SourceIPHash      = "ip_hash"
+	SourceCountry     = "country"
+	SourceASOrg       = "as_org"
+)
+
+// SourceDimensions lists the valid sources report dimensions
+var SourceDimensions = []string{SourceReferrer, SourceUTMSource, SourceUTMMedium, SourceUTMCampaign, SourceLanguage,
+	SourceIPHash, SourceCountry, SourceASOrg}
+
+// SourceFilter selects the submissions counted by the sources report
+type SourceFilter struct {
+	By    string     // one of SourceDimensions
+	Since *time.Time // received at or after
+	Until *time.Time // received before
+	Limit int
+}
+
+// SourceCount is one row of the sources report: how many submissions came
+// with a given value, and how many of them got a reply or were marked spam
+type SourceCount struct {
+	Value   string `json:"value"`
+	Count   int64  `json:"count"`
+	Replied int64  `json:"replied"`
+	Spam    int64  `json:"spam"`
+}
+
+// ContactSearchResult is a submission matching a full-text query
+type ContactSearchResult struct {
+	ContactSubmission
+	Rank    float32 `json:"rank"`
+	Snippet string  `json:"snippet"` // HTML-escaped message excerpt, matches wrapped in <mark>
+}
+
+// ContactChanges is a partial update of a submission; nil fields are left untouched
+type ContactChanges struct {
+	Status   *string   `json:"status"`
+	Tags     *[]string `json:"tags"`
+	Assignee *string   `json:"assignee"` // empty string unassigns
+}
+
+// ContactUpdate is the body of PATCH /admin/contacts/:id
+type ContactUpdate struct {
+	ContactChanges
+	AddTags    []string `json:"add_tags"`
+	RemoveTags []string `json:"remove_tags"`
+	Note       string   `json:"note"`   // appended as an internal note
+	Author     string   `json:"author"` // author of the note
+}
+
+// ContactBulkUpdate is the body of POST /admin/contacts/bulk
+type ContactBulkUpdate struct {
+	IDs        []int64  `json:"ids" binding:"required,min=1,max=500"`
+	Status     *string  `json:"status"`
+	Assignee   *string  `json:"assignee"`
+	AddTags    []string `json:"add_tags"`
+	RemoveTags []string `json:"remove_tags"`
+}
+
+// ContactBulkResult reports which submissions a bulk update changed
+type ContactBulkResult struct {
+	Updated []int64          `json:"updated"`
+	Failed  map[int64]string `json:"failed"`
+}
+
+// ContactNote is an internal admin note on a submission
+type ContactNote struct {
+	ID           int64     `json:"id"`
+	SubmissionID int64     `json:"submission_id"`
+	Author       string    `json:"author"`
+	Body         string    `json:"body"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// ContactDetail is a submission with its notes
+type ContactDetail struct {
+	ContactSubmission
+	Notes []ContactNote `json:"notes"`
+}
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// Media is an uploaded file, stored under the SHA-256 of its content (after
+// metadata removal), so uploading the same file twice stores it once.
+// URLs are relative to the site root.
+type Media struct {
+	ID          int64          `json:"id"`
+	SHA256      string         `json:"sha256"`
+	ContentType string         `json:"content_type"`
+	Size        int64          `json:"size"`
+	Width       int            `json:"width,omitempty"` // images only
+	Height      int            `json:"height,omitempty"`
+	Filename    string         `json:"filename"` // name of the first upload, for display
+	Name        string         `json:"name"`     // stored file name: <sha256>.<ext>
+	URL         string         `json:"url"`
+	Variants    []MediaVariant `json:"variants"` // smaller copies, narrowest first
+	SrcSet      string         `json:"srcset,omitempty"`
+	CreatedAt   time.Time      `json:"created_at"`
+}
+
+// MediaVariant is a resized copy of an image, for srcset
+type MediaVariant struct {
+	Name        string `json:"name"` // <sha256>-<width>w.<ext>
+	ContentType string `json:"content_type"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	Size        int64  `json:"size"`
+	URL         string `json:"url,omitempty"`
+}
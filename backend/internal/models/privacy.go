@@ -0,0 +1,63 @@
+package models
+
+import "time"
+
+// Retention modes: what happens to submissions past the retention period
+// and to the data of a visitor who asks for erasure
+const (
+	RetentionAnonymize = "anonymize" // personal fields are overwritten, statistics survive
+	RetentionDelete    = "delete"    // rows are removed
+)
+
+// Actions recorded in the privacy audit log
+const (
+	PrivacyActionRequest   = "request"
+	PrivacyActionExport    = "export"
+	PrivacyActionErase     = "erase"
+	PrivacyActionRetention = "retention"
+)
+
+// AnonymizedValue replaces personal text fields when a submission is anonymized
+const AnonymizedValue = "[anonymized]"
+
+// IsRetentionMode reports whether m is a known retention mode
+func IsRetentionMode(m string) bool {
+	return m == RetentionAnonymize || m == RetentionDelete
+}
+
+// PrivacyRequest is a pending "request my data" verification. Only the hash
+// of the token sent by email is stored.
+type PrivacyRequest struct {
+	ID        int64
+	Email     string
+	TokenHash string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+	CreatedAt time.Time
+}
+
+// PrivacyExport is everything stored about one email address
+type PrivacyExport struct {
+	Email       string              `json:"email"`
+	GeneratedAt time.Time           `json:"generated_at"`
+	Submissions []PrivacySubmission `json:"submissions"`
+}
+
+// PrivacySubmission is a submission with its notes and conversation
+type PrivacySubmission struct {
+	ContactSubmission
+	Notes    []ContactNote    `json:"notes"`
+	Messages []ContactMessage `json:"messages"`
+}
+
+// PrivacyAuditEntry records a privacy action. The subject is identified by
+// the SHA-256 of the normalized email so the log holds no personal data.
+type PrivacyAuditEntry struct {
+	ID          int64     `json:"id"`
+	Action      string    `json:"action"`
+	SubjectHash string    `json:"subject_hash,omitempty"`
+	Actor       string    `json:"actor"`
+	Affected    int64     `json:"affected"`
+	Detail      string    `json:"detail,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
@@ -0,0 +1,168 @@
+package models
+
+import "time"
+
+// ProfileKey is the content key of the profile in revisions: there is a
+// single profile
+const ProfileKey = "main"
+
+// Profile is the stored résumé with its optimistic locking version
+type Profile struct {
+	Resume    Resume    `json:"resume"`
+	Version   int       `json:"version"` // 0 until the profile is first saved
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ProfileInput is the body of PUT /admin/profile. Version must match the
+// stored profile (0 before the first save).
+type ProfileInput struct {
+	Resume  Resume `json:"resume"`
+	Version int    `json:"version"`
+}
+
+// Resume is a profile in the JSON Resume schema (https://jsonresume.org/schema,
+// v1.0.0). Dates are ISO 8601: "2023", "2023-09" or "2023-09-01"; an empty
+// end date means ongoing. Properties outside the schema are dropped, except
+// the skill description and icon used by the site.
+type Resume struct {
+	Schema       string              `json:"$schema,omitempty"`
+	Basics       ResumeBasics        `json:"basics"`
+	Work         []ResumeWork        `json:"work,omitempty"`
+	Volunteer    []ResumeVolunteer   `json:"volunteer,omitempty"`
+	Education    []ResumeEducation   `json:"education,omitempty"`
+	Awards       []ResumeAward       `json:"awards,omitempty"`
+	Certificates []ResumeCertificate `json:"certificates,omitempty"`
+	Publications []ResumePublication `json:"publications,omitempty"`
+	Skills       []ResumeSkill       `json:"skills,omitempty"`
+	Languages    []ResumeLanguage    `json:"languages,omitempty"`
+	Interests    []ResumeInterest    `json:"interests,omitempty"`
+	References   []ResumeReference   `json:"references,omitempty"`
+	Projects     []ResumeProject     `json:"projects,omitempty"`
+	Meta         *ResumeMeta         `json:"meta,omitempty"`
+}
+
+type ResumeBasics struct {
+	Name     string          `json:"name"`
+	Label    string          `json:"label,omitempty"` // job title, such as "Web Developer"
+	Image    string          `json:"image,omitempty"`
+	Email    string          `json:"email,omitempty"`
+	Phone    string          `json:"phone,omitempty"`
+	URL      string          `json:"url,omitempty"`
+	Summary  string          `json:"summary,omitempty"` // paragraphs separated by blank lines
+	Location *ResumeLocation `json:"location,omitempty"`
+	Profiles []ResumeProfile `json:"profiles,omitempty"`
+}
+
+type ResumeLocation struct {
+	Address     string `json:"address,omitempty"`
+	PostalCode  string `json:"postalCode,omitempty"`
+	City        string `json:"city,omitempty"`
+	CountryCode string `json:"countryCode,omitempty"` // ISO-3166-1 alpha-2
+	Region      string `json:"region,omitempty"`
+}
+
+// ResumeProfile is an account on a social network
+type ResumeProfile struct {
+	Network  string `json:"network"`
+	Username string `json:"username,omitempty"`
+	URL      string `json:"url,omitempty"`
+}
+
+type ResumeWork struct {
+	Name        string   `json:"name"` // company
+	Location    string   `json:"location,omitempty"`
+	Description string   `json:"description,omitempty"` // of the company
+	Position    string   `json:"position,omitempty"`
+	URL         string   `json:"url,omitempty"`
+	StartDate   string   `json:"startDate,omitempty"`
+	EndDate     string   `json:"endDate,omitempty"`
+	Summary     string   `json:"summary,omitempty"`
+	Highlights  []string `json:"highlights,omitempty"`
+}
+
+type ResumeVolunteer struct {
+	Organization string   `json:"organization"`
+	Position     string   `json:"position,omitempty"`
+	URL          string   `json:"url,omitempty"`
+	StartDate    string   `json:"startDate,omitempty"`
+	EndDate      string   `json:"endDate,omitempty"`
+	Summary      string   `json:"summary,omitempty"`
+	Highlights   []string `json:"highlights,omitempty"`
+}
+
+type ResumeEducation struct {
+	Institution string   `json:"institution"`
+	URL         string   `json:"url,omitempty"`
+	Area        string   `json:"area,omitempty"`      // such as "Computer Science"
+	StudyType   string   `json:"studyType,omitempty"` // such as "Bachelor"
+	StartDate   string   `json:"startDate,omitempty"`
+	EndDate     string   `json:"endDate,omitempty"`
+	Score       string   `json:"score,omitempty"`
+	Courses     []string `json:"courses,omitempty"`
+}
+
+type ResumeAward struct {
+	Title   string `json:"title"`
+	Date    string `json:"date,omitempty"`
+	Awarder string `json:"awarder,omitempty"`
+	Summary string `json:"summary,omitempty"`
+}
+
+type ResumeCertificate struct {
+	Name   string `json:"name"`
+	Date   string `json:"date,omitempty"`
+	Issuer string `json:"issuer,omitempty"`
+	URL    string `json:"url,omitempty"`
+}
+
+type ResumePublication struct {
+	Name        string `json:"name"`
+	Publisher   string `json:"publisher,omitempty"`
+	ReleaseDate string `json:"releaseDate,omitempty"`
+	URL         string `json:"url,omitempty"`
+	Summary     string `json:"summary,omitempty"`
+}
+
+// ResumeSkill is a skill area. Description and Icon (Font Awesome classes)
+// extend the schema for the skills grid of the site.
+type ResumeSkill struct {
+	Name        string   `json:"name"`
+	Level       string   `json:"level,omitempty"`
+	Keywords    []string `json:"keywords,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Icon        string   `json:"icon,omitempty"`
+}
+
+type ResumeLanguage struct {
+	Language string `json:"language"`
+	Fluency  string `json:"fluency,omitempty"`
+}
+
+type ResumeInterest struct {
+	Name     string   `json:"name"`
+	Keywords []string `json:"keywords,omitempty"`
+}
+
+type ResumeReference struct {
+	Name      string `json:"name"`
+	Reference string `json:"reference,omitempty"`
+}
+
+type ResumeProject struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Highlights  []string `json:"highlights,omitempty"`
+	Keywords    []string `json:"keywords,omitempty"`
+	StartDate   string   `json:"startDate,omitempty"`
+	EndDate     string   `json:"endDate,omitempty"`
+	URL         string   `json:"url,omitempty"`
+	Roles       []string `json:"roles,omitempty"`
+	Entity      string   `json:"entity,omitempty"`
+	Type        string   `json:"type,omitempty"`
+}
+
+type ResumeMeta struct {
+	Canonical    string `json:"canonical,omitempty"`
+	Version      string `json:"version,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+}
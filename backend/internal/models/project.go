@@ -0,0 +1,150 @@
+package models
+
+import (
+	"strings"
+	"time"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Project categories, used by the filter buttons of the projects page
+const (
+	ProjectInfrastructure = "infrastructure"
+	ProjectWeb            = "web"
+	ProjectSystem         = "system"
+	ProjectIoT            = "iot"
+)
+
+// ProjectCategories lists the valid project categories
+var ProjectCategories = []string{ProjectInfrastructure, ProjectWeb, ProjectSystem, ProjectIoT}
+
+// Orders of the public project list
+const (
+	ProjectSortPosition = "position" // the order chosen by the admin (default)
+	ProjectSortRecent   = "recent"   // most recently published first
+	ProjectSortUpdated  = "updated"  // most recently updated first
+	ProjectSortTitle    = "title"    // alphabetical
+)
+
+// ProjectSorts lists the valid orders of the project list
+var ProjectSorts = []string{ProjectSortPosition, ProjectSortRecent, ProjectSortUpdated, ProjectSortTitle}
+
+// Technology is a tag shared by projects, such as "proxmox" or "ansible"
+type Technology struct {
+	Slug     string `json:"slug"`
+	Name     string `json:"name"`
+	Projects int    `json:"projects,omitempty"` // published projects using it, in technology lists
+}
+
+// ProjectLink is an action button of a project card ("Détails", "Pipeline"...)
+type ProjectLink struct {
+	Label string `json:"label"`
+	URL   string `json:"url"`
+	Icon  string `json:"icon,omitempty"` // Font Awesome classes
+}
+
+// ProjectImage is a picture of the project gallery
+type ProjectImage struct {
+	URL     string `json:"url"`
+	Alt     string `json:"alt"`
+	Caption string `json:"caption,omitempty"`
+}
+
+// Project is an entry of the portfolio. Only projects with a PublishedAt in
+// the past are public. Version is incremented on every change of the content
+// or publication and guards admin edits against lost updates.
+type Project struct {
+	ID           int64          `json:"id"`
+	Slug         string         `json:"slug"`
+	Title        string         `json:"title"`
+	Summary      string         `json:"summary"`        // shown on the card
+	Body         string         `json:"body,omitempty"` // detail page text, paragraphs separated by blank lines
+	Category     string         `json:"category"`
+	Badge        string         `json:"badge,omitempty"` // status badge of the card
+	Icon         string         `json:"icon,omitempty"`  // Font Awesome classes
+	Technologies []Technology   `json:"technologies"`
+	Links        []ProjectLink  `json:"links"`
+	Images       []ProjectImage `json:"images"`
+	Position     int            `json:"position"`
+	Version      int            `json:"version"`
+	PublishedAt  *time.Time     `json:"published_at,omitempty"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+}
+
+// ProjectFilter selects projects; only published ones unless Drafts is set
+type ProjectFilter struct {
+	Technology string // technology slug
+	Category   string
+	Sort       string // one of ProjectSorts, ProjectSortPosition when empty
+	Drafts     bool   // include unpublished and scheduled projects (admin list)
+	Limit      int
+	Offset     int
+}
+
+// Limits on admin-provided project data
+const (
+	MaxProjectSlugLength    = 100
+	MaxProjectTitleLength   = 200
+	MaxProjectSummaryLength = 1000
+	MaxProjectBodyLength    = 50000
+	MaxProjectLabelLength   = 100 // badge, icon, link labels and technology names
+	MaxProjectURLLength     = 2000
+	MaxProjectTechnologies  = 20
+	MaxProjectLinks         = 10
+	MaxProjectImages        = 30
+	MaxImageAltLength       = 300
+	MaxImageCaptionLength   = 500
+)
+
+// ProjectInput is the body of POST and PUT /admin/projects. Technologies are
+// given by name ("Proxmox VE") and matched by slug, so existing tags are
+// reused. Version is required on update: the version that was edited.
+type ProjectInput struct {
+	Slug         string         `json:"slug"`
+	Title        string         `json:"title"`
+	Summary      string         `json:"summary"`
+	Body         string         `json:"body"`
+	Category     string         `json:"category"`
+	Badge        string         `json:"badge"`
+	Icon         string         `json:"icon"`
+	Technologies []string       `json:"technologies"`
+	Links        []ProjectLink  `json:"links"`
+	Images       []ProjectImage `json:"images"`
+	Version      int            `json:"version"`
+}
+
+// ProjectPublication is the optional body of POST /admin/projects/:id/publish.
+// A future At schedules the project; Version, when set, must match.
+type ProjectPublication struct {
+	At      *time.Time `json:"at"`
+	Version int        `json:"version"`
+}
+
+// Slugify turns a title into a URL slug: "Infrastructure Réseau HomeLab"
+// becomes "infrastructure-reseau-homelab". Accents are dropped, other
+// characters than ASCII letters and digits become single dashes.
+func Slugify(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range norm.NFD.String(strings.ToLower(s)) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			// combining accent of the previous letter
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		default:
+			dash = true
+		}
+	}
+	slug := b.String()
+	if len(slug) > MaxProjectSlugLength {
+		slug = strings.TrimRight(slug[:MaxProjectSlugLength], "-")
+	}
+	return slug
+}
@@ -0,0 +1,77 @@
+package models
+
+import "time"
+
+// Editable content types, for revisions, previews and schedules
+const (
+	ContentProject = "project" // keyed by project id
+	ContentArticle = "article" // keyed by slug
+	ContentProfile = "profile" // keyed by ProfileKey
+)
+
+// ContentTypes lists the content types with a revision history
+var ContentTypes = []string{ContentProject, ContentArticle, ContentProfile}
+
+// DraftContentTypes lists the content types that can be unpublished, so
+// shown through preview links and scheduled
+var DraftContentTypes = []string{ContentProject, ContentArticle}
+
+// Revision is an immutable snapshot of a content item, stored on every
+// save. Body is the project as YAML, the article Markdown or the profile
+// JSON; it is left out of revision lists.
+type Revision struct {
+	ID          int64     `json:"id"`
+	ContentType string    `json:"content_type"`
+	ContentKey  string    `json:"content_key"`
+	Number      int       `json:"number"` // 1 for the first save of the item
+	Body        string    `json:"body,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// RevisionDiff compares two revisions of the same content item
+type RevisionDiff struct {
+	ContentType string `json:"content_type"`
+	ContentKey  string `json:"content_key"`
+	From        int    `json:"from"` // revision numbers
+	To          int    `json:"to"`
+	Diff        string `json:"diff"` // unified diff, empty when the revisions are equal
+}
+
+// RevisionRestore is the optional body of POST /admin/revisions/:id/restore.
+// Version, when set, must match the current project version.
+type RevisionRestore struct {
+	Version int `json:"version"`
+}
+
+// Scheduled actions
+const (
+	SchedulePublish   = "publish"
+	ScheduleUnpublish = "unpublish"
+)
+
+// ScheduleActions lists the valid scheduled actions
+var ScheduleActions = []string{SchedulePublish, ScheduleUnpublish}
+
+// Schedule publishes or unpublishes a content item at RunAt. DoneAt is set
+// once it ran, with Error when it failed.
+type Schedule struct {
+	ID          int64      `json:"id"`
+	ContentType string     `json:"content_type"`
+	ContentKey  string     `json:"content_key"`
+	Action      string     `json:"action"`
+	RunAt       time.Time  `json:"run_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	DoneAt      *time.Time `json:"done_at,omitempty"`
+	FailedAt    *time.Time `json:"failed_at,omitempty"` // set when the last allowed attempt failed
+	Attempts    int        `json:"attempts"`
+	Error       string     `json:"error,omitempty"` // error of the last failed attempt
+}
+
+// ScheduleFilter selects schedules; ContentType and ContentKey are optional
+type ScheduleFilter struct {
+	ContentType string
+	ContentKey  string
+	Pending     bool // only schedules that did not run yet nor fail for good
+	Limit       int
+	Offset      int
+}
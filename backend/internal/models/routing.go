@@ -0,0 +1,53 @@
+package models
+
+// Submission priorities, from least to most urgent
+const (
+	PriorityLow    = "low"
+	PriorityNormal = "normal"
+	PriorityHigh   = "high"
+	PriorityUrgent = "urgent"
+)
+
+// IsPriority reports whether p is one of the Priority* constants
+func IsPriority(p string) bool {
+	switch p {
+	case PriorityLow, PriorityNormal, PriorityHigh, PriorityUrgent:
+		return true
+	}
+	return false
+}
+
+// RoutingRule decides how a submission is notified. Every non-empty criterion
+// must match (a criterion matches when any of its values does); a rule
+// without criteria matches everything. Rules are evaluated by Position and
+// the first match wins.
+type RoutingRule struct {
+	ID       int64  `json:"id,omitempty"`
+	Name     string `json:"name"`
+	Position int    `json:"position"`
+	Enabled  bool   `json:"enabled"`
+
+	// Criteria
+	Subjects      []string `json:"subjects,omitempty"`       // exact subject values
+	Keywords      []string `json:"keywords,omitempty"`       // case-insensitive, searched in subject and message
+	SenderDomains []string `json:"sender_domains,omitempty"` // sender domain or parent domain
+	Countries     []string `json:"countries,omitempty"`      // ISO country codes of the client IP (GeoIP)
+	ASNs          []int64  `json:"asns,omitempty"`           // autonomous system numbers of the client IP (GeoIP)
+
+	// Actions
+	Recipients []string `json:"recipients,omitempty"` // replaces ADMIN_EMAIL for the email channel
+	Priority   string   `json:"priority,omitempty"`
+	Tags       []string `json:"tags,omitempty"`
+	Channels   []string `json:"channels,omitempty"` // notifier names; empty means all
+	Suppress   bool     `json:"suppress,omitempty"` // store the submission but notify nobody
+}
+
+// RoutingDecision is the outcome of evaluating the routing rules for a submission
+type RoutingDecision struct {
+	Rule       string // name of the matching rule, empty when none matched
+	Recipients []string
+	Priority   string
+	Tags       []string
+	Channels   []string
+	Suppress   bool
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// ShareLink is a short link (/s/<code>) given to one recipient, such as a
+// recruiter, that redirects to the CV or a page of the site
+type ShareLink struct {
+	ID        int64      `json:"id"`
+	Code      string     `json:"code"`
+	Target    string     `json:"target"` // site path, such as /cv.pdf or /projects/proxmox
+	Label     string     `json:"label"`  // who the link was given to
+	ExpiresAt *time.Time `json:"expires_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+	CreatedAt time.Time  `json:"created_at"`
+	Hits      int        `json:"hits"`
+	LastHitAt *time.Time `json:"last_hit_at"`
+	URL       string     `json:"url"` // public short URL
+}
+
+// Active reports whether the link still redirects at now
+func (l *ShareLink) Active(now time.Time) bool {
+	return l.RevokedAt == nil && (l.ExpiresAt == nil || now.Before(*l.ExpiresAt))
+}
+
+// ShareLinkInput is the body of POST /admin/share-links. Code is generated
+// when empty.
+type ShareLinkInput struct {
+	Target    string     `json:"target"`
+	Label     string     `json:"label"`
+	Code      string     `json:"code"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// Client device types of a ShareHit
+const (
+	DeviceDesktop = "desktop"
+	DeviceMobile  = "mobile"
+	DeviceTablet  = "tablet"
+	DeviceBot     = "bot" // crawlers and link previews (Slack, LinkedIn...)
+)
+
+// ShareHit is a visit of a share link. Only coarse client information is
+// kept: no IP, cookie or full user agent.
+type ShareHit struct {
+	ID       int64     `json:"id"`
+	LinkID   int64     `json:"link_id"`
+	At       time.Time `json:"at"`
+	Referrer string    `json:"referrer"` // scheme, host and path only
+	Browser  string    `json:"browser"`  // family, such as Firefox
+	OS       string    `json:"os"`       // family, such as Android
+	Device   string    `json:"device"`
+	Country  string    `json:"country"` // ISO 3166-1 alpha-2 code, from GeoIP
+}
+
+// Normalize bounds the hit fields like ClientMetadata.Normalize
+func (h *ShareHit) Normalize() {
+	h.Referrer = truncate(cleanReferrer(normalizeLine(h.Referrer)), MaxReferrerLength)
+	h.Country = strings.ToUpper(truncate(normalizeLine(h.Country), 2))
+}
+
+// ShareTargetStats counts the links and hits of a target, such as the CV
+type ShareTargetStats struct {
+	Target    string     `json:"target"`
+	Links     int        `json:"links"`
+	Hits      int        `json:"hits"`
+	LastHitAt *time.Time `json:"last_hit_at"`
+}
@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// Webhook event types
+const (
+	WebhookEventContactSubmitted = "contact.submitted"
+)
+
+// Webhook delivery statuses
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// WebhookEvent is the JSON document POSTed to webhook endpoints
+type WebhookEvent struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// WebhookDelivery is one event sent (or to be sent) to one endpoint. Only
+// the submission ID is stored: the payload is built from the submission at
+// each attempt, so no copy of its personal data is kept.
+type WebhookDelivery struct {
+	ID             int64      `json:"id"`
+	Endpoint       string     `json:"endpoint"`
+	EventID        string     `json:"event_id"`
+	EventType      string     `json:"event_type"`
+	SubmissionID   int64      `json:"submission_id"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	LastStatusCode *int       `json:"last_status_code,omitempty"`
+	LastError      *string    `json:"last_error,omitempty"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at,omitempty"` // set while pending
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// IArticleRepository stores the article sources edited from the admin API
+type IArticleRepository interface {
+	ListArticleSources(ctx context.Context) ([]models.ArticleSource, error)
+	GetArticleSource(ctx context.Context, slug string) (*models.ArticleSource, error)
+	SaveArticleSource(ctx context.Context, source *models.ArticleSource) error
+	DeleteArticleSource(ctx context.Context, slug string) error
+}
+
+// ArticleRepository implements IArticleRepository on Postgres
+type ArticleRepository struct {
+	db DBExecutor
+}
+
+// NewArticleRepository creates a new instance of ArticleRepository
+func NewArticleRepository(db DBExecutor) IArticleRepository {
+	return &ArticleRepository{
+		db: db,
+	}
+}
+
+// ListArticleSources returns the Markdown of every article, drafts included
+func (r *ArticleRepository) ListArticleSources(ctx context.Context) ([]models.ArticleSource, error) {
+	rows, err := r.db.Query(ctx, `SELECT slug, markdown, updated_at FROM articles ORDER BY slug`)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list articles: %w", err)
+	}
+	defer rows.Close()
+
+	sources := []models.ArticleSource{}
+	for rows.Next() {
+		var source models.ArticleSource
+		if err := rows.Scan(&source.Slug, &source.Markdown, &source.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("unable to read article: %w", err)
+		}
+		sources = append(sources, source)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("unable to list articles: %w", err)
+	}
+	return sources, nil
+}
+
+// GetArticleSource loads the Markdown of one article
+func (r *ArticleRepository) GetArticleSource(ctx context.Context, slug string) (*models.ArticleSource, error) {
+	var source models.ArticleSource
+	err := r.db.QueryRow(ctx, `SELECT slug, markdown, updated_at FROM articles WHERE slug = $1`, slug).
+		Scan(&source.Slug, &source.Markdown, &source.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to load article: %w", err)
+	}
+	return &source, nil
+}
+
+// SaveArticleSource creates or replaces an article and fills in its update time
+func (r *ArticleRepository) SaveArticleSource(ctx context.Context, source *models.ArticleSource) error {
+	query := `
+		INSERT INTO articles (slug, markdown) VALUES ($1, $2)
+		ON CONFLICT (slug) DO UPDATE SET markdown = EXCLUDED.markdown, updated_at = NOW()
+		RETURNING updated_at`
+
+	if err := r.db.QueryRow(ctx, query, source.Slug, source.Markdown).Scan(&source.UpdatedAt); err != nil {
+		return fmt.Errorf("unable to save article: %w", err)
+	}
+	return nil
+}
+
+// DeleteArticleSource removes an article
+func (r *ArticleRepository) DeleteArticleSource(ctx context.Context, slug string) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM articles WHERE slug = $1`, slug)
+	if err != nil {
+		return fmt.Errorf("unable to delete article: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
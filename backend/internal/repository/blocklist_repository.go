@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// IBlocklistRepository stores the admin deny rules and automatic bans
+type IBlocklistRepository interface {
+	ListRules(ctx context.Context, includeExpired bool) ([]models.BlockRule, error)
+	SaveRule(ctx context.Context, rule *models.BlockRule) error
+	DeleteRule(ctx context.Context, id int64) error
+	RecordHit(ctx context.Context, id int64) error
+	DeleteExpired(ctx context.Context, expiredBefore time.Time) (int64, error)
+}
+
+// BlocklistRepository implements IBlocklistRepository on Postgres
+type BlocklistRepository struct {
+	db DBExecutor
+}
+
+// NewBlocklistRepository creates a new instance of BlocklistRepository
+func NewBlocklistRepository(db DBExecutor) IBlocklistRepository {
+	return &BlocklistRepository{
+		db: db,
+	}
+}
+
+const blockRuleColumns = `id, kind, value, reason, source, expires_at, hits, last_hit_at, created_at`
+
+func scanBlockRule(row pgx.Row) (*models.BlockRule, error) {
+	var r models.BlockRule
+	if err := row.Scan(&r.ID, &r.Kind, &r.Value, &r.Reason, &r.Source, &r.ExpiresAt, &r.Hits, &r.LastHitAt, &r.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// ListRules returns the rules, newest first; expired ones only with includeExpired
+func (r *BlocklistRepository) ListRules(ctx context.Context, includeExpired bool) ([]models.BlockRule, error) {
+	query := `SELECT ` + blockRuleColumns + ` FROM block_rules
+		WHERE $1 OR expires_at IS NULL OR expires_at > NOW()
+		ORDER BY created_at DESC, id DESC`
+
+	rows, err := r.db.Query(ctx, query, includeExpired)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list block rules: %w", err)
+	}
+	defer rows.Close()
+
+	rules := []models.BlockRule{}
+	for rows.Next() {
+		rule, err := scanBlockRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read block rule: %w", err)
+		}
+		rules = append(rules, *rule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("unable to list block rules: %w", err)
+	}
+	return rules, nil
+}
+
+// SaveRule inserts a rule, or replaces the reason, source and expiry of the
+// rule with the same kind and value, and fills in the stored row. An
+// automatic ban of a value that already has a rule only extends its expiry.
+func (r *BlocklistRepository) SaveRule(ctx context.Context, rule *models.BlockRule) error {
+	query := `
+		INSERT INTO block_rules (kind, value, reason, source, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (kind, value) DO UPDATE SET
+			reason = CASE WHEN EXCLUDED.source = 'auto' THEN block_rules.reason ELSE EXCLUDED.reason END,
+			source = CASE WHEN EXCLUDED.source = 'auto' THEN block_rules.source ELSE EXCLUDED.source END,
+			expires_at = CASE
+				WHEN EXCLUDED.source <> 'auto' THEN EXCLUDED.expires_at
+				WHEN block_rules.expires_at IS NULL THEN NULL
+				ELSE GREATEST(block_rules.expires_at, EXCLUDED.expires_at) END
+		RETURNING ` + blockRuleColumns
+
+	saved, err := scanBlockRule(r.db.QueryRow(ctx, query, rule.Kind, rule.Value, rule.Reason, rule.Source, rule.ExpiresAt))
+	if err != nil {
+		return fmt.Errorf("unable to save block rule: %w", err)
+	}
+	*rule = *saved
+	return nil
+}
+
+// DeleteRule removes a rule
+func (r *BlocklistRepository) DeleteRule(ctx context.Context, id int64) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM block_rules WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("unable to delete block rule: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// RecordHit counts a request denied by a rule
+func (r *BlocklistRepository) RecordHit(ctx context.Context, id int64) error {
+	_, err := r.db.Exec(ctx, `UPDATE block_rules SET hits = hits + 1, last_hit_at = NOW() WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("unable to record block rule hit: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpired removes the rules that expired before expiredBefore
+func (r *BlocklistRepository) DeleteExpired(ctx context.Context, expiredBefore time.Time) (int64, error) {
+	tag, err := r.db.Exec(ctx, `DELETE FROM block_rules WHERE expires_at < $1`, expiredBefore)
+	if err != nil {
+		return 0, fmt.Errorf("unable to delete expired block rules: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
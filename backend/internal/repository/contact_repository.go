@@ -2,33 +2,33 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"backend/internal/models"
 
-	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-// DBExecutor interface for database operations (compatible with pgxpool.Pool and pgxmock)
-type DBExecutor interface {
-	Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error)
-}
-
 // IContactRepository defines the interface for contact repository
 type IContactRepository interface {
-	SaveContactForm(ctx context.Context, form models.ContactForm) error
+	SaveContactForm(ctx context.Context, submission *models.ContactSubmission) error
+	SaveContactFormOnce(ctx context.Context, submission *models.ContactSubmission, window time.Duration) (bool, error)
 }
 
 // ContactRepository implements IContactRepository
 type ContactRepository struct {
-	db DBExecutor
+	db    DBExecutor
+	codec submissionCodec
 }
 
 // NewContactRepository creates a new instance of ContactRepository
-func NewContactRepository(db DBExecutor) IContactRepository {
+func NewContactRepository(db DBExecutor, opts ...Option) IContactRepository {
 	return &ContactRepository{
-		db: db,
+		db:    db,
+		codec: newSubmissionCodec(opts),
 	}
 }
 
@@ -37,17 +37,92 @@ func NewContactRepositoryFromPool(pool *pgxpool.Pool) IContactRepository {
 	return NewContactRepository(pool)
 }
 
-// SaveContactForm saves the contact form data to the database
-func (r *ContactRepository) SaveContactForm(ctx context.Context, form models.ContactForm) error {
+// contactInsertColumns are the columns written by SaveContactForm and
+// SaveContactFormOnce, in the order of contactInsertArgs
+const contactInsertColumns = `name, email, subject, message, dedupe_hash, priority, tags,
+			key_version, data_key, email_index,
+			ip_hash, ip_prefix, user_agent, accept_language, referrer,
+			utm_source, utm_medium, utm_campaign, utm_term, utm_content, country, asn, as_org`
+
+// contactInsertArgs seals submission and returns the values of
+// contactInsertColumns
+func (r *ContactRepository) contactInsertArgs(submission *models.ContactSubmission) ([]any, error) {
+	if submission.Priority == "" {
+		submission.Priority = models.PriorityNormal
+	}
+	if submission.Tags == nil {
+		submission.Tags = []string{}
+	}
+
+	client := submission.Client
+	sealed, err := r.codec.seal(submission.ContactForm)
+	if err != nil {
+		return nil, err
+	}
+	return []any{sealed.Name, sealed.Email, submission.Subject, sealed.Message, sealed.DedupeHash,
+		submission.Priority, submission.Tags, sealed.KeyVersion, sealed.DataKey, sealed.EmailIndex,
+		client.IPHash, client.IPPrefix, client.UserAgent, client.Language, client.Referrer,
+		client.UTM.Source, client.UTM.Medium, client.UTM.Campaign, client.UTM.Term, client.UTM.Content,
+		client.Country, client.ASN, client.ASOrg}, nil
+}
+
+// SaveContactForm saves the submission to the database and fills in its ID
+// and creation date. With a keyring, name, email and message are encrypted.
+func (r *ContactRepository) SaveContactForm(ctx context.Context, submission *models.ContactSubmission) error {
 	query := `
-		INSERT INTO contact_submissions (name, email, subject, message)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO contact_submissions (` + contactInsertColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23)
+		RETURNING id, created_at
 		`
 
-	// Call Exec on the db pool
-	_, err := r.db.Exec(ctx, query, form.Name, form.Email, form.Subject, form.Message)
+	args, err := r.contactInsertArgs(submission)
 	if err != nil {
+		return err
+	}
+	if err := r.db.QueryRow(ctx, query, args...).Scan(&submission.ID, &submission.CreatedAt); err != nil {
 		return fmt.Errorf("unable to insert contact in database: %w", err)
 	}
+	submission.Status = models.ContactStatusNew
+	submission.UpdatedAt = submission.CreatedAt
 	return nil
 }
+
+// SaveContactFormOnce saves the submission like SaveContactForm unless one
+// with the same dedupe hash was saved less than window ago, and reports
+// whether it was saved. The duplicate check claims the hash in
+// contact_dedupe within the insert statement: of concurrent duplicates
+// (double clicks, retries) only one gets the claim, the others wait for it
+// and store nothing. Expired claims of other hashes are removed on the way.
+func (r *ContactRepository) SaveContactFormOnce(ctx context.Context, submission *models.ContactSubmission, window time.Duration) (bool, error) {
+	query := `
+		WITH purge AS (
+			DELETE FROM contact_dedupe WHERE expires_at < NOW() AND dedupe_hash <> $5
+		), claim AS (
+			INSERT INTO contact_dedupe (dedupe_hash, expires_at)
+			VALUES ($5, NOW() + $24 * INTERVAL '1 second')
+			ON CONFLICT (dedupe_hash) DO UPDATE SET expires_at = EXCLUDED.expires_at
+				WHERE contact_dedupe.expires_at <= NOW()
+			RETURNING dedupe_hash
+		)
+		INSERT INTO contact_submissions (` + contactInsertColumns + `)
+		SELECT $1, $2, $3, $4, claim.dedupe_hash, $6, $7::TEXT[], $8::SMALLINT, $9::BYTEA, $10,
+			$11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22::BIGINT, $23
+		FROM claim
+		RETURNING id, created_at
+		`
+
+	args, err := r.contactInsertArgs(submission)
+	if err != nil {
+		return false, err
+	}
+	err = r.db.QueryRow(ctx, query, append(args, window.Seconds())...).Scan(&submission.ID, &submission.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("unable to insert contact in database: %w", err)
+	}
+	submission.Status = models.ContactStatusNew
+	submission.UpdatedAt = submission.CreatedAt
+	return true, nil
+}
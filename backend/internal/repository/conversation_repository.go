@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrDuplicateMessage is returned when a message with the same Message-ID is already stored
+var ErrDuplicateMessage = errors.New("message already stored")
+
+// IConversationRepository stores the emails exchanged about submissions
+type IConversationRepository interface {
+	AddMessage(ctx context.Context, message *models.ContactMessage) error
+	ListMessages(ctx context.Context, submissionID int64) ([]models.ContactMessage, error)
+	FindSubmissionByMessageID(ctx context.Context, messageIDs []string) (int64, error)
+}
+
+// ConversationRepository implements IConversationRepository on Postgres
+type ConversationRepository struct {
+	db    DBExecutor
+	codec submissionCodec
+}
+
+// NewConversationRepository creates a new instance of ConversationRepository.
+// With a keyring, the addresses, subject and body of messages are encrypted.
+func NewConversationRepository(db DBExecutor, opts ...Option) IConversationRepository {
+	return &ConversationRepository{
+		db:    db,
+		codec: newSubmissionCodec(opts),
+	}
+}
+
+// messageFields are the encrypted columns of a message
+func messageFields(m *models.ContactMessage) []codecField {
+	return []codecField{{"from", &m.From}, {"to", &m.To}, {"subject", &m.Subject}, {"body", &m.Body}}
+}
+
+// AddMessage stores a message and fills in its ID and creation date.
+// Storing the same Message-ID twice returns ErrDuplicateMessage.
+func (r *ConversationRepository) AddMessage(ctx context.Context, message *models.ContactMessage) error {
+	query := `
+		INSERT INTO contact_messages (submission_id, direction, message_id, in_reply_to, refs,
+			from_address, to_address, subject, body, author, key_version, data_key)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, $7, $8, $9, $10, $11, $12)
+		ON CONFLICT (message_id) DO NOTHING
+		RETURNING id, created_at
+		`
+
+	refs := message.References
+	if refs == nil {
+		refs = []string{}
+	}
+	sealed := *message
+	keyVersion, dataKey, err := r.codec.sealFields(messageFields(&sealed)...)
+	if err != nil {
+		return err
+	}
+	err = r.db.QueryRow(ctx, query, message.SubmissionID, message.Direction, message.MessageID, message.InReplyTo, refs,
+		sealed.From, sealed.To, sealed.Subject, sealed.Body, message.Author, keyVersion, dataKey).
+		Scan(&message.ID, &message.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrDuplicateMessage
+	}
+	if err != nil {
+		return fmt.Errorf("unable to insert message: %w", err)
+	}
+	return nil
+}
+
+// ListMessages returns the conversation of a submission, oldest first
+func (r *ConversationRepository) ListMessages(ctx context.Context, submissionID int64) ([]models.ContactMessage, error) {
+	query := `
+		SELECT id, submission_id, direction, message_id, COALESCE(in_reply_to, ''), refs,
+			from_address, to_address, subject, body, author, created_at, key_version, data_key
+		FROM contact_messages
+		WHERE submission_id = $1
+		ORDER BY created_at, id
+		`
+
+	rows, err := r.db.Query(ctx, query, submissionID)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list messages: %w", err)
+	}
+	defer rows.Close()
+
+	messages := []models.ContactMessage{}
+	for rows.Next() {
+		var m models.ContactMessage
+		var keyVersion *int16
+		var dataKey []byte
+		if err := rows.Scan(&m.ID, &m.SubmissionID, &m.Direction, &m.MessageID, &m.InReplyTo, &m.References,
+			&m.From, &m.To, &m.Subject, &m.Body, &m.Author, &m.CreatedAt, &keyVersion, &dataKey); err != nil {
+			return nil, fmt.Errorf("unable to read message: %w", err)
+		}
+		if err := r.codec.openFields(fmt.Sprintf("message %d", m.ID), keyVersion, dataKey, messageFields(&m)...); err != nil {
+			return nil, err
+		}
+		messages = append(messages, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("unable to list messages: %w", err)
+	}
+	return messages, nil
+}
+
+// FindSubmissionByMessageID returns the submission whose conversation contains
+// one of the given Message-IDs, preferring the most recent message
+func (r *ConversationRepository) FindSubmissionByMessageID(ctx context.Context, messageIDs []string) (int64, error) {
+	query := `
+		SELECT submission_id
+		FROM contact_messages
+		WHERE message_id = ANY($1)
+		ORDER BY created_at DESC, id DESC
+		LIMIT 1
+		`
+
+	var id int64
+	err := r.db.QueryRow(ctx, query, messageIDs).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, ErrNotFound
+	}
+	if err != nil {
+		return 0, fmt.Errorf("unable to find message: %w", err)
+	}
+	return id, nil
+}
@@ -8,9 +8,18 @@ import (
 
 	"backend/config"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// DBExecutor interface for database operations (compatible with pgxpool.Pool and pgxmock)
+type DBExecutor interface {
+	Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, arguments ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, arguments ...interface{}) pgx.Row
+}
+
 // NewDbPool creates and returns a new PostgreSQL connection pool
 func NewDbPool(config config.Config) (*pgxpool.Pool, error) {
 
@@ -0,0 +1,272 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"unicode"
+
+	"backend/internal/models"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// decryptedSearchBatch is the number of submissions decrypted per query by an
+// application-side search; only the matches are kept between batches
+const decryptedSearchBatch = 500
+
+// Snippet window, in characters, around the first match in the message, and
+// how far its ends may move to fall between words
+const (
+	snippetBefore = 80
+	snippetLength = 240
+	snippetSnap   = 20
+)
+
+// searchClause is a group of terms that must all be present and none of the
+// excluded ones; a query matches when any of its clauses does
+type searchClause struct {
+	include [][]rune
+	exclude [][]rune
+}
+
+// searchDecrypted approximates SearchSubmissions on decrypted rows, reading
+// every submission matching the other filters in batches. It understands the
+// same web search syntax ("phrase", -excluded, or) but matches case- and
+// accent-insensitive substrings instead of stemmed words.
+func (r *InboxRepository) searchDecrypted(ctx context.Context, filter models.ContactFilter) ([]models.ContactSearchResult, error) {
+	clauses := parseSearchQuery(filter.Query)
+	if len(clauses) == 0 {
+		return []models.ContactSearchResult{}, nil
+	}
+
+	results := []models.ContactSearchResult{}
+	candidates := filter
+	candidates.Limit, candidates.Offset = decryptedSearchBatch, 0
+	for {
+		submissions, err := r.ListSubmissions(ctx, candidates)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range submissions {
+			fields := [3][]rune{foldRunes(s.Name + " " + s.Email), foldRunes(s.Subject), foldRunes(s.Message)}
+			rank, terms := rankSubmission(fields, clauses)
+			if terms == nil {
+				continue
+			}
+			results = append(results, models.ContactSearchResult{
+				ContactSubmission: s,
+				Rank:              rank,
+				Snippet:           highlight([]rune(s.Message), fields[2], terms),
+			})
+		}
+		if len(submissions) < decryptedSearchBatch {
+			break
+		}
+		candidates.Offset += decryptedSearchBatch
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Rank > results[j].Rank
+	})
+	if filter.Offset >= len(results) {
+		return []models.ContactSearchResult{}, nil
+	}
+	results = results[filter.Offset:]
+	if filter.Limit > 0 && filter.Limit < len(results) {
+		results = results[:filter.Limit]
+	}
+	return results, nil
+}
+
+// fieldWeights mirrors the A/B/C weights of search_vector: sender, subject, message
+var fieldWeights = [3]float32{1.0, 0.4, 0.2}
+
+// rankSubmission returns the rank of the best matching clause and the terms
+// to highlight, or nil terms when no clause matches
+func rankSubmission(fields [3][]rune, clauses []searchClause) (float32, [][]rune) {
+	var best float32
+	var terms [][]rune
+	for _, clause := range clauses {
+		var rank float32
+		matched := true
+		for _, term := range clause.include {
+			found := false
+			for i, field := range fields {
+				if n := countRunes(field, term); n > 0 {
+					found = true
+					rank += fieldWeights[i] * float32(min(n, 5))
+				}
+			}
+			if !found {
+				matched = false
+				break
+			}
+		}
+		for _, term := range clause.exclude {
+			for _, field := range fields {
+				if countRunes(field, term) > 0 {
+					matched = false
+				}
+			}
+		}
+		if matched && len(clause.include) > 0 {
+			terms = append(terms, clause.include...)
+			best = max(best, rank)
+		}
+	}
+	return best, terms
+}
+
+// parseSearchQuery splits a web search query into clauses of folded terms
+func parseSearchQuery(query string) []searchClause {
+	clauses := []searchClause{{}}
+	runes := []rune(query)
+	for i := 0; i < len(runes); {
+		if unicode.IsSpace(runes[i]) {
+			i++
+			continue
+		}
+		exclude := false
+		if runes[i] == '-' {
+			exclude = true
+			i++
+		}
+
+		var token []rune
+		quoted := i < len(runes) && runes[i] == '"'
+		if quoted {
+			i++
+			for i < len(runes) && runes[i] != '"' {
+				token = append(token, runes[i])
+				i++
+			}
+			i++
+		} else {
+			for i < len(runes) && !unicode.IsSpace(runes[i]) {
+				token = append(token, runes[i])
+				i++
+			}
+		}
+
+		word := strings.TrimSpace(string(token))
+		switch {
+		case word == "":
+		case !quoted && !exclude && strings.EqualFold(word, "or"):
+			clauses = append(clauses, searchClause{})
+		case exclude:
+			clauses[len(clauses)-1].exclude = append(clauses[len(clauses)-1].exclude, foldRunes(word))
+		default:
+			clauses[len(clauses)-1].include = append(clauses[len(clauses)-1].include, foldRunes(word))
+		}
+	}
+
+	valid := clauses[:0]
+	for _, c := range clauses {
+		if len(c.include) > 0 {
+			valid = append(valid, c)
+		}
+	}
+	return valid
+}
+
+// foldRunes lowercases s and strips accents rune by rune, so that indexes in
+// the result match indexes in []rune(s)
+func foldRunes(s string) []rune {
+	folded := []rune(s)
+	for i, r := range folded {
+		r = unicode.ToLower(r)
+		if r >= 0x80 {
+			if base := []rune(norm.NFD.String(string(r))); len(base) > 0 {
+				r = base[0]
+			}
+		}
+		folded[i] = r
+	}
+	return folded
+}
+
+func indexRunes(haystack, needle []rune, from int) int {
+	for i := from; i+len(needle) <= len(haystack); i++ {
+		match := true
+		for j := range needle {
+			if haystack[i+j] != needle[j] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return i
+		}
+	}
+	return -1
+}
+
+func countRunes(haystack, needle []rune) int {
+	n := 0
+	for i := indexRunes(haystack, needle, 0); i >= 0; i = indexRunes(haystack, needle, i+len(needle)) {
+		n++
+	}
+	return n
+}
+
+// highlight returns an excerpt of text around the first match, with the
+// matches wrapped in HeadlineStart/HeadlineStop like ts_headline does
+func highlight(text, folded []rune, terms [][]rune) string {
+	first := -1
+	for _, term := range terms {
+		if i := indexRunes(folded, term, 0); i >= 0 && (first < 0 || i < first) {
+			first = i
+		}
+	}
+
+	// The excerpt is cut between words when a space is close enough to the
+	// offsets, and in the middle of long unbroken text otherwise
+	start := 0
+	if first > snippetBefore {
+		start = first - snippetBefore
+		for i := start; i < first && i <= start+snippetSnap; i++ {
+			if unicode.IsSpace(text[i]) {
+				start = i
+				break
+			}
+		}
+	}
+	end := len(text)
+	if start+snippetLength < end {
+		end = start + snippetLength
+		for i := end; i > start && i >= end-snippetSnap; i-- {
+			if unicode.IsSpace(text[i-1]) {
+				end = i
+				break
+			}
+		}
+	}
+
+	marked := make([]bool, len(text))
+	for _, term := range terms {
+		for i := indexRunes(folded, term, start); i >= 0 && i < end; i = indexRunes(folded, term, i+len(term)) {
+			for j := i; j < i+len(term) && j < end; j++ {
+				marked[j] = true
+			}
+		}
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("… ")
+	}
+	for i := start; i < end; i++ {
+		if marked[i] && (i == start || !marked[i-1]) {
+			b.WriteString(HeadlineStart)
+		}
+		b.WriteRune(text[i])
+		if marked[i] && (i == end-1 || !marked[i+1]) {
+			b.WriteString(HeadlineStop)
+		}
+	}
+	if end < len(text) {
+		b.WriteString(" …")
+	}
+	return strings.TrimSpace(b.String())
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"backend/internal/models"
+)
+
+// IIdempotencyRepository stores the outcome of requests sent with an Idempotency-Key
+type IIdempotencyRepository interface {
+	// Reserve claims key for a new request until lockedUntil. When the key is
+	// already taken (not older than expiredBefore, and either completed or
+	// still locked) the existing record is returned with reserved=false.
+	Reserve(ctx context.Context, key, requestHash string, expiredBefore, lockedUntil time.Time) (record *models.IdempotencyRecord, reserved bool, err error)
+	// Complete stores the response of a reserved key so it can be replayed
+	Complete(ctx context.Context, key string, statusCode int, body []byte) error
+	// Release forgets a reserved key, allowing the client to retry with it
+	Release(ctx context.Context, key string) error
+	// DeleteExpired removes records created before the given time
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+// IdempotencyRepository implements IIdempotencyRepository on Postgres
+type IdempotencyRepository struct {
+	db DBExecutor
+}
+
+// NewIdempotencyRepository creates a new instance of IdempotencyRepository
+func NewIdempotencyRepository(db DBExecutor) IIdempotencyRepository {
+	return &IdempotencyRepository{
+		db: db,
+	}
+}
+
+// Reserve inserts a placeholder row for key, taking over the row if it
+// expired or if its request never completed within its lease (crash, timeout)
+func (r *IdempotencyRepository) Reserve(ctx context.Context, key, requestHash string, expiredBefore, lockedUntil time.Time) (*models.IdempotencyRecord, bool, error) {
+	insert := `
+		INSERT INTO idempotency_keys (key, request_hash, locked_until)
+		VALUES ($1, $2, $4)
+		ON CONFLICT (key) DO UPDATE
+			SET request_hash = EXCLUDED.request_hash, status_code = NULL, response_body = NULL,
+				locked_until = EXCLUDED.locked_until, created_at = NOW()
+			WHERE idempotency_keys.created_at < $3
+				OR (idempotency_keys.status_code IS NULL AND idempotency_keys.locked_until < NOW())
+		`
+
+	tag, err := r.db.Exec(ctx, insert, key, requestHash, expiredBefore, lockedUntil)
+	if err != nil {
+		return nil, false, fmt.Errorf("unable to reserve idempotency key: %w", err)
+	}
+	if tag.RowsAffected() == 1 {
+		return nil, true, nil
+	}
+
+	query := `
+		SELECT key, request_hash, status_code, response_body, created_at
+		FROM idempotency_keys
+		WHERE key = $1
+		`
+
+	var rec models.IdempotencyRecord
+	err = r.db.QueryRow(ctx, query, key).Scan(&rec.Key, &rec.RequestHash, &rec.StatusCode, &rec.ResponseBody, &rec.CreatedAt)
+	if err != nil {
+		return nil, false, fmt.Errorf("unable to load idempotency key: %w", err)
+	}
+	return &rec, false, nil
+}
+
+// Complete records the final response for key. The first response stored
+// is kept, should a request whose lease ran out complete after its retry.
+func (r *IdempotencyRepository) Complete(ctx context.Context, key string, statusCode int, body []byte) error {
+	query := `
+		UPDATE idempotency_keys
+		SET status_code = $2, response_body = $3, locked_until = NULL
+		WHERE key = $1 AND status_code IS NULL
+		`
+
+	if _, err := r.db.Exec(ctx, query, key, statusCode, body); err != nil {
+		return fmt.Errorf("unable to store idempotent response: %w", err)
+	}
+	return nil
+}
+
+// Release deletes the reservation for key, unless a response was stored
+func (r *IdempotencyRepository) Release(ctx context.Context, key string) error {
+	if _, err := r.db.Exec(ctx, `DELETE FROM idempotency_keys WHERE key = $1 AND status_code IS NULL`, key); err != nil {
+		return fmt.Errorf("unable to release idempotency key: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpired purges records older than before
+func (r *IdempotencyRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	tag, err := r.db.Exec(ctx, `DELETE FROM idempotency_keys WHERE created_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("unable to purge idempotency keys: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
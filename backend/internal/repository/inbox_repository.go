@@ -0,0 +1,315 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// IInboxRepository gives the admin inbox access to stored submissions
+type IInboxRepository interface {
+	ListSubmissions(ctx context.Context, filter models.ContactFilter) ([]models.ContactSubmission, error)
+	SearchSubmissions(ctx context.Context, filter models.ContactFilter) ([]models.ContactSearchResult, error)
+	GetSubmission(ctx context.Context, id int64) (*models.ContactSubmission, error)
+	UpdateSubmission(ctx context.Context, id int64, from string, changes models.ContactChanges, note *models.ContactNote) (*models.ContactSubmission, error)
+	AddNote(ctx context.Context, note *models.ContactNote) error
+	ListNotes(ctx context.Context, submissionID int64) ([]models.ContactNote, error)
+	PurgeTrashed(ctx context.Context, deletedBefore time.Time) (int64, error)
+	CountSources(ctx context.Context, filter models.SourceFilter) ([]models.SourceCount, error)
+}
+
+// InboxRepository implements IInboxRepository on Postgres
+type InboxRepository struct {
+	db    DBExecutor
+	codec submissionCodec
+}
+
+// NewInboxRepository creates a new instance of InboxRepository
+func NewInboxRepository(db DBExecutor, opts ...Option) IInboxRepository {
+	return &InboxRepository{
+		db:    db,
+		codec: newSubmissionCodec(opts),
+	}
+}
+
+// ListSubmissions returns submissions matching filter, newest first
+func (r *InboxRepository) ListSubmissions(ctx context.Context, filter models.ContactFilter) ([]models.ContactSubmission, error) {
+	query := `SELECT ` + submissionColumns + ` FROM contact_submissions
+		WHERE (($1 = '' AND status <> 'trashed') OR status = $1)
+			AND ($2 = '' OR $2 = ANY(tags))
+			AND ($3 = '' OR assignee = $3)
+			AND ($4::timestamptz IS NULL OR created_at >= $4)
+			AND ($5::timestamptz IS NULL OR created_at < $5)
+			AND ($8 = '' OR ip_hash = $8)
+		ORDER BY created_at DESC, id DESC
+		LIMIT $6 OFFSET $7`
+
+	rows, err := r.db.Query(ctx, query, filter.Status, filter.Tag, filter.Assignee, filter.Since, filter.Until, filter.Limit, filter.Offset,
+		filter.IPHash)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list submissions: %w", err)
+	}
+	defer rows.Close()
+
+	submissions := []models.ContactSubmission{}
+	for rows.Next() {
+		s, err := r.codec.scan(rows)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read submission: %w", err)
+		}
+		submissions = append(submissions, *s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("unable to list submissions: %w", err)
+	}
+	return submissions, nil
+}
+
+// Markers placed around matches by ts_headline. Private-use characters cannot
+// be mistaken for markup, so the snippet can be escaped before they are
+// turned into <mark> tags.
+const (
+	HeadlineStart = "\ue000"
+	HeadlineStop  = "\ue001"
+)
+
+// headlineOptions configures ts_headline; passed as a parameter to avoid quoting issues
+const headlineOptions = `StartSel=` + HeadlineStart + `, StopSel=` + HeadlineStop +
+	`, MaxFragments=2, MaxWords=25, MinWords=8, FragmentDelimiter=" … "`
+
+// SearchSubmissions runs a full-text query over name, email, subject and
+// message (French and English stemming) and returns the best matches first,
+// with a highlighted excerpt of the message. Encrypted fields cannot be
+// indexed by Postgres, so with a keyring the search runs in the application.
+func (r *InboxRepository) SearchSubmissions(ctx context.Context, filter models.ContactFilter) ([]models.ContactSearchResult, error) {
+	if r.codec.encrypted() {
+		return r.searchDecrypted(ctx, filter)
+	}
+
+	query := `
+		WITH q AS (
+			SELECT websearch_to_tsquery('french', $1)
+				|| websearch_to_tsquery('english', $1)
+				|| websearch_to_tsquery('simple', $1) AS query
+		)
+		SELECT ` + submissionColumns + `,
+			ts_rank_cd(search_vector, q.query) AS rank,
+			ts_headline('french', message, q.query, $2) AS snippet
+		FROM contact_submissions, q
+		WHERE search_vector @@ q.query
+			AND (($3 = '' AND status <> 'trashed') OR status = $3)
+			AND ($4 = '' OR $4 = ANY(tags))
+			AND ($5 = '' OR assignee = $5)
+			AND ($6::timestamptz IS NULL OR created_at >= $6)
+			AND ($7::timestamptz IS NULL OR created_at < $7)
+			AND ($10 = '' OR ip_hash = $10)
+		ORDER BY rank DESC, created_at DESC, id DESC
+		LIMIT $8 OFFSET $9`
+
+	rows, err := r.db.Query(ctx, query, filter.Query, headlineOptions, filter.Status, filter.Tag, filter.Assignee,
+		filter.Since, filter.Until, filter.Limit, filter.Offset, filter.IPHash)
+	if err != nil {
+		return nil, fmt.Errorf("unable to search submissions: %w", err)
+	}
+	defer rows.Close()
+
+	results := []models.ContactSearchResult{}
+	for rows.Next() {
+		var res models.ContactSearchResult
+		s, err := r.codec.scan(rows, &res.Rank, &res.Snippet)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read submission: %w", err)
+		}
+		res.ContactSubmission = *s
+		results = append(results, res)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("unable to search submissions: %w", err)
+	}
+	return results, nil
+}
+
+// GetSubmission loads one submission, including trashed ones
+func (r *InboxRepository) GetSubmission(ctx context.Context, id int64) (*models.ContactSubmission, error) {
+	query := `SELECT ` + submissionColumns + ` FROM contact_submissions WHERE id = $1`
+
+	s, err := r.codec.scan(r.db.QueryRow(ctx, query, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to load submission: %w", err)
+	}
+	return s, nil
+}
+
+// UpdateSubmission applies changes and maintains the lifecycle timestamps:
+// read_at, replied_at and archived_at record the first time the status was
+// reached, deleted_at is set while the submission is trashed. Unless from is
+// empty, the submission is only updated while its status is still from, and
+// ErrVersionConflict is returned otherwise. note, when not nil, is stored by
+// the same statement, so it exists only if the change was made.
+func (r *InboxRepository) UpdateSubmission(ctx context.Context, id int64, from string, changes models.ContactChanges, note *models.ContactNote) (*models.ContactSubmission, error) {
+	query := `
+		WITH updated AS (
+			UPDATE contact_submissions SET
+				status = COALESCE($2::text, status),
+				tags = COALESCE($3::text[], tags),
+				assignee = CASE WHEN $4::boolean THEN NULLIF($5::text, '') ELSE assignee END,
+				read_at = CASE WHEN $2::text IN ('read', 'replied') THEN COALESCE(read_at, NOW()) ELSE read_at END,
+				replied_at = CASE WHEN $2::text = 'replied' THEN COALESCE(replied_at, NOW()) ELSE replied_at END,
+				archived_at = CASE WHEN $2::text = 'archived' THEN COALESCE(archived_at, NOW()) ELSE archived_at END,
+				deleted_at = CASE
+					WHEN $2::text = 'trashed' THEN COALESCE(deleted_at, NOW())
+					WHEN $2::text IS NOT NULL THEN NULL
+					ELSE deleted_at END,
+				updated_at = NOW()
+			WHERE id = $1 AND ($6::text = '' OR status = $6::text)
+			RETURNING ` + submissionColumns + `
+		), noted AS (
+			INSERT INTO contact_notes (submission_id, author, body)
+			SELECT id, $7::text, $8::text FROM updated WHERE $9::boolean
+			RETURNING id, created_at
+		)
+		SELECT updated.*, noted.id, noted.created_at
+		FROM updated LEFT JOIN noted ON true
+		`
+
+	var assignee string
+	if changes.Assignee != nil {
+		assignee = *changes.Assignee
+	}
+	var author, body string
+	if note != nil {
+		author, body = note.Author, note.Body
+	}
+
+	var noteID *int64
+	var noteCreatedAt *time.Time
+	row := r.db.QueryRow(ctx, query, id, changes.Status, changes.Tags, changes.Assignee != nil, assignee, from, author, body, note != nil)
+	s, err := r.codec.scan(row, &noteID, &noteCreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		if from == "" {
+			return nil, ErrNotFound
+		}
+		if _, err := r.GetSubmission(ctx, id); err != nil {
+			return nil, err
+		}
+		return nil, ErrVersionConflict
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to update submission: %w", err)
+	}
+	if note != nil && noteID != nil {
+		note.ID, note.SubmissionID, note.CreatedAt = *noteID, id, *noteCreatedAt
+	}
+	return s, nil
+}
+
+// AddNote stores an internal note and fills in its ID and creation date
+func (r *InboxRepository) AddNote(ctx context.Context, note *models.ContactNote) error {
+	query := `
+		INSERT INTO contact_notes (submission_id, author, body)
+		VALUES ($1, $2, $3)
+		RETURNING id, created_at
+		`
+
+	if err := r.db.QueryRow(ctx, query, note.SubmissionID, note.Author, note.Body).Scan(&note.ID, &note.CreatedAt); err != nil {
+		return fmt.Errorf("unable to insert note: %w", err)
+	}
+	return nil
+}
+
+// ListNotes returns the notes of a submission, oldest first
+func (r *InboxRepository) ListNotes(ctx context.Context, submissionID int64) ([]models.ContactNote, error) {
+	query := `
+		SELECT id, submission_id, author, body, created_at
+		FROM contact_notes
+		WHERE submission_id = $1
+		ORDER BY created_at, id
+		`
+
+	rows, err := r.db.Query(ctx, query, submissionID)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list notes: %w", err)
+	}
+	defer rows.Close()
+
+	notes := []models.ContactNote{}
+	for rows.Next() {
+		var n models.ContactNote
+		if err := rows.Scan(&n.ID, &n.SubmissionID, &n.Author, &n.Body, &n.CreatedAt); err != nil {
+			return nil, fmt.Errorf("unable to read note: %w", err)
+		}
+		notes = append(notes, n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("unable to list notes: %w", err)
+	}
+	return notes, nil
+}
+
+// PurgeTrashed permanently deletes submissions trashed before deletedBefore (notes cascade)
+func (r *InboxRepository) PurgeTrashed(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	tag, err := r.db.Exec(ctx, `DELETE FROM contact_submissions WHERE status = 'trashed' AND deleted_at < $1`, deletedBefore)
+	if err != nil {
+		return 0, fmt.Errorf("unable to purge trashed submissions: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// sourceColumns maps the sources report dimensions to their column
+var sourceColumns = map[string]string{
+	models.SourceReferrer:    "referrer",
+	models.SourceUTMSource:   "utm_source",
+	models.SourceUTMMedium:   "utm_medium",
+	models.SourceUTMCampaign: "utm_campaign",
+	models.SourceLanguage:    "accept_language",
+	models.SourceIPHash:      "ip_hash",
+	models.SourceCountry:     "country",
+	models.SourceASOrg:       "as_org",
+}
+
+// CountSources groups the submissions received in the filter's period by a
+// metadata column, most frequent values first. Empty values are left out.
+func (r *InboxRepository) CountSources(ctx context.Context, filter models.SourceFilter) ([]models.SourceCount, error) {
+	column, ok := sourceColumns[filter.By]
+	if !ok {
+		return nil, fmt.Errorf("unknown source dimension %q", filter.By)
+	}
+	query := `
+		SELECT ` + column + `, COUNT(*),
+			COUNT(*) FILTER (WHERE replied_at IS NOT NULL),
+			COUNT(*) FILTER (WHERE status = 'spam')
+		FROM contact_submissions
+		WHERE ` + column + ` <> ''
+			AND ($1::timestamptz IS NULL OR created_at >= $1)
+			AND ($2::timestamptz IS NULL OR created_at < $2)
+		GROUP BY 1
+		ORDER BY 2 DESC, 1
+		LIMIT $3`
+
+	rows, err := r.db.Query(ctx, query, filter.Since, filter.Until, filter.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("unable to count sources: %w", err)
+	}
+	defer rows.Close()
+
+	counts := []models.SourceCount{}
+	for rows.Next() {
+		var sc models.SourceCount
+		if err := rows.Scan(&sc.Value, &sc.Count, &sc.Replied, &sc.Spam); err != nil {
+			return nil, fmt.Errorf("unable to read source count: %w", err)
+		}
+		counts = append(counts, sc)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("unable to count sources: %w", err)
+	}
+	return counts, nil
+}
@@ -0,0 +1,272 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"backend/internal/encryption"
+	"backend/internal/models"
+)
+
+// IKeyRotationRepository re-encrypts stored personal data after a key rotation
+type IKeyRotationRepository interface {
+	Reencrypt(ctx context.Context, all bool, batchSize int) (int64, error)
+}
+
+// KeyRotationRepository implements IKeyRotationRepository on Postgres
+type KeyRotationRepository struct {
+	db    DBExecutor
+	codec submissionCodec
+}
+
+// NewKeyRotationRepository creates a new instance of KeyRotationRepository
+func NewKeyRotationRepository(db DBExecutor, keyring *encryption.Keyring) IKeyRotationRepository {
+	return &KeyRotationRepository{
+		db:    db,
+		codec: newSubmissionCodec([]Option{WithKeyring(keyring)}),
+	}
+}
+
+// Reencrypt encrypts with a fresh data key, wrapped with the active key, the
+// submissions, conversation messages and privacy requests stored in clear or
+// under an older key version, and refreshes their blind indexes. With all,
+// every row is processed, which is needed after changing the index key.
+// Returns the number of rows rewritten.
+func (r *KeyRotationRepository) Reencrypt(ctx context.Context, all bool, batchSize int) (int64, error) {
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+	total, err := r.reencryptSubmissions(ctx, all, batchSize)
+	if err != nil {
+		return total, err
+	}
+	messages, err := r.reencryptMessages(ctx, all, batchSize)
+	total += messages
+	if err != nil {
+		return total, err
+	}
+	requests, err := r.reencryptPrivacyRequests(ctx, all, batchSize)
+	return total + requests, err
+}
+
+// reencryptSubmissions rewrites the submissions. Anonymized rows hold no
+// personal data and are skipped.
+func (r *KeyRotationRepository) reencryptSubmissions(ctx context.Context, all bool, batchSize int) (int64, error) {
+	selectQuery := `
+		SELECT id, name, email, subject, message, key_version, data_key
+		FROM contact_submissions
+		WHERE id > $1 AND anonymized_at IS NULL
+			AND ($2 OR key_version IS DISTINCT FROM $3)
+		ORDER BY id
+		LIMIT $4
+		`
+	// The key_version check skips rows changed since they were read
+	updateQuery := `
+		UPDATE contact_submissions SET
+			name = $2, email = $3, message = $4, dedupe_hash = $5,
+			key_version = $6, data_key = $7, email_index = $8
+		WHERE id = $1 AND key_version IS NOT DISTINCT FROM $9
+		`
+
+	active := int16(r.codec.keyring.ActiveVersion())
+	var total, lastID int64
+	for {
+		batch, err := r.readBatch(ctx, selectQuery, lastID, all, active, batchSize)
+		if err != nil {
+			return total, err
+		}
+		if len(batch) == 0 {
+			return total, nil
+		}
+
+		for _, row := range batch {
+			sealed, err := r.codec.seal(row.ContactForm)
+			if err != nil {
+				return total, err
+			}
+			tag, err := r.db.Exec(ctx, updateQuery, row.ID, sealed.Name, sealed.Email, sealed.Message, sealed.DedupeHash,
+				sealed.KeyVersion, sealed.DataKey, sealed.EmailIndex, row.keyVersion)
+			if err != nil {
+				return total, fmt.Errorf("unable to re-encrypt submission %d: %w", row.ID, err)
+			}
+			total += tag.RowsAffected()
+		}
+		lastID = batch[len(batch)-1].ID
+	}
+}
+
+type rotationRow struct {
+	models.ContactSubmission
+	keyVersion *int16
+}
+
+func (r *KeyRotationRepository) readBatch(ctx context.Context, query string, afterID int64, all bool, active int16, limit int) ([]rotationRow, error) {
+	rows, err := r.db.Query(ctx, query, afterID, all, active, limit)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list submissions to re-encrypt: %w", err)
+	}
+	defer rows.Close()
+
+	batch := []rotationRow{}
+	for rows.Next() {
+		var row rotationRow
+		var dataKey []byte
+		s := &row.ContactSubmission
+		if err := rows.Scan(&s.ID, &s.Name, &s.Email, &s.Subject, &s.Message, &row.keyVersion, &dataKey); err != nil {
+			return nil, fmt.Errorf("unable to read submission: %w", err)
+		}
+		if err := r.codec.open(s, row.keyVersion, dataKey); err != nil {
+			return nil, err
+		}
+		batch = append(batch, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("unable to list submissions to re-encrypt: %w", err)
+	}
+	return batch, nil
+}
+
+// reencryptMessages rewrites the conversation messages
+func (r *KeyRotationRepository) reencryptMessages(ctx context.Context, all bool, batchSize int) (int64, error) {
+	selectQuery := `
+		SELECT id, from_address, to_address, subject, body, key_version, data_key
+		FROM contact_messages
+		WHERE id > $1 AND ($2 OR key_version IS DISTINCT FROM $3)
+		ORDER BY id
+		LIMIT $4
+		`
+	updateQuery := `
+		UPDATE contact_messages SET
+			from_address = $2, to_address = $3, subject = $4, body = $5, key_version = $6, data_key = $7
+		WHERE id = $1 AND key_version IS NOT DISTINCT FROM $8
+		`
+
+	active := int16(r.codec.keyring.ActiveVersion())
+	var total, lastID int64
+	for {
+		batch, err := r.readMessageBatch(ctx, selectQuery, lastID, all, active, batchSize)
+		if err != nil {
+			return total, err
+		}
+		if len(batch) == 0 {
+			return total, nil
+		}
+
+		for _, row := range batch {
+			sealed := row.ContactMessage
+			keyVersion, dataKey, err := r.codec.sealFields(messageFields(&sealed)...)
+			if err != nil {
+				return total, err
+			}
+			tag, err := r.db.Exec(ctx, updateQuery, row.ID, sealed.From, sealed.To, sealed.Subject, sealed.Body,
+				keyVersion, dataKey, row.keyVersion)
+			if err != nil {
+				return total, fmt.Errorf("unable to re-encrypt message %d: %w", row.ID, err)
+			}
+			total += tag.RowsAffected()
+		}
+		lastID = batch[len(batch)-1].ID
+	}
+}
+
+type messageRotationRow struct {
+	models.ContactMessage
+	keyVersion *int16
+}
+
+func (r *KeyRotationRepository) readMessageBatch(ctx context.Context, query string, afterID int64, all bool, active int16, limit int) ([]messageRotationRow, error) {
+	rows, err := r.db.Query(ctx, query, afterID, all, active, limit)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list messages to re-encrypt: %w", err)
+	}
+	defer rows.Close()
+
+	batch := []messageRotationRow{}
+	for rows.Next() {
+		var row messageRotationRow
+		var dataKey []byte
+		m := &row.ContactMessage
+		if err := rows.Scan(&m.ID, &m.From, &m.To, &m.Subject, &m.Body, &row.keyVersion, &dataKey); err != nil {
+			return nil, fmt.Errorf("unable to read message: %w", err)
+		}
+		if err := r.codec.openFields(fmt.Sprintf("message %d", m.ID), row.keyVersion, dataKey, messageFields(m)...); err != nil {
+			return nil, err
+		}
+		batch = append(batch, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("unable to list messages to re-encrypt: %w", err)
+	}
+	return batch, nil
+}
+
+// reencryptPrivacyRequests rewrites the emails of privacy requests
+func (r *KeyRotationRepository) reencryptPrivacyRequests(ctx context.Context, all bool, batchSize int) (int64, error) {
+	selectQuery := `
+		SELECT id, email, key_version, data_key
+		FROM privacy_requests
+		WHERE id > $1 AND ($2 OR key_version IS DISTINCT FROM $3)
+		ORDER BY id
+		LIMIT $4
+		`
+	updateQuery := `
+		UPDATE privacy_requests SET email = $2, email_index = $3, key_version = $4, data_key = $5
+		WHERE id = $1 AND key_version IS NOT DISTINCT FROM $6
+		`
+
+	active := int16(r.codec.keyring.ActiveVersion())
+	var total, lastID int64
+	for {
+		batch, err := r.readPrivacyRequestBatch(ctx, selectQuery, lastID, all, active, batchSize)
+		if err != nil {
+			return total, err
+		}
+		if len(batch) == 0 {
+			return total, nil
+		}
+
+		for _, row := range batch {
+			email := row.Email
+			keyVersion, dataKey, err := r.codec.sealFields(codecField{"email", &email})
+			if err != nil {
+				return total, err
+			}
+			tag, err := r.db.Exec(ctx, updateQuery, row.ID, email, r.codec.emailIndex(row.Email), keyVersion, dataKey, row.keyVersion)
+			if err != nil {
+				return total, fmt.Errorf("unable to re-encrypt privacy request %d: %w", row.ID, err)
+			}
+			total += tag.RowsAffected()
+		}
+		lastID = batch[len(batch)-1].ID
+	}
+}
+
+type privacyRequestRotationRow struct {
+	models.PrivacyRequest
+	keyVersion *int16
+}
+
+func (r *KeyRotationRepository) readPrivacyRequestBatch(ctx context.Context, query string, afterID int64, all bool, active int16, limit int) ([]privacyRequestRotationRow, error) {
+	rows, err := r.db.Query(ctx, query, afterID, all, active, limit)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list privacy requests to re-encrypt: %w", err)
+	}
+	defer rows.Close()
+
+	batch := []privacyRequestRotationRow{}
+	for rows.Next() {
+		var row privacyRequestRotationRow
+		var dataKey []byte
+		if err := rows.Scan(&row.ID, &row.Email, &row.keyVersion, &dataKey); err != nil {
+			return nil, fmt.Errorf("unable to read privacy request: %w", err)
+		}
+		if err := r.codec.openFields(fmt.Sprintf("privacy request %d", row.ID), row.keyVersion, dataKey, codecField{"email", &row.Email}); err != nil {
+			return nil, err
+		}
+		batch = append(batch, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("unable to list privacy requests to re-encrypt: %w", err)
+	}
+	return batch, nil
+}
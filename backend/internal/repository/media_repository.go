@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// IMediaRepository stores the metadata of uploaded files; the files
+// themselves live in a media store
+type IMediaRepository interface {
+	CreateMedia(ctx context.Context, media *models.Media) (bool, error)
+	GetMedia(ctx context.Context, id int64) (*models.Media, error)
+	GetMediaBySHA256(ctx context.Context, sha256 string) (*models.Media, error)
+	ListMedia(ctx context.Context, limit, offset int) ([]models.Media, int, error)
+	DeleteMedia(ctx context.Context, id int64) error
+}
+
+// MediaRepository implements IMediaRepository on Postgres
+type MediaRepository struct {
+	db DBExecutor
+}
+
+// NewMediaRepository creates a new instance of MediaRepository
+func NewMediaRepository(db DBExecutor) IMediaRepository {
+	return &MediaRepository{
+		db: db,
+	}
+}
+
+const mediaColumns = `id, sha256, content_type, size, width, height, filename, name, variants, created_at`
+
+func scanMedia(row pgx.Row) (*models.Media, error) {
+	var m models.Media
+	var variants []byte
+	if err := row.Scan(&m.ID, &m.SHA256, &m.ContentType, &m.Size, &m.Width, &m.Height, &m.Filename, &m.Name, &variants, &m.CreatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(variants, &m.Variants); err != nil {
+		return nil, fmt.Errorf("unable to decode media variants: %w", err)
+	}
+	return &m, nil
+}
+
+// CreateMedia stores media and fills in its id and creation time. When a
+// file with the same SHA-256 is already stored, media is replaced by the
+// stored row and false is returned.
+func (r *MediaRepository) CreateMedia(ctx context.Context, media *models.Media) (bool, error) {
+	variants, err := json.Marshal(media.Variants)
+	if err != nil {
+		return false, fmt.Errorf("unable to encode media variants: %w", err)
+	}
+	// The no-op update makes RETURNING give back the existing row on conflict;
+	// xmax is 0 only for freshly inserted rows
+	query := `
+		INSERT INTO media (sha256, content_type, size, width, height, filename, name, variants)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (sha256) DO UPDATE SET sha256 = EXCLUDED.sha256
+		RETURNING ` + mediaColumns + `, xmax = 0`
+
+	var inserted bool
+	var stored models.Media
+	var storedVariants []byte
+	err = r.db.QueryRow(ctx, query, media.SHA256, media.ContentType, media.Size, media.Width, media.Height,
+		media.Filename, media.Name, variants).
+		Scan(&stored.ID, &stored.SHA256, &stored.ContentType, &stored.Size, &stored.Width, &stored.Height,
+			&stored.Filename, &stored.Name, &storedVariants, &stored.CreatedAt, &inserted)
+	if err != nil {
+		return false, fmt.Errorf("unable to save media: %w", err)
+	}
+	if err := json.Unmarshal(storedVariants, &stored.Variants); err != nil {
+		return false, fmt.Errorf("unable to decode media variants: %w", err)
+	}
+	*media = stored
+	return inserted, nil
+}
+
+// GetMedia returns a file by id, ErrNotFound when missing
+func (r *MediaRepository) GetMedia(ctx context.Context, id int64) (*models.Media, error) {
+	m, err := scanMedia(r.db.QueryRow(ctx, `SELECT `+mediaColumns+` FROM media WHERE id = $1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to get media: %w", err)
+	}
+	return m, nil
+}
+
+// GetMediaBySHA256 returns a file by content hash, ErrNotFound when missing
+func (r *MediaRepository) GetMediaBySHA256(ctx context.Context, sha256 string) (*models.Media, error) {
+	m, err := scanMedia(r.db.QueryRow(ctx, `SELECT `+mediaColumns+` FROM media WHERE sha256 = $1`, sha256))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to get media: %w", err)
+	}
+	return m, nil
+}
+
+// ListMedia returns a page of files, newest first, with the total count
+func (r *MediaRepository) ListMedia(ctx context.Context, limit, offset int) ([]models.Media, int, error) {
+	query := `SELECT ` + mediaColumns + `, COUNT(*) OVER () FROM media
+		ORDER BY created_at DESC, id DESC LIMIT $1 OFFSET $2`
+
+	rows, err := r.db.Query(ctx, query, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("unable to list media: %w", err)
+	}
+	defer rows.Close()
+
+	list := []models.Media{}
+	total := 0
+	for rows.Next() {
+		var m models.Media
+		var variants []byte
+		if err := rows.Scan(&m.ID, &m.SHA256, &m.ContentType, &m.Size, &m.Width, &m.Height, &m.Filename, &m.Name, &variants, &m.CreatedAt, &total); err != nil {
+			return nil, 0, fmt.Errorf("unable to scan media: %w", err)
+		}
+		if err := json.Unmarshal(variants, &m.Variants); err != nil {
+			return nil, 0, fmt.Errorf("unable to decode media variants: %w", err)
+		}
+		list = append(list, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("unable to list media: %w", err)
+	}
+	return list, total, nil
+}
+
+// DeleteMedia removes a file's row, ErrNotFound when missing
+func (r *MediaRepository) DeleteMedia(ctx context.Context, id int64) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM media WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("unable to delete media: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
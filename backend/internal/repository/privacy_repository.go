@@ -0,0 +1,277 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// IPrivacyRepository stores privacy requests and the audit log, and removes
+// personal data on erasure or when the retention period is over
+type IPrivacyRepository interface {
+	CreateRequest(ctx context.Context, req *models.PrivacyRequest) error
+	FindRequest(ctx context.Context, tokenHash string) (*models.PrivacyRequest, error)
+	MarkRequestUsed(ctx context.Context, id int64) error
+	HasRecentRequest(ctx context.Context, email string, since time.Time) (bool, error)
+	DeleteExpiredRequests(ctx context.Context, before time.Time) (int64, error)
+
+	ListSubmissionsByEmail(ctx context.Context, email string) ([]models.ContactSubmission, error)
+	EraseByEmail(ctx context.Context, email, mode string) (int64, error)
+	ApplyRetention(ctx context.Context, createdBefore time.Time, mode string) (int64, error)
+
+	AddAuditEntry(ctx context.Context, entry *models.PrivacyAuditEntry) error
+	ListAuditEntries(ctx context.Context, limit, offset int) ([]models.PrivacyAuditEntry, error)
+}
+
+// PrivacyRepository implements IPrivacyRepository on Postgres
+type PrivacyRepository struct {
+	db    DBExecutor
+	codec submissionCodec
+}
+
+// NewPrivacyRepository creates a new instance of PrivacyRepository
+func NewPrivacyRepository(db DBExecutor, opts ...Option) IPrivacyRepository {
+	return &PrivacyRepository{
+		db:    db,
+		codec: newSubmissionCodec(opts),
+	}
+}
+
+// emailMatch selects the submissions or privacy requests of the email in $1
+// and its blind index in $2: encrypted rows by index, rows stored in clear
+// by value
+const emailMatch = `(email_index = $2 OR (data_key IS NULL AND lower(email) = lower($1)))`
+
+// CreateRequest stores a verification request and fills in its ID and
+// creation date. With a keyring the email is encrypted and looked up by its
+// blind index.
+func (r *PrivacyRepository) CreateRequest(ctx context.Context, req *models.PrivacyRequest) error {
+	query := `
+		INSERT INTO privacy_requests (email, email_index, token_hash, expires_at, key_version, data_key)
+		VALUES ($1, NULLIF($2, ''), $3, $4, $5, $6)
+		RETURNING id, created_at
+		`
+
+	email := req.Email
+	keyVersion, dataKey, err := r.codec.sealFields(codecField{"email", &email})
+	if err != nil {
+		return err
+	}
+	err = r.db.QueryRow(ctx, query, email, r.codec.emailIndex(req.Email), req.TokenHash, req.ExpiresAt, keyVersion, dataKey).
+		Scan(&req.ID, &req.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("unable to insert privacy request: %w", err)
+	}
+	return nil
+}
+
+// FindRequest loads the request of a token hash, expired or not
+func (r *PrivacyRepository) FindRequest(ctx context.Context, tokenHash string) (*models.PrivacyRequest, error) {
+	query := `
+		SELECT id, email, token_hash, expires_at, used_at, created_at, key_version, data_key
+		FROM privacy_requests
+		WHERE token_hash = $1
+		`
+
+	var req models.PrivacyRequest
+	var keyVersion *int16
+	var dataKey []byte
+	err := r.db.QueryRow(ctx, query, tokenHash).
+		Scan(&req.ID, &req.Email, &req.TokenHash, &req.ExpiresAt, &req.UsedAt, &req.CreatedAt, &keyVersion, &dataKey)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to load privacy request: %w", err)
+	}
+	if err := r.codec.openFields(fmt.Sprintf("privacy request %d", req.ID), keyVersion, dataKey, codecField{"email", &req.Email}); err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+// MarkRequestUsed consumes a request so its token cannot be used again
+func (r *PrivacyRepository) MarkRequestUsed(ctx context.Context, id int64) error {
+	if _, err := r.db.Exec(ctx, `UPDATE privacy_requests SET used_at = NOW() WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("unable to update privacy request: %w", err)
+	}
+	return nil
+}
+
+// HasRecentRequest reports whether a verification was sent to email since the given time
+func (r *PrivacyRepository) HasRecentRequest(ctx context.Context, email string, since time.Time) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS (SELECT 1 FROM privacy_requests WHERE ` + emailMatch + ` AND created_at >= $3)`
+	err := r.db.QueryRow(ctx, query, email, r.codec.emailIndex(email), since).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("unable to check privacy requests: %w", err)
+	}
+	return exists, nil
+}
+
+// DeleteExpiredRequests removes requests that expired before the given time
+func (r *PrivacyRepository) DeleteExpiredRequests(ctx context.Context, before time.Time) (int64, error) {
+	tag, err := r.db.Exec(ctx, `DELETE FROM privacy_requests WHERE expires_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("unable to delete expired privacy requests: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// ListSubmissionsByEmail returns every submission sent from email (case-insensitive), oldest first
+func (r *PrivacyRepository) ListSubmissionsByEmail(ctx context.Context, email string) ([]models.ContactSubmission, error) {
+	query := `SELECT ` + submissionColumns + ` FROM contact_submissions
+		WHERE ` + emailMatch + `
+		ORDER BY created_at, id`
+
+	rows, err := r.db.Query(ctx, query, email, r.codec.emailIndex(email))
+	if err != nil {
+		return nil, fmt.Errorf("unable to list submissions: %w", err)
+	}
+	defer rows.Close()
+
+	submissions := []models.ContactSubmission{}
+	for rows.Next() {
+		s, err := r.codec.scan(rows)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read submission: %w", err)
+		}
+		submissions = append(submissions, *s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("unable to list submissions: %w", err)
+	}
+	return submissions, nil
+}
+
+// removalQuery builds a single statement (hence atomic) that deletes or
+// anonymizes the submissions selected by target, along with their notes,
+// conversation and webhook deliveries.
+// Anonymized rows keep their subject, status, tags and dates for statistics,
+// but none of the client metadata (IP, location, browser, referrer, campaign).
+func removalQuery(target, mode string) string {
+	query := `
+		WITH target AS (` + target + `),
+		webhooks AS (
+			DELETE FROM webhook_deliveries
+			WHERE submission_id IN (SELECT id FROM target)
+		),`
+	if mode == models.RetentionDelete {
+		return query + `
+		removed AS (
+			DELETE FROM contact_submissions WHERE id IN (SELECT id FROM target) RETURNING id
+		)
+		SELECT COUNT(*) FROM removed`
+	}
+	return query + `
+		notes AS (
+			DELETE FROM contact_notes WHERE submission_id IN (SELECT id FROM target)
+		),
+		messages AS (
+			DELETE FROM contact_messages WHERE submission_id IN (SELECT id FROM target)
+		),
+		removed AS (
+			UPDATE contact_submissions SET
+				name = '` + models.AnonymizedValue + `',
+				email = 'anonymized-' || id || '@invalid',
+				message = '` + models.AnonymizedValue + `',
+				dedupe_hash = NULL,
+				key_version = NULL,
+				data_key = NULL,
+				email_index = NULL,
+				ip_hash = '',
+				ip_prefix = '',
+				user_agent = '',
+				referrer = '',
+				accept_language = '',
+				country = '',
+				asn = 0,
+				as_org = '',
+				utm_source = '',
+				utm_medium = '',
+				utm_campaign = '',
+				utm_term = '',
+				utm_content = '',
+				anonymized_at = NOW(),
+				updated_at = NOW()
+			WHERE id IN (SELECT id FROM target)
+			RETURNING id
+		)
+		SELECT COUNT(*) FROM removed`
+}
+
+// EraseByEmail deletes or anonymizes everything tied to email and returns the
+// number of submissions affected
+func (r *PrivacyRepository) EraseByEmail(ctx context.Context, email, mode string) (int64, error) {
+	target := `SELECT id FROM contact_submissions WHERE ` + emailMatch + ` AND anonymized_at IS NULL`
+
+	var count int64
+	if err := r.db.QueryRow(ctx, removalQuery(target, mode), email, r.codec.emailIndex(email)).Scan(&count); err != nil {
+		return 0, fmt.Errorf("unable to erase personal data: %w", err)
+	}
+	if _, err := r.db.Exec(ctx, `DELETE FROM privacy_requests WHERE `+emailMatch, email, r.codec.emailIndex(email)); err != nil {
+		return count, fmt.Errorf("unable to delete privacy requests: %w", err)
+	}
+	return count, nil
+}
+
+// ApplyRetention deletes or anonymizes the submissions received before createdBefore
+func (r *PrivacyRepository) ApplyRetention(ctx context.Context, createdBefore time.Time, mode string) (int64, error) {
+	target := `SELECT id FROM contact_submissions WHERE created_at < $1 AND anonymized_at IS NULL`
+
+	var count int64
+	if err := r.db.QueryRow(ctx, removalQuery(target, mode), createdBefore).Scan(&count); err != nil {
+		return 0, fmt.Errorf("unable to apply retention policy: %w", err)
+	}
+	return count, nil
+}
+
+// AddAuditEntry appends an entry to the privacy audit log
+func (r *PrivacyRepository) AddAuditEntry(ctx context.Context, entry *models.PrivacyAuditEntry) error {
+	query := `
+		INSERT INTO privacy_audit_log (action, subject_hash, actor, affected, detail)
+		VALUES ($1, NULLIF($2, ''), $3, $4, NULLIF($5, ''))
+		RETURNING id, created_at
+		`
+
+	err := r.db.QueryRow(ctx, query, entry.Action, entry.SubjectHash, entry.Actor, entry.Affected, entry.Detail).
+		Scan(&entry.ID, &entry.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("unable to insert privacy audit entry: %w", err)
+	}
+	return nil
+}
+
+// ListAuditEntries returns the audit log, newest first
+func (r *PrivacyRepository) ListAuditEntries(ctx context.Context, limit, offset int) ([]models.PrivacyAuditEntry, error) {
+	query := `
+		SELECT id, action, COALESCE(subject_hash, ''), actor, affected, COALESCE(detail, ''), created_at
+		FROM privacy_audit_log
+		ORDER BY created_at DESC, id DESC
+		LIMIT $1 OFFSET $2
+		`
+
+	rows, err := r.db.Query(ctx, query, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list privacy audit log: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []models.PrivacyAuditEntry{}
+	for rows.Next() {
+		var e models.PrivacyAuditEntry
+		if err := rows.Scan(&e.ID, &e.Action, &e.SubjectHash, &e.Actor, &e.Affected, &e.Detail, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("unable to read privacy audit entry: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("unable to list privacy audit log: %w", err)
+	}
+	return entries, nil
+}
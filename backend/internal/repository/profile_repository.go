@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// IProfileRepository stores the profile, a single JSON Resume document
+type IProfileRepository interface {
+	GetProfile(ctx context.Context) (*models.Profile, error)
+	SaveProfile(ctx context.Context, resume models.Resume, version int) (*models.Profile, error)
+}
+
+// ProfileRepository implements IProfileRepository on Postgres
+type ProfileRepository struct {
+	db DBExecutor
+}
+
+// NewProfileRepository creates a new instance of ProfileRepository
+func NewProfileRepository(db DBExecutor) IProfileRepository {
+	return &ProfileRepository{
+		db: db,
+	}
+}
+
+func scanProfile(row pgx.Row) (*models.Profile, error) {
+	var p models.Profile
+	var resume []byte
+	if err := row.Scan(&resume, &p.Version, &p.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(resume, &p.Resume); err != nil {
+		return nil, fmt.Errorf("unable to decode profile: %w", err)
+	}
+	return &p, nil
+}
+
+// GetProfile returns the stored profile, ErrNotFound before the first save
+func (r *ProfileRepository) GetProfile(ctx context.Context) (*models.Profile, error) {
+	p, err := scanProfile(r.db.QueryRow(ctx, `SELECT resume, version, updated_at FROM profile WHERE id = 1`))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to get profile: %w", err)
+	}
+	return p, nil
+}
+
+// SaveProfile replaces the profile if it is still at version, or creates it
+// at version 1. Returns ErrVersionConflict when it changed in the meantime.
+func (r *ProfileRepository) SaveProfile(ctx context.Context, resume models.Resume, version int) (*models.Profile, error) {
+	data, err := json.Marshal(resume)
+	if err != nil {
+		return nil, fmt.Errorf("unable to encode profile: %w", err)
+	}
+	query := `
+		INSERT INTO profile (id, resume, version) VALUES (1, $1, 1)
+		ON CONFLICT (id) DO UPDATE SET resume = EXCLUDED.resume, version = profile.version + 1, updated_at = NOW()
+		WHERE profile.version = $2
+		RETURNING resume, version, updated_at`
+
+	p, err := scanProfile(r.db.QueryRow(ctx, query, data, version))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrVersionConflict
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to save profile: %w", err)
+	}
+	return p, nil
+}
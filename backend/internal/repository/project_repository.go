@@ -0,0 +1,432 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+var (
+	// ErrVersionConflict is returned when a row changed since the version the
+	// caller edited
+	ErrVersionConflict = errors.New("version conflict")
+	// ErrSlugTaken is returned when another project already uses a slug
+	ErrSlugTaken = errors.New("slug already in use")
+)
+
+// IProjectRepository stores the portfolio projects
+type IProjectRepository interface {
+	ListProjects(ctx context.Context, filter models.ProjectFilter) ([]models.Project, int, error)
+	GetProjectBySlug(ctx context.Context, slug string) (*models.Project, error)
+	ListTechnologies(ctx context.Context) ([]models.Technology, error)
+
+	GetProject(ctx context.Context, id int64) (*models.Project, error)
+	SlugTaken(ctx context.Context, slug string, exceptID int64) (bool, error)
+	CreateProject(ctx context.Context, p *models.Project) error
+	UpdateProject(ctx context.Context, p *models.Project) error
+	SetPublication(ctx context.Context, id int64, publishedAt *time.Time, version int) error
+	ReorderProjects(ctx context.Context, ids []int64) error
+	DeleteProject(ctx context.Context, id int64) error
+}
+
+// ProjectRepository implements IProjectRepository on Postgres
+type ProjectRepository struct {
+	db DBExecutor
+}
+
+// NewProjectRepository creates a new instance of ProjectRepository
+func NewProjectRepository(db DBExecutor) IProjectRepository {
+	return &ProjectRepository{
+		db: db,
+	}
+}
+
+// projectColumns selects a project with its technologies, links and images
+// aggregated as JSON arrays, so one query returns complete projects
+const projectColumns = `p.id, p.slug, p.title, p.summary, p.body, p.category, p.badge, p.icon, p.position, p.version,
+	p.published_at, p.created_at, p.updated_at,
+	COALESCE((SELECT json_agg(json_build_object('slug', t.slug, 'name', t.name) ORDER BY pt.position, t.name)
+		FROM project_technologies pt JOIN technologies t ON t.id = pt.technology_id
+		WHERE pt.project_id = p.id), '[]'),
+	COALESCE((SELECT json_agg(json_build_object('label', l.label, 'url', l.url, 'icon', l.icon) ORDER BY l.position, l.id)
+		FROM project_links l WHERE l.project_id = p.id), '[]'),
+	COALESCE((SELECT json_agg(json_build_object('url', i.url, 'alt', i.alt, 'caption', i.caption) ORDER BY i.position, i.id)
+		FROM project_images i WHERE i.project_id = p.id), '[]')`
+
+// publishedProject restricts a query on projects p to the public ones
+const publishedProject = `p.published_at IS NOT NULL AND p.published_at <= NOW()`
+
+// projectOrders maps the list orders to ORDER BY clauses; ids break ties so
+// pages never overlap
+var projectOrders = map[string]string{
+	models.ProjectSortPosition: `p.position, p.published_at DESC, p.id`,
+	models.ProjectSortRecent:   `p.published_at DESC, p.id DESC`,
+	models.ProjectSortUpdated:  `p.updated_at DESC, p.id DESC`,
+	models.ProjectSortTitle:    `lower(p.title), p.id`,
+}
+
+func scanProject(row pgx.Row, extra ...any) (*models.Project, error) {
+	var p models.Project
+	var technologies, links, images []byte
+	dest := []any{&p.ID, &p.Slug, &p.Title, &p.Summary, &p.Body, &p.Category, &p.Badge, &p.Icon, &p.Position, &p.Version,
+		&p.PublishedAt, &p.CreatedAt, &p.UpdatedAt, &technologies, &links, &images}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(technologies, &p.Technologies); err != nil {
+		return nil, fmt.Errorf("invalid technologies: %w", err)
+	}
+	if err := json.Unmarshal(links, &p.Links); err != nil {
+		return nil, fmt.Errorf("invalid links: %w", err)
+	}
+	if err := json.Unmarshal(images, &p.Images); err != nil {
+		return nil, fmt.Errorf("invalid images: %w", err)
+	}
+	return &p, nil
+}
+
+// ListProjects returns a page of projects and the number of projects
+// matching the filter
+func (r *ProjectRepository) ListProjects(ctx context.Context, filter models.ProjectFilter) ([]models.Project, int, error) {
+	order, ok := projectOrders[filter.Sort]
+	if !ok {
+		order = projectOrders[models.ProjectSortPosition]
+	}
+	where := `
+		FROM projects p
+		WHERE ($3 OR ` + publishedProject + `)
+			AND ($1 = '' OR EXISTS (
+				SELECT 1 FROM project_technologies pt JOIN technologies t ON t.id = pt.technology_id
+				WHERE pt.project_id = p.id AND t.slug = $1))
+			AND ($2 = '' OR p.category = $2)`
+	query := `SELECT ` + projectColumns + `, COUNT(*) OVER()` + where + `
+		ORDER BY ` + order + `
+		LIMIT $4 OFFSET $5`
+
+	rows, err := r.db.Query(ctx, query, filter.Technology, filter.Category, filter.Drafts, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("unable to list projects: %w", err)
+	}
+	defer rows.Close()
+
+	projects := []models.Project{}
+	var total int
+	for rows.Next() {
+		p, err := scanProject(rows, &total)
+		if err != nil {
+			return nil, 0, fmt.Errorf("unable to read project: %w", err)
+		}
+		projects = append(projects, *p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("unable to list projects: %w", err)
+	}
+
+	// A page past the end has no row to carry the count
+	if len(projects) == 0 && filter.Offset > 0 {
+		err := r.db.QueryRow(ctx, `SELECT COUNT(*)`+where, filter.Technology, filter.Category, filter.Drafts).Scan(&total)
+		if err != nil {
+			return nil, 0, fmt.Errorf("unable to count projects: %w", err)
+		}
+	}
+	return projects, total, nil
+}
+
+// GetProjectBySlug returns a published project, or ErrNotFound
+func (r *ProjectRepository) GetProjectBySlug(ctx context.Context, slug string) (*models.Project, error) {
+	query := `SELECT ` + projectColumns + ` FROM projects p WHERE p.slug = $1 AND ` + publishedProject
+
+	p, err := scanProject(r.db.QueryRow(ctx, query, slug))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to get project: %w", err)
+	}
+	return p, nil
+}
+
+// ListTechnologies returns the technologies used by published projects,
+// most used first
+func (r *ProjectRepository) ListTechnologies(ctx context.Context) ([]models.Technology, error) {
+	query := `
+		SELECT t.slug, t.name, COUNT(*)
+		FROM technologies t
+		JOIN project_technologies pt ON pt.technology_id = t.id
+		JOIN projects p ON p.id = pt.project_id
+		WHERE ` + publishedProject + `
+		GROUP BY t.id
+		ORDER BY COUNT(*) DESC, t.name
+		`
+
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list technologies: %w", err)
+	}
+	defer rows.Close()
+
+	technologies := []models.Technology{}
+	for rows.Next() {
+		var t models.Technology
+		if err := rows.Scan(&t.Slug, &t.Name, &t.Projects); err != nil {
+			return nil, fmt.Errorf("unable to read technology: %w", err)
+		}
+		technologies = append(technologies, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("unable to list technologies: %w", err)
+	}
+	return technologies, nil
+}
+
+// GetProject returns a project whether or not it is published, or ErrNotFound
+func (r *ProjectRepository) GetProject(ctx context.Context, id int64) (*models.Project, error) {
+	query := `SELECT ` + projectColumns + ` FROM projects p WHERE p.id = $1`
+
+	p, err := scanProject(r.db.QueryRow(ctx, query, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to get project: %w", err)
+	}
+	return p, nil
+}
+
+// SlugTaken reports whether a project other than exceptID uses slug
+func (r *ProjectRepository) SlugTaken(ctx context.Context, slug string, exceptID int64) (bool, error) {
+	var taken bool
+	err := r.db.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM projects WHERE slug = $1 AND id <> $2)`, slug, exceptID).Scan(&taken)
+	if err != nil {
+		return false, fmt.Errorf("unable to check slug: %w", err)
+	}
+	return taken, nil
+}
+
+// projectChildren are the statements replacing the technologies, links and
+// images of the project returned by the "saved" CTE, from JSON arrays passed
+// as the parameters $first to $first+2. Nothing is written when saved is
+// empty (version conflict).
+func projectChildren(first int) string {
+	tech, links, images := first, first+1, first+2
+	return fmt.Sprintf(`,
+		tech AS (
+			INSERT INTO technologies (slug, name)
+			SELECT t.slug, t.name FROM json_to_recordset($%[1]d::json) AS t(slug text, name text, position int)
+			WHERE EXISTS (SELECT 1 FROM saved)
+			ON CONFLICT (slug) DO UPDATE SET name = EXCLUDED.name
+			RETURNING id, slug
+		),
+		tech_added AS (
+			INSERT INTO project_technologies (project_id, technology_id, position)
+			SELECT saved.id, tech.id, t.position
+			FROM saved, tech JOIN json_to_recordset($%[1]d::json) AS t(slug text, name text, position int) ON t.slug = tech.slug
+			ON CONFLICT (project_id, technology_id) DO UPDATE SET position = EXCLUDED.position
+		),
+		tech_removed AS (
+			DELETE FROM project_technologies pt USING saved
+			WHERE pt.project_id = saved.id AND pt.technology_id NOT IN (SELECT id FROM tech)
+		),
+		links_removed AS (
+			DELETE FROM project_links l USING saved WHERE l.project_id = saved.id
+		),
+		links_added AS (
+			INSERT INTO project_links (project_id, label, url, icon, position)
+			SELECT saved.id, l.label, l.url, l.icon, l.position
+			FROM saved, json_to_recordset($%[2]d::json) AS l(label text, url text, icon text, position int)
+		),
+		images_removed AS (
+			DELETE FROM project_images i USING saved WHERE i.project_id = saved.id
+		),
+		images_added AS (
+			INSERT INTO project_images (project_id, url, alt, caption, position)
+			SELECT saved.id, i.url, i.alt, i.caption, i.position
+			FROM saved, json_to_recordset($%[3]d::json) AS i(url text, alt text, caption text, position int)
+		)
+		SELECT id, version, position, created_at, updated_at FROM saved`, tech, links, images)
+}
+
+// projectChildrenArgs encodes the technologies, links and images of p for projectChildren
+func projectChildrenArgs(p *models.Project) ([]any, error) {
+	type technology struct {
+		Slug     string `json:"slug"`
+		Name     string `json:"name"`
+		Position int    `json:"position"`
+	}
+	type link struct {
+		models.ProjectLink
+		Position int `json:"position"`
+	}
+	type image struct {
+		models.ProjectImage
+		Position int `json:"position"`
+	}
+	technologies := make([]technology, len(p.Technologies))
+	for i, t := range p.Technologies {
+		technologies[i] = technology{Slug: t.Slug, Name: t.Name, Position: i}
+	}
+	links := make([]link, len(p.Links))
+	for i, l := range p.Links {
+		links[i] = link{ProjectLink: l, Position: i}
+	}
+	images := make([]image, len(p.Images))
+	for i, img := range p.Images {
+		images[i] = image{ProjectImage: img, Position: i}
+	}
+
+	args := []any{}
+	for _, v := range []any{technologies, links, images} {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, string(data))
+	}
+	return args, nil
+}
+
+// CreateProject inserts an unpublished project at the end of the list, with
+// its technologies, links and images, and fills in the stored fields
+func (r *ProjectRepository) CreateProject(ctx context.Context, p *models.Project) error {
+	children, err := projectChildrenArgs(p)
+	if err != nil {
+		return fmt.Errorf("unable to create project: %w", err)
+	}
+	query := `
+		WITH saved AS (
+			INSERT INTO projects (slug, title, summary, body, category, badge, icon, position)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, (SELECT COALESCE(MAX(position), 0) + 1 FROM projects))
+			RETURNING id, version, position, created_at, updated_at
+		)` + projectChildren(8)
+
+	args := append([]any{p.Slug, p.Title, p.Summary, p.Body, p.Category, p.Badge, p.Icon}, children...)
+	err = r.db.QueryRow(ctx, query, args...).Scan(&p.ID, &p.Version, &p.Position, &p.CreatedAt, &p.UpdatedAt)
+	if isUniqueViolation(err) {
+		return ErrSlugTaken
+	}
+	if err != nil {
+		return fmt.Errorf("unable to create project: %w", err)
+	}
+	p.PublishedAt = nil
+	return nil
+}
+
+// UpdateProject replaces the content of a project if its stored version is
+// still p.Version, then increments the version. It returns ErrNotFound or
+// ErrVersionConflict when nothing was written.
+func (r *ProjectRepository) UpdateProject(ctx context.Context, p *models.Project) error {
+	children, err := projectChildrenArgs(p)
+	if err != nil {
+		return fmt.Errorf("unable to update project: %w", err)
+	}
+	query := `
+		WITH saved AS (
+			UPDATE projects SET
+				slug = $2, title = $3, summary = $4, body = $5, category = $6, badge = $7, icon = $8,
+				version = version + 1,
+				updated_at = NOW()
+			WHERE id = $1 AND version = $9
+			RETURNING id, version, position, created_at, updated_at
+		)` + projectChildren(10)
+
+	args := append([]any{p.ID, p.Slug, p.Title, p.Summary, p.Body, p.Category, p.Badge, p.Icon, p.Version}, children...)
+	err = r.db.QueryRow(ctx, query, args...).Scan(&p.ID, &p.Version, &p.Position, &p.CreatedAt, &p.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return r.missingOrConflict(ctx, p.ID)
+	}
+	if isUniqueViolation(err) {
+		return ErrSlugTaken
+	}
+	if err != nil {
+		return fmt.Errorf("unable to update project: %w", err)
+	}
+	return nil
+}
+
+// SetPublication publishes a project at publishedAt, or unpublishes it when
+// publishedAt is nil, and increments its version. A zero version skips the
+// concurrency check.
+func (r *ProjectRepository) SetPublication(ctx context.Context, id int64, publishedAt *time.Time, version int) error {
+	query := `
+		UPDATE projects SET published_at = $2, version = version + 1, updated_at = NOW()
+		WHERE id = $1 AND ($3 = 0 OR version = $3)
+		`
+
+	tag, err := r.db.Exec(ctx, query, id, publishedAt, version)
+	if err != nil {
+		return fmt.Errorf("unable to publish project: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return r.missingOrConflict(ctx, id)
+	}
+	return nil
+}
+
+// ReorderProjects gives the projects of ids the positions 1, 2, 3... in that
+// order; the projects not listed follow in their current order. Nothing
+// changes and ErrNotFound is returned if an id is unknown.
+func (r *ProjectRepository) ReorderProjects(ctx context.Context, ids []int64) error {
+	query := `
+		WITH listed AS (
+			SELECT o.id, o.ord FROM unnest($1::bigint[]) WITH ORDINALITY AS o(id, ord)
+		),
+		known AS (
+			SELECT COUNT(*) AS n FROM listed JOIN projects p ON p.id = listed.id
+		),
+		ranked AS (
+			SELECT p.id, ROW_NUMBER() OVER (ORDER BY listed.ord NULLS LAST, p.position, p.id) AS position
+			FROM projects p LEFT JOIN listed ON listed.id = p.id
+		),
+		moved AS (
+			UPDATE projects p SET position = ranked.position
+			FROM ranked, known
+			WHERE p.id = ranked.id AND p.position <> ranked.position AND known.n = cardinality($1::bigint[])
+		)
+		SELECT n FROM known`
+
+	var known int
+	if err := r.db.QueryRow(ctx, query, ids).Scan(&known); err != nil {
+		return fmt.Errorf("unable to reorder projects: %w", err)
+	}
+	if known != len(ids) {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// DeleteProject deletes a project with its links and images
+func (r *ProjectRepository) DeleteProject(ctx context.Context, id int64) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM projects WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("unable to delete project: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// missingOrConflict tells why a versioned update of project id wrote nothing
+func (r *ProjectRepository) missingOrConflict(ctx context.Context, id int64) error {
+	var exists bool
+	if err := r.db.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM projects WHERE id = $1)`, id).Scan(&exists); err != nil {
+		return fmt.Errorf("unable to check project: %w", err)
+	}
+	if !exists {
+		return ErrNotFound
+	}
+	return ErrVersionConflict
+}
+
+// isUniqueViolation reports whether err is a unique constraint violation
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// IRevisionRepository stores the immutable revisions of editable content
+type IRevisionRepository interface {
+	CreateRevision(ctx context.Context, revision *models.Revision) error
+	ListRevisions(ctx context.Context, contentType, contentKey string) ([]models.Revision, error)
+	GetRevision(ctx context.Context, id int64) (*models.Revision, error)
+}
+
+// RevisionRepository implements IRevisionRepository on Postgres. Revisions
+// are only ever inserted.
+type RevisionRepository struct {
+	db DBExecutor
+}
+
+// NewRevisionRepository creates a new instance of RevisionRepository
+func NewRevisionRepository(db DBExecutor) IRevisionRepository {
+	return &RevisionRepository{
+		db: db,
+	}
+}
+
+// CreateRevision stores revision as the next number of its content item and
+// fills in its id, number and creation time
+func (r *RevisionRepository) CreateRevision(ctx context.Context, revision *models.Revision) error {
+	query := `
+		INSERT INTO content_revisions (content_type, content_key, number, body)
+		SELECT $1, $2, COALESCE(MAX(number), 0) + 1, $3
+		FROM content_revisions WHERE content_type = $1 AND content_key = $2
+		RETURNING id, number, created_at`
+
+	err := r.db.QueryRow(ctx, query, revision.ContentType, revision.ContentKey, revision.Body).
+		Scan(&revision.ID, &revision.Number, &revision.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("unable to save revision: %w", err)
+	}
+	return nil
+}
+
+// ListRevisions returns the revisions of a content item without their body,
+// newest first
+func (r *RevisionRepository) ListRevisions(ctx context.Context, contentType, contentKey string) ([]models.Revision, error) {
+	query := `
+		SELECT id, content_type, content_key, number, created_at FROM content_revisions
+		WHERE content_type = $1 AND content_key = $2
+		ORDER BY number DESC`
+
+	rows, err := r.db.Query(ctx, query, contentType, contentKey)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list revisions: %w", err)
+	}
+	defer rows.Close()
+
+	revisions := []models.Revision{}
+	for rows.Next() {
+		var rev models.Revision
+		if err := rows.Scan(&rev.ID, &rev.ContentType, &rev.ContentKey, &rev.Number, &rev.CreatedAt); err != nil {
+			return nil, fmt.Errorf("unable to read revision: %w", err)
+		}
+		revisions = append(revisions, rev)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("unable to list revisions: %w", err)
+	}
+	return revisions, nil
+}
+
+// GetRevision loads a revision with its body
+func (r *RevisionRepository) GetRevision(ctx context.Context, id int64) (*models.Revision, error) {
+	query := `SELECT id, content_type, content_key, number, body, created_at FROM content_revisions WHERE id = $1`
+
+	var rev models.Revision
+	err := r.db.QueryRow(ctx, query, id).Scan(&rev.ID, &rev.ContentType, &rev.ContentKey, &rev.Number, &rev.Body, &rev.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to load revision: %w", err)
+	}
+	return &rev, nil
+}
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"backend/internal/models"
+)
+
+// IRoutingRuleRepository loads the routing rules stored in the database
+type IRoutingRuleRepository interface {
+	ListRoutingRules(ctx context.Context) ([]models.RoutingRule, error)
+}
+
+// RoutingRuleRepository implements IRoutingRuleRepository on Postgres
+type RoutingRuleRepository struct {
+	db DBExecutor
+}
+
+// NewRoutingRuleRepository creates a new instance of RoutingRuleRepository
+func NewRoutingRuleRepository(db DBExecutor) IRoutingRuleRepository {
+	return &RoutingRuleRepository{
+		db: db,
+	}
+}
+
+// ListRoutingRules returns every rule ordered by position
+func (r *RoutingRuleRepository) ListRoutingRules(ctx context.Context) ([]models.RoutingRule, error) {
+	query := `
+		SELECT id, name, position, enabled, subjects, keywords, sender_domains, countries, asns,
+			recipients, COALESCE(priority, ''), tags, channels, suppress
+		FROM routing_rules
+		ORDER BY position, id
+		`
+
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list routing rules: %w", err)
+	}
+	defer rows.Close()
+
+	rules := []models.RoutingRule{}
+	for rows.Next() {
+		var rule models.RoutingRule
+		if err := rows.Scan(&rule.ID, &rule.Name, &rule.Position, &rule.Enabled, &rule.Subjects, &rule.Keywords,
+			&rule.SenderDomains, &rule.Countries, &rule.ASNs, &rule.Recipients, &rule.Priority, &rule.Tags, &rule.Channels, &rule.Suppress); err != nil {
+			return nil, fmt.Errorf("unable to read routing rule: %w", err)
+		}
+		rules = append(rules, rule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("unable to list routing rules: %w", err)
+	}
+	return rules, nil
+}
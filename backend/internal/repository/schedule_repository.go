@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// IScheduleRepository stores the scheduled publications and unpublications
+type IScheduleRepository interface {
+	CreateSchedule(ctx context.Context, schedule *models.Schedule) error
+	ListSchedules(ctx context.Context, filter models.ScheduleFilter) ([]models.Schedule, int, error)
+	DeleteSchedule(ctx context.Context, id int64) error
+	ClaimDueSchedules(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]models.Schedule, error)
+	RecordScheduleAttempt(ctx context.Context, id int64, attemptErr string, retryAt *time.Time) error
+}
+
+// ScheduleRepository implements IScheduleRepository on Postgres
+type ScheduleRepository struct {
+	db DBExecutor
+}
+
+// NewScheduleRepository creates a new instance of ScheduleRepository
+func NewScheduleRepository(db DBExecutor) IScheduleRepository {
+	return &ScheduleRepository{
+		db: db,
+	}
+}
+
+const scheduleColumns = `id, content_type, content_key, action, run_at, created_at, done_at, failed_at, attempts, error`
+
+func scanSchedule(row pgx.Row, extra ...any) (*models.Schedule, error) {
+	var s models.Schedule
+	dest := append([]any{&s.ID, &s.ContentType, &s.ContentKey, &s.Action, &s.RunAt, &s.CreatedAt, &s.DoneAt, &s.FailedAt,
+		&s.Attempts, &s.Error}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// CreateSchedule stores a pending schedule and fills in its id and creation time
+func (r *ScheduleRepository) CreateSchedule(ctx context.Context, schedule *models.Schedule) error {
+	query := `
+		INSERT INTO content_schedules (content_type, content_key, action, run_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING ` + scheduleColumns
+
+	saved, err := scanSchedule(r.db.QueryRow(ctx, query, schedule.ContentType, schedule.ContentKey, schedule.Action, schedule.RunAt))
+	if err != nil {
+		return fmt.Errorf("unable to save schedule: %w", err)
+	}
+	*schedule = *saved
+	return nil
+}
+
+// ListSchedules returns a page of schedules, next to run first, and the
+// number of matches
+func (r *ScheduleRepository) ListSchedules(ctx context.Context, filter models.ScheduleFilter) ([]models.Schedule, int, error) {
+	query := `
+		SELECT ` + scheduleColumns + `, COUNT(*) OVER () FROM content_schedules
+		WHERE ($1 = '' OR content_type = $1) AND ($2 = '' OR content_key = $2) AND (NOT $3 OR (done_at IS NULL AND failed_at IS NULL))
+		ORDER BY run_at, id
+		LIMIT $4 OFFSET $5`
+
+	rows, err := r.db.Query(ctx, query, filter.ContentType, filter.ContentKey, filter.Pending, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("unable to list schedules: %w", err)
+	}
+	defer rows.Close()
+
+	schedules := []models.Schedule{}
+	total := 0
+	for rows.Next() {
+		s, err := scanSchedule(rows, &total)
+		if err != nil {
+			return nil, 0, fmt.Errorf("unable to read schedule: %w", err)
+		}
+		schedules = append(schedules, *s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("unable to list schedules: %w", err)
+	}
+	return schedules, total, nil
+}
+
+// DeleteSchedule cancels a schedule that did not run yet
+func (r *ScheduleRepository) DeleteSchedule(ctx context.Context, id int64) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM content_schedules WHERE id = $1 AND done_at IS NULL`, id)
+	if err != nil {
+		return fmt.Errorf("unable to delete schedule: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ClaimDueSchedules leases up to limit pending schedules due at now and
+// returns them, oldest first. Rows claimed by a concurrent instance are
+// skipped, and a claimed row is only returned again once its lease expires,
+// so a schedule is retried if its worker dies but never runs twice at once.
+func (r *ScheduleRepository) ClaimDueSchedules(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]models.Schedule, error) {
+	query := `
+		UPDATE content_schedules SET claimed_until = NOW() + $3 * INTERVAL '1 second'
+		WHERE id IN (
+			SELECT id FROM content_schedules
+			WHERE done_at IS NULL AND failed_at IS NULL AND run_at <= $1
+				AND (claimed_until IS NULL OR claimed_until <= NOW())
+			ORDER BY run_at, id
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + scheduleColumns
+
+	rows, err := r.db.Query(ctx, query, now, limit, lease.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("unable to claim schedules: %w", err)
+	}
+	defer rows.Close()
+
+	var schedules []models.Schedule
+	for rows.Next() {
+		s, err := scanSchedule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read schedule: %w", err)
+		}
+		schedules = append(schedules, *s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("unable to claim schedules: %w", err)
+	}
+	slices.SortFunc(schedules, func(a, b models.Schedule) int {
+		return cmp.Or(a.RunAt.Compare(b.RunAt), cmp.Compare(a.ID, b.ID))
+	})
+	return schedules, nil
+}
+
+// RecordScheduleAttempt stores the outcome of running a claimed schedule. An
+// empty attemptErr marks it done. A failure is retried at retryAt, or marks
+// the schedule failed when retryAt is nil.
+func (r *ScheduleRepository) RecordScheduleAttempt(ctx context.Context, id int64, attemptErr string, retryAt *time.Time) error {
+	query := `
+		UPDATE content_schedules
+		SET attempts = attempts + 1,
+			error = $2,
+			claimed_until = $3,
+			done_at = CASE WHEN $2 = '' THEN NOW() END,
+			failed_at = CASE WHEN $2 <> '' AND $3::timestamptz IS NULL THEN NOW() END
+		WHERE id = $1
+		`
+
+	if _, err := r.db.Exec(ctx, query, id, attemptErr, retryAt); err != nil {
+		return fmt.Errorf("unable to record schedule attempt: %w", err)
+	}
+	return nil
+}
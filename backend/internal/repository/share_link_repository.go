@@ -0,0 +1,240 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrCodeTaken is returned when a share link code is already used
+var ErrCodeTaken = errors.New("code already in use")
+
+// IShareLinkRepository stores the share links and their hits
+type IShareLinkRepository interface {
+	CreateShareLink(ctx context.Context, link *models.ShareLink) error
+	GetShareLink(ctx context.Context, id int64) (*models.ShareLink, error)
+	GetShareLinkByCode(ctx context.Context, code string) (*models.ShareLink, error)
+	ListShareLinks(ctx context.Context, limit, offset int) ([]models.ShareLink, int, error)
+	RevokeShareLink(ctx context.Context, id int64, at time.Time) error
+	DeleteShareLink(ctx context.Context, id int64) error
+
+	CreateShareHit(ctx context.Context, hit *models.ShareHit) error
+	ListShareHits(ctx context.Context, linkID int64, limit, offset int) ([]models.ShareHit, int, error)
+	ShareTargetStats(ctx context.Context) ([]models.ShareTargetStats, error)
+	DeleteShareHitsBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// ShareLinkRepository implements IShareLinkRepository on Postgres
+type ShareLinkRepository struct {
+	db DBExecutor
+}
+
+// NewShareLinkRepository creates a new instance of ShareLinkRepository
+func NewShareLinkRepository(db DBExecutor) IShareLinkRepository {
+	return &ShareLinkRepository{
+		db: db,
+	}
+}
+
+// shareLinkColumns reads links with their hit counts, from shareLinkJoin.
+// Bot hits (link previews, crawlers) are kept but not counted.
+const (
+	shareLinkColumns = `l.id, l.code, l.target, l.label, l.expires_at, l.revoked_at, l.created_at,
+		COUNT(h.id) FILTER (WHERE h.device <> 'bot'), MAX(h.created_at) FILTER (WHERE h.device <> 'bot')`
+	shareLinkJoin = ` FROM share_links l LEFT JOIN share_hits h ON h.link_id = l.id`
+)
+
+func scanShareLink(row pgx.Row, extra ...any) (*models.ShareLink, error) {
+	var l models.ShareLink
+	dest := append([]any{&l.ID, &l.Code, &l.Target, &l.Label, &l.ExpiresAt, &l.RevokedAt, &l.CreatedAt, &l.Hits, &l.LastHitAt}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+// CreateShareLink stores link and fills in its id and creation time.
+// Returns ErrCodeTaken when its code is already used.
+func (r *ShareLinkRepository) CreateShareLink(ctx context.Context, link *models.ShareLink) error {
+	query := `
+		INSERT INTO share_links (code, target, label, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at`
+
+	err := r.db.QueryRow(ctx, query, link.Code, link.Target, link.Label, link.ExpiresAt).Scan(&link.ID, &link.CreatedAt)
+	if isUniqueViolation(err) {
+		return ErrCodeTaken
+	}
+	if err != nil {
+		return fmt.Errorf("unable to create share link: %w", err)
+	}
+	return nil
+}
+
+// GetShareLink returns a link with its hit count, ErrNotFound when missing
+func (r *ShareLinkRepository) GetShareLink(ctx context.Context, id int64) (*models.ShareLink, error) {
+	l, err := scanShareLink(r.db.QueryRow(ctx, `SELECT `+shareLinkColumns+shareLinkJoin+` WHERE l.id = $1 GROUP BY l.id`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to get share link: %w", err)
+	}
+	return l, nil
+}
+
+// GetShareLinkByCode returns a link, without its hit count, ErrNotFound
+// when missing. It is read on every redirect.
+func (r *ShareLinkRepository) GetShareLinkByCode(ctx context.Context, code string) (*models.ShareLink, error) {
+	var l models.ShareLink
+	err := r.db.QueryRow(ctx, `SELECT id, code, target, label, expires_at, revoked_at, created_at FROM share_links WHERE code = $1`, code).
+		Scan(&l.ID, &l.Code, &l.Target, &l.Label, &l.ExpiresAt, &l.RevokedAt, &l.CreatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to get share link: %w", err)
+	}
+	return &l, nil
+}
+
+// ListShareLinks returns a page of links, newest first, with the total count
+func (r *ShareLinkRepository) ListShareLinks(ctx context.Context, limit, offset int) ([]models.ShareLink, int, error) {
+	// The window counts the groups, so every link once
+	query := `SELECT ` + shareLinkColumns + `, COUNT(*) OVER ()` + shareLinkJoin + `
+		GROUP BY l.id
+		ORDER BY l.created_at DESC, l.id DESC LIMIT $1 OFFSET $2`
+
+	rows, err := r.db.Query(ctx, query, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("unable to list share links: %w", err)
+	}
+	defer rows.Close()
+
+	list := []models.ShareLink{}
+	total := 0
+	for rows.Next() {
+		l, err := scanShareLink(rows, &total)
+		if err != nil {
+			return nil, 0, fmt.Errorf("unable to scan share link: %w", err)
+		}
+		list = append(list, *l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("unable to list share links: %w", err)
+	}
+	return list, total, nil
+}
+
+// RevokeShareLink marks a link revoked at at, keeping the first revocation
+// time. Returns ErrNotFound when missing.
+func (r *ShareLinkRepository) RevokeShareLink(ctx context.Context, id int64, at time.Time) error {
+	tag, err := r.db.Exec(ctx, `UPDATE share_links SET revoked_at = COALESCE(revoked_at, $2) WHERE id = $1`, id, at)
+	if err != nil {
+		return fmt.Errorf("unable to revoke share link: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// DeleteShareLink removes a link and its hits, ErrNotFound when missing
+func (r *ShareLinkRepository) DeleteShareLink(ctx context.Context, id int64) error {
+	tag, err := r.db.Exec(ctx, `DELETE FROM share_links WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("unable to delete share link: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// CreateShareHit stores a visit and fills in its id
+func (r *ShareLinkRepository) CreateShareHit(ctx context.Context, hit *models.ShareHit) error {
+	query := `
+		INSERT INTO share_hits (link_id, created_at, referrer, browser, os, device, country)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id`
+
+	err := r.db.QueryRow(ctx, query, hit.LinkID, hit.At, hit.Referrer, hit.Browser, hit.OS, hit.Device, hit.Country).Scan(&hit.ID)
+	if err != nil {
+		return fmt.Errorf("unable to record share hit: %w", err)
+	}
+	return nil
+}
+
+// ListShareHits returns a page of the visits of a link, newest first, with
+// the total count
+func (r *ShareLinkRepository) ListShareHits(ctx context.Context, linkID int64, limit, offset int) ([]models.ShareHit, int, error) {
+	query := `
+		SELECT id, link_id, created_at, referrer, browser, os, device, country, COUNT(*) OVER ()
+		FROM share_hits WHERE link_id = $1
+		ORDER BY created_at DESC, id DESC LIMIT $2 OFFSET $3`
+
+	rows, err := r.db.Query(ctx, query, linkID, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("unable to list share hits: %w", err)
+	}
+	defer rows.Close()
+
+	list := []models.ShareHit{}
+	total := 0
+	for rows.Next() {
+		var h models.ShareHit
+		if err := rows.Scan(&h.ID, &h.LinkID, &h.At, &h.Referrer, &h.Browser, &h.OS, &h.Device, &h.Country, &total); err != nil {
+			return nil, 0, fmt.Errorf("unable to scan share hit: %w", err)
+		}
+		list = append(list, h)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("unable to list share hits: %w", err)
+	}
+	return list, total, nil
+}
+
+// ShareTargetStats counts links and hits per target, most visited first
+func (r *ShareLinkRepository) ShareTargetStats(ctx context.Context) ([]models.ShareTargetStats, error) {
+	query := `
+		SELECT l.target, COUNT(DISTINCT l.id),
+			COUNT(h.id) FILTER (WHERE h.device <> 'bot'), MAX(h.created_at) FILTER (WHERE h.device <> 'bot')
+		FROM share_links l
+		LEFT JOIN share_hits h ON h.link_id = l.id
+		GROUP BY l.target
+		ORDER BY 3 DESC, l.target`
+
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("unable to count share hits: %w", err)
+	}
+	defer rows.Close()
+
+	stats := []models.ShareTargetStats{}
+	for rows.Next() {
+		var s models.ShareTargetStats
+		if err := rows.Scan(&s.Target, &s.Links, &s.Hits, &s.LastHitAt); err != nil {
+			return nil, fmt.Errorf("unable to scan share stats: %w", err)
+		}
+		stats = append(stats, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("unable to count share hits: %w", err)
+	}
+	return stats, nil
+}
+
+// DeleteShareHitsBefore removes the visits older than before and returns
+// how many were deleted
+func (r *ShareLinkRepository) DeleteShareHitsBefore(ctx context.Context, before time.Time) (int64, error) {
+	tag, err := r.db.Exec(ctx, `DELETE FROM share_hits WHERE created_at < $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("unable to purge share hits: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
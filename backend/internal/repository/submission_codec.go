@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+
+	"backend/internal/encryption"
+	"backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Option configures the repositories that read or write personal data
+type Option func(*submissionCodec)
+
+// WithKeyring encrypts personal data at rest: the name, email and message of
+// submissions, the conversation messages and the emails of privacy requests.
+// Rows written without a keyring stay readable.
+func WithKeyring(keyring *encryption.Keyring) Option {
+	return func(c *submissionCodec) {
+		c.keyring = keyring
+	}
+}
+
+// Blind index purposes
+const (
+	emailIndexPurpose  = "email"
+	dedupeIndexPurpose = "dedupe"
+)
+
+// submissionCodec converts the personal fields of submissions and of the
+// rows tied to them to and from their stored form. Without a keyring it
+// stores them in clear.
+type submissionCodec struct {
+	keyring *encryption.Keyring
+}
+
+func newSubmissionCodec(opts []Option) submissionCodec {
+	var c submissionCodec
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
+
+// encrypted reports whether new rows are encrypted
+func (c submissionCodec) encrypted() bool {
+	return c.keyring != nil
+}
+
+// sealedFields are the stored values of the personal fields of a submission
+type sealedFields struct {
+	Name, Email, Message string
+	KeyVersion           *int16  // NULL for rows stored in clear
+	DataKey              []byte  // Wrapped data key, NULL for rows stored in clear
+	EmailIndex           *string // Blind index of the normalized email
+	DedupeHash           string
+}
+
+func (c submissionCodec) seal(form models.ContactForm) (sealedFields, error) {
+	fields := sealedFields{Name: form.Name, Email: form.Email, Message: form.Message, DedupeHash: c.dedupeHash(form.DedupeHash())}
+	if c.keyring == nil {
+		return fields, nil
+	}
+
+	var err error
+	fields.KeyVersion, fields.DataKey, err = c.sealFields(
+		codecField{"name", &fields.Name}, codecField{"email", &fields.Email}, codecField{"message", &fields.Message})
+	if err != nil {
+		return fields, err
+	}
+	index := c.emailIndex(form.Email)
+	fields.EmailIndex = &index
+	return fields, nil
+}
+
+// open decrypts the personal fields of s in place
+func (c submissionCodec) open(s *models.ContactSubmission, keyVersion *int16, dataKey []byte) error {
+	return c.openFields(fmt.Sprintf("submission %d", s.ID), keyVersion, dataKey,
+		codecField{"name", &s.Name}, codecField{"email", &s.Email}, codecField{"message", &s.Message})
+}
+
+// codecField is a column encrypted with the data key of its row. Its name is
+// authenticated with the ciphertext, so values cannot be swapped between
+// columns.
+type codecField struct {
+	name  string
+	value *string
+}
+
+// sealFields encrypts fields in place with a new data key and returns the
+// key version and wrapped key to store with the row. Without a keyring the
+// fields are left in clear and both are nil.
+func (c submissionCodec) sealFields(fields ...codecField) (*int16, []byte, error) {
+	if c.keyring == nil {
+		return nil, nil, nil
+	}
+
+	dek, err := c.keyring.NewDataKey()
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to create data key: %w", err)
+	}
+	for _, f := range fields {
+		if *f.value, err = dek.Seal(f.name, *f.value); err != nil {
+			return nil, nil, fmt.Errorf("unable to encrypt %s: %w", f.name, err)
+		}
+	}
+	version := int16(dek.Version)
+	return &version, dek.Wrapped, nil
+}
+
+// openFields decrypts fields in place; row names the record in errors.
+// Rows stored in clear have no data key and are left as they are.
+func (c submissionCodec) openFields(row string, keyVersion *int16, dataKey []byte, fields ...codecField) error {
+	if keyVersion == nil || dataKey == nil {
+		return nil
+	}
+	if c.keyring == nil {
+		return fmt.Errorf("%s is encrypted but no encryption key is configured", row)
+	}
+
+	dek, err := c.keyring.OpenDataKey(int(*keyVersion), dataKey)
+	if err != nil {
+		return fmt.Errorf("%s: %w", row, err)
+	}
+	for _, f := range fields {
+		if *f.value, err = dek.Open(f.name, *f.value); err != nil {
+			return fmt.Errorf("%s: %w", row, err)
+		}
+	}
+	return nil
+}
+
+// emailIndex returns the blind index of an email, or "" without a keyring
+func (c submissionCodec) emailIndex(email string) string {
+	if c.keyring == nil {
+		return ""
+	}
+	return c.keyring.BlindIndex(emailIndexPurpose, normalizeEmail(email))
+}
+
+// dedupeHash keys the duplicate fingerprint with the index key: a plain
+// SHA-256 would let anyone holding a dump confirm a guessed message
+func (c submissionCodec) dedupeHash(hash string) string {
+	if c.keyring == nil {
+		return hash
+	}
+	return c.keyring.BlindIndex(dedupeIndexPurpose, hash)
+}
+
+// normalizeEmail returns the form of email the blind index is computed on:
+// canonical (IDN domains in Unicode) and lowercase
+func normalizeEmail(email string) string {
+	email = strings.TrimSpace(email)
+	if canonical, err := models.CanonicalEmail(email); err == nil {
+		email = canonical
+	}
+	return strings.ToLower(email)
+}
+
+const submissionColumns = `id, name, email, subject, message, priority, tags, status, assignee,
+	created_at, updated_at, read_at, replied_at, archived_at, deleted_at,
+	ip_hash, ip_prefix, user_agent, accept_language, referrer,
+	utm_source, utm_medium, utm_campaign, utm_term, utm_content, country, asn, as_org, key_version, data_key`
+
+// scan reads the submissionColumns of a row, followed by extra destinations
+func (c submissionCodec) scan(row pgx.Row, extra ...any) (*models.ContactSubmission, error) {
+	var s models.ContactSubmission
+	client := &s.Client
+	var keyVersion *int16
+	var dataKey []byte
+	dest := append([]any{&s.ID, &s.Name, &s.Email, &s.Subject, &s.Message, &s.Priority, &s.Tags, &s.Status, &s.Assignee,
+		&s.CreatedAt, &s.UpdatedAt, &s.ReadAt, &s.RepliedAt, &s.ArchivedAt, &s.DeletedAt,
+		&client.IPHash, &client.IPPrefix, &client.UserAgent, &client.Language, &client.Referrer,
+		&client.UTM.Source, &client.UTM.Medium, &client.UTM.Campaign, &client.UTM.Term, &client.UTM.Content,
+		&client.Country, &client.ASN, &client.ASOrg, &keyVersion, &dataKey}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	if err := c.open(&s, keyVersion, dataKey); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
@@ -0,0 +1,198 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrNotFound is returned when a requested row does not exist
+var ErrNotFound = errors.New("not found")
+
+// ErrDeliveryLeased is returned when claiming a pending delivery whose next
+// attempt is not due: it is being sent, or waits for its retry
+var ErrDeliveryLeased = errors.New("webhook delivery is pending")
+
+// IWebhookRepository stores the webhook delivery log
+type IWebhookRepository interface {
+	CreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error
+	// ClaimDueDeliveries returns up to limit pending deliveries whose next
+	// attempt is due and postpones that attempt by lease, so that other
+	// workers skip them while they are sent and a crash retries them
+	ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error)
+	// ClaimDelivery leases one delivery the same way, whatever its status,
+	// unless it is pending and not due (ErrDeliveryLeased)
+	ClaimDelivery(ctx context.Context, id int64, lease time.Duration) (*models.WebhookDelivery, error)
+	// RecordAttempt stores the outcome of one attempt; nextAttemptAt is the
+	// time of the retry of a pending delivery
+	RecordAttempt(ctx context.Context, id int64, status string, statusCode *int, attemptErr *string, nextAttemptAt *time.Time) error
+	GetDelivery(ctx context.Context, id int64) (*models.WebhookDelivery, error)
+	ListDeliveries(ctx context.Context, endpoint string, limit, offset int) ([]models.WebhookDelivery, error)
+}
+
+// WebhookRepository implements IWebhookRepository on Postgres
+type WebhookRepository struct {
+	db DBExecutor
+}
+
+// NewWebhookRepository creates a new instance of WebhookRepository
+func NewWebhookRepository(db DBExecutor) IWebhookRepository {
+	return &WebhookRepository{
+		db: db,
+	}
+}
+
+const webhookDeliveryColumns = `id, endpoint, event_id, event_type, submission_id, status, attempts,
+	last_status_code, last_error, next_attempt_at, created_at, updated_at, delivered_at`
+
+func scanWebhookDelivery(row pgx.Row) (*models.WebhookDelivery, error) {
+	var d models.WebhookDelivery
+	err := row.Scan(&d.ID, &d.Endpoint, &d.EventID, &d.EventType, &d.SubmissionID, &d.Status, &d.Attempts,
+		&d.LastStatusCode, &d.LastError, &d.NextAttemptAt, &d.CreatedAt, &d.UpdatedAt, &d.DeliveredAt)
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// CreateDelivery inserts a pending delivery, due at once, and fills in its
+// ID and timestamps
+func (r *WebhookRepository) CreateDelivery(ctx context.Context, delivery *models.WebhookDelivery) error {
+	query := `
+		INSERT INTO webhook_deliveries (endpoint, event_id, event_type, submission_id, status, next_attempt_at)
+		VALUES ($1, $2, $3, $4, $5, NOW())
+		RETURNING id, next_attempt_at, created_at, updated_at
+		`
+
+	delivery.Status = models.WebhookDeliveryPending
+	err := r.db.QueryRow(ctx, query, delivery.Endpoint, delivery.EventID, delivery.EventType, delivery.SubmissionID, delivery.Status).
+		Scan(&delivery.ID, &delivery.NextAttemptAt, &delivery.CreatedAt, &delivery.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("unable to insert webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// ClaimDueDeliveries locks due deliveries with SKIP LOCKED, so concurrent
+// claims never return the same row, and moves their next attempt lease away
+func (r *WebhookRepository) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error) {
+	query := `
+		UPDATE webhook_deliveries
+		SET next_attempt_at = NOW() + $2 * INTERVAL '1 second'
+		WHERE id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= NOW()
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + webhookDeliveryColumns
+
+	rows, err := r.db.Query(ctx, query, limit, lease.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("unable to claim webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := []models.WebhookDelivery{}
+	for rows.Next() {
+		d, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, *d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("unable to claim webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// ClaimDelivery leases a delivery for a manual redelivery. A pending
+// delivery can only be claimed once due, so it is never sent by a worker
+// and by an admin at the same time.
+func (r *WebhookRepository) ClaimDelivery(ctx context.Context, id int64, lease time.Duration) (*models.WebhookDelivery, error) {
+	query := `
+		UPDATE webhook_deliveries
+		SET next_attempt_at = NOW() + $2 * INTERVAL '1 second'
+		WHERE id = $1 AND (status <> 'pending' OR next_attempt_at <= NOW())
+		RETURNING ` + webhookDeliveryColumns
+
+	d, err := scanWebhookDelivery(r.db.QueryRow(ctx, query, id, lease.Seconds()))
+	if errors.Is(err, pgx.ErrNoRows) {
+		if _, err := r.GetDelivery(ctx, id); err != nil {
+			return nil, err
+		}
+		return nil, ErrDeliveryLeased
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to claim webhook delivery: %w", err)
+	}
+	return d, nil
+}
+
+// RecordAttempt stores the outcome of one delivery attempt
+func (r *WebhookRepository) RecordAttempt(ctx context.Context, id int64, status string, statusCode *int, attemptErr *string, nextAttemptAt *time.Time) error {
+	query := `
+		UPDATE webhook_deliveries
+		SET status = $2,
+			attempts = attempts + 1,
+			last_status_code = $3,
+			last_error = $4,
+			next_attempt_at = $5,
+			updated_at = NOW(),
+			delivered_at = CASE WHEN $2 = 'succeeded' THEN NOW() ELSE delivered_at END
+		WHERE id = $1
+		`
+
+	if _, err := r.db.Exec(ctx, query, id, status, statusCode, attemptErr, nextAttemptAt); err != nil {
+		return fmt.Errorf("unable to record webhook attempt: %w", err)
+	}
+	return nil
+}
+
+// GetDelivery loads a delivery by ID
+func (r *WebhookRepository) GetDelivery(ctx context.Context, id int64) (*models.WebhookDelivery, error) {
+	query := `SELECT ` + webhookDeliveryColumns + ` FROM webhook_deliveries WHERE id = $1`
+
+	d, err := scanWebhookDelivery(r.db.QueryRow(ctx, query, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to load webhook delivery: %w", err)
+	}
+	return d, nil
+}
+
+// ListDeliveries returns the most recent deliveries, optionally for a single endpoint
+func (r *WebhookRepository) ListDeliveries(ctx context.Context, endpoint string, limit, offset int) ([]models.WebhookDelivery, error) {
+	query := `SELECT ` + webhookDeliveryColumns + ` FROM webhook_deliveries
+		WHERE ($1 = '' OR endpoint = $1)
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2 OFFSET $3`
+
+	rows, err := r.db.Query(ctx, query, endpoint, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := []models.WebhookDelivery{}
+	for rows.Next() {
+		d, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, *d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("unable to list webhook deliveries: %w", err)
+	}
+	return deliveries, nil
+}
@@ -0,0 +1,471 @@
+package services
+
+import (
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"backend/internal/markdown"
+	"backend/internal/models"
+	"backend/internal/repository"
+)
+
+var (
+	// ErrInvalidArticle is returned when an article source cannot be published
+	ErrInvalidArticle = errors.New("invalid article")
+	// ErrReadOnlyArticles is returned by admin changes when the article
+	// store cannot be written, such as ARTICLES_DIR
+	ErrReadOnlyArticles = errors.New("articles are read-only")
+)
+
+// ArticleStore provides the article sources (Markdown directory or database)
+type ArticleStore interface {
+	ListArticleSources(ctx context.Context) ([]models.ArticleSource, error)
+}
+
+// ArticleWriter is implemented by the article stores admins can edit
+type ArticleWriter interface {
+	GetArticleSource(ctx context.Context, slug string) (*models.ArticleSource, error)
+	SaveArticleSource(ctx context.Context, source *models.ArticleSource) error
+	DeleteArticleSource(ctx context.Context, slug string) error
+}
+
+// IArticleService serves the published articles
+type IArticleService interface {
+	List(ctx context.Context, filter models.ArticleFilter) ([]models.Article, int, error)
+	Get(ctx context.Context, slug string) (*models.Article, error)
+	Tags(ctx context.Context) ([]models.ArticleTag, error)
+	// Refresh reloads the sources and renders the ones that changed
+	Refresh(ctx context.Context) error
+	ContentSource
+
+	Preview(ctx context.Context, slug string) (*models.Article, error)
+	Source(ctx context.Context, slug string) (*models.ArticleSource, error)
+	Save(ctx context.Context, slug, source string) (*models.Article, error)
+	Publish(ctx context.Context, slug string, publication models.ArticlePublication) (*models.Article, error)
+	Unpublish(ctx context.Context, slug string) (*models.Article, error)
+	Delete(ctx context.Context, slug string) error
+	ContentRestorer
+}
+
+// ArticleService implements IArticleService. Articles are rendered when
+// they are loaded and kept in memory; renders are cached by the hash of the
+// Markdown body so a refresh only renders what changed. Drafts and
+// scheduled articles are loaded too, and filtered out at request time so a
+// scheduled article shows up as soon as its date is reached.
+type ArticleService struct {
+	store     ArticleStore
+	now       func() time.Time
+	onChange  func()
+	revisions IRevisionService
+
+	mu          sync.RWMutex
+	articles    []models.Article // every valid article, newest first
+	renders     map[[sha256.Size]byte]*markdown.Document
+	fingerprint [sha256.Size]byte // of the loaded sources, to detect changes
+}
+
+// ArticleServiceOption configures optional ArticleService behaviour
+type ArticleServiceOption func(*ArticleService)
+
+// WithArticleChanges calls onChange after a refresh that changed the
+// articles, for instance to invalidate the feeds
+func WithArticleChanges(onChange func()) ArticleServiceOption {
+	return func(s *ArticleService) {
+		s.onChange = onChange
+	}
+}
+
+// WithArticleRevisions records a revision of every article saved through
+// the service
+func WithArticleRevisions(revisions IRevisionService) ArticleServiceOption {
+	return func(s *ArticleService) {
+		s.revisions = revisions
+	}
+}
+
+// WithArticleClock replaces time.Now, to test scheduled articles
+func WithArticleClock(now func() time.Time) ArticleServiceOption {
+	return func(s *ArticleService) {
+		s.now = now
+	}
+}
+
+// NewArticleService creates a new instance of ArticleService. No article is
+// served until Refresh succeeds.
+func NewArticleService(store ArticleStore, opts ...ArticleServiceOption) IArticleService {
+	s := &ArticleService{
+		store:   store,
+		now:     time.Now,
+		renders: map[[sha256.Size]byte]*markdown.Document{},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Refresh loads every source. Invalid sources are logged and skipped so one
+// broken article does not take the others down.
+func (s *ArticleService) Refresh(ctx context.Context) error {
+	sources, err := s.store.ListArticleSources(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to load articles: %w", err)
+	}
+	slices.SortFunc(sources, func(a, b models.ArticleSource) int { return strings.Compare(a.Slug, b.Slug) })
+
+	s.mu.RLock()
+	previous := s.renders
+	s.mu.RUnlock()
+
+	renders := make(map[[sha256.Size]byte]*markdown.Document, len(sources))
+	articles := make([]models.Article, 0, len(sources))
+	seen := map[string]bool{}
+	fingerprint := sha256.New()
+	for _, source := range sources {
+		fm, body, err := markdown.SplitFrontMatter(source.Markdown)
+		if err == nil {
+			err = validateFrontMatter(&fm, source.Slug)
+		}
+		if err != nil {
+			log.Printf("Article %s skipped: %v", source.Slug, err)
+			continue
+		}
+		if seen[fm.Slug] {
+			log.Printf("Article %s skipped: duplicate slug %q", source.Slug, fm.Slug)
+			continue
+		}
+		seen[fm.Slug] = true
+
+		hash := sha256.Sum256([]byte(body))
+		doc, ok := previous[hash]
+		if !ok {
+			if doc, err = markdown.Render(body); err != nil {
+				log.Printf("Article %s skipped: %v", source.Slug, err)
+				continue
+			}
+		}
+		renders[hash] = doc
+		fmt.Fprintf(fingerprint, "%s\x00%x\x00%s\n", source.Slug, sha256.Sum256([]byte(source.Markdown)), source.UpdatedAt.UTC())
+		articles = append(articles, newArticle(fm, source, doc))
+	}
+	slices.SortStableFunc(articles, compareArticles)
+
+	var sum [sha256.Size]byte
+	copy(sum[:], fingerprint.Sum(nil))
+	s.mu.Lock()
+	changed := sum != s.fingerprint
+	s.articles, s.renders, s.fingerprint = articles, renders, sum
+	s.mu.Unlock()
+
+	if changed && s.onChange != nil {
+		s.onChange()
+	}
+	return nil
+}
+
+// List returns a page of published articles, newest first, without their
+// body. The tag is compared case-insensitively.
+func (s *ArticleService) List(ctx context.Context, filter models.ArticleFilter) ([]models.Article, int, error) {
+	tag := strings.ToLower(strings.TrimSpace(filter.Tag))
+	articles := s.published()
+	if filter.Drafts {
+		articles = s.loaded()
+	}
+	var matches []models.Article
+	for _, article := range articles {
+		if tag != "" && !slices.Contains(article.Tags, tag) {
+			continue
+		}
+		article.HTML, article.Headings = "", nil
+		matches = append(matches, article)
+	}
+
+	total := len(matches)
+	start := min(max(filter.Offset, 0), total)
+	end := total
+	if filter.Limit > 0 {
+		end = min(start+filter.Limit, total)
+	}
+	return append([]models.Article{}, matches[start:end]...), total, nil
+}
+
+// Get returns a published article with its rendered body, or
+// repository.ErrNotFound for unknown slugs, drafts and scheduled articles
+func (s *ArticleService) Get(ctx context.Context, slug string) (*models.Article, error) {
+	for _, article := range s.published() {
+		if article.Slug == slug {
+			return &article, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+// Tags returns the tags of the published articles with their article
+// count, most used first
+func (s *ArticleService) Tags(ctx context.Context) ([]models.ArticleTag, error) {
+	counts := map[string]int{}
+	for _, article := range s.published() {
+		for _, tag := range article.Tags {
+			counts[tag]++
+		}
+	}
+	tags := make([]models.ArticleTag, 0, len(counts))
+	for tag, n := range counts {
+		tags = append(tags, models.ArticleTag{Tag: tag, Articles: n})
+	}
+	slices.SortFunc(tags, func(a, b models.ArticleTag) int {
+		return cmp.Or(cmp.Compare(b.Articles, a.Articles), strings.Compare(a.Tag, b.Tag))
+	})
+	return tags, nil
+}
+
+// PublishedEntries lists the published articles for the sitemap and feeds
+func (s *ArticleService) PublishedEntries(ctx context.Context) ([]models.ContentEntry, error) {
+	var entries []models.ContentEntry
+	for _, article := range s.published() {
+		entries = append(entries, models.ContentEntry{
+			Title:       article.Title,
+			Path:        "/articles/" + article.Slug,
+			Summary:     article.Summary,
+			Tags:        article.Tags,
+			PublishedAt: *article.PublishedAt,
+			UpdatedAt:   article.UpdatedAt,
+		})
+	}
+	return entries, nil
+}
+
+// Preview returns an article with its rendered body, drafts and scheduled
+// articles included
+func (s *ArticleService) Preview(ctx context.Context, slug string) (*models.Article, error) {
+	for _, article := range s.loaded() {
+		if article.Slug == slug {
+			return &article, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+// Source returns the Markdown of an article as stored
+func (s *ArticleService) Source(ctx context.Context, slug string) (*models.ArticleSource, error) {
+	writer, err := s.writer()
+	if err != nil {
+		return nil, err
+	}
+	return writer.GetArticleSource(ctx, slug)
+}
+
+// Save creates or replaces an article, records a revision and reloads the
+// articles. The source must be a valid article whose front matter slug,
+// when set, is slug.
+func (s *ArticleService) Save(ctx context.Context, slug, source string) (*models.Article, error) {
+	writer, err := s.writer()
+	if err != nil {
+		return nil, err
+	}
+	fm, _, err := markdown.SplitFrontMatter(source)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidArticle, err)
+	}
+	if err := validateFrontMatter(&fm, slug); err != nil {
+		return nil, err
+	}
+	if fm.Slug != slug {
+		return nil, fmt.Errorf("%w: front matter slug %q does not match %q", ErrInvalidArticle, fm.Slug, slug)
+	}
+
+	if err := writer.SaveArticleSource(ctx, &models.ArticleSource{Slug: slug, Markdown: source}); err != nil {
+		return nil, err
+	}
+	if s.revisions != nil {
+		if _, err := s.revisions.Record(ctx, models.ContentArticle, slug, source); err != nil {
+			log.Printf("Error recording revision of article %s: %v", slug, err)
+		}
+	}
+	if err := s.Refresh(ctx); err != nil {
+		return nil, err
+	}
+	return s.Preview(ctx, slug)
+}
+
+// Publish clears the draft flag and sets the date of an article to now, or
+// to publication.At when set
+func (s *ArticleService) Publish(ctx context.Context, slug string, publication models.ArticlePublication) (*models.Article, error) {
+	at := s.now().UTC()
+	if publication.At != nil {
+		at = publication.At.UTC()
+	}
+	return s.edit(ctx, slug, markdown.Field{Key: "draft", Value: "false"}, markdown.Field{Key: "date", Value: at.Format(time.RFC3339)})
+}
+
+// Unpublish marks an article as a draft
+func (s *ArticleService) Unpublish(ctx context.Context, slug string) (*models.Article, error) {
+	return s.edit(ctx, slug, markdown.Field{Key: "draft", Value: "true"})
+}
+
+// RestoreRevision saves an article revision as the current source. Articles
+// are not versioned, so version is ignored.
+func (s *ArticleService) RestoreRevision(ctx context.Context, revision *models.Revision, version int) error {
+	if revision.ContentType != models.ContentArticle {
+		return fmt.Errorf("%w: revision %d is not an article", ErrInvalidRevision, revision.ID)
+	}
+	_, err := s.Save(ctx, revision.ContentKey, revision.Body)
+	return err
+}
+
+// Delete removes an article; its revisions are kept
+func (s *ArticleService) Delete(ctx context.Context, slug string) error {
+	writer, err := s.writer()
+	if err != nil {
+		return err
+	}
+	if err := writer.DeleteArticleSource(ctx, slug); err != nil {
+		return err
+	}
+	return s.Refresh(ctx)
+}
+
+// edit saves an article with fields of its front matter changed
+func (s *ArticleService) edit(ctx context.Context, slug string, fields ...markdown.Field) (*models.Article, error) {
+	source, err := s.Source(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+	return s.Save(ctx, slug, markdown.SetFrontMatter(source.Markdown, fields...))
+}
+
+func (s *ArticleService) writer() (ArticleWriter, error) {
+	writer, ok := s.store.(ArticleWriter)
+	if !ok {
+		return nil, ErrReadOnlyArticles
+	}
+	return writer, nil
+}
+
+// loaded returns every valid article, drafts included, newest first
+func (s *ArticleService) loaded() []models.Article {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]models.Article(nil), s.articles...)
+}
+
+// published returns the articles public at the current time, newest first
+func (s *ArticleService) published() []models.Article {
+	now := s.now()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var out []models.Article
+	for _, article := range s.articles {
+		if article.Published(now) {
+			out = append(out, article)
+		}
+	}
+	return out
+}
+
+// validateFrontMatter checks and normalizes fm. The slug defaults to the
+// source's and tags are lowercased and deduplicated.
+func validateFrontMatter(fm *markdown.FrontMatter, slug string) error {
+	fm.Title = strings.TrimSpace(fm.Title)
+	if fm.Title == "" {
+		return fmt.Errorf("%w: title is required", ErrInvalidArticle)
+	}
+	fm.Slug = strings.TrimSpace(cmp.Or(fm.Slug, slug))
+	if len(fm.Slug) > models.MaxArticleSlugLength || !slugPattern.MatchString(fm.Slug) {
+		return fmt.Errorf("%w: invalid slug %q", ErrInvalidArticle, fm.Slug)
+	}
+	var tags []string
+	for _, tag := range fm.Tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" && !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	fm.Tags = tags
+	return nil
+}
+
+func newArticle(fm markdown.FrontMatter, source models.ArticleSource, doc *markdown.Document) models.Article {
+	article := models.Article{
+		Slug:           fm.Slug,
+		Title:          fm.Title,
+		Summary:        strings.TrimSpace(fm.Summary),
+		Tags:           fm.Tags,
+		ReadingMinutes: doc.ReadingMinutes,
+		Draft:          fm.Draft,
+		PublishedAt:    fm.Date,
+		UpdatedAt:      source.UpdatedAt,
+		HTML:           doc.HTML,
+		Headings:       doc.Headings,
+	}
+	if article.Tags == nil {
+		article.Tags = []string{}
+	}
+	if fm.Updated != nil {
+		article.UpdatedAt = *fm.Updated
+	}
+	// A scheduled article appears after its last edit
+	if article.PublishedAt != nil && article.PublishedAt.After(article.UpdatedAt) {
+		article.UpdatedAt = *article.PublishedAt
+	}
+	return article
+}
+
+// compareArticles orders articles newest first, undated ones last
+func compareArticles(a, b models.Article) int {
+	switch {
+	case a.PublishedAt == nil && b.PublishedAt == nil:
+		return strings.Compare(a.Slug, b.Slug)
+	case a.PublishedAt == nil:
+		return 1
+	case b.PublishedAt == nil:
+		return -1
+	}
+	return cmp.Or(b.PublishedAt.Compare(*a.PublishedAt), strings.Compare(a.Slug, b.Slug))
+}
+
+// DirArticleStore reads articles from the *.md files of a directory. The
+// file name without extension is the default slug and the modification
+// time the default update time.
+type DirArticleStore struct {
+	dir string
+}
+
+// NewDirArticleStore creates an article store backed by a directory
+func NewDirArticleStore(dir string) *DirArticleStore {
+	return &DirArticleStore{dir: dir}
+}
+
+func (d *DirArticleStore) ListArticleSources(ctx context.Context) ([]models.ArticleSource, error) {
+	files, err := filepath.Glob(filepath.Join(d.dir, "*.md"))
+	if err != nil {
+		return nil, fmt.Errorf("unable to list articles: %w", err)
+	}
+	sources := make([]models.ArticleSource, 0, len(files))
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read article %s: %w", file, err)
+		}
+		sources = append(sources, models.ArticleSource{
+			Slug:      strings.TrimSuffix(filepath.Base(file), ".md"),
+			Markdown:  string(data),
+			UpdatedAt: info.ModTime(),
+		})
+	}
+	return sources, nil
+}
@@ -0,0 +1,397 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/netip"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"backend/internal/models"
+	"backend/internal/repository"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// ErrInvalidBlockRule is returned when an admin block rule is malformed
+var ErrInvalidBlockRule = errors.New("invalid block rule")
+
+// IBlocklistService denies submissions from blocked clients, senders or
+// content, and bans clients that keep tripping the rules
+type IBlocklistService interface {
+	List(ctx context.Context, includeExpired bool) ([]models.BlockRule, error)
+	Create(ctx context.Context, input models.BlockRuleInput) (*models.BlockRule, error)
+	Delete(ctx context.Context, id int64) error
+	Refresh(ctx context.Context) error
+	PurgeExpired(ctx context.Context) error
+
+	// BlockedIP returns the rule denying ip, if any
+	BlockedIP(ctx context.Context, ip string) *models.BlockRule
+	// CheckSubmission returns the rule denying form or the client IP, if any
+	CheckSubmission(ctx context.Context, form models.ContactForm, ip string) *models.BlockRule
+	// Strike records abuse from ip (a blocked submission, a rate-limit
+	// rejection) and bans it once it reaches the strike threshold
+	Strike(ctx context.Context, ip, reason string)
+}
+
+// BanOptions configures automatic bans: a client with Strikes strikes within
+// Window is banned for Duration. Zero Strikes disables automatic bans.
+type BanOptions struct {
+	Strikes  int
+	Window   time.Duration
+	Duration time.Duration
+}
+
+// blockPrefix is a compiled ip rule
+type blockPrefix struct {
+	prefix netip.Prefix
+	rule   *models.BlockRule
+}
+
+// blockRegex is a compiled regex rule
+type blockRegex struct {
+	re   *regexp.Regexp
+	rule *models.BlockRule
+}
+
+// compiledBlocklist indexes the active rules by kind
+type compiledBlocklist struct {
+	prefixes []blockPrefix
+	emails   map[string]*models.BlockRule
+	domains  map[string]*models.BlockRule
+	keywords []*models.BlockRule
+	regexes  []blockRegex
+}
+
+// strikeCount counts the strikes of one client in the current window
+type strikeCount struct {
+	start time.Time
+	count int
+}
+
+// BlocklistService implements IBlocklistService. Rules are cached in memory
+// and reloaded by Refresh, which admin changes trigger.
+type BlocklistService struct {
+	repo repository.IBlocklistRepository
+	bans BanOptions
+
+	mu    sync.RWMutex
+	rules compiledBlocklist
+
+	strikesMu sync.Mutex
+	strikes   map[netip.Prefix]*strikeCount
+}
+
+// NewBlocklistService creates a new instance of BlocklistService. Nothing is
+// blocked until Refresh succeeds.
+func NewBlocklistService(repo repository.IBlocklistRepository, bans BanOptions) IBlocklistService {
+	return &BlocklistService{
+		repo:    repo,
+		bans:    bans,
+		strikes: map[netip.Prefix]*strikeCount{},
+	}
+}
+
+func (s *BlocklistService) List(ctx context.Context, includeExpired bool) ([]models.BlockRule, error) {
+	return s.repo.ListRules(ctx, includeExpired)
+}
+
+// Create validates and stores an admin rule. Adding a rule that exists
+// (same kind and value) replaces its reason and expiry.
+func (s *BlocklistService) Create(ctx context.Context, input models.BlockRuleInput) (*models.BlockRule, error) {
+	rule, err := newBlockRule(input, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.SaveRule(ctx, rule); err != nil {
+		return nil, err
+	}
+	if err := s.Refresh(ctx); err != nil {
+		log.Printf("Error reloading blocklist: %v", err)
+	}
+	return rule, nil
+}
+
+func (s *BlocklistService) Delete(ctx context.Context, id int64) error {
+	if err := s.repo.DeleteRule(ctx, id); err != nil {
+		return err
+	}
+	if err := s.Refresh(ctx); err != nil {
+		log.Printf("Error reloading blocklist: %v", err)
+	}
+	return nil
+}
+
+// newBlockRule validates input and returns the rule to store, with its
+// value in canonical form
+func newBlockRule(input models.BlockRuleInput, now time.Time) (*models.BlockRule, error) {
+	rule := &models.BlockRule{
+		Kind:      strings.TrimSpace(input.Kind),
+		Reason:    strings.TrimSpace(input.Reason),
+		Source:    models.BlockSourceAdmin,
+		ExpiresAt: input.ExpiresAt,
+	}
+	if !slices.Contains(models.BlockKinds, rule.Kind) {
+		return nil, fmt.Errorf("%w: kind must be one of %s", ErrInvalidBlockRule, strings.Join(models.BlockKinds, ", "))
+	}
+	value, err := canonicalBlockValue(rule.Kind, input.Value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBlockRule, err)
+	}
+	rule.Value = value
+	if utf8.RuneCountInString(rule.Reason) > models.MaxBlockReasonLength {
+		return nil, fmt.Errorf("%w: reason must be at most %d characters", ErrInvalidBlockRule, models.MaxBlockReasonLength)
+	}
+
+	if input.ExpiresIn != "" {
+		if input.ExpiresAt != nil {
+			return nil, fmt.Errorf("%w: set expires_at or expires_in, not both", ErrInvalidBlockRule)
+		}
+		d, err := time.ParseDuration(input.ExpiresIn)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("%w: expires_in must be a positive duration such as 72h", ErrInvalidBlockRule)
+		}
+		expires := now.Add(d)
+		rule.ExpiresAt = &expires
+	}
+	if rule.ExpiresAt != nil && !rule.ExpiresAt.After(now) {
+		return nil, fmt.Errorf("%w: expires_at must be in the future", ErrInvalidBlockRule)
+	}
+	return rule, nil
+}
+
+// canonicalBlockValue checks a rule value and normalizes it so equal rules
+// compare equal: IPs and ranges in CIDR form, addresses and domains lowercased
+func canonicalBlockValue(kind, value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || utf8.RuneCountInString(value) > models.MaxBlockValueLength {
+		return "", fmt.Errorf("value must be between 1 and %d characters", models.MaxBlockValueLength)
+	}
+	switch kind {
+	case models.BlockIP:
+		if prefix, err := netip.ParsePrefix(value); err == nil {
+			return prefix.Masked().String(), nil
+		}
+		addr, err := netip.ParseAddr(value)
+		if err != nil {
+			return "", fmt.Errorf("%q is not an IP address or CIDR range", value)
+		}
+		addr = addr.Unmap().WithZone("")
+		return netip.PrefixFrom(addr, addr.BitLen()).String(), nil
+	case models.BlockEmail:
+		// Same form as stored submissions, so punycode and Unicode spellings match
+		canonical, err := models.CanonicalEmail(norm.NFC.String(value))
+		if err != nil {
+			return "", fmt.Errorf("%q is not an email address", value)
+		}
+		return strings.ToLower(canonical), nil
+	case models.BlockDomain:
+		value = strings.TrimPrefix(strings.TrimPrefix(value, "@"), "*.")
+		canonical, err := models.CanonicalEmail("x@" + norm.NFC.String(value))
+		if err != nil || strings.ContainsAny(value, "@/ ") || !strings.Contains(value, ".") {
+			return "", fmt.Errorf("%q is not a domain", value)
+		}
+		return strings.ToLower(emailDomain(canonical)), nil
+	case models.BlockKeyword:
+		return strings.ToLower(value), nil
+	case models.BlockRegex:
+		if _, err := regexp.Compile(value); err != nil {
+			return "", fmt.Errorf("invalid regex: %v", err)
+		}
+		return value, nil
+	}
+	return value, nil
+}
+
+// Refresh reloads the active rules. Rules that no longer compile are skipped
+// with a warning rather than disabling the whole blocklist.
+func (s *BlocklistService) Refresh(ctx context.Context) error {
+	rules, err := s.repo.ListRules(ctx, false)
+	if err != nil {
+		return err
+	}
+
+	compiled := compiledBlocklist{emails: map[string]*models.BlockRule{}, domains: map[string]*models.BlockRule{}}
+	for i := range rules {
+		rule := &rules[i]
+		switch rule.Kind {
+		case models.BlockIP:
+			prefix, err := netip.ParsePrefix(rule.Value)
+			if err != nil {
+				log.Printf("Skipping block rule %d: invalid range %q", rule.ID, rule.Value)
+				continue
+			}
+			compiled.prefixes = append(compiled.prefixes, blockPrefix{prefix: prefix, rule: rule})
+		case models.BlockEmail:
+			compiled.emails[strings.ToLower(rule.Value)] = rule
+		case models.BlockDomain:
+			compiled.domains[strings.ToLower(rule.Value)] = rule
+		case models.BlockKeyword:
+			compiled.keywords = append(compiled.keywords, rule)
+		case models.BlockRegex:
+			re, err := regexp.Compile(rule.Value)
+			if err != nil {
+				log.Printf("Skipping block rule %d: %v", rule.ID, err)
+				continue
+			}
+			compiled.regexes = append(compiled.regexes, blockRegex{re: re, rule: rule})
+		}
+	}
+
+	s.mu.Lock()
+	s.rules = compiled
+	s.mu.Unlock()
+	return nil
+}
+
+// PurgeExpired deletes the rules that expired more than a day ago, so recent
+// bans stay visible to admins for a while
+func (s *BlocklistService) PurgeExpired(ctx context.Context) error {
+	deleted, err := s.repo.DeleteExpired(ctx, time.Now().Add(-24*time.Hour))
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		log.Printf("Deleted %d expired block rules", deleted)
+	}
+	return nil
+}
+
+func (s *BlocklistService) BlockedIP(ctx context.Context, ip string) *models.BlockRule {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return nil
+	}
+	addr = addr.Unmap().WithZone("")
+	now := time.Now()
+
+	s.mu.RLock()
+	var match *models.BlockRule
+	for _, p := range s.rules.prefixes {
+		if p.prefix.Contains(addr) && p.rule.Active(now) {
+			match = p.rule
+			break
+		}
+	}
+	s.mu.RUnlock()
+	return s.hit(ctx, match)
+}
+
+func (s *BlocklistService) CheckSubmission(ctx context.Context, form models.ContactForm, ip string) *models.BlockRule {
+	if rule := s.BlockedIP(ctx, ip); rule != nil {
+		return rule
+	}
+	return s.hit(ctx, s.matchContent(form, time.Now()))
+}
+
+// matchContent returns the first active sender or content rule matching form
+func (s *BlocklistService) matchContent(form models.ContactForm, now time.Time) *models.BlockRule {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	email := strings.ToLower(strings.TrimSpace(form.Email))
+	if rule, ok := s.rules.emails[email]; ok && rule.Active(now) {
+		return rule
+	}
+	// the domain and each of its parents: mail.spam.example, spam.example, example
+	for domain := emailDomain(email); domain != ""; {
+		if rule, ok := s.rules.domains[domain]; ok && rule.Active(now) {
+			return rule
+		}
+		dot := strings.IndexByte(domain, '.')
+		if dot < 0 {
+			break
+		}
+		domain = domain[dot+1:]
+	}
+
+	text := form.Subject + "\n" + form.Message
+	lower := strings.ToLower(text)
+	for _, rule := range s.rules.keywords {
+		if strings.Contains(lower, rule.Value) && rule.Active(now) {
+			return rule
+		}
+	}
+	for _, r := range s.rules.regexes {
+		if r.re.MatchString(text) && r.rule.Active(now) {
+			return r.rule
+		}
+	}
+	return nil
+}
+
+// hit records that rule denied a request and returns it
+func (s *BlocklistService) hit(ctx context.Context, rule *models.BlockRule) *models.BlockRule {
+	if rule == nil {
+		return nil
+	}
+	if err := s.repo.RecordHit(ctx, rule.ID); err != nil {
+		log.Printf("Error recording block rule hit: %v", err)
+	}
+	return rule
+}
+
+func (s *BlocklistService) Strike(ctx context.Context, ip, reason string) {
+	if s.bans.Strikes <= 0 || s.bans.Duration <= 0 {
+		return
+	}
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return
+	}
+	// Same granularity as the rate limiter: one host, or a /64 in IPv6
+	addr = addr.Unmap().WithZone("")
+	bits := 32
+	if addr.Is6() {
+		bits = 64
+	}
+	key, _ := addr.Prefix(bits)
+
+	now := time.Now()
+	s.strikesMu.Lock()
+	for k, c := range s.strikes {
+		if now.Sub(c.start) >= s.bans.Window {
+			delete(s.strikes, k)
+		}
+	}
+	c, ok := s.strikes[key]
+	if !ok {
+		c = &strikeCount{start: now}
+		s.strikes[key] = c
+	}
+	c.count++
+	banned := c.count >= s.bans.Strikes
+	if banned {
+		delete(s.strikes, key)
+	}
+	s.strikesMu.Unlock()
+	if !banned {
+		return
+	}
+
+	expires := now.Add(s.bans.Duration)
+	rule := &models.BlockRule{
+		Kind:      models.BlockIP,
+		Value:     key.String(),
+		Reason:    fmt.Sprintf("%d strikes in %s, last: %s", s.bans.Strikes, s.bans.Window, reason),
+		Source:    models.BlockSourceAuto,
+		ExpiresAt: &expires,
+	}
+	if utf8.RuneCountInString(rule.Reason) > models.MaxBlockReasonLength {
+		rule.Reason = string([]rune(rule.Reason)[:models.MaxBlockReasonLength])
+	}
+	if err := s.repo.SaveRule(ctx, rule); err != nil {
+		log.Printf("Error banning %s: %v", key, err)
+		return
+	}
+	log.Printf("Banned %s until %s (%s)", key, expires.Format(time.RFC3339), reason)
+	if err := s.Refresh(ctx); err != nil {
+		log.Printf("Error reloading blocklist: %v", err)
+	}
+}
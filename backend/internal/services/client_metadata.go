@@ -0,0 +1,88 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/netip"
+
+	"backend/internal/models"
+)
+
+// How client IPs are stored (CLIENT_IP_MODE)
+const (
+	ClientIPHash     = "hash"     // salted HMAC-SHA256: equal IPs can be grouped, not recovered
+	ClientIPTruncate = "truncate" // network prefix: /24 for IPv4, /48 for IPv6
+	ClientIPNone     = "none"     // not stored
+)
+
+// GeoLocator resolves the country and network of a client IP (see package geoip)
+type GeoLocator interface {
+	Lookup(ip string) models.GeoLocation
+}
+
+type clientMetadataCtx struct{}
+
+// WithClientMetadata attaches the metadata of the current request to ctx
+func WithClientMetadata(ctx context.Context, m models.ClientMetadata) context.Context {
+	return context.WithValue(ctx, clientMetadataCtx{}, m)
+}
+
+// ClientMetadataFromContext returns the request metadata attached to ctx, if any
+func ClientMetadataFromContext(ctx context.Context) models.ClientMetadata {
+	m, _ := ctx.Value(clientMetadataCtx{}).(models.ClientMetadata)
+	return m
+}
+
+// IPAnonymizer replaces the raw client IP of metadata with what may be stored
+type IPAnonymizer struct {
+	mode string
+	salt []byte
+}
+
+// NewIPAnonymizer creates an IPAnonymizer. In hash mode an empty salt is
+// replaced with a random one, so hashes only match until the next restart.
+func NewIPAnonymizer(mode, salt string) (*IPAnonymizer, error) {
+	switch mode {
+	case ClientIPHash, ClientIPTruncate, ClientIPNone:
+	default:
+		return nil, fmt.Errorf("unknown client IP mode %q: use %s, %s or %s", mode, ClientIPHash, ClientIPTruncate, ClientIPNone)
+	}
+
+	a := &IPAnonymizer{mode: mode, salt: []byte(salt)}
+	if mode == ClientIPHash && salt == "" {
+		log.Print("CLIENT_IP_SALT is not set: client IP hashes will change on restart")
+		a.salt = make([]byte, 32)
+		_, _ = rand.Read(a.salt)
+	}
+	return a, nil
+}
+
+// Apply fills IPHash or IPPrefix from IP and clears IP
+func (a *IPAnonymizer) Apply(m *models.ClientMetadata) {
+	addr, err := netip.ParseAddr(m.IP)
+	m.IP, m.IPHash, m.IPPrefix = "", "", ""
+	if err != nil {
+		return
+	}
+	addr = addr.Unmap().WithZone("")
+
+	switch a.mode {
+	case ClientIPHash:
+		mac := hmac.New(sha256.New, a.salt)
+		mac.Write([]byte(addr.String()))
+		m.IPHash = hex.EncodeToString(mac.Sum(nil))
+	case ClientIPTruncate:
+		bits := 48
+		if addr.Is4() {
+			bits = 24
+		}
+		if prefix, err := addr.Prefix(bits); err == nil {
+			m.IPPrefix = prefix.String()
+		}
+	}
+}
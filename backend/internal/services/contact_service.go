@@ -3,6 +3,8 @@ package services
 import (
 	"context"
 	"log"
+	"slices"
+	"time"
 
 	"backend/internal/models"
 	"backend/internal/repository"
@@ -14,32 +16,192 @@ type IContactService interface {
 
 type ContactService struct {
 	contactRepo  repository.IContactRepository
-	emailService IEmailService
+	notifier     Notifier
+	dedupeWindow time.Duration
+	webhooks     IWebhookPublisher
+	router       IRouter
+	anonymizer   *IPAnonymizer
+	geo          GeoLocator
+	blocklist    IBlocklistService
+	emails       IEmailVerifier
 }
 
-func NewContactService(contactRepo repository.IContactRepository, emailService IEmailService) IContactService {
-	return &ContactService{
-		contactRepo:  contactRepo,
-		emailService: emailService,
+// ContactServiceOption configures optional ContactService behaviour
+type ContactServiceOption func(*ContactService)
+
+// WithDedupeWindow drops submissions identical (email, subject, message) to one
+// stored within window. Requests carrying an idempotency key are not deduplicated.
+func WithDedupeWindow(window time.Duration) ContactServiceOption {
+	return func(s *ContactService) {
+		s.dedupeWindow = window
+	}
+}
+
+// WithWebhooks publishes a contact.submitted event for every stored submission
+func WithWebhooks(publisher IWebhookPublisher) ContactServiceOption {
+	return func(s *ContactService) {
+		s.webhooks = publisher
+	}
+}
+
+// WithRouting applies routing rules (recipients, priority, tags, channels,
+// suppression) to every submission
+func WithRouting(router IRouter) ContactServiceOption {
+	return func(s *ContactService) {
+		s.router = router
+	}
+}
+
+// WithClientIP stores the client IP of submissions, hashed or truncated by
+// anonymizer. Without it no IP is stored.
+func WithClientIP(anonymizer *IPAnonymizer) ContactServiceOption {
+	return func(s *ContactService) {
+		s.anonymizer = anonymizer
+	}
+}
+
+// WithGeoIP stores the country and network of the client IP with each
+// submission and makes them available to the routing rules
+func WithGeoIP(locator GeoLocator) ContactServiceOption {
+	return func(s *ContactService) {
+		s.geo = locator
 	}
 }
 
+// WithBlocklist silently drops submissions matching a block rule: the client
+// gets the usual success response, nothing is stored or sent, and the client
+// IP earns a strike towards an automatic ban
+func WithBlocklist(blocklist IBlocklistService) ContactServiceOption {
+	return func(s *ContactService) {
+		s.blocklist = blocklist
+	}
+}
+
+// WithEmailVerifier checks the sender domain of submissions (disposable
+// domains, mail servers); depending on its mode failures are rejected or
+// stored with a tag
+func WithEmailVerifier(verifier IEmailVerifier) ContactServiceOption {
+	return func(s *ContactService) {
+		s.emails = verifier
+	}
+}
+
+// NewContactService creates a ContactService. notifier is usually a
+// NotificationDispatcher fanning out to every configured channel.
+func NewContactService(contactRepo repository.IContactRepository, notifier Notifier, opts ...ContactServiceOption) IContactService {
+	s := &ContactService{
+		contactRepo: contactRepo,
+		notifier:    notifier,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
 func (s *ContactService) SubmitContactForm(ctx context.Context, form models.ContactForm) error {
 
+	// Never let an unvalidated form reach the database or the mailer
+	form.Normalize()
+	if err := form.Validate(); err != nil {
+		return err
+	}
+
+	if s.blocklist != nil {
+		ip := ClientMetadataFromContext(ctx).IP
+		if rule := s.blocklist.CheckSubmission(ctx, form, ip); rule != nil {
+			log.Printf("Contact form dropped by block rule %d (%s)", rule.ID, rule.Kind)
+			s.blocklist.Strike(ctx, ip, "blocked "+rule.Kind)
+			markSilentlyDropped(ctx)
+			return nil
+		}
+	}
+
+	var emailFlags []string
+	if s.emails != nil {
+		flags, err := s.emails.Check(ctx, form.Email)
+		if err != nil {
+			return err
+		}
+		emailFlags = flags
+	}
+
+	// The IP is located before it is hashed or truncated
+	client := ClientMetadataFromContext(ctx)
+	if s.geo != nil && client.IP != "" {
+		client.GeoLocation = s.geo.Lookup(client.IP)
+	}
+	client.Normalize()
+
+	decision := models.RoutingDecision{Priority: models.PriorityNormal}
+	if s.router != nil {
+		decision = s.router.Route(form, client)
+	}
+	if len(emailFlags) > 0 {
+		// the tags may belong to a cached routing rule
+		decision.Tags = slices.Clone(decision.Tags)
+	}
+	for _, flag := range emailFlags {
+		if !slices.Contains(decision.Tags, flag) {
+			decision.Tags = append(decision.Tags, flag)
+		}
+	}
+
 	// Save the contact form to the database
-	err := s.contactRepo.SaveContactForm(ctx, form)
-	if err != nil {
+	if s.anonymizer != nil {
+		s.anonymizer.Apply(&client)
+	}
+	client.IP = ""
+
+	submission := &models.ContactSubmission{
+		ContactForm: form,
+		Priority:    decision.Priority,
+		Tags:        decision.Tags,
+		Client:      client,
+	}
+	// Silently accept resubmissions of the same message (double clicks,
+	// retries); the check is part of the insert so concurrent copies are
+	// caught too
+	if s.dedupeWindow > 0 && IdempotencyKeyFromContext(ctx) == "" {
+		saved, err := s.contactRepo.SaveContactFormOnce(ctx, submission, s.dedupeWindow)
+		if err != nil {
+			log.Printf("Error saving contact form to database: %v", err)
+			return err
+		}
+		if !saved {
+			log.Printf("Duplicate contact form ignored")
+			return nil
+		}
+	} else if err := s.contactRepo.SaveContactForm(ctx, submission); err != nil {
 		log.Printf("Error saving contact form to database: %v", err)
 		return err
 	}
 
-	// Send notification email
+	// Send notifications and webhook events
 	// Doing this asynchronously in goroutine to avoid blocking the main flow
-	go func() {
-		err := s.emailService.SendContactEmail(form)
-		if err != nil {
-			log.Printf("Error sending contact email: %v", err)
+	if decision.Suppress {
+		log.Printf("Notification for contact %d suppressed by routing rule %q", submission.ID, decision.Rule)
+	} else {
+		notification := Notification{
+			Submission: *submission,
+			Priority:   decision.Priority,
+			Tags:       decision.Tags,
+			Recipients: decision.Recipients,
+			Channels:   decision.Channels,
 		}
-	}()
+		go func() {
+			err := s.notifier.Notify(context.Background(), notification)
+			if err != nil {
+				log.Printf("Error sending contact notification: %v", err)
+			}
+		}()
+	}
+	if s.webhooks != nil {
+		go func() {
+			if err := s.webhooks.Publish(context.Background(), models.WebhookEventContactSubmitted, submission.ID); err != nil {
+				log.Printf("Error publishing contact webhook: %v", err)
+			}
+		}()
+	}
 	return nil
 }
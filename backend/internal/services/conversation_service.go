@@ -0,0 +1,245 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/mail"
+	"strings"
+	"text/template"
+	"time"
+	"unicode/utf8"
+
+	"backend/internal/models"
+	"backend/internal/repository"
+)
+
+// DefaultReplyTemplate wraps the admin's text and quotes the original message
+const DefaultReplyTemplate = `Bonjour {{.Name}},
+
+{{.Body}}
+
+---
+Le {{.Submission.CreatedAt.Format "02/01/2006"}}, vous avez écrit :
+{{quote .Submission.Message}}`
+
+// ErrReplyNotSent is returned when the mail transport rejects a reply
+var ErrReplyNotSent = errors.New("reply could not be sent")
+
+// maxReferences bounds the References header: the thread root plus the most recent messages
+const maxReferences = 10
+
+// IConversationService sends admin replies and exposes the conversation history
+type IConversationService interface {
+	Reply(ctx context.Context, submissionID int64, req models.ReplyRequest) (*models.ContactMessage, error)
+	Messages(ctx context.Context, submissionID int64) ([]models.ContactMessage, error)
+}
+
+// ReplyOptions holds the reply settings
+type ReplyOptions struct {
+	From     string // Sender address of replies
+	Domain   string // Right-hand side of generated Message-IDs; defaults to the From domain
+	Template string // text/template for the reply body; DefaultReplyTemplate when empty
+	// TokenSecret enables plus-addressed Reply-To headers (local+token@domain)
+	// so visitor answers can be matched even without threading headers
+	TokenSecret string
+}
+
+// ConversationService threads admin replies to submissions
+type ConversationService struct {
+	inbox     repository.IInboxRepository
+	repo      repository.IConversationRepository
+	transport IMailTransport
+	from      string
+	domain    string
+	secret    string
+	tmpl      *template.Template
+}
+
+// replyData is passed to the reply template
+type replyData struct {
+	Name       string
+	Body       string
+	Submission models.ContactSubmission
+}
+
+// NewConversationService creates a new instance of ConversationService
+func NewConversationService(inbox repository.IInboxRepository, repo repository.IConversationRepository, transport IMailTransport, opts ReplyOptions) (IConversationService, error) {
+	text := opts.Template
+	if strings.TrimSpace(text) == "" {
+		text = DefaultReplyTemplate
+	}
+	tmpl, err := template.New("reply").Funcs(template.FuncMap{"quote": quoteText}).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid reply template: %w", err)
+	}
+
+	return &ConversationService{
+		inbox:     inbox,
+		repo:      repo,
+		transport: transport,
+		from:      opts.From,
+		domain:    MessageIDDomain(opts.From, opts.Domain),
+		secret:    opts.TokenSecret,
+		tmpl:      tmpl,
+	}, nil
+}
+
+// MessageIDDomain returns the domain used in generated Message-IDs: the
+// configured one, else the domain of the sender address
+func MessageIDDomain(from, configured string) string {
+	if configured != "" {
+		return configured
+	}
+	if parsed, err := mail.ParseAddress(from); err == nil {
+		from = parsed.Address
+	}
+	if domain := emailDomain(from); domain != "" {
+		return domain
+	}
+	return "localhost"
+}
+
+// SubmissionMessageID is the Message-ID standing for the submission itself.
+// It roots every email thread about the submission.
+func SubmissionMessageID(submissionID int64, domain string) string {
+	return fmt.Sprintf("<contact-%d@%s>", submissionID, domain)
+}
+
+// Reply emails the visitor, records the message and marks the submission replied
+func (s *ConversationService) Reply(ctx context.Context, submissionID int64, req models.ReplyRequest) (*models.ContactMessage, error) {
+	body := strings.TrimSpace(req.Body)
+	if body == "" || utf8.RuneCountInString(body) > models.MaxReplyLength {
+		return nil, fmt.Errorf("%w: reply must be between 1 and %d characters", ErrInvalidUpdate, models.MaxReplyLength)
+	}
+	author := strings.TrimSpace(req.Author)
+	if author == "" {
+		author = "admin"
+	}
+	if utf8.RuneCountInString(author) > maxAssigneeLength {
+		return nil, fmt.Errorf("%w: author must be at most %d characters", ErrInvalidUpdate, maxAssigneeLength)
+	}
+
+	submission, err := s.inbox.GetSubmission(ctx, submissionID)
+	if err != nil {
+		return nil, err
+	}
+	if !models.CanTransition(submission.Status, models.ContactStatusReplied) {
+		return nil, fmt.Errorf("%w: cannot reply to a %s submission", ErrInvalidTransition, submission.Status)
+	}
+	history, err := s.repo.ListMessages(ctx, submissionID)
+	if err != nil {
+		return nil, err
+	}
+
+	var text bytes.Buffer
+	if err := s.tmpl.Execute(&text, replyData{Name: submission.Name, Body: body, Submission: *submission}); err != nil {
+		return nil, fmt.Errorf("unable to render reply: %w", err)
+	}
+
+	to := (&mail.Address{Name: submission.Name, Address: submission.Email}).String()
+	inReplyTo, references := threadHeaders(SubmissionMessageID(submissionID, s.domain), history)
+	message := &models.ContactMessage{
+		SubmissionID: submissionID,
+		Direction:    models.MessageOutbound,
+		MessageID:    s.newMessageID(),
+		InReplyTo:    inReplyTo,
+		References:   references,
+		From:         s.from,
+		To:           to,
+		Subject:      replySubject(req.Subject, submission.Subject, history),
+		Body:         text.String(),
+		Author:       author,
+	}
+
+	var replyTo string
+	if s.secret != "" && s.from != "" {
+		replyTo = PlusAddress(s.from, ReplyToken(s.secret, submissionID))
+	}
+	err = s.transport.SendEmail(ctx, models.OutgoingEmail{
+		From:       message.From,
+		To:         []string{to},
+		ReplyTo:    replyTo,
+		Subject:    message.Subject,
+		Text:       message.Body,
+		MessageID:  message.MessageID,
+		InReplyTo:  message.InReplyTo,
+		References: message.References,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrReplyNotSent, err)
+	}
+
+	// The email is gone: record it even if the request was cancelled meanwhile
+	storeCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.repo.AddMessage(storeCtx, message); err != nil {
+		log.Printf("Reply %s to contact %d was sent but not recorded: %v", message.MessageID, submissionID, err)
+		return nil, err
+	}
+	status := models.ContactStatusReplied
+	if _, err := s.inbox.UpdateSubmission(storeCtx, submissionID, "", models.ContactChanges{Status: &status}, nil); err != nil {
+		log.Printf("Error marking contact %d as replied: %v", submissionID, err)
+	}
+	return message, nil
+}
+
+// Messages returns the conversation of a submission, oldest first
+func (s *ConversationService) Messages(ctx context.Context, submissionID int64) ([]models.ContactMessage, error) {
+	if _, err := s.inbox.GetSubmission(ctx, submissionID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListMessages(ctx, submissionID)
+}
+
+func (s *ConversationService) newMessageID() string {
+	b := make([]byte, 12)
+	_, _ = rand.Read(b)
+	return fmt.Sprintf("<%d.%s@%s>", time.Now().Unix(), hex.EncodeToString(b), s.domain)
+}
+
+// threadHeaders answers the latest message of the conversation (or the
+// submission itself) and references the thread root plus recent messages
+func threadHeaders(root string, history []models.ContactMessage) (inReplyTo string, references []string) {
+	if len(history) == 0 {
+		return root, []string{root}
+	}
+	if len(history) > maxReferences-1 {
+		history = history[len(history)-(maxReferences-1):]
+	}
+	references = []string{root}
+	for _, m := range history {
+		references = append(references, m.MessageID)
+	}
+	return history[len(history)-1].MessageID, references
+}
+
+// replySubject keeps the subject of the ongoing thread so mail clients group the messages
+func replySubject(requested, category string, history []models.ContactMessage) string {
+	subject := strings.TrimSpace(requested)
+	if subject == "" && len(history) > 0 {
+		subject = history[len(history)-1].Subject
+	}
+	if subject == "" {
+		subject = fmt.Sprintf("Votre message via le portfolio (%s)", category)
+	}
+	// Subjects end up in a header: never let a line break through
+	subject = strings.Join(strings.Fields(subject), " ")
+	if !strings.HasPrefix(strings.ToLower(subject), "re:") {
+		subject = "Re: " + subject
+	}
+	return truncateRunes(subject, 255)
+}
+
+// quoteText prefixes every line with "> "
+func quoteText(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		lines[i] = "> " + l
+	}
+	return strings.Join(lines, "\n")
+}
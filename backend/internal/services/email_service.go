@@ -1,9 +1,20 @@
 package services
 
 import (
+	"context"
+
 	"backend/internal/models"
 )
 
+// IMailTransport sends plain-text emails such as admin replies
+type IMailTransport interface {
+	SendEmail(ctx context.Context, msg models.OutgoingEmail) error
+}
+
+// IEmailService is the email channel. Besides Notifier it can send a contact
+// form straight to the admin address, and any email as a mail transport.
 type IEmailService interface {
+	Notifier
+	IMailTransport
 	SendContactEmail(contact models.ContactForm) error
 }
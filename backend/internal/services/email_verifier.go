@@ -0,0 +1,257 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"backend/internal/models"
+)
+
+// What the contact service does with an address failing verification
+const (
+	EmailCheckReject = "reject" // refuse the submission with 400
+	EmailCheckFlag   = "flag"   // store it with a tag (see EmailFlag*)
+)
+
+// Tags added to submissions whose sender address failed a check in flag mode
+const (
+	EmailFlagInvalidDomain = "invalid-email-domain"
+	EmailFlagDisposable    = "disposable-email"
+	EmailFlagNoMailServer  = "no-mail-server"
+)
+
+// IEmailVerifier checks the sender address of submissions beyond its syntax
+type IEmailVerifier interface {
+	// Check returns the flags of email, or an error wrapping
+	// models.ErrInvalidContactForm when it must be rejected
+	Check(ctx context.Context, email string) ([]string, error)
+}
+
+// EmailVerifierOptions configures an EmailVerifier
+type EmailVerifierOptions struct {
+	Mode           string        // EmailCheckReject or EmailCheckFlag (default)
+	DisposableFile string        // Disposable domains, one per line ('#' starts a comment)
+	CheckDNS       bool          // Require an MX record, or an A/AAAA record without MX
+	DNSServer      string        // host:port of the DNS server (system resolver when empty)
+	DNSTimeout     time.Duration // Timeout of each lookup
+	DNSCacheTTL    time.Duration // How long lookup results are kept
+}
+
+// dnsResult is a cached lookup result
+type dnsResult struct {
+	acceptsMail bool
+	expires     time.Time
+}
+
+// maxDNSCacheEntries bounds the lookup cache; expired entries are pruned when it is full
+const maxDNSCacheEntries = 10000
+
+// EmailVerifier rejects or flags addresses on disposable domains and domains
+// that cannot receive mail. Domains are compared in their ASCII (punycode)
+// form, so IDN and punycode spellings of a domain match the same entries.
+// DNS failures other than a definite "no such domain" never fail a check.
+type EmailVerifier struct {
+	opts     EmailVerifierOptions
+	resolver *net.Resolver
+
+	mu         sync.RWMutex
+	disposable map[string]bool
+	modTime    time.Time
+	size       int64
+
+	cacheMu sync.Mutex
+	cache   map[string]dnsResult
+}
+
+// NewEmailVerifier creates a new instance of EmailVerifier and loads the
+// disposable domain list
+func NewEmailVerifier(opts EmailVerifierOptions) (*EmailVerifier, error) {
+	if opts.Mode == "" {
+		opts.Mode = EmailCheckFlag
+	}
+	if opts.Mode != EmailCheckReject && opts.Mode != EmailCheckFlag {
+		return nil, fmt.Errorf("email check mode must be %q or %q", EmailCheckReject, EmailCheckFlag)
+	}
+	if opts.DNSTimeout <= 0 {
+		opts.DNSTimeout = 3 * time.Second
+	}
+	v := &EmailVerifier{
+		opts:       opts,
+		resolver:   net.DefaultResolver,
+		disposable: map[string]bool{},
+		cache:      map[string]dnsResult{},
+	}
+	if opts.DNSServer != "" {
+		// The Go resolver sends every query to DNSServer instead of the
+		// servers of /etc/resolv.conf
+		v.resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, network, opts.DNSServer)
+			},
+		}
+	}
+	if err := v.Reload(context.Background()); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// Reload reads the disposable domain list again if the file changed. On
+// error the previous list is kept.
+func (v *EmailVerifier) Reload(ctx context.Context) error {
+	if v.opts.DisposableFile == "" {
+		return nil
+	}
+	info, err := os.Stat(v.opts.DisposableFile)
+	if err != nil {
+		return fmt.Errorf("unable to read disposable domains: %w", err)
+	}
+	v.mu.RLock()
+	unchanged := info.ModTime().Equal(v.modTime) && info.Size() == v.size
+	v.mu.RUnlock()
+	if unchanged {
+		return nil
+	}
+
+	data, err := os.ReadFile(v.opts.DisposableFile)
+	if err != nil {
+		return fmt.Errorf("unable to read disposable domains: %w", err)
+	}
+	domains := map[string]bool{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		domain, err := models.ASCIIDomain(line)
+		if err != nil {
+			log.Printf("Skipping disposable domain %q: %v", line, err)
+			continue
+		}
+		domains[domain] = true
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("unable to read disposable domains: %w", err)
+	}
+
+	v.mu.Lock()
+	v.disposable, v.modTime, v.size = domains, info.ModTime(), info.Size()
+	v.mu.Unlock()
+	log.Printf("Loaded %d disposable email domains", len(domains))
+	return nil
+}
+
+func (v *EmailVerifier) Check(ctx context.Context, email string) ([]string, error) {
+	at := strings.LastIndex(email, "@")
+	domain, err := models.ASCIIDomain(email[at+1:])
+	if at < 0 || err != nil {
+		return v.fail(EmailFlagInvalidDomain, "email domain is invalid")
+	}
+	if v.isDisposable(domain) {
+		return v.fail(EmailFlagDisposable, "disposable email addresses are not accepted")
+	}
+	if v.opts.CheckDNS && !v.acceptsMail(ctx, domain) {
+		return v.fail(EmailFlagNoMailServer, "email domain does not accept mail")
+	}
+	return nil, nil
+}
+
+// fail applies the mode to a failed check
+func (v *EmailVerifier) fail(flag, reason string) ([]string, error) {
+	if v.opts.Mode == EmailCheckReject {
+		return nil, fmt.Errorf("%w: %s", models.ErrInvalidContactForm, reason)
+	}
+	return []string{flag}, nil
+}
+
+// isDisposable reports whether domain or one of its parents is listed
+func (v *EmailVerifier) isDisposable(domain string) bool {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	for {
+		if v.disposable[domain] {
+			return true
+		}
+		dot := strings.IndexByte(domain, '.')
+		if dot < 0 {
+			return false
+		}
+		domain = domain[dot+1:]
+	}
+}
+
+// acceptsMail reports whether domain has a mail server: an MX record other
+// than the null MX (RFC 7505), or else an address record (RFC 5321 5.1)
+func (v *EmailVerifier) acceptsMail(ctx context.Context, domain string) bool {
+	now := time.Now()
+	v.cacheMu.Lock()
+	cached, ok := v.cache[domain]
+	v.cacheMu.Unlock()
+	if ok && now.Before(cached.expires) {
+		return cached.acceptsMail
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, v.opts.DNSTimeout)
+	defer cancel()
+	accepts, err := v.lookupMail(ctx, domain)
+	if err != nil {
+		// Timeouts and server failures say nothing about the domain
+		log.Printf("Error looking up mail servers of %s: %v", domain, err)
+		return true
+	}
+
+	v.cacheMu.Lock()
+	if len(v.cache) >= maxDNSCacheEntries {
+		for key, entry := range v.cache {
+			if now.After(entry.expires) {
+				delete(v.cache, key)
+			}
+		}
+	}
+	if len(v.cache) < maxDNSCacheEntries {
+		v.cache[domain] = dnsResult{acceptsMail: accepts, expires: now.Add(v.opts.DNSCacheTTL)}
+	}
+	v.cacheMu.Unlock()
+	return accepts
+}
+
+// lookupMail resolves domain. Only a definite answer (records, no records or
+// no such domain) returns a nil error.
+func (v *EmailVerifier) lookupMail(ctx context.Context, domain string) (bool, error) {
+	// A trailing dot keeps the resolver from trying search domains
+	mxs, err := v.resolver.LookupMX(ctx, domain+".")
+	if err == nil && len(mxs) > 0 {
+		nullMX := len(mxs) == 1 && (mxs[0].Host == "." || mxs[0].Host == "")
+		return !nullMX, nil
+	}
+	if err != nil && !isNotFound(err) {
+		return false, err
+	}
+
+	addrs, err := v.resolver.LookupIPAddr(ctx, domain+".")
+	if err != nil {
+		if isNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return len(addrs) > 0, nil
+}
+
+func isNotFound(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) && dnsErr.IsNotFound
+}
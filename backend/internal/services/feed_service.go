@@ -0,0 +1,219 @@
+package services
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"backend/internal/models"
+	"backend/internal/site"
+)
+
+// Paths of the generated documents
+const (
+	SitemapPath = "/sitemap.xml"
+	AtomPath    = "/feed.atom"
+	RSSPath     = "/feed.rss"
+)
+
+// ContentSource lists one type of published content for the sitemap and feeds
+type ContentSource interface {
+	PublishedEntries(ctx context.Context) ([]models.ContentEntry, error)
+}
+
+// IFeedService generates the sitemap, the Atom and RSS feeds and robots.txt
+// from the published content. Outputs are cached until Invalidate is called
+// or CacheTTL elapses.
+type IFeedService interface {
+	Sitemap(ctx context.Context) ([]byte, error)
+	Atom(ctx context.Context) ([]byte, error)
+	RSS(ctx context.Context) ([]byte, error)
+	Robots() []byte
+	// Invalidate drops the cached outputs; call it when content changes
+	Invalidate()
+}
+
+// FeedOptions configures a FeedService
+type FeedOptions struct {
+	Site     site.Info
+	Pages    []string // static pages listed in the sitemap, such as "/" and "/about"
+	Robots   site.RobotsOptions
+	CacheTTL time.Duration // outputs are rebuilt after this long even without changes, so scheduled content shows up
+	FeedSize int           // entries per feed, the most recently published
+}
+
+// FeedService implements IFeedService
+type FeedService struct {
+	opts    FeedOptions
+	sources []ContentSource
+	robots  []byte
+
+	mu         sync.Mutex
+	cache      map[string]cachedOutput
+	generation int // incremented by Invalidate
+}
+
+type cachedOutput struct {
+	data    []byte
+	builtAt time.Time
+}
+
+// NewFeedService creates a FeedService listing the content of sources
+func NewFeedService(sources []ContentSource, opts FeedOptions) IFeedService {
+	if opts.FeedSize <= 0 {
+		opts.FeedSize = 20
+	}
+	return &FeedService{
+		opts:    opts,
+		sources: sources,
+		robots:  site.Robots(opts.Site, opts.Robots),
+		cache:   map[string]cachedOutput{},
+	}
+}
+
+// Sitemap lists the static pages and every published entry. A page's
+// lastmod is the latest update of the entries under its path ("/" covers
+// everything), an entry's is its own update time.
+func (s *FeedService) Sitemap(ctx context.Context) ([]byte, error) {
+	return s.cached(ctx, SitemapPath, func(entries []models.ContentEntry) ([]byte, error) {
+		var urls []site.SitemapURL
+		for _, page := range s.opts.Pages {
+			u := site.SitemapURL{Path: page}
+			prefix := strings.TrimSuffix(page, "/") + "/"
+			for _, e := range entries {
+				if strings.HasPrefix(e.Path, prefix) && e.UpdatedAt.After(u.LastMod) {
+					u.LastMod = e.UpdatedAt
+				}
+			}
+			urls = append(urls, u)
+		}
+		for _, e := range entries {
+			urls = append(urls, site.SitemapURL{Path: e.Path, LastMod: e.UpdatedAt})
+		}
+		return site.Sitemap(s.opts.Site, urls)
+	})
+}
+
+// Atom returns the Atom feed of the most recently published entries
+func (s *FeedService) Atom(ctx context.Context) ([]byte, error) {
+	return s.cached(ctx, AtomPath, func(entries []models.ContentEntry) ([]byte, error) {
+		return site.AtomFeed(s.opts.Site, AtomPath, s.latest(entries))
+	})
+}
+
+// RSS returns the RSS feed of the most recently published entries
+func (s *FeedService) RSS(ctx context.Context) ([]byte, error) {
+	return s.cached(ctx, RSSPath, func(entries []models.ContentEntry) ([]byte, error) {
+		return site.RSSFeed(s.opts.Site, RSSPath, s.latest(entries))
+	})
+}
+
+// Robots returns robots.txt, which only depends on the configuration
+func (s *FeedService) Robots() []byte {
+	return s.robots
+}
+
+func (s *FeedService) Invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	clear(s.cache)
+	s.generation++
+}
+
+// cached returns the output stored under name, building it from the
+// published entries when missing or expired
+func (s *FeedService) cached(ctx context.Context, name string, build func([]models.ContentEntry) ([]byte, error)) ([]byte, error) {
+	s.mu.Lock()
+	out, ok := s.cache[name]
+	generation := s.generation
+	s.mu.Unlock()
+	if ok && (s.opts.CacheTTL <= 0 || time.Since(out.builtAt) < s.opts.CacheTTL) {
+		return out.data, nil
+	}
+
+	builtAt := time.Now()
+	entries, err := s.entries(ctx)
+	if err != nil {
+		return nil, err
+	}
+	data, err := build(entries)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// An output built from content read before an invalidation is not kept
+	if s.generation == generation {
+		s.cache[name] = cachedOutput{data: data, builtAt: builtAt}
+	}
+	return data, nil
+}
+
+// entries gathers the published entries of every source, newest first
+func (s *FeedService) entries(ctx context.Context) ([]models.ContentEntry, error) {
+	var entries []models.ContentEntry
+	for _, source := range s.sources {
+		list, err := source.PublishedEntries(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("unable to list published content: %w", err)
+		}
+		entries = append(entries, list...)
+	}
+	slices.SortStableFunc(entries, func(a, b models.ContentEntry) int {
+		return cmp.Or(b.PublishedAt.Compare(a.PublishedAt), strings.Compare(a.Path, b.Path))
+	})
+	return entries, nil
+}
+
+func (s *FeedService) latest(entries []models.ContentEntry) []models.ContentEntry {
+	return entries[:min(len(entries), s.opts.FeedSize)]
+}
+
+// ProjectEntries adapts the project service to a ContentSource
+func ProjectEntries(projects IProjectService) ContentSource {
+	return projectEntries{projects: projects}
+}
+
+type projectEntries struct {
+	projects IProjectService
+}
+
+// feedPageSize is the page size used to walk the whole project list
+const feedPageSize = 200
+
+func (p projectEntries) PublishedEntries(ctx context.Context) ([]models.ContentEntry, error) {
+	var entries []models.ContentEntry
+	for offset := 0; ; offset += feedPageSize {
+		page, total, err := p.projects.List(ctx, models.ProjectFilter{Limit: feedPageSize, Offset: offset})
+		if err != nil {
+			return nil, err
+		}
+		for _, project := range page {
+			entry := models.ContentEntry{
+				Title:     project.Title,
+				Path:      "/projects/" + project.Slug,
+				Summary:   project.Summary,
+				UpdatedAt: project.UpdatedAt,
+			}
+			if project.PublishedAt != nil {
+				entry.PublishedAt = *project.PublishedAt
+				// A scheduled project appears after its last update
+				if entry.PublishedAt.After(entry.UpdatedAt) {
+					entry.UpdatedAt = entry.PublishedAt
+				}
+			}
+			for _, tech := range project.Technologies {
+				entry.Tags = append(entry.Tags, tech.Name)
+			}
+			entries = append(entries, entry)
+		}
+		if len(page) == 0 || offset+len(page) >= total {
+			return entries, nil
+		}
+	}
+}
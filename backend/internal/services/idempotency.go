@@ -0,0 +1,16 @@
+package services
+
+import "context"
+
+type idempotencyKeyCtx struct{}
+
+// WithIdempotencyKey marks ctx as belonging to a request sent with an Idempotency-Key header
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyCtx{}, key)
+}
+
+// IdempotencyKeyFromContext returns the idempotency key of the request, or "" if none was sent
+func IdempotencyKeyFromContext(ctx context.Context) string {
+	key, _ := ctx.Value(idempotencyKeyCtx{}).(string)
+	return key
+}
@@ -0,0 +1,383 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"backend/internal/models"
+	"backend/internal/repository"
+
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+// Limits on inbound emails
+const (
+	maxInboundBytes      = 10 << 20
+	maxInboundBodyLength = 50000
+)
+
+var (
+	// ErrUnmatchedMessage is returned when an inbound email belongs to no known submission
+	ErrUnmatchedMessage = errors.New("inbound message matches no submission")
+	// ErrInvalidEmail is returned when an inbound email cannot be parsed
+	ErrInvalidEmail = errors.New("invalid email")
+)
+
+// IInboundService stores visitor emails in the matching conversation
+type IInboundService interface {
+	Ingest(ctx context.Context, r io.Reader) (*models.ContactMessage, error)
+}
+
+// InboundOptions holds the inbound email settings
+type InboundOptions struct {
+	Domain      string // Domain of the submission Message-IDs (see MessageIDDomain)
+	TokenSecret string // Secret of the plus-addressed reply tokens; empty disables them
+}
+
+// InboundService parses MIME emails and threads them into conversations
+type InboundService struct {
+	inbox  repository.IInboxRepository
+	repo   repository.IConversationRepository
+	domain string
+	secret string
+}
+
+// NewInboundService creates a new instance of InboundService
+func NewInboundService(inbox repository.IInboxRepository, repo repository.IConversationRepository, opts InboundOptions) IInboundService {
+	return &InboundService{
+		inbox:  inbox,
+		repo:   repo,
+		domain: MessageIDDomain("", opts.Domain),
+		secret: opts.TokenSecret,
+	}
+}
+
+// ReplyToken identifies a submission in a plus-addressed Reply-To. It is
+// signed so that a sender cannot attach mail to an arbitrary submission.
+func ReplyToken(secret string, submissionID int64) string {
+	return "c" + strconv.FormatInt(submissionID, 10) + "." + replyTokenSignature(secret, submissionID)
+}
+
+// PlusAddress inserts tag in the local part of address: local+tag@domain
+func PlusAddress(address, tag string) string {
+	if parsed, err := mail.ParseAddress(address); err == nil {
+		address = parsed.Address
+	}
+	at := strings.LastIndex(address, "@")
+	if at < 0 {
+		return address
+	}
+	return address[:at] + "+" + tag + address[at:]
+}
+
+func replyTokenSignature(secret string, submissionID int64) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("contact:" + strconv.FormatInt(submissionID, 10)))
+	return hex.EncodeToString(mac.Sum(nil))[:16]
+}
+
+// parseReplyToken returns the submission of a valid token
+func parseReplyToken(secret, token string) (int64, bool) {
+	if secret == "" || !strings.HasPrefix(token, "c") {
+		return 0, false
+	}
+	idPart, sig, ok := strings.Cut(token[1:], ".")
+	if !ok {
+		return 0, false
+	}
+	id, err := strconv.ParseInt(idPart, 10, 64)
+	if err != nil || id <= 0 {
+		return 0, false
+	}
+	if !hmac.Equal([]byte(sig), []byte(replyTokenSignature(secret, id))) {
+		return 0, false
+	}
+	return id, true
+}
+
+// Ingest parses a raw email, finds its submission and stores it as an inbound message.
+// Already ingested emails return repository.ErrDuplicateMessage.
+func (s *InboundService) Ingest(ctx context.Context, r io.Reader) (*models.ContactMessage, error) {
+	msg, err := mail.ReadMessage(io.LimitReader(r, maxInboundBytes))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEmail, err)
+	}
+
+	from, err := msg.Header.AddressList("From")
+	if err != nil || len(from) == 0 {
+		return nil, fmt.Errorf("%w: no valid From address", ErrInvalidEmail)
+	}
+	sender := from[0].Address
+
+	submission, err := s.match(ctx, msg.Header, sender)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := extractText(msg.Header.Get("Content-Type"), msg.Header.Get("Content-Transfer-Encoding"), msg.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEmail, err)
+	}
+
+	decoder := mime.WordDecoder{CharsetReader: charsetReader}
+	subject, err := decoder.DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		subject = msg.Header.Get("Subject")
+	}
+
+	messageID := strings.TrimSpace(msg.Header.Get("Message-ID"))
+	if messageID == "" {
+		// Keep a stable identity so a re-delivered file is not stored twice
+		sum := sha256.Sum256([]byte(sender + "\x00" + msg.Header.Get("Date") + "\x00" + body))
+		messageID = "<inbound-" + hex.EncodeToString(sum[:12]) + "@" + s.domain + ">"
+	}
+
+	message := &models.ContactMessage{
+		SubmissionID: submission.ID,
+		Direction:    models.MessageInbound,
+		MessageID:    truncateRunes(messageID, 255),
+		InReplyTo:    truncateRunes(firstMessageID(msg.Header.Get("In-Reply-To")), 255),
+		References:   messageIDs(msg.Header.Get("References")),
+		From:         truncateRunes(from[0].String(), 320),
+		To:           truncateRunes(msg.Header.Get("To"), 320),
+		Subject:      truncateRunes(strings.Join(strings.Fields(subject), " "), 255),
+		Body:         truncateRunes(body, maxInboundBodyLength),
+	}
+	if err := s.repo.AddMessage(ctx, message); err != nil {
+		return nil, err
+	}
+	return message, nil
+}
+
+// match finds the submission an email answers: first through its threading
+// headers, then through a signed plus-address token in the recipients
+func (s *InboundService) match(ctx context.Context, header mail.Header, sender string) (*models.ContactSubmission, error) {
+	ids := append(messageIDs(header.Get("In-Reply-To")), messageIDs(header.Get("References"))...)
+
+	if len(ids) > 0 {
+		id, err := s.repo.FindSubmissionByMessageID(ctx, ids)
+		if err == nil {
+			return s.inbox.GetSubmission(ctx, id)
+		}
+		if !errors.Is(err, repository.ErrNotFound) {
+			return nil, err
+		}
+	}
+
+	// The submission Message-ID is guessable: only trust it from the visitor's own address
+	for _, ref := range ids {
+		if id, ok := s.submissionFromMessageID(ref); ok {
+			submission, err := s.inbox.GetSubmission(ctx, id)
+			if err == nil && strings.EqualFold(submission.Email, sender) {
+				return submission, nil
+			}
+			if err != nil && !errors.Is(err, repository.ErrNotFound) {
+				return nil, err
+			}
+		}
+	}
+
+	for _, field := range []string{"To", "Cc", "Delivered-To", "X-Original-To"} {
+		addresses, err := header.AddressList(field)
+		if err != nil {
+			continue
+		}
+		for _, a := range addresses {
+			local, _, _ := strings.Cut(a.Address, "@")
+			_, tag, ok := strings.Cut(local, "+")
+			if !ok {
+				continue
+			}
+			if id, ok := parseReplyToken(s.secret, tag); ok {
+				submission, err := s.inbox.GetSubmission(ctx, id)
+				if errors.Is(err, repository.ErrNotFound) {
+					continue
+				}
+				return submission, err
+			}
+		}
+	}
+	return nil, ErrUnmatchedMessage
+}
+
+var submissionMessageIDPattern = regexp.MustCompile(`^<contact-(\d+)@(.+)>$`)
+
+func (s *InboundService) submissionFromMessageID(messageID string) (int64, bool) {
+	m := submissionMessageIDPattern.FindStringSubmatch(messageID)
+	if m == nil || !strings.EqualFold(m[2], s.domain) {
+		return 0, false
+	}
+	id, err := strconv.ParseInt(m[1], 10, 64)
+	return id, err == nil
+}
+
+var messageIDPattern = regexp.MustCompile(`<[^<>\s]+>`)
+
+// messageIDs extracts the <...> identifiers of an In-Reply-To or References header
+func messageIDs(value string) []string {
+	ids := messageIDPattern.FindAllString(value, -1)
+	if ids == nil {
+		return []string{}
+	}
+	return ids
+}
+
+func firstMessageID(value string) string {
+	if ids := messageIDs(value); len(ids) > 0 {
+		return ids[0]
+	}
+	return ""
+}
+
+// extractText returns the text of a MIME entity: the text/plain part when
+// there is one, otherwise the text/html part with its markup removed
+func extractText(contentType, transferEncoding string, body io.Reader) (string, error) {
+	plain, htmlText, err := walkParts(contentType, transferEncoding, body, 0)
+	if err != nil {
+		return "", err
+	}
+	text := plain
+	if text == "" && htmlText != "" {
+		text = htmlToText(htmlText)
+	}
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	return strings.TrimSpace(text), nil
+}
+
+func walkParts(contentType, transferEncoding string, body io.Reader, depth int) (plain, htmlText string, err error) {
+	if contentType == "" {
+		contentType = "text/plain; charset=us-ascii"
+	}
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType, params = "text/plain", map[string]string{}
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		if depth > 5 || params["boundary"] == "" {
+			return "", "", nil
+		}
+		reader := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return plain, htmlText, err
+			}
+			if strings.HasPrefix(part.Header.Get("Content-Disposition"), "attachment") {
+				continue
+			}
+			p, h, err := walkParts(part.Header.Get("Content-Type"), part.Header.Get("Content-Transfer-Encoding"), part, depth+1)
+			if err != nil {
+				return plain, htmlText, err
+			}
+			if plain == "" {
+				plain = p
+			}
+			if htmlText == "" {
+				htmlText = h
+			}
+		}
+		return plain, htmlText, nil
+	}
+
+	if mediaType != "text/plain" && mediaType != "text/html" {
+		return "", "", nil
+	}
+	text, err := decodeBody(body, transferEncoding, params["charset"])
+	if err != nil {
+		return "", "", err
+	}
+	if mediaType == "text/html" {
+		return "", text, nil
+	}
+	return text, "", nil
+}
+
+// decodeBody undoes the transfer encoding and converts the charset to UTF-8
+func decodeBody(body io.Reader, transferEncoding, charset string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(transferEncoding)) {
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, &newlineStripper{r: body})
+	}
+	if charset != "" && !strings.EqualFold(charset, "utf-8") && !strings.EqualFold(charset, "us-ascii") {
+		converted, err := charsetReader(charset, body)
+		if err != nil {
+			return "", err
+		}
+		body = converted
+	}
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return "", err
+	}
+	return strings.ToValidUTF8(string(data), "�"), nil
+}
+
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	enc, err := htmlindex.Get(charset)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported charset %q", charset)
+	}
+	return enc.NewDecoder().Reader(input), nil
+}
+
+// newlineStripper drops line breaks so base64 bodies wrapped at 76 columns decode
+type newlineStripper struct {
+	r io.Reader
+}
+
+func (n *newlineStripper) Read(p []byte) (int, error) {
+	for {
+		count, err := n.r.Read(p)
+		out := p[:0]
+		for _, b := range p[:count] {
+			if b != '\r' && b != '\n' {
+				out = append(out, b)
+			}
+		}
+		if len(out) > 0 || err != nil {
+			return len(out), err
+		}
+	}
+}
+
+var (
+	htmlBreakPattern = regexp.MustCompile(`(?i)<\s*(br|/p|/div|/li|/tr)[^>]*>`)
+	htmlTagPattern   = regexp.MustCompile(`(?s)<[^>]*>`)
+	htmlSkipPattern  = regexp.MustCompile(`(?is)<(style|script)[^>]*>.*?</(style|script)>`)
+)
+
+// htmlToText is a crude fallback for HTML-only emails
+func htmlToText(s string) string {
+	s = htmlSkipPattern.ReplaceAllString(s, "")
+	s = htmlBreakPattern.ReplaceAllString(s, "\n")
+	s = htmlTagPattern.ReplaceAllString(s, "")
+	s = html.UnescapeString(s)
+
+	var out bytes.Buffer
+	for _, line := range strings.Split(s, "\n") {
+		out.WriteString(strings.TrimSpace(line))
+		out.WriteByte('\n')
+	}
+	return out.String()
+}
@@ -0,0 +1,293 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"backend/internal/models"
+	"backend/internal/repository"
+)
+
+// Limits on admin-provided inbox data
+const (
+	maxTagsPerSubmission = 20
+	maxTagLength         = 50
+	maxAssigneeLength    = 100
+	maxNoteLength        = 5000
+)
+
+var (
+	// ErrInvalidTransition is returned when a status change is not allowed
+	ErrInvalidTransition = errors.New("invalid status transition")
+	// ErrInvalidUpdate is returned when an inbox update carries invalid data
+	ErrInvalidUpdate = errors.New("invalid update")
+)
+
+// IInboxService manages the lifecycle of stored submissions for admins
+type IInboxService interface {
+	List(ctx context.Context, filter models.ContactFilter) ([]models.ContactSubmission, error)
+	Search(ctx context.Context, filter models.ContactFilter) ([]models.ContactSearchResult, error)
+	Get(ctx context.Context, id int64) (*models.ContactDetail, error)
+	Update(ctx context.Context, id int64, update models.ContactUpdate) (*models.ContactDetail, error)
+	BulkUpdate(ctx context.Context, bulk models.ContactBulkUpdate) (*models.ContactBulkResult, error)
+	AddNote(ctx context.Context, id int64, author, body string) (*models.ContactNote, error)
+	Trash(ctx context.Context, id int64) (*models.ContactSubmission, error)
+	PurgeTrashed(ctx context.Context) (int64, error)
+	Sources(ctx context.Context, filter models.SourceFilter) ([]models.SourceCount, error)
+}
+
+// InboxService implements IInboxService
+type InboxService struct {
+	repo       repository.IInboxRepository
+	purgeAfter time.Duration
+}
+
+// NewInboxService creates a new instance of InboxService. Trashed submissions
+// are permanently deleted purgeAfter they were trashed.
+func NewInboxService(repo repository.IInboxRepository, purgeAfter time.Duration) IInboxService {
+	return &InboxService{
+		repo:       repo,
+		purgeAfter: purgeAfter,
+	}
+}
+
+func (s *InboxService) List(ctx context.Context, filter models.ContactFilter) ([]models.ContactSubmission, error) {
+	if err := validateFilter(filter); err != nil {
+		return nil, err
+	}
+	return s.repo.ListSubmissions(ctx, filter)
+}
+
+// Search returns the submissions matching filter.Query, best matches first.
+// Snippets are HTML-escaped with the matches wrapped in <mark>.
+func (s *InboxService) Search(ctx context.Context, filter models.ContactFilter) ([]models.ContactSearchResult, error) {
+	filter.Query = strings.TrimSpace(filter.Query)
+	if filter.Query == "" || utf8.RuneCountInString(filter.Query) > models.MaxSearchQueryLength {
+		return nil, fmt.Errorf("%w: search query must be between 1 and %d characters", ErrInvalidUpdate, models.MaxSearchQueryLength)
+	}
+	if err := validateFilter(filter); err != nil {
+		return nil, err
+	}
+
+	results, err := s.repo.SearchSubmissions(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	for i := range results {
+		results[i].Snippet = highlightSnippet(results[i].Snippet)
+	}
+	return results, nil
+}
+
+func validateFilter(filter models.ContactFilter) error {
+	if filter.Status != "" && !models.IsContactStatus(filter.Status) {
+		return fmt.Errorf("%w: unknown status %q", ErrInvalidUpdate, filter.Status)
+	}
+	if filter.Since != nil && filter.Until != nil && !filter.Since.Before(*filter.Until) {
+		return fmt.Errorf("%w: from must be before to", ErrInvalidUpdate)
+	}
+	return nil
+}
+
+// Sources reports where submissions come from: the pages and campaigns that
+// convert, or the client IPs sending the most
+func (s *InboxService) Sources(ctx context.Context, filter models.SourceFilter) ([]models.SourceCount, error) {
+	if !slices.Contains(models.SourceDimensions, filter.By) {
+		return nil, fmt.Errorf("%w: by must be one of %s", ErrInvalidUpdate, strings.Join(models.SourceDimensions, ", "))
+	}
+	if filter.Since != nil && filter.Until != nil && !filter.Since.Before(*filter.Until) {
+		return nil, fmt.Errorf("%w: from must be before to", ErrInvalidUpdate)
+	}
+	return s.repo.CountSources(ctx, filter)
+}
+
+// highlightSnippet escapes a ts_headline excerpt and turns its markers into <mark> tags
+func highlightSnippet(snippet string) string {
+	escaped := html.EscapeString(snippet)
+	escaped = strings.ReplaceAll(escaped, repository.HeadlineStart, "<mark>")
+	return strings.ReplaceAll(escaped, repository.HeadlineStop, "</mark>")
+}
+
+func (s *InboxService) Get(ctx context.Context, id int64) (*models.ContactDetail, error) {
+	submission, err := s.repo.GetSubmission(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.withNotes(ctx, submission)
+}
+
+// Update applies a partial update and optionally appends a note. The note is
+// stored with the change, so it is not kept when the change is refused.
+func (s *InboxService) Update(ctx context.Context, id int64, update models.ContactUpdate) (*models.ContactDetail, error) {
+	var note *models.ContactNote
+	if body := strings.TrimSpace(update.Note); body != "" {
+		var err error
+		if note, err = newNote(id, update.Author, body); err != nil {
+			return nil, err
+		}
+	}
+
+	submission, err := s.apply(ctx, id, update.ContactChanges, update.AddTags, update.RemoveTags, note)
+	if err != nil {
+		return nil, err
+	}
+	return s.withNotes(ctx, submission)
+}
+
+// BulkUpdate applies the same change to many submissions. Failures (unknown
+// id, forbidden transition) are reported per id and do not stop the batch.
+func (s *InboxService) BulkUpdate(ctx context.Context, bulk models.ContactBulkUpdate) (*models.ContactBulkResult, error) {
+	changes := models.ContactChanges{Status: bulk.Status, Assignee: bulk.Assignee}
+	result := &models.ContactBulkResult{Updated: []int64{}, Failed: map[int64]string{}}
+
+	for _, id := range bulk.IDs {
+		_, err := s.apply(ctx, id, changes, bulk.AddTags, bulk.RemoveTags, nil)
+		switch {
+		case err == nil:
+			result.Updated = append(result.Updated, id)
+		case errors.Is(err, repository.ErrNotFound), errors.Is(err, ErrInvalidTransition), errors.Is(err, ErrInvalidUpdate):
+			result.Failed[id] = err.Error()
+		default:
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// AddNote appends an internal note to a submission
+func (s *InboxService) AddNote(ctx context.Context, id int64, author, body string) (*models.ContactNote, error) {
+	note, err := newNote(id, author, body)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.repo.GetSubmission(ctx, id); err != nil {
+		return nil, err
+	}
+	if err := s.repo.AddNote(ctx, note); err != nil {
+		return nil, err
+	}
+	return note, nil
+}
+
+// newNote validates a note, the author defaulting to "admin"
+func newNote(id int64, author, body string) (*models.ContactNote, error) {
+	body = strings.TrimSpace(body)
+	if body == "" || utf8.RuneCountInString(body) > maxNoteLength {
+		return nil, fmt.Errorf("%w: note must be between 1 and %d characters", ErrInvalidUpdate, maxNoteLength)
+	}
+	author = strings.TrimSpace(author)
+	if author == "" {
+		author = "admin"
+	}
+	if utf8.RuneCountInString(author) > maxAssigneeLength {
+		return nil, fmt.Errorf("%w: author must be at most %d characters", ErrInvalidUpdate, maxAssigneeLength)
+	}
+	return &models.ContactNote{SubmissionID: id, Author: author, Body: body}, nil
+}
+
+// Trash soft-deletes a submission; it is purged after the purge window
+func (s *InboxService) Trash(ctx context.Context, id int64) (*models.ContactSubmission, error) {
+	status := models.ContactStatusTrashed
+	return s.apply(ctx, id, models.ContactChanges{Status: &status}, nil, nil, nil)
+}
+
+// PurgeTrashed permanently deletes submissions trashed longer than the purge window
+func (s *InboxService) PurgeTrashed(ctx context.Context) (int64, error) {
+	return s.repo.PurgeTrashed(ctx, time.Now().Add(-s.purgeAfter))
+}
+
+// apply validates changes against the current state of the submission and
+// stores them, along with note when not nil. A status change is only stored
+// if the status is still the one it was checked against.
+func (s *InboxService) apply(ctx context.Context, id int64, changes models.ContactChanges, addTags, removeTags []string, note *models.ContactNote) (*models.ContactSubmission, error) {
+	current, err := s.repo.GetSubmission(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if changes.Status != nil {
+		to := *changes.Status
+		if !models.IsContactStatus(to) {
+			return nil, fmt.Errorf("%w: unknown status %q", ErrInvalidUpdate, to)
+		}
+		if !models.CanTransition(current.Status, to) {
+			return nil, fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, current.Status, to)
+		}
+	}
+	if changes.Assignee != nil {
+		assignee := strings.TrimSpace(*changes.Assignee)
+		if utf8.RuneCountInString(assignee) > maxAssigneeLength {
+			return nil, fmt.Errorf("%w: assignee must be at most %d characters", ErrInvalidUpdate, maxAssigneeLength)
+		}
+		changes.Assignee = &assignee
+	}
+	if changes.Tags != nil || len(addTags) > 0 || len(removeTags) > 0 {
+		base := current.Tags
+		if changes.Tags != nil {
+			base = *changes.Tags
+		}
+		tags, err := mergeTags(base, addTags, removeTags)
+		if err != nil {
+			return nil, err
+		}
+		changes.Tags = &tags
+	}
+
+	if changes.Status == nil && changes.Tags == nil && changes.Assignee == nil {
+		if note != nil {
+			if err := s.repo.AddNote(ctx, note); err != nil {
+				return nil, err
+			}
+		}
+		return current, nil
+	}
+	var from string
+	if changes.Status != nil {
+		from = current.Status
+	}
+	updated, err := s.repo.UpdateSubmission(ctx, id, from, changes, note)
+	if errors.Is(err, repository.ErrVersionConflict) {
+		return nil, fmt.Errorf("%w: the status changed meanwhile, reload and try again", ErrInvalidTransition)
+	}
+	return updated, err
+}
+
+func (s *InboxService) withNotes(ctx context.Context, submission *models.ContactSubmission) (*models.ContactDetail, error) {
+	notes, err := s.repo.ListNotes(ctx, submission.ID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.ContactDetail{ContactSubmission: *submission, Notes: notes}, nil
+}
+
+// mergeTags normalizes tags (trimmed, lowercase, unique), adds and removes the given ones
+func mergeTags(base, add, remove []string) ([]string, error) {
+	removed := map[string]bool{}
+	for _, t := range remove {
+		removed[strings.ToLower(strings.TrimSpace(t))] = true
+	}
+
+	seen := map[string]bool{}
+	tags := []string{}
+	for _, t := range append(append([]string{}, base...), add...) {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t == "" || seen[t] || removed[t] {
+			continue
+		}
+		if utf8.RuneCountInString(t) > maxTagLength {
+			return nil, fmt.Errorf("%w: tags must be at most %d characters", ErrInvalidUpdate, maxTagLength)
+		}
+		seen[t] = true
+		tags = append(tags, t)
+	}
+	if len(tags) > maxTagsPerSubmission {
+		return nil, fmt.Errorf("%w: at most %d tags per submission", ErrInvalidUpdate, maxTagsPerSubmission)
+	}
+	return tags, nil
+}
@@ -0,0 +1,28 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// RunPeriodic calls job every interval until ctx is cancelled.
+// Errors are logged and do not stop the loop.
+func RunPeriodic(ctx context.Context, name string, interval time.Duration, job func(ctx context.Context) error) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := job(ctx); err != nil {
+				log.Printf("Error running job %s: %v", name, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"backend/internal/repository"
+)
+
+// MaildirWatcher ingests the emails a mail server delivers to a maildir
+// (see https://cr.yp.to/proto/maildir.html)
+type MaildirWatcher struct {
+	dir      string
+	ingester IInboundService
+}
+
+// NewMaildirWatcher creates a watcher over the maildir at dir
+func NewMaildirWatcher(dir string, ingester IInboundService) *MaildirWatcher {
+	return &MaildirWatcher{
+		dir:      dir,
+		ingester: ingester,
+	}
+}
+
+// Scan ingests every message waiting in new/ and moves it to cur/. Ingested
+// messages are flagged seen; unmatched or unreadable ones stay unseen so they
+// remain visible in a regular mail client. On a storage error the message is
+// logged and left in new/ to be retried by the next scan, and the scan goes
+// on with the next message so one bad message cannot hold up the others.
+func (w *MaildirWatcher) Scan(ctx context.Context) error {
+	entries, err := os.ReadDir(filepath.Join(w.dir, "new"))
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		path := filepath.Join(w.dir, "new", entry.Name())
+
+		seen, err := w.ingest(ctx, path)
+		if err != nil {
+			log.Printf("Error storing inbound email %s: %v", entry.Name(), err)
+			continue
+		}
+		flags := ":2,"
+		if seen {
+			flags = ":2,S"
+		}
+		base, _, _ := strings.Cut(entry.Name(), ":")
+		if err := os.Rename(path, filepath.Join(w.dir, "cur", base+flags)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ingest stores one message and reports whether it was threaded into a conversation
+func (w *MaildirWatcher) ingest(ctx context.Context, path string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	message, err := w.ingester.Ingest(ctx, f)
+	switch {
+	case err == nil:
+		log.Printf("Inbound email %s added to contact %d", message.MessageID, message.SubmissionID)
+		return true, nil
+	case errors.Is(err, repository.ErrDuplicateMessage):
+		return true, nil
+	case errors.Is(err, ErrUnmatchedMessage), errors.Is(err, ErrInvalidEmail):
+		log.Printf("Inbound email %s skipped: %v", filepath.Base(path), err)
+		return false, nil
+	default:
+		return false, err
+	}
+}
@@ -0,0 +1,270 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"backend/internal/media"
+	"backend/internal/models"
+	"backend/internal/repository"
+)
+
+// MediaPath is the URL prefix media files are served under
+const MediaPath = "/media/"
+
+// MediaStore keeps the uploaded files by name. Names are derived from the
+// content hash, so a stored file never changes.
+type MediaStore interface {
+	Put(name string, data []byte) error
+	Open(name string) (io.ReadSeekCloser, error)
+	Delete(name string) error
+}
+
+// IMediaService manages the media library: uploads are checked, cleaned of
+// their metadata, resized and stored once per content hash
+type IMediaService interface {
+	// Upload stores a file and returns it with true, or the already stored
+	// identical file with false
+	Upload(ctx context.Context, filename string, data []byte) (*models.Media, bool, error)
+	List(ctx context.Context, limit, offset int) ([]models.Media, int, error)
+	Get(ctx context.Context, id int64) (*models.Media, error)
+	Delete(ctx context.Context, id int64) error
+	// Open returns a stored file and its content type, ErrNotFound for
+	// unknown or malformed names
+	Open(name string) (io.ReadSeekCloser, string, error)
+}
+
+// MediaOptions configures a MediaService
+type MediaOptions struct {
+	Limits media.Limits
+	Widths []int // widths of the resized variants; only those smaller than the image are made
+}
+
+// MediaService implements IMediaService
+type MediaService struct {
+	repo  repository.IMediaRepository
+	store MediaStore
+	opts  MediaOptions
+}
+
+// NewMediaService creates a new instance of MediaService
+func NewMediaService(repo repository.IMediaRepository, store MediaStore, opts MediaOptions) IMediaService {
+	return &MediaService{
+		repo:  repo,
+		store: store,
+		opts:  opts,
+	}
+}
+
+// maxFilenameLength bounds the original file name kept for display
+const maxFilenameLength = 255
+
+func (s *MediaService) Upload(ctx context.Context, filename string, data []byte) (*models.Media, bool, error) {
+	file, err := media.Prepare(data, s.opts.Limits)
+	if err != nil {
+		return nil, false, err
+	}
+	sum := sha256.Sum256(file.Data)
+	hash := hex.EncodeToString(sum[:])
+
+	existing, err := s.repo.GetMediaBySHA256(ctx, hash)
+	if err == nil {
+		return withMediaURLs(existing), false, nil
+	}
+	if !errors.Is(err, repository.ErrNotFound) {
+		return nil, false, err
+	}
+
+	m := &models.Media{
+		SHA256:      hash,
+		ContentType: file.ContentType,
+		Size:        int64(len(file.Data)),
+		Width:       file.Width,
+		Height:      file.Height,
+		Filename:    cleanFilename(filename),
+		Name:        hash + "." + media.Types[file.ContentType],
+		Variants:    []models.MediaVariant{},
+	}
+	variants, err := media.Variants(file, s.opts.Widths)
+	if err != nil {
+		return nil, false, err
+	}
+	// Variants are written first: a concurrent upload of the same file
+	// finds the row only once every file exists
+	for _, v := range variants {
+		name := fmt.Sprintf("%s-%dw.%s", hash, v.Width, media.Types[v.ContentType])
+		if err := s.store.Put(name, v.Data); err != nil {
+			return nil, false, err
+		}
+		m.Variants = append(m.Variants, models.MediaVariant{
+			Name:        name,
+			ContentType: v.ContentType,
+			Width:       v.Width,
+			Height:      v.Height,
+			Size:        int64(len(v.Data)),
+		})
+	}
+	if err := s.store.Put(m.Name, file.Data); err != nil {
+		return nil, false, err
+	}
+
+	created, err := s.repo.CreateMedia(ctx, m)
+	if err != nil {
+		return nil, false, err
+	}
+	return withMediaURLs(m), created, nil
+}
+
+func (s *MediaService) List(ctx context.Context, limit, offset int) ([]models.Media, int, error) {
+	list, total, err := s.repo.ListMedia(ctx, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range list {
+		withMediaURLs(&list[i])
+	}
+	return list, total, nil
+}
+
+func (s *MediaService) Get(ctx context.Context, id int64) (*models.Media, error) {
+	m, err := s.repo.GetMedia(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return withMediaURLs(m), nil
+}
+
+// Delete removes the row, then the files. A file that cannot be removed is
+// logged: it is no longer listed and is replaced if uploaded again.
+func (s *MediaService) Delete(ctx context.Context, id int64) error {
+	m, err := s.repo.GetMedia(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.DeleteMedia(ctx, id); err != nil {
+		return err
+	}
+	names := []string{m.Name}
+	for _, v := range m.Variants {
+		names = append(names, v.Name)
+	}
+	for _, name := range names {
+		if err := s.store.Delete(name); err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Printf("Failed to delete media file %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// mediaNamePattern matches the stored names: the hash, an optional variant
+// width and the extension
+var mediaNamePattern = regexp.MustCompile(`^[0-9a-f]{64}(?:-[1-9][0-9]{0,4}w)?\.([a-z]+)$`)
+
+func (s *MediaService) Open(name string) (io.ReadSeekCloser, string, error) {
+	match := mediaNamePattern.FindStringSubmatch(name)
+	if match == nil {
+		return nil, "", repository.ErrNotFound
+	}
+	contentType := media.TypeByExtension(match[1])
+	if contentType == "" {
+		return nil, "", repository.ErrNotFound
+	}
+	f, err := s.store.Open(name)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, "", repository.ErrNotFound
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return f, contentType, nil
+}
+
+// withMediaURLs fills in the URLs and the srcset of m
+func withMediaURLs(m *models.Media) *models.Media {
+	m.URL = MediaPath + m.Name
+	var srcset []string
+	for i := range m.Variants {
+		m.Variants[i].URL = MediaPath + m.Variants[i].Name
+		srcset = append(srcset, m.Variants[i].URL+" "+strconv.Itoa(m.Variants[i].Width)+"w")
+	}
+	if len(srcset) > 0 {
+		m.SrcSet = strings.Join(append(srcset, m.URL+" "+strconv.Itoa(m.Width)+"w"), ", ")
+	}
+	return m
+}
+
+// cleanFilename keeps the base name of an uploaded file, without control
+// characters, for display only
+func cleanFilename(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f {
+			return -1
+		}
+		return r
+	}, filepath.Base(strings.ReplaceAll(name, "\\", "/")))
+	if name == "." || name == "/" {
+		return ""
+	}
+	if len(name) > maxFilenameLength {
+		name = strings.ToValidUTF8(name[:maxFilenameLength], "")
+	}
+	return name
+}
+
+// DirMediaStore keeps media files in a directory. Files are written to a
+// temporary name and renamed, so a file is never served half written.
+type DirMediaStore struct {
+	dir string
+}
+
+// NewDirMediaStore creates a media store backed by dir, creating it if needed
+func NewDirMediaStore(dir string) (*DirMediaStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("unable to create media directory: %w", err)
+	}
+	return &DirMediaStore{dir: dir}, nil
+}
+
+func (d *DirMediaStore) Put(name string, data []byte) error {
+	path := filepath.Join(d.dir, filepath.Base(name))
+	if _, err := os.Stat(path); err == nil {
+		return nil // same name, same content
+	}
+	tmp, err := os.CreateTemp(d.dir, ".upload-*")
+	if err != nil {
+		return fmt.Errorf("unable to store media: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("unable to store media: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("unable to store media: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return fmt.Errorf("unable to store media: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("unable to store media: %w", err)
+	}
+	return nil
+}
+
+func (d *DirMediaStore) Open(name string) (io.ReadSeekCloser, error) {
+	return os.Open(filepath.Join(d.dir, filepath.Base(name)))
+}
+
+func (d *DirMediaStore) Delete(name string) error {
+	return os.Remove(filepath.Join(d.dir, filepath.Base(name)))
+}
@@ -0,0 +1,175 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"text/template"
+	"unicode/utf8"
+
+	"backend/internal/models"
+)
+
+// DefaultNotificationTitle is used when a notification has no title of its own
+const DefaultNotificationTitle = "Nouveau message via le formulaire de contact - Portfolio Enzo"
+
+// DefaultNotificationTemplate renders a plain-text summary of a submission
+const DefaultNotificationTemplate = `{{.Title}}
+De : {{.Submission.Name}} <{{.Submission.Email}}>
+Sujet : {{.Submission.Subject}}
+
+{{.Submission.Message}}`
+
+// Notification tells the admin about a new submission
+type Notification struct {
+	Submission models.ContactSubmission
+	Title      string
+	Priority   string   // one of the models.Priority* constants; empty means normal
+	Tags       []string // free-form labels, shown by channels that support them
+	Recipients []string // overrides the default email recipient
+	Channels   []string // restricts delivery to these channel names; empty means all
+}
+
+// Notifier delivers notifications to one channel (email, chat, push...)
+type Notifier interface {
+	Name() string
+	Notify(ctx context.Context, n Notification) error
+}
+
+// NotificationDispatcher fans a notification out to several channels
+type NotificationDispatcher struct {
+	notifiers []Notifier
+}
+
+// NewNotificationDispatcher creates a dispatcher over the given channels
+func NewNotificationDispatcher(notifiers ...Notifier) *NotificationDispatcher {
+	return &NotificationDispatcher{
+		notifiers: notifiers,
+	}
+}
+
+// Name identifies the dispatcher itself
+func (d *NotificationDispatcher) Name() string {
+	return "dispatcher"
+}
+
+// Channels returns the names of the registered channels
+func (d *NotificationDispatcher) Channels() []string {
+	names := make([]string, 0, len(d.notifiers))
+	for _, n := range d.notifiers {
+		names = append(names, n.Name())
+	}
+	return names
+}
+
+// Notify sends n to every selected channel concurrently. One failing channel
+// does not prevent the others; all errors are returned joined.
+func (d *NotificationDispatcher) Notify(ctx context.Context, n Notification) error {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	for _, notifier := range d.notifiers {
+		if !channelSelected(n.Channels, notifier.Name()) {
+			continue
+		}
+		wg.Add(1)
+		go func(notifier Notifier) {
+			defer wg.Done()
+			if err := notifier.Notify(ctx, n); err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("%s: %w", notifier.Name(), err))
+				mu.Unlock()
+			}
+		}(notifier)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+func channelSelected(channels []string, name string) bool {
+	if len(channels) == 0 {
+		return true
+	}
+	for _, c := range channels {
+		if c == name {
+			return true
+		}
+	}
+	return false
+}
+
+// NewNotificationTemplate parses a per-channel text/template, falling back to
+// DefaultNotificationTemplate when text is empty. Templates receive the
+// Notification (Title, Submission, Priority, Tags).
+func NewNotificationTemplate(name, text string) (*template.Template, error) {
+	if strings.TrimSpace(text) == "" {
+		text = DefaultNotificationTemplate
+	}
+	tmpl, err := template.New(name).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s template: %w", name, err)
+	}
+	return tmpl, nil
+}
+
+// renderNotification executes tmpl and truncates the result to maxLen runes (0 = no limit)
+func renderNotification(tmpl *template.Template, n Notification, maxLen int) (string, error) {
+	if n.Title == "" {
+		n.Title = DefaultNotificationTitle
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, n); err != nil {
+		return "", fmt.Errorf("unable to render notification: %w", err)
+	}
+	return truncateRunes(buf.String(), maxLen), nil
+}
+
+func truncateRunes(s string, maxLen int) string {
+	if maxLen <= 0 || utf8.RuneCountInString(s) <= maxLen {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:maxLen-1]) + "…"
+}
+
+// sendJSON sends body as JSON and fails on any non-2xx answer
+func sendJSON(ctx context.Context, client *http.Client, method, endpoint string, headers map[string]string, body interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		// Drop the URL from the error: some APIs (Telegram) carry secrets in the path
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			return fmt.Errorf("%s request failed: %w", method, urlErr.Err)
+		}
+		return err
+	}
+	defer resp.Body.Close()
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(snippet)))
+	}
+	return nil
+}
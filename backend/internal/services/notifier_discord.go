@@ -0,0 +1,54 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"text/template"
+	"time"
+)
+
+// discordMaxContent is the message length limit of Discord webhooks
+const discordMaxContent = 2000
+
+// DiscordOptions configures the Discord channel
+type DiscordOptions struct {
+	WebhookURL string // channel webhook URL
+	Template   string // text/template for the message content
+	Timeout    time.Duration
+}
+
+// DiscordNotifier posts notifications through a Discord channel webhook
+type DiscordNotifier struct {
+	opts   DiscordOptions
+	tmpl   *template.Template
+	client *http.Client
+}
+
+// NewDiscordNotifier creates a new instance of DiscordNotifier
+func NewDiscordNotifier(opts DiscordOptions) (Notifier, error) {
+	if opts.WebhookURL == "" {
+		return nil, errors.New("discord: webhook URL is required")
+	}
+	tmpl, err := NewNotificationTemplate("discord", opts.Template)
+	if err != nil {
+		return nil, err
+	}
+	return &DiscordNotifier{opts: opts, tmpl: tmpl, client: &http.Client{Timeout: opts.Timeout}}, nil
+}
+
+func (d *DiscordNotifier) Name() string {
+	return "discord"
+}
+
+// Notify disables mention parsing so a visitor cannot ping @everyone
+func (d *DiscordNotifier) Notify(ctx context.Context, n Notification) error {
+	content, err := renderNotification(d.tmpl, n, discordMaxContent)
+	if err != nil {
+		return err
+	}
+	return sendJSON(ctx, d.client, http.MethodPost, d.opts.WebhookURL, nil, map[string]interface{}{
+		"content":          content,
+		"allowed_mentions": map[string]interface{}{"parse": []string{}},
+	})
+}
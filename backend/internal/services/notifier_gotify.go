@@ -0,0 +1,71 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+
+	"backend/internal/models"
+)
+
+// GotifyOptions configures the Gotify channel
+type GotifyOptions struct {
+	ServerURL string // e.g. https://gotify.example.org
+	AppToken  string // application token
+	Template  string // text/template for the message body
+	Timeout   time.Duration
+}
+
+// GotifyNotifier pushes notifications to a Gotify application
+type GotifyNotifier struct {
+	opts   GotifyOptions
+	tmpl   *template.Template
+	client *http.Client
+}
+
+// NewGotifyNotifier creates a new instance of GotifyNotifier
+func NewGotifyNotifier(opts GotifyOptions) (Notifier, error) {
+	if opts.ServerURL == "" || opts.AppToken == "" {
+		return nil, errors.New("gotify: server URL and application token are required")
+	}
+	tmpl, err := NewNotificationTemplate("gotify", opts.Template)
+	if err != nil {
+		return nil, err
+	}
+	return &GotifyNotifier{opts: opts, tmpl: tmpl, client: &http.Client{Timeout: opts.Timeout}}, nil
+}
+
+func (g *GotifyNotifier) Name() string {
+	return "gotify"
+}
+
+// gotifyPriority maps our priorities onto Gotify's 0-10 scale
+func gotifyPriority(priority string) int {
+	switch priority {
+	case models.PriorityLow:
+		return 2
+	case models.PriorityHigh:
+		return 7
+	case models.PriorityUrgent:
+		return 10
+	default:
+		return 5
+	}
+}
+
+func (g *GotifyNotifier) Notify(ctx context.Context, n Notification) error {
+	message, err := renderNotification(g.tmpl, n, 0)
+	if err != nil {
+		return err
+	}
+	title := n.Title
+	if title == "" {
+		title = DefaultNotificationTitle
+	}
+	return sendJSON(ctx, g.client, http.MethodPost, strings.TrimRight(g.opts.ServerURL, "/")+"/message",
+		map[string]string{"X-Gotify-Key": g.opts.AppToken},
+		map[string]interface{}{"title": title, "message": message, "priority": gotifyPriority(n.Priority)})
+}
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// MatrixOptions configures the Matrix channel
+type MatrixOptions struct {
+	HomeserverURL string // e.g. https://matrix.example.org
+	AccessToken   string // access token of the bot account
+	RoomID        string // e.g. !abcdef:example.org
+	Template      string // text/template for the message body
+	Timeout       time.Duration
+}
+
+// MatrixNotifier posts notifications as m.text messages in a Matrix room
+type MatrixNotifier struct {
+	opts   MatrixOptions
+	tmpl   *template.Template
+	client *http.Client
+}
+
+// NewMatrixNotifier creates a new instance of MatrixNotifier
+func NewMatrixNotifier(opts MatrixOptions) (Notifier, error) {
+	if opts.HomeserverURL == "" || opts.AccessToken == "" || opts.RoomID == "" {
+		return nil, errors.New("matrix: homeserver URL, access token and room id are required")
+	}
+	tmpl, err := NewNotificationTemplate("matrix", opts.Template)
+	if err != nil {
+		return nil, err
+	}
+	return &MatrixNotifier{opts: opts, tmpl: tmpl, client: &http.Client{Timeout: opts.Timeout}}, nil
+}
+
+func (m *MatrixNotifier) Name() string {
+	return "matrix"
+}
+
+// Notify sends the message with the client-server API; the transaction id makes retries safe
+func (m *MatrixNotifier) Notify(ctx context.Context, n Notification) error {
+	body, err := renderNotification(m.tmpl, n, 0)
+	if err != nil {
+		return err
+	}
+	txn := make([]byte, 16)
+	_, _ = rand.Read(txn)
+
+	endpoint := strings.TrimRight(m.opts.HomeserverURL, "/") +
+		"/_matrix/client/v3/rooms/" + url.PathEscape(m.opts.RoomID) +
+		"/send/m.room.message/" + hex.EncodeToString(txn)
+
+	return sendJSON(ctx, m.client, http.MethodPut, endpoint,
+		map[string]string{"Authorization": "Bearer " + m.opts.AccessToken},
+		map[string]string{"msgtype": "m.text", "body": body})
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+
+	"backend/internal/models"
+)
+
+// NtfyOptions configures the ntfy channel
+type NtfyOptions struct {
+	ServerURL string // e.g. https://ntfy.sh
+	Topic     string
+	Token     string // optional access token
+	Template  string // text/template for the message body
+	Timeout   time.Duration
+}
+
+// NtfyNotifier publishes notifications to an ntfy topic
+type NtfyNotifier struct {
+	opts   NtfyOptions
+	tmpl   *template.Template
+	client *http.Client
+}
+
+// NewNtfyNotifier creates a new instance of NtfyNotifier
+func NewNtfyNotifier(opts NtfyOptions) (Notifier, error) {
+	if opts.ServerURL == "" || opts.Topic == "" {
+		return nil, errors.New("ntfy: server URL and topic are required")
+	}
+	tmpl, err := NewNotificationTemplate("ntfy", opts.Template)
+	if err != nil {
+		return nil, err
+	}
+	return &NtfyNotifier{opts: opts, tmpl: tmpl, client: &http.Client{Timeout: opts.Timeout}}, nil
+}
+
+func (n *NtfyNotifier) Name() string {
+	return "ntfy"
+}
+
+// ntfyPriority maps our priorities onto ntfy's 1 (min) to 5 (max) scale
+func ntfyPriority(priority string) int {
+	switch priority {
+	case models.PriorityLow:
+		return 2
+	case models.PriorityHigh:
+		return 4
+	case models.PriorityUrgent:
+		return 5
+	default:
+		return 3
+	}
+}
+
+// Notify uses ntfy's JSON publishing API so titles and tags may contain any Unicode
+func (n *NtfyNotifier) Notify(ctx context.Context, notif Notification) error {
+	message, err := renderNotification(n.tmpl, notif, 4096)
+	if err != nil {
+		return err
+	}
+	title := notif.Title
+	if title == "" {
+		title = DefaultNotificationTitle
+	}
+
+	headers := map[string]string{}
+	if n.opts.Token != "" {
+		headers["Authorization"] = "Bearer " + n.opts.Token
+	}
+	body := map[string]interface{}{
+		"topic":    n.opts.Topic,
+		"title":    title,
+		"message":  message,
+		"priority": ntfyPriority(notif.Priority),
+	}
+	if len(notif.Tags) > 0 {
+		body["tags"] = notif.Tags
+	}
+	return sendJSON(ctx, n.client, http.MethodPost, strings.TrimRight(n.opts.ServerURL, "/"), headers, body)
+}
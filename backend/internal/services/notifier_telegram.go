@@ -0,0 +1,68 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+
+	"backend/internal/models"
+)
+
+// telegramMaxText is the message length limit of the Bot API
+const telegramMaxText = 4096
+
+// DefaultTelegramAPIURL is the public Telegram Bot API
+const DefaultTelegramAPIURL = "https://api.telegram.org"
+
+// TelegramOptions configures the Telegram channel
+type TelegramOptions struct {
+	APIURL   string // Bot API base URL, DefaultTelegramAPIURL when empty
+	BotToken string
+	ChatID   string
+	Template string // text/template for the message text
+	Timeout  time.Duration
+}
+
+// TelegramNotifier sends notifications with the Telegram Bot API
+type TelegramNotifier struct {
+	opts   TelegramOptions
+	tmpl   *template.Template
+	client *http.Client
+}
+
+// NewTelegramNotifier creates a new instance of TelegramNotifier
+func NewTelegramNotifier(opts TelegramOptions) (Notifier, error) {
+	if opts.BotToken == "" || opts.ChatID == "" {
+		return nil, errors.New("telegram: bot token and chat id are required")
+	}
+	if opts.APIURL == "" {
+		opts.APIURL = DefaultTelegramAPIURL
+	}
+	tmpl, err := NewNotificationTemplate("telegram", opts.Template)
+	if err != nil {
+		return nil, err
+	}
+	return &TelegramNotifier{opts: opts, tmpl: tmpl, client: &http.Client{Timeout: opts.Timeout}}, nil
+}
+
+func (t *TelegramNotifier) Name() string {
+	return "telegram"
+}
+
+// Notify sends plain text (no parse_mode) so user content cannot inject markup
+func (t *TelegramNotifier) Notify(ctx context.Context, n Notification) error {
+	text, err := renderNotification(t.tmpl, n, telegramMaxText)
+	if err != nil {
+		return err
+	}
+	endpoint := strings.TrimRight(t.opts.APIURL, "/") + "/bot" + t.opts.BotToken + "/sendMessage"
+	return sendJSON(ctx, t.client, http.MethodPost, endpoint, nil, map[string]interface{}{
+		"chat_id":                  t.opts.ChatID,
+		"text":                     text,
+		"disable_web_page_preview": true,
+		"disable_notification":     n.Priority == models.PriorityLow,
+	})
+}
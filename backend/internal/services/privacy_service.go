@@ -0,0 +1,379 @@
+package services
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/mail"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"backend/internal/models"
+	"backend/internal/repository"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// privacyRequestCooldown limits verification emails to one per address in this window
+const privacyRequestCooldown = 10 * time.Minute
+
+var (
+	// ErrInvalidPrivacyToken is returned for unknown, used or expired verification tokens
+	ErrInvalidPrivacyToken = errors.New("invalid or expired privacy token")
+	// ErrInvalidPrivacyRequest is returned when a privacy request carries no valid email
+	ErrInvalidPrivacyRequest = errors.New("invalid privacy request")
+)
+
+// IPrivacyService implements the GDPR rights: access (export), erasure and
+// storage limitation (retention)
+type IPrivacyService interface {
+	RequestAccess(ctx context.Context, email string) error
+	CheckToken(ctx context.Context, token string) error
+	ExportByToken(ctx context.Context, token string) (*models.PrivacyExport, error)
+	EraseByToken(ctx context.Context, token string) (int64, error)
+	Export(ctx context.Context, email, actor string) (*models.PrivacyExport, error)
+	Erase(ctx context.Context, email, actor string) (int64, error)
+	ApplyRetention(ctx context.Context) (int64, error)
+	AuditLog(ctx context.Context, limit, offset int) ([]models.PrivacyAuditEntry, error)
+}
+
+// PrivacyOptions holds the privacy settings
+type PrivacyOptions struct {
+	LinkURL         string        // Page or endpoint receiving the ?token= of verification links
+	TokenTTL        time.Duration // Validity of verification links
+	From            string        // Sender of verification emails
+	ErasureMode     string        // models.RetentionAnonymize or models.RetentionDelete
+	RetentionPeriod time.Duration // Age after which submissions are purged (0 keeps them forever)
+	RetentionMode   string        // What the retention job does with old submissions
+	AuditKey        []byte        // HMAC key of the subject hashes in the audit log (required)
+}
+
+// PrivacyService implements IPrivacyService
+type PrivacyService struct {
+	repo          repository.IPrivacyRepository
+	inbox         repository.IInboxRepository
+	conversations repository.IConversationRepository
+	transport     IMailTransport
+	opts          PrivacyOptions
+	requestMu     sync.Mutex // serializes verification requests
+}
+
+// NewPrivacyService creates a new instance of PrivacyService
+func NewPrivacyService(repo repository.IPrivacyRepository, inbox repository.IInboxRepository, conversations repository.IConversationRepository, transport IMailTransport, opts PrivacyOptions) (IPrivacyService, error) {
+	if opts.ErasureMode == "" {
+		opts.ErasureMode = models.RetentionAnonymize
+	}
+	if opts.RetentionMode == "" {
+		opts.RetentionMode = models.RetentionAnonymize
+	}
+	if !models.IsRetentionMode(opts.ErasureMode) || !models.IsRetentionMode(opts.RetentionMode) {
+		return nil, fmt.Errorf("unknown retention mode: use %q or %q", models.RetentionAnonymize, models.RetentionDelete)
+	}
+	if opts.TokenTTL <= 0 {
+		opts.TokenTTL = time.Hour
+	}
+	if len(opts.AuditKey) == 0 {
+		// A key drawn at startup would make the hashes of the same address
+		// differ across restarts, defeating the audit log
+		return nil, errors.New("an audit key is required to hash privacy audit subjects")
+	}
+	return &PrivacyService{
+		repo:          repo,
+		inbox:         inbox,
+		conversations: conversations,
+		transport:     transport,
+		opts:          opts,
+	}, nil
+}
+
+// PrivacySubjectHash identifies an email address in the audit log without
+// storing it. The hash is keyed so that it cannot be reversed by hashing
+// candidate addresses.
+func PrivacySubjectHash(key []byte, email string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(strings.ToLower(strings.TrimSpace(email))))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// canonicalEmail returns the stored form of email (see models.CanonicalEmail),
+// so an address typed with a punycode or uppercase domain finds its data
+func canonicalEmail(email string) string {
+	email = norm.NFC.String(strings.TrimSpace(email))
+	if canonical, err := models.CanonicalEmail(email); err == nil {
+		return canonical
+	}
+	return email
+}
+
+// RequestAccess emails a verification link to the address if data is stored
+// for it. Only the address is checked before returning: the lookup and the
+// email happen in the background, so neither the outcome nor the response
+// time tells whether data exists and the endpoint cannot be used to find out
+// who wrote.
+func (s *PrivacyService) RequestAccess(ctx context.Context, email string) error {
+	parsed, err := mail.ParseAddress(strings.TrimSpace(email))
+	if err != nil || len(parsed.Address) > models.MaxEmailLength {
+		return fmt.Errorf("%w: invalid email address", ErrInvalidPrivacyRequest)
+	}
+	email = canonicalEmail(parsed.Address)
+
+	go func() {
+		if err := s.sendVerification(context.WithoutCancel(ctx), email); err != nil {
+			log.Printf("Error handling privacy request: %v", err)
+		}
+	}()
+	return nil
+}
+
+// sendVerification stores a request and emails its link when data exists
+// for email and no link was sent recently
+func (s *PrivacyService) sendVerification(ctx context.Context, email string) error {
+	token, submissions, err := s.createRequest(ctx, email)
+	if err != nil || token == "" {
+		return err
+	}
+	s.audit(ctx, models.PrivacyActionRequest, email, "visitor", int64(submissions), "")
+
+	err = s.transport.SendEmail(ctx, models.OutgoingEmail{
+		From:    s.opts.From,
+		To:      []string{email},
+		Subject: "Vos données personnelles - Portfolio Enzo",
+		Text:    privacyEmailText(s.verificationLink(token), s.opts.TokenTTL),
+	})
+	if err != nil {
+		return fmt.Errorf("unable to send privacy verification: %w", err)
+	}
+	return nil
+}
+
+// createRequest stores a verification request and returns its token, or an
+// empty token when there is nothing to send. Requests are handled one at a
+// time so that concurrent ones respect the cooldown.
+func (s *PrivacyService) createRequest(ctx context.Context, email string) (string, int, error) {
+	s.requestMu.Lock()
+	defer s.requestMu.Unlock()
+
+	recent, err := s.repo.HasRecentRequest(ctx, email, time.Now().Add(-privacyRequestCooldown))
+	if err != nil || recent {
+		return "", 0, err
+	}
+	submissions, err := s.repo.ListSubmissionsByEmail(ctx, email)
+	if err != nil || len(submissions) == 0 {
+		return "", 0, err
+	}
+
+	token, tokenHash := newPrivacyToken()
+	req := &models.PrivacyRequest{Email: email, TokenHash: tokenHash, ExpiresAt: time.Now().Add(s.opts.TokenTTL)}
+	if err := s.repo.CreateRequest(ctx, req); err != nil {
+		return "", 0, err
+	}
+	return token, len(submissions), nil
+}
+
+// ExportByToken returns the data of a verified request; the link can be
+// used again until it expires
+func (s *PrivacyService) ExportByToken(ctx context.Context, token string) (*models.PrivacyExport, error) {
+	req, err := s.verify(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	return s.Export(ctx, req.Email, "visitor")
+}
+
+// CheckToken returns ErrInvalidPrivacyToken unless token can still be used,
+// without using it
+func (s *PrivacyService) CheckToken(ctx context.Context, token string) error {
+	_, err := s.verify(ctx, token)
+	return err
+}
+
+// EraseByToken erases the data of a verified request and consumes the token
+func (s *PrivacyService) EraseByToken(ctx context.Context, token string) (int64, error) {
+	req, err := s.verify(ctx, token)
+	if err != nil {
+		return 0, err
+	}
+	if err := s.repo.MarkRequestUsed(ctx, req.ID); err != nil {
+		return 0, err
+	}
+	return s.Erase(ctx, req.Email, "visitor")
+}
+
+// Export gathers every submission, note and message tied to email
+func (s *PrivacyService) Export(ctx context.Context, email, actor string) (*models.PrivacyExport, error) {
+	email = canonicalEmail(email)
+	if email == "" {
+		return nil, fmt.Errorf("%w: email is required", ErrInvalidPrivacyRequest)
+	}
+	submissions, err := s.repo.ListSubmissionsByEmail(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &models.PrivacyExport{
+		Email:       email,
+		GeneratedAt: time.Now().UTC(),
+		Submissions: make([]models.PrivacySubmission, 0, len(submissions)),
+	}
+	for _, submission := range submissions {
+		notes, err := s.inbox.ListNotes(ctx, submission.ID)
+		if err != nil {
+			return nil, err
+		}
+		messages, err := s.conversations.ListMessages(ctx, submission.ID)
+		if err != nil {
+			return nil, err
+		}
+		export.Submissions = append(export.Submissions, models.PrivacySubmission{
+			ContactSubmission: submission,
+			Notes:             notes,
+			Messages:          messages,
+		})
+	}
+	s.audit(ctx, models.PrivacyActionExport, email, actor, int64(len(submissions)), "")
+	return export, nil
+}
+
+// Erase deletes or anonymizes (see PrivacyOptions.ErasureMode) everything tied to email
+func (s *PrivacyService) Erase(ctx context.Context, email, actor string) (int64, error) {
+	email = canonicalEmail(email)
+	if email == "" {
+		return 0, fmt.Errorf("%w: email is required", ErrInvalidPrivacyRequest)
+	}
+	count, err := s.repo.EraseByEmail(ctx, email, s.opts.ErasureMode)
+	if err != nil {
+		return 0, err
+	}
+	s.audit(ctx, models.PrivacyActionErase, email, actor, count, s.opts.ErasureMode)
+	return count, nil
+}
+
+// ApplyRetention purges the submissions older than the retention period
+// and the expired verification requests
+func (s *PrivacyService) ApplyRetention(ctx context.Context) (int64, error) {
+	if _, err := s.repo.DeleteExpiredRequests(ctx, time.Now()); err != nil {
+		return 0, err
+	}
+	if s.opts.RetentionPeriod <= 0 {
+		return 0, nil
+	}
+	count, err := s.repo.ApplyRetention(ctx, time.Now().Add(-s.opts.RetentionPeriod), s.opts.RetentionMode)
+	if err != nil {
+		return 0, err
+	}
+	if count > 0 {
+		log.Printf("Retention policy applied to %d submissions (%s)", count, s.opts.RetentionMode)
+		s.audit(ctx, models.PrivacyActionRetention, "", "retention", count, s.opts.RetentionMode)
+	}
+	return count, nil
+}
+
+// AuditLog returns the privacy audit log, newest first
+func (s *PrivacyService) AuditLog(ctx context.Context, limit, offset int) ([]models.PrivacyAuditEntry, error) {
+	return s.repo.ListAuditEntries(ctx, limit, offset)
+}
+
+func (s *PrivacyService) verify(ctx context.Context, token string) (*models.PrivacyRequest, error) {
+	if token == "" {
+		return nil, ErrInvalidPrivacyToken
+	}
+	sum := sha256.Sum256([]byte(token))
+	req, err := s.repo.FindRequest(ctx, hex.EncodeToString(sum[:]))
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrInvalidPrivacyToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	if req.UsedAt != nil || time.Now().After(req.ExpiresAt) {
+		return nil, ErrInvalidPrivacyToken
+	}
+	return req, nil
+}
+
+// audit records an action; a failure is logged but does not undo the action
+func (s *PrivacyService) audit(ctx context.Context, action, email, actor string, affected int64, detail string) {
+	entry := &models.PrivacyAuditEntry{Action: action, Actor: actor, Affected: affected, Detail: detail}
+	if email != "" {
+		entry.SubjectHash = PrivacySubjectHash(s.opts.AuditKey, email)
+	}
+	if err := s.repo.AddAuditEntry(ctx, entry); err != nil {
+		log.Printf("Error recording privacy audit entry (%s): %v", action, err)
+	}
+}
+
+func (s *PrivacyService) verificationLink(token string) string {
+	sep := "?"
+	if strings.Contains(s.opts.LinkURL, "?") {
+		sep = "&"
+	}
+	return s.opts.LinkURL + sep + "token=" + url.QueryEscape(token)
+}
+
+// newPrivacyToken returns a random token and the hash stored in its place
+func newPrivacyToken() (token, hash string) {
+	b := make([]byte, 32)
+	_, _ = rand.Read(b)
+	token = base64.RawURLEncoding.EncodeToString(b)
+	sum := sha256.Sum256([]byte(token))
+	return token, hex.EncodeToString(sum[:])
+}
+
+func privacyEmailText(link string, ttl time.Duration) string {
+	return fmt.Sprintf(`Bonjour,
+
+Vous avez demandé à consulter les données conservées à la suite de vos messages envoyés via le formulaire de contact.
+
+Téléchargez-les ou demandez leur suppression depuis cette page (lien valable %s) :
+%s
+
+Si vous n'êtes pas à l'origine de cette demande, ignorez simplement ce message.`, formatTTL(ttl), link)
+}
+
+func formatTTL(ttl time.Duration) string {
+	if ttl >= time.Hour && ttl%time.Hour == 0 {
+		return fmt.Sprintf("%d h", int(ttl.Hours()))
+	}
+	return fmt.Sprintf("%d min", int(ttl.Minutes()))
+}
+
+// WritePrivacyArchive writes export as a ZIP containing data.json and a
+// plain-text copy of each message
+func WritePrivacyArchive(w io.Writer, export *models.PrivacyExport) error {
+	archive := zip.NewWriter(w)
+
+	data, err := archive.Create("data.json")
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(data)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(export); err != nil {
+		return err
+	}
+
+	for _, submission := range export.Submissions {
+		f, err := archive.Create(fmt.Sprintf("submission-%d.txt", submission.ID))
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(f, "Date: %s\nFrom: %s <%s>\nSubject: %s\n\n%s\n",
+			submission.CreatedAt.Format(time.RFC3339), submission.Name, submission.Email, submission.Subject, submission.Message)
+		for _, m := range submission.Messages {
+			fmt.Fprintf(f, "\n----------\nDate: %s\nFrom: %s\nTo: %s\nSubject: %s\n\n%s\n",
+				m.CreatedAt.Format(time.RFC3339), m.From, m.To, m.Subject, m.Body)
+		}
+	}
+	return archive.Close()
+}
@@ -0,0 +1,244 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+
+	"backend/internal/models"
+	"backend/internal/repository"
+	"backend/internal/site"
+)
+
+// ErrInvalidProfile is returned when admin-provided profile data is invalid
+var ErrInvalidProfile = errors.New("invalid profile")
+
+// resumeDatePattern matches the ISO 8601 dates of the JSON Resume schema:
+// a year, a month or a day
+var resumeDatePattern = regexp.MustCompile(`^\d{4}(-(0[1-9]|1[0-2])(-(0[1-9]|[12]\d|3[01]))?)?$`)
+
+// IProfileService serves the profile (résumé) and lets admins edit it. The
+// about page, GET /profile and the CV PDF are all rendered from it.
+type IProfileService interface {
+	// Get returns the stored profile, or the default one at version 0
+	// before the first save
+	Get(ctx context.Context) (*models.Profile, error)
+	Update(ctx context.Context, input models.ProfileInput) (*models.Profile, error)
+	// PDF returns the profile rendered as a PDF and its file name
+	PDF(ctx context.Context) ([]byte, string, error)
+	ContentRestorer
+}
+
+// ProfileService implements IProfileService
+type ProfileService struct {
+	repo      repository.IProfileRepository
+	fallback  models.Resume
+	revisions IRevisionService
+
+	mu     sync.Mutex
+	pdfKey [sha256.Size]byte // hash of the profile the cached PDF was rendered from
+	pdf    []byte
+}
+
+// ProfileServiceOption configures optional ProfileService behaviour
+type ProfileServiceOption func(*ProfileService)
+
+// WithProfileRevisions records a revision of the profile on every update
+func WithProfileRevisions(revisions IRevisionService) ProfileServiceOption {
+	return func(s *ProfileService) {
+		s.revisions = revisions
+	}
+}
+
+// NewProfileService creates a new instance of ProfileService. fallback is
+// served until a profile is saved.
+func NewProfileService(repo repository.IProfileRepository, fallback models.Resume, opts ...ProfileServiceOption) IProfileService {
+	s := &ProfileService{
+		repo:     repo,
+		fallback: fallback,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+func (s *ProfileService) Get(ctx context.Context) (*models.Profile, error) {
+	profile, err := s.repo.GetProfile(ctx)
+	if errors.Is(err, repository.ErrNotFound) {
+		return &models.Profile{Resume: s.fallback}, nil
+	}
+	return profile, err
+}
+
+// Update replaces the profile if input.Version is still current
+func (s *ProfileService) Update(ctx context.Context, input models.ProfileInput) (*models.Profile, error) {
+	resume := input.Resume
+	resume.Basics.Name = strings.TrimSpace(resume.Basics.Name)
+	resume.Basics.Email = strings.TrimSpace(resume.Basics.Email)
+	if err := validateResume(&resume); err != nil {
+		return nil, err
+	}
+	profile, err := s.repo.SaveProfile(ctx, resume, input.Version)
+	if err != nil {
+		return nil, err
+	}
+	s.record(ctx, profile.Resume)
+	return profile, nil
+}
+
+// PDF renders the profile, or returns the last rendering when it did not
+// change
+func (s *ProfileService) PDF(ctx context.Context) ([]byte, string, error) {
+	profile, err := s.Get(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	data, err := json.Marshal(profile)
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to encode profile: %w", err)
+	}
+	key := sha256.Sum256(data)
+	name := "CV_" + strings.Join(strings.Fields(profile.Resume.Basics.Name), "_") + ".pdf"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.pdf == nil || s.pdfKey != key {
+		pdf, err := site.ResumePDF(profile.Resume, profile.UpdatedAt)
+		if err != nil {
+			return nil, "", err
+		}
+		s.pdf, s.pdfKey = pdf, key
+	}
+	return s.pdf, name, nil
+}
+
+// RestoreRevision saves a profile revision as the current profile. Without
+// a version, the current one is used.
+func (s *ProfileService) RestoreRevision(ctx context.Context, revision *models.Revision, version int) error {
+	if revision.ContentType != models.ContentProfile {
+		return fmt.Errorf("%w: revision %d is not a profile", ErrInvalidRevision, revision.ID)
+	}
+	var resume models.Resume
+	if err := json.Unmarshal([]byte(revision.Body), &resume); err != nil {
+		return fmt.Errorf("unable to decode profile revision %d: %w", revision.ID, err)
+	}
+	if version == 0 {
+		current, err := s.Get(ctx)
+		if err != nil {
+			return err
+		}
+		version = current.Version
+	}
+	_, err := s.Update(ctx, models.ProfileInput{Resume: resume, Version: version})
+	return err
+}
+
+// record stores the saved profile as a revision. A failed revision is
+// logged: the change itself is already stored.
+func (s *ProfileService) record(ctx context.Context, resume models.Resume) {
+	if s.revisions == nil {
+		return
+	}
+	body, err := json.MarshalIndent(resume, "", "  ")
+	if err == nil {
+		_, err = s.revisions.Record(ctx, models.ContentProfile, models.ProfileKey, string(body))
+	}
+	if err != nil {
+		log.Printf("Error recording revision of profile: %v", err)
+	}
+}
+
+// validateResume checks the fields the site and the PDF rely on: a name,
+// parseable dates, web links and the skill icons
+func validateResume(r *models.Resume) error {
+	b := r.Basics
+	if b.Name == "" {
+		return fmt.Errorf("%w: basics.name is required", ErrInvalidProfile)
+	}
+	if b.Email != "" {
+		if addr, err := mail.ParseAddress(b.Email); err != nil || addr.Address != b.Email {
+			return fmt.Errorf("%w: basics.email is not a valid address", ErrInvalidProfile)
+		}
+	}
+	if b.Image != "" && !strings.HasPrefix(b.Image, "/") {
+		if err := checkResumeURL("basics.image", b.Image); err != nil {
+			return err
+		}
+	}
+
+	urls := map[string]string{"basics.url": b.URL}
+	dates := map[string]string{}
+	for i, p := range b.Profiles {
+		urls[fmt.Sprintf("basics.profiles[%d].url", i)] = p.URL
+	}
+	for i, w := range r.Work {
+		urls[fmt.Sprintf("work[%d].url", i)] = w.URL
+		dates[fmt.Sprintf("work[%d].startDate", i)] = w.StartDate
+		dates[fmt.Sprintf("work[%d].endDate", i)] = w.EndDate
+	}
+	for i, v := range r.Volunteer {
+		urls[fmt.Sprintf("volunteer[%d].url", i)] = v.URL
+		dates[fmt.Sprintf("volunteer[%d].startDate", i)] = v.StartDate
+		dates[fmt.Sprintf("volunteer[%d].endDate", i)] = v.EndDate
+	}
+	for i, e := range r.Education {
+		urls[fmt.Sprintf("education[%d].url", i)] = e.URL
+		dates[fmt.Sprintf("education[%d].startDate", i)] = e.StartDate
+		dates[fmt.Sprintf("education[%d].endDate", i)] = e.EndDate
+	}
+	for i, a := range r.Awards {
+		dates[fmt.Sprintf("awards[%d].date", i)] = a.Date
+	}
+	for i, c := range r.Certificates {
+		urls[fmt.Sprintf("certificates[%d].url", i)] = c.URL
+		dates[fmt.Sprintf("certificates[%d].date", i)] = c.Date
+	}
+	for i, p := range r.Publications {
+		urls[fmt.Sprintf("publications[%d].url", i)] = p.URL
+		dates[fmt.Sprintf("publications[%d].releaseDate", i)] = p.ReleaseDate
+	}
+	for i, p := range r.Projects {
+		urls[fmt.Sprintf("projects[%d].url", i)] = p.URL
+		dates[fmt.Sprintf("projects[%d].startDate", i)] = p.StartDate
+		dates[fmt.Sprintf("projects[%d].endDate", i)] = p.EndDate
+	}
+	for field, u := range urls {
+		if u != "" {
+			if err := checkResumeURL(field, u); err != nil {
+				return err
+			}
+		}
+	}
+	for field, d := range dates {
+		if d != "" && !resumeDatePattern.MatchString(d) {
+			return fmt.Errorf("%w: %s must be an ISO 8601 date such as 2023, 2023-09 or 2023-09-01", ErrInvalidProfile, field)
+		}
+	}
+	for i, skill := range r.Skills {
+		if strings.TrimSpace(skill.Name) == "" {
+			return fmt.Errorf("%w: skills[%d].name is required", ErrInvalidProfile, i)
+		}
+		if !iconPattern.MatchString(skill.Icon) {
+			return fmt.Errorf("%w: skills[%d].icon must be a list of CSS classes", ErrInvalidProfile, i)
+		}
+	}
+	return nil
+}
+
+// checkResumeURL accepts absolute http and https URLs
+func checkResumeURL(field, raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: %s must be an http or https URL", ErrInvalidProfile, field)
+	}
+	return nil
+}
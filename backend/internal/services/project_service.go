@@ -0,0 +1,384 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"backend/internal/models"
+	"backend/internal/repository"
+)
+
+var (
+	// ErrInvalidProjectFilter is returned when a project list query is invalid
+	ErrInvalidProjectFilter = errors.New("invalid project filter")
+	// ErrInvalidProject is returned when admin-provided project data is invalid
+	ErrInvalidProject = errors.New("invalid project")
+)
+
+// slugPattern matches the slugs admins may choose
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// iconPattern matches a list of CSS classes such as "fab fa-jenkins"
+var iconPattern = regexp.MustCompile(`^[a-z0-9 -]*$`)
+
+// IProjectService serves the published portfolio projects and lets admins
+// edit them
+type IProjectService interface {
+	List(ctx context.Context, filter models.ProjectFilter) ([]models.Project, int, error)
+	Get(ctx context.Context, slug string) (*models.Project, error)
+	Technologies(ctx context.Context) ([]models.Technology, error)
+
+	GetByID(ctx context.Context, id int64) (*models.Project, error)
+	Create(ctx context.Context, input models.ProjectInput) (*models.Project, error)
+	Update(ctx context.Context, id int64, input models.ProjectInput) (*models.Project, error)
+	Publish(ctx context.Context, id int64, publication models.ProjectPublication) (*models.Project, error)
+	Unpublish(ctx context.Context, id int64, version int) (*models.Project, error)
+	Reorder(ctx context.Context, ids []int64) error
+	Delete(ctx context.Context, id int64) error
+}
+
+// ProjectService implements IProjectService
+type ProjectService struct {
+	repo      repository.IProjectRepository
+	onChange  func()
+	revisions IRevisionService
+}
+
+// ProjectServiceOption configures optional ProjectService behaviour
+type ProjectServiceOption func(*ProjectService)
+
+// WithProjectChanges calls onChange after every successful admin change,
+// for instance to invalidate caches built from the published projects
+func WithProjectChanges(onChange func()) ProjectServiceOption {
+	return func(s *ProjectService) {
+		s.onChange = onChange
+	}
+}
+
+// WithProjectRevisions records a revision of the content of every project
+// created or updated
+func WithProjectRevisions(revisions IRevisionService) ProjectServiceOption {
+	return func(s *ProjectService) {
+		s.revisions = revisions
+	}
+}
+
+// NewProjectService creates a new instance of ProjectService
+func NewProjectService(repo repository.IProjectRepository, opts ...ProjectServiceOption) IProjectService {
+	s := &ProjectService{
+		repo: repo,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// List returns a page of projects and the number of matches. Technology and
+// category are compared case-insensitively.
+func (s *ProjectService) List(ctx context.Context, filter models.ProjectFilter) ([]models.Project, int, error) {
+	filter.Technology = strings.ToLower(strings.TrimSpace(filter.Technology))
+	filter.Category = strings.ToLower(strings.TrimSpace(filter.Category))
+	if filter.Sort == "" {
+		filter.Sort = models.ProjectSortPosition
+	}
+	if !slices.Contains(models.ProjectSorts, filter.Sort) {
+		return nil, 0, fmt.Errorf("%w: sort must be one of %s", ErrInvalidProjectFilter, strings.Join(models.ProjectSorts, ", "))
+	}
+	if filter.Category != "" && !slices.Contains(models.ProjectCategories, filter.Category) {
+		return nil, 0, fmt.Errorf("%w: category must be one of %s", ErrInvalidProjectFilter, strings.Join(models.ProjectCategories, ", "))
+	}
+	return s.repo.ListProjects(ctx, filter)
+}
+
+func (s *ProjectService) Get(ctx context.Context, slug string) (*models.Project, error) {
+	return s.repo.GetProjectBySlug(ctx, strings.ToLower(slug))
+}
+
+func (s *ProjectService) Technologies(ctx context.Context) ([]models.Technology, error) {
+	return s.repo.ListTechnologies(ctx)
+}
+
+// GetByID returns a project whether or not it is published
+func (s *ProjectService) GetByID(ctx context.Context, id int64) (*models.Project, error) {
+	return s.repo.GetProject(ctx, id)
+}
+
+// Create stores a new unpublished project at the end of the list. Without a
+// slug, one is generated from the title, with a -2, -3... suffix when taken.
+func (s *ProjectService) Create(ctx context.Context, input models.ProjectInput) (*models.Project, error) {
+	project, err := projectFromInput(input)
+	if err != nil {
+		return nil, err
+	}
+	if project.Slug, err = s.uniqueSlug(ctx, project.Slug, project.Title, 0); err != nil {
+		return nil, err
+	}
+	if err := s.repo.CreateProject(ctx, project); err != nil {
+		return nil, err
+	}
+	s.changed()
+	return s.saved(ctx, project.ID)
+}
+
+// Update replaces the content of a project edited from input.Version. It
+// fails with repository.ErrVersionConflict when the project changed since.
+// Without a slug, the project keeps its current one.
+func (s *ProjectService) Update(ctx context.Context, id int64, input models.ProjectInput) (*models.Project, error) {
+	if input.Version <= 0 {
+		return nil, fmt.Errorf("%w: version is required", ErrInvalidProject)
+	}
+	project, err := projectFromInput(input)
+	if err != nil {
+		return nil, err
+	}
+	project.ID = id
+	if project.Slug == "" {
+		current, err := s.repo.GetProject(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		project.Slug = current.Slug
+	} else if project.Slug, err = s.uniqueSlug(ctx, project.Slug, project.Title, id); err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateProject(ctx, project); err != nil {
+		return nil, err
+	}
+	s.changed()
+	return s.saved(ctx, id)
+}
+
+// Publish makes a project public now, or at publication.At when set
+func (s *ProjectService) Publish(ctx context.Context, id int64, publication models.ProjectPublication) (*models.Project, error) {
+	at := time.Now().UTC()
+	if publication.At != nil {
+		at = publication.At.UTC()
+	}
+	if err := s.repo.SetPublication(ctx, id, &at, publication.Version); err != nil {
+		return nil, err
+	}
+	s.changed()
+	return s.repo.GetProject(ctx, id)
+}
+
+// Unpublish hides a project from the public API
+func (s *ProjectService) Unpublish(ctx context.Context, id int64, version int) (*models.Project, error) {
+	if err := s.repo.SetPublication(ctx, id, nil, version); err != nil {
+		return nil, err
+	}
+	s.changed()
+	return s.repo.GetProject(ctx, id)
+}
+
+// Reorder moves the projects of ids, in that order, to the top of the list
+func (s *ProjectService) Reorder(ctx context.Context, ids []int64) error {
+	if len(ids) == 0 {
+		return fmt.Errorf("%w: ids are required", ErrInvalidProject)
+	}
+	seen := map[int64]bool{}
+	for _, id := range ids {
+		if seen[id] {
+			return fmt.Errorf("%w: project %d is listed twice", ErrInvalidProject, id)
+		}
+		seen[id] = true
+	}
+	if err := s.repo.ReorderProjects(ctx, ids); err != nil {
+		return err
+	}
+	s.changed()
+	return nil
+}
+
+func (s *ProjectService) Delete(ctx context.Context, id int64) error {
+	if err := s.repo.DeleteProject(ctx, id); err != nil {
+		return err
+	}
+	s.changed()
+	return nil
+}
+
+// saved reloads a project after a content change and records its revision.
+// A failed revision is logged: the change itself is already stored.
+func (s *ProjectService) saved(ctx context.Context, id int64) (*models.Project, error) {
+	project, err := s.repo.GetProject(ctx, id)
+	if err != nil || s.revisions == nil {
+		return project, err
+	}
+	body, err := projectRevisionBody(project)
+	if err == nil {
+		_, err = s.revisions.Record(ctx, models.ContentProject, strconv.FormatInt(id, 10), body)
+	}
+	if err != nil {
+		log.Printf("Error recording revision of project %d: %v", id, err)
+	}
+	return project, nil
+}
+
+// changed notifies the WithProjectChanges listener, if any
+func (s *ProjectService) changed() {
+	if s.onChange != nil {
+		s.onChange()
+	}
+}
+
+// uniqueSlug validates an admin-chosen slug, or generates one from title
+// that no other project than exceptID uses
+func (s *ProjectService) uniqueSlug(ctx context.Context, slug, title string, exceptID int64) (string, error) {
+	if slug != "" {
+		taken, err := s.repo.SlugTaken(ctx, slug, exceptID)
+		if err != nil {
+			return "", err
+		}
+		if taken {
+			return "", repository.ErrSlugTaken
+		}
+		return slug, nil
+	}
+
+	base := models.Slugify(title)
+	if base == "" {
+		return "", fmt.Errorf("%w: a slug is required when the title has no letters or digits", ErrInvalidProject)
+	}
+	for n := 1; n <= 100; n++ {
+		candidate := base
+		if n > 1 {
+			suffix := "-" + strconv.Itoa(n)
+			candidate = strings.TrimRight(base[:min(len(base), models.MaxProjectSlugLength-len(suffix))], "-") + suffix
+		}
+		taken, err := s.repo.SlugTaken(ctx, candidate, exceptID)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return candidate, nil
+		}
+	}
+	return "", repository.ErrSlugTaken
+}
+
+// projectFromInput validates and normalizes admin-provided project data
+func projectFromInput(input models.ProjectInput) (*models.Project, error) {
+	p := &models.Project{
+		Slug:     strings.ToLower(strings.TrimSpace(input.Slug)),
+		Title:    strings.TrimSpace(input.Title),
+		Summary:  strings.TrimSpace(input.Summary),
+		Body:     strings.TrimSpace(input.Body),
+		Category: strings.ToLower(strings.TrimSpace(input.Category)),
+		Badge:    strings.TrimSpace(input.Badge),
+		Icon:     strings.TrimSpace(input.Icon),
+		Version:  input.Version,
+	}
+	invalid := func(format string, args ...any) (*models.Project, error) {
+		return nil, fmt.Errorf("%w: "+format, append([]any{ErrInvalidProject}, args...)...)
+	}
+
+	if p.Slug != "" && (len(p.Slug) > models.MaxProjectSlugLength || !slugPattern.MatchString(p.Slug)) {
+		return invalid("slug must be lowercase letters, digits and dashes, at most %d characters", models.MaxProjectSlugLength)
+	}
+	if p.Title == "" || utf8.RuneCountInString(p.Title) > models.MaxProjectTitleLength {
+		return invalid("title must be between 1 and %d characters", models.MaxProjectTitleLength)
+	}
+	if utf8.RuneCountInString(p.Summary) > models.MaxProjectSummaryLength {
+		return invalid("summary must be at most %d characters", models.MaxProjectSummaryLength)
+	}
+	if utf8.RuneCountInString(p.Body) > models.MaxProjectBodyLength {
+		return invalid("body must be at most %d characters", models.MaxProjectBodyLength)
+	}
+	if !slices.Contains(models.ProjectCategories, p.Category) {
+		return invalid("category must be one of %s", strings.Join(models.ProjectCategories, ", "))
+	}
+	if utf8.RuneCountInString(p.Badge) > models.MaxProjectLabelLength {
+		return invalid("badge must be at most %d characters", models.MaxProjectLabelLength)
+	}
+	if !validIcon(p.Icon) {
+		return invalid("icon must be CSS classes such as \"fas fa-server\"")
+	}
+
+	if len(input.Technologies) > models.MaxProjectTechnologies {
+		return invalid("at most %d technologies", models.MaxProjectTechnologies)
+	}
+	p.Technologies = []models.Technology{}
+	seen := map[string]bool{}
+	for _, name := range input.Technologies {
+		name = strings.TrimSpace(name)
+		slug := models.Slugify(name)
+		if slug == "" || utf8.RuneCountInString(name) > models.MaxProjectLabelLength {
+			return invalid("technology %q must have letters or digits and at most %d characters", name, models.MaxProjectLabelLength)
+		}
+		if !seen[slug] {
+			seen[slug] = true
+			p.Technologies = append(p.Technologies, models.Technology{Slug: slug, Name: name})
+		}
+	}
+
+	if len(input.Links) > models.MaxProjectLinks {
+		return invalid("at most %d links", models.MaxProjectLinks)
+	}
+	p.Links = []models.ProjectLink{}
+	for _, l := range input.Links {
+		l.Label, l.URL, l.Icon = strings.TrimSpace(l.Label), strings.TrimSpace(l.URL), strings.TrimSpace(l.Icon)
+		if l.Label == "" || utf8.RuneCountInString(l.Label) > models.MaxProjectLabelLength {
+			return invalid("link labels must be between 1 and %d characters", models.MaxProjectLabelLength)
+		}
+		if !validLinkURL(l.URL) {
+			return invalid("link %q must be an http(s) or relative URL", l.Label)
+		}
+		if !validIcon(l.Icon) {
+			return invalid("icon of link %q must be CSS classes", l.Label)
+		}
+		p.Links = append(p.Links, l)
+	}
+
+	if len(input.Images) > models.MaxProjectImages {
+		return invalid("at most %d images", models.MaxProjectImages)
+	}
+	p.Images = []models.ProjectImage{}
+	for _, img := range input.Images {
+		img.URL, img.Alt, img.Caption = strings.TrimSpace(img.URL), strings.TrimSpace(img.Alt), strings.TrimSpace(img.Caption)
+		if !validLinkURL(img.URL) {
+			return invalid("image %q must be an http(s) or relative URL", img.URL)
+		}
+		if img.Alt == "" || utf8.RuneCountInString(img.Alt) > models.MaxImageAltLength {
+			return invalid("image alt texts must be between 1 and %d characters", models.MaxImageAltLength)
+		}
+		if utf8.RuneCountInString(img.Caption) > models.MaxImageCaptionLength {
+			return invalid("image captions must be at most %d characters", models.MaxImageCaptionLength)
+		}
+		p.Images = append(p.Images, img)
+	}
+	return p, nil
+}
+
+// validIcon accepts an empty value or CSS class names
+func validIcon(icon string) bool {
+	return len(icon) <= models.MaxProjectLabelLength && iconPattern.MatchString(icon)
+}
+
+// validLinkURL accepts absolute http(s) URLs and relative references, so
+// "javascript:" or "data:" URLs never end up in an href or src
+func validLinkURL(raw string) bool {
+	if raw == "" || len(raw) > models.MaxProjectURLLength {
+		return false
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	switch u.Scheme {
+	case "":
+		return u.Host == "" // no protocol-relative "//host" URLs
+	case "http", "https":
+		return u.Host != ""
+	}
+	return false
+}
@@ -0,0 +1,121 @@
+package services
+
+import (
+	"context"
+	"net/netip"
+	"slices"
+	"sync"
+	"time"
+)
+
+// RateLimitPolicy allows Limit requests per Window from one client. A zero
+// Limit disables the policy.
+type RateLimitPolicy struct {
+	Limit  int
+	Window time.Duration
+}
+
+// RateLimitOptions configures a RateLimiter
+type RateLimitOptions struct {
+	Default RateLimitPolicy
+	// Strict replaces Default for clients whose network is in StrictASNs or
+	// whose country is in StrictCountries (hosting providers, VPN exits...)
+	Strict          RateLimitPolicy
+	StrictASNs      []int64
+	StrictCountries []string
+	// OnLimited, if set, is called with the client IP of every rejected
+	// request, e.g. to count strikes towards a ban
+	OnLimited func(ip string)
+}
+
+// rateCounter counts the requests of one client in the current window
+type rateCounter struct {
+	start time.Time
+	count int
+	ttl   time.Duration
+}
+
+// RateLimiter counts requests per client IP in fixed windows. IPv6 clients
+// are counted per /64, the block a single host usually gets.
+type RateLimiter struct {
+	geo  GeoLocator
+	opts RateLimitOptions
+
+	mu       sync.Mutex
+	counters map[netip.Prefix]*rateCounter
+}
+
+// NewRateLimiter creates a new instance of RateLimiter. geo may be nil, in
+// which case every client gets the default policy.
+func NewRateLimiter(geo GeoLocator, opts RateLimitOptions) *RateLimiter {
+	return &RateLimiter{
+		geo:      geo,
+		opts:     opts,
+		counters: map[netip.Prefix]*rateCounter{},
+	}
+}
+
+// policy returns the policy applying to ip
+func (l *RateLimiter) policy(ip string) RateLimitPolicy {
+	if l.geo == nil || (len(l.opts.StrictASNs) == 0 && len(l.opts.StrictCountries) == 0) {
+		return l.opts.Default
+	}
+	loc := l.geo.Lookup(ip)
+	if (loc.ASN != 0 && slices.Contains(l.opts.StrictASNs, loc.ASN)) ||
+		(loc.Country != "" && slices.Contains(l.opts.StrictCountries, loc.Country)) {
+		return l.opts.Strict
+	}
+	return l.opts.Default
+}
+
+// Allow records a request from ip and reports whether it is within the
+// limit. When it is not, retryAfter tells when the window resets.
+// Unparsable addresses are not limited.
+func (l *RateLimiter) Allow(ip string) (allowed bool, retryAfter time.Duration) {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return true, 0
+	}
+	policy := l.policy(ip)
+	if policy.Limit <= 0 || policy.Window <= 0 {
+		return true, 0
+	}
+	addr = addr.Unmap().WithZone("")
+	bits := 32
+	if addr.Is6() {
+		bits = 64
+	}
+	key, _ := addr.Prefix(bits)
+
+	now := time.Now()
+	l.mu.Lock()
+	counter, ok := l.counters[key]
+	if !ok || now.Sub(counter.start) >= policy.Window {
+		counter = &rateCounter{start: now, ttl: policy.Window}
+		l.counters[key] = counter
+	}
+	if counter.count >= policy.Limit {
+		retryAfter = counter.start.Add(policy.Window).Sub(now)
+		l.mu.Unlock()
+		if l.opts.OnLimited != nil {
+			l.opts.OnLimited(ip)
+		}
+		return false, retryAfter
+	}
+	counter.count++
+	l.mu.Unlock()
+	return true, 0
+}
+
+// Prune forgets the clients whose window has ended
+func (l *RateLimiter) Prune(ctx context.Context) error {
+	now := time.Now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for key, counter := range l.counters {
+		if now.Sub(counter.start) >= counter.ttl {
+			delete(l.counters, key)
+		}
+	}
+	return nil
+}
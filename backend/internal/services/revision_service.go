@@ -0,0 +1,175 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+
+	"backend/internal/models"
+	"backend/internal/repository"
+	"backend/internal/textdiff"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrInvalidRevision is returned for revision queries that cannot be answered
+var ErrInvalidRevision = errors.New("invalid revision")
+
+// IRevisionService keeps the revision history of editable content
+type IRevisionService interface {
+	// Record stores body as the next revision of a content item
+	Record(ctx context.Context, contentType, contentKey, body string) (*models.Revision, error)
+	List(ctx context.Context, contentType, contentKey string) ([]models.Revision, error)
+	Get(ctx context.Context, id int64) (*models.Revision, error)
+	Diff(ctx context.Context, fromID, toID int64) (*models.RevisionDiff, error)
+}
+
+// ContentRestorer saves the body of a revision as the current content of
+// its item, which records a new revision
+type ContentRestorer interface {
+	RestoreRevision(ctx context.Context, revision *models.Revision, version int) error
+}
+
+// RevisionService implements IRevisionService
+type RevisionService struct {
+	repo repository.IRevisionRepository
+}
+
+// NewRevisionService creates a new instance of RevisionService
+func NewRevisionService(repo repository.IRevisionRepository) IRevisionService {
+	return &RevisionService{
+		repo: repo,
+	}
+}
+
+func (s *RevisionService) Record(ctx context.Context, contentType, contentKey, body string) (*models.Revision, error) {
+	revision := &models.Revision{ContentType: contentType, ContentKey: contentKey, Body: body}
+	if err := s.repo.CreateRevision(ctx, revision); err != nil {
+		return nil, err
+	}
+	return revision, nil
+}
+
+// List returns the revisions of an item without their body, newest first
+func (s *RevisionService) List(ctx context.Context, contentType, contentKey string) ([]models.Revision, error) {
+	if !slices.Contains(models.ContentTypes, contentType) {
+		return nil, fmt.Errorf("%w: unknown content type %q", ErrInvalidRevision, contentType)
+	}
+	if contentKey == "" {
+		return nil, fmt.Errorf("%w: content_key is required", ErrInvalidRevision)
+	}
+	return s.repo.ListRevisions(ctx, contentType, contentKey)
+}
+
+func (s *RevisionService) Get(ctx context.Context, id int64) (*models.Revision, error) {
+	return s.repo.GetRevision(ctx, id)
+}
+
+// Diff compares two revisions of the same item as a unified diff
+func (s *RevisionService) Diff(ctx context.Context, fromID, toID int64) (*models.RevisionDiff, error) {
+	from, err := s.repo.GetRevision(ctx, fromID)
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.repo.GetRevision(ctx, toID)
+	if err != nil {
+		return nil, err
+	}
+	if from.ContentType != to.ContentType || from.ContentKey != to.ContentKey {
+		return nil, fmt.Errorf("%w: revisions %d and %d belong to different content", ErrInvalidRevision, fromID, toID)
+	}
+	label := func(r *models.Revision) string {
+		return fmt.Sprintf("%s/%s #%d", r.ContentType, r.ContentKey, r.Number)
+	}
+	return &models.RevisionDiff{
+		ContentType: from.ContentType,
+		ContentKey:  from.ContentKey,
+		From:        from.Number,
+		To:          to.Number,
+		Diff:        textdiff.Unified(label(from), label(to), from.Body, to.Body),
+	}, nil
+}
+
+// projectRevision is the YAML body of a project revision. YAML keeps the
+// body paragraphs on their own lines, so diffs stay readable.
+type projectRevision struct {
+	Slug         string                `yaml:"slug"`
+	Title        string                `yaml:"title"`
+	Summary      string                `yaml:"summary"`
+	Body         string                `yaml:"body"`
+	Category     string                `yaml:"category"`
+	Badge        string                `yaml:"badge,omitempty"`
+	Icon         string                `yaml:"icon,omitempty"`
+	Technologies []string              `yaml:"technologies,omitempty"`
+	Links        []models.ProjectLink  `yaml:"links,omitempty"`
+	Images       []models.ProjectImage `yaml:"images,omitempty"`
+}
+
+// projectRevisionBody serializes the content of a project, without its
+// publication state
+func projectRevisionBody(p *models.Project) (string, error) {
+	rev := projectRevision{
+		Slug:     p.Slug,
+		Title:    p.Title,
+		Summary:  p.Summary,
+		Body:     p.Body,
+		Category: p.Category,
+		Badge:    p.Badge,
+		Icon:     p.Icon,
+		Links:    p.Links,
+		Images:   p.Images,
+	}
+	for _, tech := range p.Technologies {
+		rev.Technologies = append(rev.Technologies, tech.Name)
+	}
+	data, err := yaml.Marshal(rev)
+	if err != nil {
+		return "", fmt.Errorf("unable to encode project revision: %w", err)
+	}
+	return string(data), nil
+}
+
+// ProjectRestorer adapts the project service to a ContentRestorer
+func ProjectRestorer(projects IProjectService) ContentRestorer {
+	return projectRestorer{projects: projects}
+}
+
+type projectRestorer struct {
+	projects IProjectService
+}
+
+// RestoreRevision saves the revision as the project content. Without a
+// version, the current one is used.
+func (p projectRestorer) RestoreRevision(ctx context.Context, revision *models.Revision, version int) error {
+	id, err := strconv.ParseInt(revision.ContentKey, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: invalid project id %q", ErrInvalidRevision, revision.ContentKey)
+	}
+	var rev projectRevision
+	if err := yaml.Unmarshal([]byte(revision.Body), &rev); err != nil {
+		return fmt.Errorf("unable to decode project revision %d: %w", revision.ID, err)
+	}
+	if version == 0 {
+		current, err := p.projects.GetByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		version = current.Version
+	}
+	_, err = p.projects.Update(ctx, id, models.ProjectInput{
+		Slug:         rev.Slug,
+		Title:        rev.Title,
+		Summary:      rev.Summary,
+		Body:         rev.Body,
+		Category:     rev.Category,
+		Badge:        rev.Badge,
+		Icon:         rev.Icon,
+		Technologies: rev.Technologies,
+		Links:        rev.Links,
+		Images:       rev.Images,
+		Version:      version,
+	})
+	return err
+}
@@ -0,0 +1,230 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"backend/internal/models"
+)
+
+// IRouter decides how a submission is notified
+type IRouter interface {
+	Route(form models.ContactForm, client models.ClientMetadata) models.RoutingDecision
+}
+
+// RoutingRuleSource provides the routing rules (config file or database)
+type RoutingRuleSource interface {
+	ListRoutingRules(ctx context.Context) ([]models.RoutingRule, error)
+}
+
+// RoutingEngine evaluates routing rules loaded from a RoutingRuleSource.
+// Rules are cached in memory; call Refresh to reload them.
+type RoutingEngine struct {
+	source RoutingRuleSource
+	mu     sync.RWMutex
+	rules  []models.RoutingRule
+}
+
+// NewRoutingEngine creates a new instance of RoutingEngine. Rules are empty
+// (every submission gets the default routing) until Refresh succeeds.
+func NewRoutingEngine(source RoutingRuleSource) *RoutingEngine {
+	return &RoutingEngine{
+		source: source,
+	}
+}
+
+// Refresh reloads the rules from the source. Invalid rule sets are rejected
+// and the previous rules stay in place.
+func (e *RoutingEngine) Refresh(ctx context.Context) error {
+	rules, err := e.source.ListRoutingRules(ctx)
+	if err != nil {
+		return err
+	}
+	enabled := make([]models.RoutingRule, 0, len(rules))
+	for _, rule := range rules {
+		if err := validateRoutingRule(rule); err != nil {
+			return err
+		}
+		if rule.Enabled {
+			enabled = append(enabled, normalizeRoutingRule(rule))
+		}
+	}
+	sort.SliceStable(enabled, func(i, j int) bool { return enabled[i].Position < enabled[j].Position })
+
+	e.mu.Lock()
+	e.rules = enabled
+	e.mu.Unlock()
+	return nil
+}
+
+// Rules returns the enabled rules in evaluation order
+func (e *RoutingEngine) Rules() []models.RoutingRule {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return append([]models.RoutingRule(nil), e.rules...)
+}
+
+// Route returns the actions of the first matching rule, or a normal-priority
+// decision when no rule matches. Country and ASN criteria are checked
+// against the GeoIP location of client.
+func (e *RoutingEngine) Route(form models.ContactForm, client models.ClientMetadata) models.RoutingDecision {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	text := strings.ToLower(form.Subject + "\n" + form.Message)
+	domain := emailDomain(form.Email)
+
+	for _, rule := range e.rules {
+		if !matchesRule(rule, form.Subject, text, domain) || !matchesLocation(rule, client.GeoLocation) {
+			continue
+		}
+		decision := models.RoutingDecision{
+			Rule:       rule.Name,
+			Recipients: rule.Recipients,
+			Priority:   rule.Priority,
+			Tags:       rule.Tags,
+			Channels:   rule.Channels,
+			Suppress:   rule.Suppress,
+		}
+		if decision.Priority == "" {
+			decision.Priority = models.PriorityNormal
+		}
+		return decision
+	}
+	return models.RoutingDecision{Priority: models.PriorityNormal}
+}
+
+func matchesRule(rule models.RoutingRule, subject, lowerText, domain string) bool {
+	if len(rule.Subjects) > 0 && !containsString(rule.Subjects, subject) {
+		return false
+	}
+	if len(rule.Keywords) > 0 {
+		found := false
+		for _, kw := range rule.Keywords {
+			if strings.Contains(lowerText, kw) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(rule.SenderDomains) > 0 {
+		found := false
+		for _, d := range rule.SenderDomains {
+			if domain == d || strings.HasSuffix(domain, "."+d) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func matchesLocation(rule models.RoutingRule, loc models.GeoLocation) bool {
+	if len(rule.Countries) > 0 && !containsString(rule.Countries, loc.Country) {
+		return false
+	}
+	if len(rule.ASNs) > 0 && !slices.Contains(rule.ASNs, loc.ASN) {
+		return false
+	}
+	return true
+}
+
+func validateRoutingRule(rule models.RoutingRule) error {
+	if strings.TrimSpace(rule.Name) == "" {
+		return fmt.Errorf("routing rule at position %d has no name", rule.Position)
+	}
+	if rule.Priority != "" && !models.IsPriority(rule.Priority) {
+		return fmt.Errorf("routing rule %q: unknown priority %q", rule.Name, rule.Priority)
+	}
+	return nil
+}
+
+// normalizeRoutingRule lowercases the criteria once so Route can compare cheaply
+func normalizeRoutingRule(rule models.RoutingRule) models.RoutingRule {
+	lower := func(values []string) []string {
+		out := make([]string, 0, len(values))
+		for _, v := range values {
+			if v = strings.ToLower(strings.TrimSpace(v)); v != "" {
+				out = append(out, v)
+			}
+		}
+		return out
+	}
+	rule.Subjects = lower(rule.Subjects)
+	rule.Keywords = lower(rule.Keywords)
+	rule.SenderDomains = lower(rule.SenderDomains)
+	for i, country := range rule.Countries {
+		rule.Countries[i] = strings.ToUpper(strings.TrimSpace(country))
+	}
+	return rule
+}
+
+func emailDomain(address string) string {
+	at := strings.LastIndex(address, "@")
+	if at < 0 {
+		return ""
+	}
+	return strings.ToLower(address[at+1:])
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// FileRoutingRuleSource reads routing rules from a JSON file containing an
+// array of rules. Rules without an explicit "enabled": false are enabled and
+// their position defaults to their index in the file.
+type FileRoutingRuleSource struct {
+	path string
+}
+
+// NewFileRoutingRuleSource creates a rule source backed by a JSON file
+func NewFileRoutingRuleSource(path string) *FileRoutingRuleSource {
+	return &FileRoutingRuleSource{path: path}
+}
+
+func (f *FileRoutingRuleSource) ListRoutingRules(ctx context.Context) ([]models.RoutingRule, error) {
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read routing rules: %w", err)
+	}
+
+	// Decode into a wrapper so a missing "enabled" field means true
+	var raw []struct {
+		models.RoutingRule
+		Enabled  *bool `json:"enabled"`
+		Position *int  `json:"position"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("unable to parse routing rules %s: %w", f.path, err)
+	}
+
+	rules := make([]models.RoutingRule, 0, len(raw))
+	for i, r := range raw {
+		rule := r.RoutingRule
+		rule.Enabled = r.Enabled == nil || *r.Enabled
+		rule.Position = i
+		if r.Position != nil {
+			rule.Position = *r.Position
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
@@ -0,0 +1,186 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend/internal/models"
+	"backend/internal/repository"
+)
+
+// ErrInvalidSchedule is returned when admin-provided schedule data is invalid
+var ErrInvalidSchedule = errors.New("invalid schedule")
+
+const (
+	// scheduleBatch is the number of due schedules claimed at once
+	scheduleBatch = 50
+	// scheduleLease is how long a claimed schedule is kept from other
+	// workers; it outlasts any publication, so a schedule is only claimed
+	// again if its worker died
+	scheduleLease = 5 * time.Minute
+	// scheduleMaxAttempts is the number of runs before a failing schedule
+	// is marked failed
+	scheduleMaxAttempts = 5
+	// scheduleRetryDelay is the delay before the first retry, doubled after
+	// each failure
+	scheduleRetryDelay = time.Minute
+)
+
+// ContentPublisher publishes and unpublishes one type of content now
+type ContentPublisher interface {
+	PublishContent(ctx context.Context, key string) error
+	UnpublishContent(ctx context.Context, key string) error
+}
+
+// IScheduleService publishes and unpublishes content at set times
+type IScheduleService interface {
+	Schedule(ctx context.Context, schedule models.Schedule) (*models.Schedule, error)
+	List(ctx context.Context, filter models.ScheduleFilter) ([]models.Schedule, int, error)
+	Cancel(ctx context.Context, id int64) error
+	// RunDue runs the schedules whose time has come
+	RunDue(ctx context.Context) error
+}
+
+// ScheduleService implements IScheduleService
+type ScheduleService struct {
+	repo       repository.IScheduleRepository
+	publishers map[string]ContentPublisher
+	now        func() time.Time
+}
+
+// NewScheduleService creates a ScheduleService running the actions through
+// the publisher of each content type
+func NewScheduleService(repo repository.IScheduleRepository, publishers map[string]ContentPublisher) IScheduleService {
+	return &ScheduleService{
+		repo:       repo,
+		publishers: publishers,
+		now:        time.Now,
+	}
+}
+
+// Schedule stores a publication or unpublication at schedule.RunAt, which
+// must be in the future
+func (s *ScheduleService) Schedule(ctx context.Context, schedule models.Schedule) (*models.Schedule, error) {
+	schedule.ContentKey = strings.TrimSpace(schedule.ContentKey)
+	switch {
+	case s.publishers[schedule.ContentType] == nil:
+		return nil, fmt.Errorf("%w: unknown content type %q", ErrInvalidSchedule, schedule.ContentType)
+	case schedule.ContentKey == "":
+		return nil, fmt.Errorf("%w: content_key is required", ErrInvalidSchedule)
+	case !slices.Contains(models.ScheduleActions, schedule.Action):
+		return nil, fmt.Errorf("%w: action must be one of %s", ErrInvalidSchedule, strings.Join(models.ScheduleActions, ", "))
+	case !schedule.RunAt.After(s.now()):
+		return nil, fmt.Errorf("%w: run_at must be in the future", ErrInvalidSchedule)
+	}
+	schedule.RunAt = schedule.RunAt.UTC()
+	if err := s.repo.CreateSchedule(ctx, &schedule); err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+// List returns a page of schedules, next to run first
+func (s *ScheduleService) List(ctx context.Context, filter models.ScheduleFilter) ([]models.Schedule, int, error) {
+	return s.repo.ListSchedules(ctx, filter)
+}
+
+// Cancel deletes a schedule that did not run yet
+func (s *ScheduleService) Cancel(ctx context.Context, id int64) error {
+	return s.repo.DeleteSchedule(ctx, id)
+}
+
+// RunDue claims the due schedules and runs them in order. A schedule is
+// marked done only once its action succeeds; a failure is recorded on it and
+// retried with a growing delay, up to scheduleMaxAttempts, without stopping
+// the others.
+func (s *ScheduleService) RunDue(ctx context.Context) error {
+	for {
+		due, err := s.repo.ClaimDueSchedules(ctx, s.now(), scheduleBatch, scheduleLease)
+		if err != nil {
+			return err
+		}
+		for _, schedule := range due {
+			var attemptErr string
+			var retryAt *time.Time
+			if err := s.run(ctx, schedule); err != nil {
+				attempt := schedule.Attempts + 1
+				attemptErr = err.Error()
+				if attempt < scheduleMaxAttempts {
+					next := s.now().Add(scheduleRetryDelay << (attempt - 1))
+					retryAt = &next
+				}
+				log.Printf("Schedule %d (%s %s/%s) failed (attempt %d/%d): %v", schedule.ID, schedule.Action,
+					schedule.ContentType, schedule.ContentKey, attempt, scheduleMaxAttempts, err)
+			}
+			if err := s.repo.RecordScheduleAttempt(ctx, schedule.ID, attemptErr, retryAt); err != nil {
+				log.Printf("Error recording schedule %d attempt: %v", schedule.ID, err)
+			}
+		}
+		if len(due) < scheduleBatch {
+			return nil
+		}
+	}
+}
+
+func (s *ScheduleService) run(ctx context.Context, schedule models.Schedule) error {
+	publisher := s.publishers[schedule.ContentType]
+	if publisher == nil {
+		return fmt.Errorf("unknown content type %q", schedule.ContentType)
+	}
+	if schedule.Action == models.ScheduleUnpublish {
+		return publisher.UnpublishContent(ctx, schedule.ContentKey)
+	}
+	return publisher.PublishContent(ctx, schedule.ContentKey)
+}
+
+// ProjectPublisher adapts the project service to a ContentPublisher
+func ProjectPublisher(projects IProjectService) ContentPublisher {
+	return projectPublisher{projects: projects}
+}
+
+type projectPublisher struct {
+	projects IProjectService
+}
+
+func (p projectPublisher) PublishContent(ctx context.Context, key string) error {
+	id, err := strconv.ParseInt(key, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid project id %q", key)
+	}
+	_, err = p.projects.Publish(ctx, id, models.ProjectPublication{})
+	return err
+}
+
+func (p projectPublisher) UnpublishContent(ctx context.Context, key string) error {
+	id, err := strconv.ParseInt(key, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid project id %q", key)
+	}
+	_, err = p.projects.Unpublish(ctx, id, 0)
+	return err
+}
+
+// ArticlePublisher adapts the article service to a ContentPublisher
+func ArticlePublisher(articles IArticleService) ContentPublisher {
+	return articlePublisher{articles: articles}
+}
+
+type articlePublisher struct {
+	articles IArticleService
+}
+
+func (p articlePublisher) PublishContent(ctx context.Context, key string) error {
+	_, err := p.articles.Publish(ctx, key, models.ArticlePublication{})
+	return err
+}
+
+func (p articlePublisher) UnpublishContent(ctx context.Context, key string) error {
+	_, err := p.articles.Unpublish(ctx, key)
+	return err
+}
@@ -0,0 +1,329 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"backend/internal/models"
+	"backend/internal/repository"
+
+	"github.com/skip2/go-qrcode"
+)
+
+var (
+	// ErrInvalidShareLink is returned when admin-provided share link data is invalid
+	ErrInvalidShareLink = errors.New("invalid share link")
+	// ErrShareLinkGone is returned when a share link expired or was revoked
+	ErrShareLinkGone = errors.New("share link expired or revoked")
+)
+
+// SharePath is the URL prefix of share links
+const SharePath = "/s/"
+
+// DefaultQRCodeSize is the width in pixels of QR codes without a size
+const DefaultQRCodeSize = 512
+
+// Share link limits
+const (
+	shareCodeAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	shareCodeLength   = 7 // generated codes: 62^7 possibilities
+	shareCodeAttempts = 5 // generated codes tried before giving up on collisions
+	maxShareLabel     = 200
+	minQRCodeSize     = 128
+	maxQRCodeSize     = 1024
+)
+
+// shareCodePattern matches the codes admins may choose
+var shareCodePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{3,64}$`)
+
+// shareTargetPattern matches the pages a share link may redirect to: the
+// CV, the about page and project or article pages
+var shareTargetPattern = regexp.MustCompile(`^/(?:cv\.pdf|about|projects(?:/[a-z0-9]+(?:-[a-z0-9]+)*)?|articles(?:/[a-z0-9]+(?:-[a-z0-9]+)*)?)$`)
+
+// IShareService manages share links: short links given to one recipient,
+// whose visits are counted
+type IShareService interface {
+	Create(ctx context.Context, input models.ShareLinkInput) (*models.ShareLink, error)
+	List(ctx context.Context, limit, offset int) ([]models.ShareLink, int, error)
+	Get(ctx context.Context, id int64) (*models.ShareLink, error)
+	Revoke(ctx context.Context, id int64) (*models.ShareLink, error)
+	Delete(ctx context.Context, id int64) error
+	Hits(ctx context.Context, id int64, limit, offset int) ([]models.ShareHit, int, error)
+	Stats(ctx context.Context) ([]models.ShareTargetStats, error)
+	// QRCode returns a PNG QR code of the short URL, size pixels wide
+	QRCode(ctx context.Context, id int64, size int) ([]byte, error)
+
+	// Resolve returns the target of an active link and records the visit.
+	// Returns ErrNotFound for unknown codes, ErrShareLinkGone for expired or
+	// revoked links.
+	Resolve(ctx context.Context, code string, client models.ClientMetadata) (string, error)
+	// PurgeHits deletes the visits older than the retention period
+	PurgeHits(ctx context.Context) error
+}
+
+// ShareOptions configures a ShareService
+type ShareOptions struct {
+	BaseURL      string        // public site URL, without trailing slash
+	Geo          GeoLocator    // optional, for the country of visits
+	HitRetention time.Duration // age after which visits are deleted, 0 keeps them
+	Now          func() time.Time
+}
+
+// ShareService implements IShareService
+type ShareService struct {
+	repo repository.IShareLinkRepository
+	opts ShareOptions
+}
+
+// NewShareService creates a new instance of ShareService
+func NewShareService(repo repository.IShareLinkRepository, opts ShareOptions) IShareService {
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	return &ShareService{
+		repo: repo,
+		opts: opts,
+	}
+}
+
+// Create stores a new link. Without a code, a random one is generated.
+func (s *ShareService) Create(ctx context.Context, input models.ShareLinkInput) (*models.ShareLink, error) {
+	link := &models.ShareLink{
+		Target: strings.TrimSpace(input.Target),
+		Label:  strings.TrimSpace(input.Label),
+		Code:   strings.TrimSpace(input.Code),
+	}
+	switch {
+	case !shareTargetPattern.MatchString(link.Target):
+		return nil, fmt.Errorf("%w: target must be /cv.pdf, /about or a project or article path", ErrInvalidShareLink)
+	case link.Label == "":
+		return nil, fmt.Errorf("%w: label is required", ErrInvalidShareLink)
+	case utf8.RuneCountInString(link.Label) > maxShareLabel:
+		return nil, fmt.Errorf("%w: label must be at most %d characters", ErrInvalidShareLink, maxShareLabel)
+	case link.Code != "" && !shareCodePattern.MatchString(link.Code):
+		return nil, fmt.Errorf("%w: code must be 3 to 64 letters, digits, - or _", ErrInvalidShareLink)
+	case input.ExpiresAt != nil && !input.ExpiresAt.After(s.opts.Now()):
+		return nil, fmt.Errorf("%w: expires_at must be in the future", ErrInvalidShareLink)
+	}
+	if input.ExpiresAt != nil {
+		at := input.ExpiresAt.UTC()
+		link.ExpiresAt = &at
+	}
+
+	if link.Code != "" {
+		if err := s.repo.CreateShareLink(ctx, link); err != nil {
+			return nil, err
+		}
+		return s.withURL(link), nil
+	}
+	for attempt := 0; ; attempt++ {
+		code, err := randomShareCode()
+		if err != nil {
+			return nil, err
+		}
+		link.Code = code
+		err = s.repo.CreateShareLink(ctx, link)
+		if err == nil {
+			return s.withURL(link), nil
+		}
+		if !errors.Is(err, repository.ErrCodeTaken) || attempt+1 == shareCodeAttempts {
+			return nil, err
+		}
+	}
+}
+
+func (s *ShareService) List(ctx context.Context, limit, offset int) ([]models.ShareLink, int, error) {
+	list, total, err := s.repo.ListShareLinks(ctx, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range list {
+		s.withURL(&list[i])
+	}
+	return list, total, nil
+}
+
+func (s *ShareService) Get(ctx context.Context, id int64) (*models.ShareLink, error) {
+	link, err := s.repo.GetShareLink(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.withURL(link), nil
+}
+
+// Revoke disables a link for good; its visits are kept
+func (s *ShareService) Revoke(ctx context.Context, id int64) (*models.ShareLink, error) {
+	if err := s.repo.RevokeShareLink(ctx, id, s.opts.Now().UTC()); err != nil {
+		return nil, err
+	}
+	return s.Get(ctx, id)
+}
+
+// Delete removes a link and its visits
+func (s *ShareService) Delete(ctx context.Context, id int64) error {
+	return s.repo.DeleteShareLink(ctx, id)
+}
+
+// Hits returns the visits of a link, newest first
+func (s *ShareService) Hits(ctx context.Context, id int64, limit, offset int) ([]models.ShareHit, int, error) {
+	if _, err := s.repo.GetShareLink(ctx, id); err != nil {
+		return nil, 0, err
+	}
+	return s.repo.ListShareHits(ctx, id, limit, offset)
+}
+
+func (s *ShareService) Stats(ctx context.Context) ([]models.ShareTargetStats, error) {
+	return s.repo.ShareTargetStats(ctx)
+}
+
+func (s *ShareService) QRCode(ctx context.Context, id int64, size int) ([]byte, error) {
+	if size == 0 {
+		size = DefaultQRCodeSize
+	}
+	if size < minQRCodeSize || size > maxQRCodeSize {
+		return nil, fmt.Errorf("%w: size must be between %d and %d", ErrInvalidShareLink, minQRCodeSize, maxQRCodeSize)
+	}
+	link, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	png, err := qrcode.Encode(link.URL, qrcode.Medium, size)
+	if err != nil {
+		return nil, fmt.Errorf("unable to encode QR code: %w", err)
+	}
+	return png, nil
+}
+
+// Resolve records the visit before redirecting. A visit that cannot be
+// recorded is logged: the recipient still gets the page.
+func (s *ShareService) Resolve(ctx context.Context, code string, client models.ClientMetadata) (string, error) {
+	if !shareCodePattern.MatchString(code) {
+		return "", repository.ErrNotFound
+	}
+	link, err := s.repo.GetShareLinkByCode(ctx, code)
+	if err != nil {
+		return "", err
+	}
+	now := s.opts.Now()
+	if !link.Active(now) {
+		return "", ErrShareLinkGone
+	}
+
+	hit := models.ShareHit{LinkID: link.ID, At: now.UTC(), Referrer: client.Referrer}
+	hit.Browser, hit.OS, hit.Device = parseUserAgent(client.UserAgent)
+	if s.opts.Geo != nil && client.IP != "" {
+		hit.Country = s.opts.Geo.Lookup(client.IP).Country
+	}
+	hit.Normalize()
+	if err := s.repo.CreateShareHit(ctx, &hit); err != nil {
+		log.Printf("Error recording visit of share link %d: %v", link.ID, err)
+	}
+	return link.Target, nil
+}
+
+func (s *ShareService) PurgeHits(ctx context.Context) error {
+	if s.opts.HitRetention <= 0 {
+		return nil
+	}
+	deleted, err := s.repo.DeleteShareHitsBefore(ctx, s.opts.Now().Add(-s.opts.HitRetention))
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		log.Printf("Purged %d share link visits", deleted)
+	}
+	return nil
+}
+
+// withURL fills in the public short URL of link
+func (s *ShareService) withURL(link *models.ShareLink) *models.ShareLink {
+	link.URL = s.opts.BaseURL + SharePath + link.Code
+	return link
+}
+
+// randomShareCode draws a code from shareCodeAlphabet with crypto/rand, so
+// codes cannot be guessed from one another
+func randomShareCode() (string, error) {
+	code := make([]byte, shareCodeLength)
+	max := big.NewInt(int64(len(shareCodeAlphabet)))
+	for i := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", fmt.Errorf("unable to generate share code: %w", err)
+		}
+		code[i] = shareCodeAlphabet[n.Int64()]
+	}
+	return string(code), nil
+}
+
+// botMarkers identify crawlers, link previews and scripts in a lowercased
+// user agent
+var botMarkers = []string{
+	"bot", "crawler", "spider", "slurp", "preview", "facebookexternalhit", "slack", "linkedin",
+	"whatsapp", "telegram", "discord", "skype", "curl", "wget", "python", "go-http-client", "headless",
+}
+
+// parseUserAgent reduces a user agent to its browser family, OS family and
+// device type. An empty user agent is counted as a bot.
+func parseUserAgent(ua string) (browser, os, device string) {
+	lower := strings.ToLower(ua)
+	for _, marker := range botMarkers {
+		if strings.Contains(lower, marker) {
+			return "", "", models.DeviceBot
+		}
+	}
+	if lower == "" {
+		return "", "", models.DeviceBot
+	}
+
+	switch {
+	case strings.Contains(ua, "Windows"):
+		os = "Windows"
+	case strings.Contains(ua, "Android"):
+		os = "Android"
+	case strings.Contains(ua, "iPhone"), strings.Contains(ua, "iPod"):
+		os = "iOS"
+	case strings.Contains(ua, "iPad"):
+		os = "iPadOS"
+	case strings.Contains(ua, "Mac OS X"), strings.Contains(ua, "Macintosh"):
+		os = "macOS"
+	case strings.Contains(ua, "CrOS"):
+		os = "ChromeOS"
+	case strings.Contains(ua, "Linux"):
+		os = "Linux"
+	}
+
+	switch {
+	case strings.Contains(ua, "Edg/"), strings.Contains(ua, "EdgiOS/"), strings.Contains(ua, "EdgA/"):
+		browser = "Edge"
+	case strings.Contains(ua, "OPR/"), strings.Contains(ua, "Opera"):
+		browser = "Opera"
+	case strings.Contains(ua, "SamsungBrowser/"):
+		browser = "Samsung Internet"
+	case strings.Contains(ua, "Firefox/"), strings.Contains(ua, "FxiOS/"):
+		browser = "Firefox"
+	case strings.Contains(ua, "Chrome/"), strings.Contains(ua, "CriOS/"):
+		browser = "Chrome"
+	case strings.Contains(ua, "Safari/"):
+		browser = "Safari"
+	}
+
+	switch {
+	case strings.Contains(ua, "iPad"), strings.Contains(ua, "Tablet"),
+		os == "Android" && !strings.Contains(ua, "Mobile"):
+		device = models.DeviceTablet
+	case strings.Contains(ua, "Mobi"), strings.Contains(ua, "iPhone"):
+		device = models.DeviceMobile
+	default:
+		device = models.DeviceDesktop
+	}
+	return browser, os, device
+}
@@ -0,0 +1,23 @@
+package services
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+type silentDropCtx struct{}
+
+// WithSilentDrops lets the caller of a service learn whether the request was
+// silently dropped, for instance to hold back the fake success response
+func WithSilentDrops(ctx context.Context) (context.Context, *atomic.Bool) {
+	dropped := &atomic.Bool{}
+	return context.WithValue(ctx, silentDropCtx{}, dropped), dropped
+}
+
+// markSilentlyDropped records that the request was answered as a success
+// without being processed
+func markSilentlyDropped(ctx context.Context) {
+	if dropped, ok := ctx.Value(silentDropCtx{}).(*atomic.Bool); ok {
+		dropped.Store(true)
+	}
+}
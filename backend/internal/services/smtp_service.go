@@ -2,6 +2,8 @@ package services
 
 import (
 	"backend/internal/models"
+	"bytes"
+	"context"
 	"crypto/tls"
 	"errors"
 	"fmt"
@@ -14,55 +16,121 @@ import (
 	"github.com/jordan-wright/email"
 )
 
+// ErrSMTPUTF8Unsupported is returned when a sender or recipient address has
+// an internationalized local part and the relay does not support SMTPUTF8
+var ErrSMTPUTF8Unsupported = errors.New("address requires SMTPUTF8, which the SMTP relay does not support")
+
 // SmtpService implements IEmailService using SMTP protocol
 // This struct holds the SMTP server configuration and authentication details.
 type SmtpService struct {
-	host    string
-	port    string
-	user    string
-	pass    string
-	address string
+	host      string
+	port      string
+	user      string
+	pass      string
+	address   string
+	tlsConfig *tls.Config
+}
+
+// SMTPOption configures optional SmtpService behaviour
+type SMTPOption func(*SmtpService)
+
+// WithSMTPTLSConfig replaces the TLS configuration used to connect to the
+// relay, e.g. to trust a private CA
+func WithSMTPTLSConfig(config *tls.Config) SMTPOption {
+	return func(s *SmtpService) {
+		s.tlsConfig = config
+	}
 }
 
 // NewSMTPService creates a new instance of SmtpService
 // with the provided SMTP server configuration and authentication details.
-func NewSMTPService(host, port, user, pass string, address string) IEmailService {
+func NewSMTPService(host, port, user, pass string, address string, opts ...SMTPOption) IEmailService {
+	s := &SmtpService{
+		host:      host,
+		port:      port,
+		user:      user,
+		pass:      pass,
+		address:   address,
+		tlsConfig: &tls.Config{ServerName: host},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Name identifies the email channel for the notification dispatcher
+func (s *SmtpService) Name() string {
+	return "email"
+}
 
-	return &SmtpService{
-		host:    host,
-		port:    port,
-		user:    user,
-		pass:    pass,
-		address: address,
+// Notify implements Notifier by emailing the submission to the notification
+// recipients, or to the configured admin address when none are set.
+func (s *SmtpService) Notify(ctx context.Context, n Notification) error {
+	to := n.Recipients
+	if len(to) == 0 {
+		to = []string{s.address}
+	}
+	subject := n.Title
+	if subject == "" {
+		subject = DefaultNotificationTitle
 	}
+	return s.sendContactEmail(n.Submission.ContactForm, to, subject, n.Priority)
 }
 
 // SendContactEmail sends an email using the SMTP server configuration.
-// It takes the recipient's email address, subject, and body as parameters.
+// The submission is sent to the configured admin address.
 func (s *SmtpService) SendContactEmail(form models.ContactForm) error {
-	// Basic trimming
-	name := strings.TrimSpace(form.Name)
-	fromEmail := strings.TrimSpace(form.Email)
-	message := strings.TrimSpace(form.Message)
+	return s.sendContactEmail(form, []string{s.address}, DefaultNotificationTitle, models.PriorityNormal)
+}
 
-	// Basic validation
-	if fromEmail == "" {
-		return errors.New("invalid email: empty")
+// SendEmail sends msg as a plain-text email with its threading headers.
+// The SMTP user is the sender when msg.From is empty.
+func (s *SmtpService) SendEmail(ctx context.Context, msg models.OutgoingEmail) error {
+	e := email.NewEmail()
+	e.From = msg.From
+	if e.From == "" {
+		e.From = s.user
 	}
-	parsed, err := mail.ParseAddress(fromEmail)
-	if err != nil {
-		return fmt.Errorf("invalid email address: %w", err)
+	e.To = msg.To
+	e.Subject = msg.Subject
+	e.Text = []byte(msg.Text)
+	var replyTo []string
+	if msg.ReplyTo != "" {
+		replyTo = []string{msg.ReplyTo}
+	}
+	if msg.MessageID != "" {
+		e.Headers.Set("Message-ID", msg.MessageID)
+	}
+	if msg.InReplyTo != "" {
+		e.Headers.Set("In-Reply-To", msg.InReplyTo)
+	}
+	if len(msg.References) > 0 {
+		e.Headers.Set("References", strings.Join(msg.References, " "))
 	}
 
-	// Simple length limits to avoid abuse
-	if len(name) > 200 {
-		return errors.New("name too long")
+	if err := s.send(e, replyTo); err != nil {
+		log.Printf("Error: smtp fail: %s", err)
+		return err
 	}
-	if len(fromEmail) > 320 {
-		return errors.New("email too long")
+
+	log.Printf("Email %s sent successfully to %s via SMTP server %s:%s", msg.MessageID, strings.Join(msg.To, ", "), s.host, s.port)
+	return nil
+}
+
+func (s *SmtpService) sendContactEmail(form models.ContactForm, to []string, subject, priority string) error {
+	// Same normalization and limits as the HTTP layer; the form is
+	// normally already clean when it gets here
+	form.Normalize()
+	if err := form.Validate(); err != nil {
+		return err
 	}
-	if len(message) > 10000 {
-		return errors.New("message too long")
+	name := form.Name
+	message := form.Message
+
+	parsed, err := mail.ParseAddress(form.Email)
+	if err != nil {
+		return fmt.Errorf("invalid email address: %w", err)
 	}
 
 	// Escape user-provided content to avoid injection in HTML email
@@ -72,8 +140,12 @@ func (s *SmtpService) SendContactEmail(form models.ContactForm) error {
 
 	e := email.NewEmail()
 	e.From = s.user
-	e.To = []string{s.address}
-	e.Subject = "Nouveau message via le formulaire de contact - Portfolio Enzo"
+	e.To = to
+	e.Subject = subject
+	if priority == models.PriorityHigh || priority == models.PriorityUrgent {
+		e.Headers.Set("X-Priority", "1")
+		e.Headers.Set("Importance", "high")
+	}
 
 	// Plain-text body
 	e.Text = []byte(fmt.Sprintf("De: %s <%s>\n\nMessage:\n%s", escName, escEmail, escMessage))
@@ -83,19 +155,121 @@ func (s *SmtpService) SendContactEmail(form models.ContactForm) error {
 
 	// Construct Reply-To using a validated address and optional name
 	reply := (&mail.Address{Name: name, Address: parsed.Address}).String()
-	e.ReplyTo = []string{reply}
 
-	address := fmt.Sprintf("%s:%s", s.host, s.port)
-	tlsConfig := &tls.Config{
-		ServerName: s.host,
-	}
-	auth := smtp.PlainAuth("", s.user, s.pass, s.host)
-	err = e.SendWithTLS(address, auth, tlsConfig)
+	err = s.send(e, []string{reply})
 	if err != nil {
 		log.Printf("Error: smtp fail: %s", err)
 		return err
 	}
 
-	log.Printf("Email sent successfully to %s via SMTP server %s", s.user, address)
+	log.Printf("Email sent successfully to %s via SMTP server %s:%s", strings.Join(to, ", "), s.host, s.port)
 	return nil
 }
+
+// send delivers e over implicit TLS. When the relay advertises SMTPUTF8
+// (RFC 6531), addresses are sent as they are; otherwise their domains are
+// converted to punycode, which fails for internationalized local parts.
+// A Reply-To that cannot be converted is left out rather than failing the
+// whole email, since it is only a convenience.
+func (s *SmtpService) send(e *email.Email, replyTo []string) error {
+	conn, err := tls.Dial("tcp", s.host+":"+s.port, s.tlsConfig)
+	if err != nil {
+		return err
+	}
+	c, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+	if err := c.Hello("localhost"); err != nil {
+		return err
+	}
+	if ok, _ := c.Extension("AUTH"); ok {
+		if err := c.Auth(smtp.PlainAuth("", s.user, s.pass, s.host)); err != nil {
+			return err
+		}
+	}
+	smtpUTF8, _ := c.Extension("SMTPUTF8")
+
+	from, err := mail.ParseAddress(e.From)
+	if err != nil {
+		return fmt.Errorf("invalid sender address: %w", err)
+	}
+	if from.Address, err = transportAddress(from.Address, smtpUTF8); err != nil {
+		return err
+	}
+	e.From = from.String()
+	recipients := make([]string, 0, len(e.To))
+	for i, to := range e.To {
+		parsed, err := mail.ParseAddress(to)
+		if err != nil {
+			return fmt.Errorf("invalid recipient address: %w", err)
+		}
+		if parsed.Address, err = transportAddress(parsed.Address, smtpUTF8); err != nil {
+			return err
+		}
+		e.To[i] = parsed.String()
+		recipients = append(recipients, parsed.Address)
+	}
+
+	// The email package Q-encodes whole Reply-To values, which breaks
+	// display names and internationalized addresses: write the header here
+	var header bytes.Buffer
+	var replies []string
+	for _, r := range replyTo {
+		parsed, err := mail.ParseAddress(r)
+		if err == nil {
+			parsed.Address, err = transportAddress(parsed.Address, smtpUTF8)
+		}
+		if err != nil {
+			log.Printf("Leaving out Reply-To %q: %v", r, err)
+			continue
+		}
+		replies = append(replies, parsed.String())
+	}
+	if len(replies) > 0 {
+		header.WriteString("Reply-To: " + strings.Join(replies, ", ") + "\r\n")
+	}
+	e.ReplyTo = nil
+	raw, err := e.Bytes()
+	if err != nil {
+		return err
+	}
+
+	// net/smtp adds the SMTPUTF8 parameter when the relay advertises it
+	if err := c.Mail(from.Address); err != nil {
+		return err
+	}
+	for _, rcpt := range recipients {
+		if err := c.Rcpt(rcpt); err != nil {
+			return err
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(append(header.Bytes(), raw...)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// transportAddress returns address as it can be sent to the relay
+func transportAddress(address string, smtpUTF8 bool) (string, error) {
+	if smtpUTF8 {
+		return address, nil
+	}
+	ascii, err := models.ASCIIEmail(address)
+	if errors.Is(err, models.ErrNonASCIILocalPart) {
+		return "", fmt.Errorf("%w: %s", ErrSMTPUTF8Unsupported, address)
+	}
+	if err != nil {
+		return "", fmt.Errorf("invalid email address %s: %w", address, err)
+	}
+	return ascii, nil
+}
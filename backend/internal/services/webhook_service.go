@@ -0,0 +1,282 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"backend/internal/models"
+	"backend/internal/repository"
+)
+
+// Headers set on every webhook request
+const (
+	WebhookSignatureHeader = "X-Webhook-Signature"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookDeliveryHeader  = "X-Webhook-Delivery"
+)
+
+// maxWebhookBackoff caps the delay between two delivery attempts
+const maxWebhookBackoff = 10 * time.Minute
+
+// webhookClaimBatch is the number of due deliveries sent together by RunDue
+const webhookClaimBatch = 20
+
+// IWebhookPublisher publishes events about a submission to the configured
+// webhook endpoints
+type IWebhookPublisher interface {
+	Publish(ctx context.Context, eventType string, submissionID int64) error
+}
+
+// IWebhookService adds the admin operations on the delivery log
+type IWebhookService interface {
+	IWebhookPublisher
+	ListDeliveries(ctx context.Context, endpoint string, limit, offset int) ([]models.WebhookDelivery, error)
+	Redeliver(ctx context.Context, id int64) (*models.WebhookDelivery, error)
+	// RunDue sends the pending deliveries whose next attempt is due. Run
+	// periodically, it retries failed attempts and picks up the deliveries
+	// interrupted by a restart.
+	RunDue(ctx context.Context) error
+}
+
+// WebhookOptions holds the webhook delivery settings
+type WebhookOptions struct {
+	Endpoints   []string      // URLs receiving every event
+	Secret      string        // HMAC-SHA256 signing secret
+	MaxAttempts int           // Attempts per delivery before it is marked failed
+	Timeout     time.Duration // Per-attempt HTTP timeout
+	BaseBackoff time.Duration // Delay before the first retry, doubled on each attempt
+}
+
+// WebhookService signs and delivers events, retrying with exponential
+// backoff. Pending deliveries and their next attempt time live in the
+// database, so retries survive restarts.
+type WebhookService struct {
+	repo        repository.IWebhookRepository
+	submissions repository.IInboxRepository
+	opts        WebhookOptions
+	client      *http.Client
+}
+
+// NewWebhookService creates a new instance of WebhookService. Event payloads
+// are built at send time from the submissions read through submissions.
+// Endpoints require a secret: a signature made with an empty key proves nothing.
+func NewWebhookService(repo repository.IWebhookRepository, submissions repository.IInboxRepository, opts WebhookOptions) (IWebhookService, error) {
+	if len(opts.Endpoints) > 0 && opts.Secret == "" {
+		return nil, errors.New("a webhook secret is required to sign deliveries")
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 1
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+	return &WebhookService{
+		repo:        repo,
+		submissions: submissions,
+		opts:        opts,
+		client:      &http.Client{Timeout: opts.Timeout},
+	}, nil
+}
+
+// SignWebhookPayload returns the signature sent in X-Webhook-Signature:
+// "sha256=" followed by the hex HMAC-SHA256 of "<timestamp>.<body>".
+// Receivers should recompute it and reject stale timestamps.
+func SignWebhookPayload(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Publish records one delivery per endpoint and sends them in the background.
+// Deliveries that fail are retried by RunDue.
+func (s *WebhookService) Publish(ctx context.Context, eventType string, submissionID int64) error {
+	if len(s.opts.Endpoints) == 0 {
+		return nil
+	}
+
+	eventID := newEventID()
+	for _, endpoint := range s.opts.Endpoints {
+		delivery := &models.WebhookDelivery{
+			Endpoint:     endpoint,
+			EventID:      eventID,
+			EventType:    eventType,
+			SubmissionID: submissionID,
+		}
+		if err := s.repo.CreateDelivery(ctx, delivery); err != nil {
+			return err
+		}
+	}
+	go func() {
+		if err := s.RunDue(context.Background()); err != nil {
+			log.Printf("Error sending webhooks: %v", err)
+		}
+	}()
+	return nil
+}
+
+// ListDeliveries returns the delivery log, optionally filtered by endpoint
+func (s *WebhookService) ListDeliveries(ctx context.Context, endpoint string, limit, offset int) ([]models.WebhookDelivery, error) {
+	return s.repo.ListDeliveries(ctx, endpoint, limit, offset)
+}
+
+// Redeliver sends a logged delivery again, once, and returns its updated
+// state. The delivery is claimed first, so a pending one that is being sent
+// or waits for its retry is refused with repository.ErrDeliveryLeased.
+func (s *WebhookService) Redeliver(ctx context.Context, id int64) (*models.WebhookDelivery, error) {
+	delivery, err := s.repo.ClaimDelivery(ctx, id, s.lease())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.attempt(ctx, *delivery, true); err != nil {
+		log.Printf("Webhook redelivery %d to %s failed: %v", delivery.ID, delivery.Endpoint, err)
+	}
+	return s.repo.GetDelivery(ctx, id)
+}
+
+// lease is how long a claimed delivery is kept from other workers. It
+// outlasts one attempt, so a delivery is only claimed again if its worker died.
+func (s *WebhookService) lease() time.Duration {
+	return s.opts.Timeout + time.Minute
+}
+
+func (s *WebhookService) RunDue(ctx context.Context) error {
+	for {
+		deliveries, err := s.repo.ClaimDueDeliveries(ctx, webhookClaimBatch, s.lease())
+		if err != nil {
+			return err
+		}
+		var wg sync.WaitGroup
+		for _, delivery := range deliveries {
+			wg.Add(1)
+			go func(delivery models.WebhookDelivery) {
+				defer wg.Done()
+				final := delivery.Attempts+1 >= s.opts.MaxAttempts
+				if err := s.attempt(ctx, delivery, final); err != nil {
+					log.Printf("Webhook delivery %d to %s failed (attempt %d/%d): %v", delivery.ID, delivery.Endpoint, delivery.Attempts+1, s.opts.MaxAttempts, err)
+				}
+			}(delivery)
+		}
+		wg.Wait()
+		if len(deliveries) < webhookClaimBatch {
+			return nil
+		}
+	}
+}
+
+// backoff returns the delay before the retry following attempt number attempts
+func (s *WebhookService) backoff(attempts int) time.Duration {
+	delay := s.opts.BaseBackoff
+	for i := 1; i < attempts && delay < maxWebhookBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, maxWebhookBackoff)
+}
+
+// attempt POSTs the delivery once and logs the outcome. A failure is recorded
+// as pending, with the time of the next attempt, unless final is set, in
+// which case the delivery is marked failed. A delivery whose submission is
+// gone fails at once.
+func (s *WebhookService) attempt(ctx context.Context, delivery models.WebhookDelivery, final bool) error {
+	statusCode, err := s.send(ctx, delivery)
+	if errors.Is(err, repository.ErrNotFound) {
+		err = fmt.Errorf("submission %d no longer exists", delivery.SubmissionID)
+		final = true
+	}
+
+	status := models.WebhookDeliverySucceeded
+	var errMsg *string
+	var next *time.Time
+	if err != nil {
+		status = models.WebhookDeliveryPending
+		if final {
+			status = models.WebhookDeliveryFailed
+		} else {
+			at := time.Now().Add(s.backoff(delivery.Attempts + 1))
+			next = &at
+		}
+		msg := err.Error()
+		errMsg = &msg
+	}
+
+	var code *int
+	if statusCode != 0 {
+		code = &statusCode
+	}
+	if recErr := s.repo.RecordAttempt(context.Background(), delivery.ID, status, code, errMsg, next); recErr != nil {
+		log.Printf("Error recording webhook attempt: %v", recErr)
+	}
+	return err
+}
+
+// payload builds the event of a delivery from the submission as it is
+// stored now. The event ID and date are those of the publication, so
+// receivers can deduplicate retries.
+func (s *WebhookService) payload(ctx context.Context, delivery models.WebhookDelivery) ([]byte, error) {
+	submission, err := s.submissions.GetSubmission(ctx, delivery.SubmissionID)
+	if err != nil {
+		return nil, err
+	}
+	event := models.WebhookEvent{
+		ID:        delivery.EventID,
+		Type:      delivery.EventType,
+		CreatedAt: delivery.CreatedAt.UTC(),
+		Data:      map[string]interface{}{"submission": submission},
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("unable to encode webhook event: %w", err)
+	}
+	return payload, nil
+}
+
+func (s *WebhookService) send(ctx context.Context, delivery models.WebhookDelivery) (int, error) {
+	payload, err := s.payload(ctx, delivery)
+	if err != nil {
+		return 0, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "portfolio-backend-webhooks")
+	req.Header.Set(WebhookEventHeader, delivery.EventType)
+	req.Header.Set(WebhookDeliveryHeader, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(WebhookTimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(s.opts.Secret, timestamp, payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+func newEventID() string {
+	b := make([]byte, 12)
+	_, _ = rand.Read(b)
+	return "evt_" + hex.EncodeToString(b)
+}
@@ -0,0 +1,209 @@
+package site
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+
+	"backend/internal/models"
+)
+
+// SitemapURL is a page of the sitemap. LastMod is omitted when zero.
+type SitemapURL struct {
+	Path    string
+	LastMod time.Time
+}
+
+type sitemapURLSet struct {
+	XMLName xml.Name        `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 urlset"`
+	URLs    []sitemapURLXML `xml:"url"`
+}
+
+type sitemapURLXML struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+// Sitemap renders sitemap.xml (sitemaps.org protocol) for the pages of info.URL
+func Sitemap(info Info, urls []SitemapURL) ([]byte, error) {
+	set := sitemapURLSet{}
+	for _, u := range urls {
+		entry := sitemapURLXML{Loc: info.URL + u.Path}
+		if !u.LastMod.IsZero() {
+			entry.LastMod = u.LastMod.UTC().Format(time.RFC3339)
+		}
+		set.URLs = append(set.URLs, entry)
+	}
+	return marshalXML(set)
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Author  atomAuthor  `xml:"author"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomEntry struct {
+	Title      string         `xml:"title"`
+	ID         string         `xml:"id"`
+	Link       atomLink       `xml:"link"`
+	Published  string         `xml:"published"`
+	Updated    string         `xml:"updated"`
+	Summary    string         `xml:"summary,omitempty"`
+	Categories []atomCategory `xml:"category"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+// AtomFeed renders an Atom feed of entries, served at feedPath
+func AtomFeed(info Info, feedPath string, entries []models.ContentEntry) ([]byte, error) {
+	updated := latestUpdate(entries)
+	if updated.IsZero() {
+		updated = time.Now()
+	}
+	feed := atomFeed{
+		Title:   info.Name,
+		ID:      info.URL + "/",
+		Updated: updated.UTC().Format(time.RFC3339),
+		Links: []atomLink{
+			{Href: info.URL + "/"},
+			{Href: info.URL + feedPath, Rel: "self", Type: "application/atom+xml"},
+		},
+		Author: atomAuthor{Name: info.Name},
+	}
+	for _, e := range entries {
+		entry := atomEntry{
+			Title:     e.Title,
+			ID:        info.URL + e.Path,
+			Link:      atomLink{Href: info.URL + e.Path},
+			Published: e.PublishedAt.UTC().Format(time.RFC3339),
+			Updated:   e.UpdatedAt.UTC().Format(time.RFC3339),
+			Summary:   e.Summary,
+		}
+		for _, tag := range e.Tags {
+			entry.Categories = append(entry.Categories, atomCategory{Term: tag})
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+	return marshalXML(feed)
+}
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Atom    string     `xml:"xmlns:atom,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	Language      string    `xml:"language"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Self          rssSelf   `xml:"atom:link"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssSelf struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	GUID        string   `xml:"guid"`
+	PubDate     string   `xml:"pubDate"`
+	Description string   `xml:"description,omitempty"`
+	Categories  []string `xml:"category"`
+}
+
+// RSSFeed renders an RSS 2.0 feed of entries, served at feedPath
+func RSSFeed(info Info, feedPath string, entries []models.ContentEntry) ([]byte, error) {
+	channel := rssChannel{
+		Title:       info.Name,
+		Link:        info.URL + "/",
+		Description: info.Description,
+		Language:    "fr",
+		Self:        rssSelf{Href: info.URL + feedPath, Rel: "self", Type: "application/rss+xml"},
+	}
+	if len(entries) > 0 {
+		channel.LastBuildDate = latestUpdate(entries).UTC().Format(time.RFC1123Z)
+	}
+	for _, e := range entries {
+		channel.Items = append(channel.Items, rssItem{
+			Title:       e.Title,
+			Link:        info.URL + e.Path,
+			GUID:        info.URL + e.Path,
+			PubDate:     e.PublishedAt.UTC().Format(time.RFC1123Z),
+			Description: e.Summary,
+			Categories:  e.Tags,
+		})
+	}
+	return marshalXML(rssFeed{Version: "2.0", Atom: "http://www.w3.org/2005/Atom", Channel: channel})
+}
+
+// RobotsOptions configures robots.txt
+type RobotsOptions struct {
+	Disallow []string // path prefixes crawlers should skip
+	NoIndex  bool     // disallow everything, for staging sites
+}
+
+// Robots renders robots.txt, pointing crawlers to the sitemap
+func Robots(info Info, opts RobotsOptions) []byte {
+	var b strings.Builder
+	b.WriteString("User-agent: *\n")
+	if opts.NoIndex {
+		b.WriteString("Disallow: /\n")
+		return []byte(b.String())
+	}
+	b.WriteString("Allow: /\n")
+	for _, path := range opts.Disallow {
+		fmt.Fprintf(&b, "Disallow: %s\n", path)
+	}
+	fmt.Fprintf(&b, "\nSitemap: %s/sitemap.xml\n", info.URL)
+	return []byte(b.String())
+}
+
+// latestUpdate returns the most recent update of entries, zero when empty
+func latestUpdate(entries []models.ContentEntry) time.Time {
+	var latest time.Time
+	for _, e := range entries {
+		if e.UpdatedAt.After(latest) {
+			latest = e.UpdatedAt
+		}
+	}
+	return latest
+}
+
+func marshalXML(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return nil, fmt.Errorf("unable to encode XML: %w", err)
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
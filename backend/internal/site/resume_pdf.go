@@ -0,0 +1,213 @@
+package site
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	"backend/internal/models"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// PDF layout, in millimetres on an A4 page
+const (
+	pdfMargin     = 18.0
+	pdfLineHeight = 5.0
+)
+
+// ResumePDF renders the résumé as a one-column A4 document with the core
+// Helvetica font. Text is encoded as Windows-1252, which covers French;
+// other characters are replaced. modified is written as the creation and
+// modification dates, so the same profile always gives the same bytes.
+func ResumePDF(resume models.Resume, modified time.Time) ([]byte, error) {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(pdfMargin, pdfMargin, pdfMargin)
+	pdf.SetAutoPageBreak(true, pdfMargin)
+	pdf.SetCreationDate(modified)
+	pdf.SetModificationDate(modified)
+	pdf.SetCatalogSort(true)
+	tr := pdf.UnicodeTranslatorFromDescriptor("cp1252")
+	pdf.SetTitle(tr("CV - "+resume.Basics.Name), false)
+	pdf.SetAuthor(tr(resume.Basics.Name), false)
+	pdf.AddPage()
+
+	w := &pdfWriter{pdf: pdf, tr: tr}
+	w.header(resume.Basics)
+	if summary := resume.Basics.Summary; summary != "" {
+		w.section("Profil")
+		for _, p := range strings.Split(strings.ReplaceAll(summary, "\r\n", "\n"), "\n\n") {
+			if p = strings.TrimSpace(p); p != "" {
+				w.paragraph(p)
+			}
+		}
+	}
+	if len(resume.Work) > 0 {
+		w.section("Expérience")
+		for _, job := range resume.Work {
+			w.entry(join(" - ", job.Position, job.Name), Period(job.StartDate, job.EndDate))
+			w.paragraph(job.Summary)
+			w.bullets(job.Highlights)
+		}
+	}
+	if len(resume.Volunteer) > 0 {
+		w.section("Bénévolat")
+		for _, v := range resume.Volunteer {
+			w.entry(join(" - ", v.Position, v.Organization), Period(v.StartDate, v.EndDate))
+			w.paragraph(v.Summary)
+			w.bullets(v.Highlights)
+		}
+	}
+	if len(resume.Education) > 0 {
+		w.section("Formation")
+		for _, e := range resume.Education {
+			w.entry(e.Institution, Period(e.StartDate, e.EndDate))
+			w.paragraph(join(", ", e.StudyType, e.Area, e.Score))
+			w.bullets(e.Courses)
+		}
+	}
+	if len(resume.Projects) > 0 {
+		w.section("Projets")
+		for _, p := range resume.Projects {
+			w.entry(p.Name, Period(p.StartDate, p.EndDate))
+			w.paragraph(p.Description)
+			w.bullets(p.Highlights)
+		}
+	}
+	if len(resume.Skills) > 0 {
+		w.section("Compétences")
+		for _, s := range resume.Skills {
+			w.item(s.Name, join(", ", s.Description, strings.Join(s.Keywords, ", ")))
+		}
+	}
+	if len(resume.Certificates) > 0 {
+		w.section("Certifications")
+		for _, c := range resume.Certificates {
+			w.item(c.Name, join(", ", c.Issuer, Period(c.Date, c.Date)))
+		}
+	}
+	if len(resume.Awards) > 0 {
+		w.section("Distinctions")
+		for _, a := range resume.Awards {
+			w.item(a.Title, join(", ", a.Awarder, Period(a.Date, a.Date)))
+		}
+	}
+	if len(resume.Publications) > 0 {
+		w.section("Publications")
+		for _, p := range resume.Publications {
+			w.item(p.Name, join(", ", p.Publisher, Period(p.ReleaseDate, p.ReleaseDate)))
+		}
+	}
+	if len(resume.Languages) > 0 {
+		w.section("Langues")
+		for _, l := range resume.Languages {
+			w.item(l.Language, l.Fluency)
+		}
+	}
+	if len(resume.Interests) > 0 {
+		w.section("Centres d'intérêt")
+		for _, i := range resume.Interests {
+			w.item(i.Name, strings.Join(i.Keywords, ", "))
+		}
+	}
+	if len(resume.References) > 0 {
+		w.section("Références")
+		for _, r := range resume.References {
+			w.item(r.Name, r.Reference)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, fmt.Errorf("unable to render profile PDF: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// pdfWriter lays out the blocks of the résumé
+type pdfWriter struct {
+	pdf *fpdf.Fpdf
+	tr  func(string) string
+}
+
+func (w *pdfWriter) header(b models.ResumeBasics) {
+	w.pdf.SetFont("Helvetica", "B", 22)
+	w.pdf.CellFormat(0, 10, w.tr(b.Name), "", 1, "L", false, 0, "")
+	if b.Label != "" {
+		w.pdf.SetFont("Helvetica", "", 12)
+		w.pdf.SetTextColor(80, 80, 80)
+		w.pdf.MultiCell(0, 6, w.tr(b.Label), "", "L", false)
+	}
+
+	var contact []string
+	if b.Location != nil {
+		contact = append(contact, join(", ", b.Location.City, b.Location.Region, b.Location.CountryCode))
+	}
+	contact = append(contact, b.Email, b.Phone, b.URL)
+	for _, p := range b.Profiles {
+		contact = append(contact, p.URL)
+	}
+	if line := join(" | ", contact...); line != "" {
+		w.pdf.SetFont("Helvetica", "", 9)
+		w.pdf.MultiCell(0, pdfLineHeight, w.tr(line), "", "L", false)
+	}
+	w.pdf.SetTextColor(0, 0, 0)
+	w.pdf.Ln(2)
+}
+
+func (w *pdfWriter) section(title string) {
+	w.pdf.Ln(3)
+	w.pdf.SetFont("Helvetica", "B", 13)
+	w.pdf.CellFormat(0, 7, w.tr(title), "B", 1, "L", false, 0, "")
+	w.pdf.Ln(2)
+}
+
+// entry writes a title with its period aligned right
+func (w *pdfWriter) entry(title, period string) {
+	w.pdf.SetFont("Helvetica", "", 9)
+	periodWidth := w.pdf.GetStringWidth(w.tr(period)) + 2
+	w.pdf.SetFont("Helvetica", "B", 11)
+	pageWidth, _ := w.pdf.GetPageSize()
+	w.pdf.CellFormat(pageWidth-2*pdfMargin-periodWidth, 6, w.tr(title), "", 0, "L", false, 0, "")
+	w.pdf.SetFont("Helvetica", "", 9)
+	w.pdf.CellFormat(periodWidth, 6, w.tr(period), "", 1, "R", false, 0, "")
+}
+
+func (w *pdfWriter) paragraph(text string) {
+	if text == "" {
+		return
+	}
+	w.pdf.SetFont("Helvetica", "", 10)
+	w.pdf.MultiCell(0, pdfLineHeight, w.tr(text), "", "L", false)
+	w.pdf.Ln(1)
+}
+
+func (w *pdfWriter) bullets(items []string) {
+	w.pdf.SetFont("Helvetica", "", 10)
+	for _, item := range items {
+		w.pdf.MultiCell(0, pdfLineHeight, w.tr("- "+item), "", "L", false)
+	}
+}
+
+// item writes a bold name followed by its details on one line
+func (w *pdfWriter) item(name, details string) {
+	w.pdf.SetFont("Helvetica", "B", 10)
+	w.pdf.Write(pdfLineHeight, w.tr(name))
+	if details != "" {
+		w.pdf.SetFont("Helvetica", "", 10)
+		w.pdf.Write(pdfLineHeight, w.tr(" : "+details))
+	}
+	w.pdf.Ln(pdfLineHeight)
+}
+
+// join joins the non-empty parts with sep
+func join(sep string, parts ...string) string {
+	var out []string
+	for _, p := range parts {
+		if p != "" {
+			out = append(out, p)
+		}
+	}
+	return strings.Join(out, sep)
+}
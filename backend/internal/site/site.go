@@ -0,0 +1,320 @@
+// Package site renders the public pages of the portfolio server-side from
+// html/template layouts, partials and per-page data.
+package site
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+	"unicode"
+
+	"backend/internal/models"
+)
+
+//go:embed templates content
+var embedded embed.FS
+
+// Page names accepted by Render
+const (
+	PageIndex    = "index"
+	PageProjects = "projects"
+	PageProject  = "project"
+	PageArticles = "articles"
+	PageArticle  = "article"
+	PageAbout    = "about"
+	PageContact  = "contact"
+	PagePrivacy  = "privacy"
+	PageNotFound = "not-found"
+)
+
+// Info describes the site itself: who it belongs to and where it is served
+type Info struct {
+	Name        string
+	Tagline     string
+	Description string
+	URL         string // public URL without trailing slash, for canonical links
+	Email       string
+	GitHubURL   string
+	LinkedInURL string
+}
+
+// Page is what every template receives. Data holds the page-specific
+// content (IndexData, ProjectsData...).
+type Page struct {
+	Site        Info
+	Title       string // document title, the site name is appended
+	Description string // meta description, defaults to Site.Description
+	Path        string // canonical path, such as /projects/proxmox
+	Nav         string // active header entry: index, projects, articles, about or contact
+	Preview     bool   // unpublished content shown through a preview link: not indexed, with a banner
+	Data        any
+}
+
+// Category is a project category with its label and icon
+type Category struct {
+	Slug  string
+	Label string
+	Icon  string
+}
+
+// Categories lists the project categories in the order of the filter buttons
+var Categories = []Category{
+	{Slug: models.ProjectInfrastructure, Label: "Infrastructure", Icon: "fas fa-server"},
+	{Slug: models.ProjectWeb, Label: "Web", Icon: "fas fa-code"},
+	{Slug: models.ProjectSystem, Label: "Système", Icon: "fas fa-cogs"},
+	{Slug: models.ProjectIoT, Label: "IoT", Icon: "fas fa-microchip"},
+}
+
+// Subject is an option of the contact form subject select
+type Subject struct {
+	Value string
+	Label string
+}
+
+// Subjects lists the contact form subjects, in the order of models.ContactSubjects
+var Subjects = []Subject{
+	{Value: "collaboration", Label: "Proposition de collaboration"},
+	{Value: "stage", Label: "Opportunité de stage"},
+	{Value: "question", Label: "Question technique"},
+	{Value: "other", Label: "Autre"},
+}
+
+// IndexData is the data of the home page
+type IndexData struct {
+	Projects []models.Project // featured projects
+	Skills   []models.ResumeSkill
+}
+
+// AboutData is the data of the about page, rendered from the profile
+type AboutData struct {
+	Resume models.Resume
+	CVURL  string // generated PDF of the same profile
+}
+
+// ProjectsData is the data of the project list page
+type ProjectsData struct {
+	Projects     []models.Project
+	Total        int
+	Categories   []Category
+	Technologies []models.Technology
+	Category     string // selected filters
+	Technology   string
+	PrevURL      string // pagination links, empty on the first and last pages
+	NextURL      string
+}
+
+// ProjectData is the data of a project page
+type ProjectData struct {
+	Project *models.Project
+}
+
+// ArticlesData is the data of the article list page
+type ArticlesData struct {
+	Articles []models.Article
+	Total    int
+	Tags     []models.ArticleTag
+	Tag      string // selected tag
+	PrevURL  string // pagination links, empty on the first and last pages
+	NextURL  string
+}
+
+// ArticleData is the data of an article page
+type ArticleData struct {
+	Article *models.Article
+}
+
+// Body is the rendered article. It is trusted as is: the Markdown renderer
+// drops raw HTML and unsafe links.
+func (d ArticleData) Body() template.HTML {
+	return template.HTML(d.Article.HTML)
+}
+
+// ContactData is the data of the contact page
+type ContactData struct {
+	Form     models.ContactForm
+	Subjects []Subject
+	Sent     bool
+	Error    string
+}
+
+// PrivacyData is the data of the page opened from a privacy verification
+// link. Token is empty once the link is used or when it is invalid.
+type PrivacyData struct {
+	Token  string
+	Erased bool
+	Error  string
+}
+
+// ExportURL is the download link of the data in format (json or zip)
+func (d PrivacyData) ExportURL(format string) string {
+	return "/api/v1/privacy/export?" + url.Values{"token": {d.Token}, "format": {format}}.Encode()
+}
+
+// NotFoundData replaces the status and message of the not-found page, for
+// content that is gone rather than missing
+type NotFoundData struct {
+	Status  int
+	Message string
+}
+
+// Options configures a Renderer
+type Options struct {
+	Dir  string // directory with templates/ and content/ overriding the embedded ones
+	Site Info
+}
+
+// Renderer holds the parsed page templates. It is safe for concurrent use.
+type Renderer struct {
+	site  Info
+	pages map[string]*template.Template
+}
+
+// NewRenderer parses every page with the shared layouts and partials
+func NewRenderer(opts Options) (*Renderer, error) {
+	var fsys fs.FS = embedded
+	if opts.Dir != "" {
+		fsys = os.DirFS(opts.Dir)
+	}
+
+	r := &Renderer{site: opts.Site, pages: make(map[string]*template.Template)}
+	shared, err := template.New("").Funcs(funcs(opts.Site)).ParseFS(fsys, "templates/layouts/*.html", "templates/partials/*.html")
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse layouts: %w", err)
+	}
+	pages, err := fs.Glob(fsys, "templates/pages/*.html")
+	if err != nil {
+		return nil, fmt.Errorf("unable to list pages: %w", err)
+	}
+	for _, file := range pages {
+		tmpl, err := shared.Clone()
+		if err != nil {
+			return nil, fmt.Errorf("unable to clone layouts: %w", err)
+		}
+		if _, err := tmpl.ParseFS(fsys, file); err != nil {
+			return nil, fmt.Errorf("unable to parse page %s: %w", file, err)
+		}
+		r.pages[strings.TrimSuffix(path.Base(file), ".html")] = tmpl
+	}
+	for _, name := range []string{PageIndex, PageProjects, PageProject, PageArticles, PageArticle, PageAbout, PageContact, PagePrivacy, PageNotFound} {
+		if r.pages[name] == nil {
+			return nil, fmt.Errorf("missing page template %q", name)
+		}
+	}
+	return r, nil
+}
+
+// Site returns the site information given to every page
+func (r *Renderer) Site() Info {
+	return r.site
+}
+
+// LoadResume reads content/profile.json from dir, or from the embedded
+// content when dir is empty. It is the profile served until one is saved.
+func LoadResume(dir string) (models.Resume, error) {
+	var fsys fs.FS = embedded
+	if dir != "" {
+		fsys = os.DirFS(dir)
+	}
+	var resume models.Resume
+	data, err := fs.ReadFile(fsys, "content/profile.json")
+	if err != nil {
+		return resume, fmt.Errorf("unable to read default profile: %w", err)
+	}
+	if err := json.Unmarshal(data, &resume); err != nil {
+		return resume, fmt.Errorf("unable to parse default profile: %w", err)
+	}
+	return resume, nil
+}
+
+// Render executes the named page into w. The page is rendered into a buffer
+// first so that a template error never leaves a half-written response.
+func (r *Renderer) Render(w io.Writer, name string, page Page) error {
+	tmpl, ok := r.pages[name]
+	if !ok {
+		return fmt.Errorf("unknown page %q", name)
+	}
+	page.Site = r.site
+	if page.Description == "" {
+		page.Description = r.site.Description
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, "base", page); err != nil {
+		return fmt.Errorf("unable to render page %s: %w", name, err)
+	}
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+func funcs(info Info) template.FuncMap {
+	return template.FuncMap{
+		"canonical": func(p string) string { return info.URL + p },
+		"year":      func() int { return time.Now().Year() },
+		"initials": func(name string) string {
+			var out []rune
+			for _, word := range strings.Fields(name) {
+				out = append(out, unicode.ToUpper([]rune(word)[0]))
+			}
+			return string(out)
+		},
+		"categories": func() []Category { return Categories },
+		"paragraphs": func(s string) []string {
+			var out []string
+			for _, p := range strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n\n") {
+				if p = strings.TrimSpace(p); p != "" {
+					out = append(out, p)
+				}
+			}
+			return out
+		},
+		"category": func(slug string) Category {
+			for _, c := range Categories {
+				if c.Slug == slug {
+					return c
+				}
+			}
+			return Category{Slug: slug, Label: slug, Icon: "fas fa-folder"}
+		},
+		"period": Period,
+		"date": func(t *time.Time) string {
+			if t == nil {
+				return ""
+			}
+			return t.Format("02/01/2006")
+		},
+	}
+}
+
+// Period formats the ISO 8601 dates of a résumé entry as "2021 - 2023",
+// "09/2023 - Présent" or a single date when both ends match
+func Period(start, end string) string {
+	start, end = resumeDate(start), resumeDate(end)
+	switch {
+	case start == "":
+		return end
+	case end == "":
+		return start + " - Présent"
+	case start == end:
+		return start
+	}
+	return start + " - " + end
+}
+
+// resumeDate turns "2023-09-01" into "01/09/2023" and "2023-09" into
+// "09/2023"; years are kept as is
+func resumeDate(d string) string {
+	parts := strings.Split(d, "-")
+	for i, j := 0, len(parts)-1; i < j; i, j = i+1, j-1 {
+		parts[i], parts[j] = parts[j], parts[i]
+	}
+	return strings.Join(parts, "/")
+}
@@ -0,0 +1,206 @@
+// Package textdiff compares two texts line by line and formats the result
+// as a unified diff, to review content revisions.
+package textdiff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// contextLines is the number of unchanged lines shown around each change
+const contextLines = 3
+
+// maxEdits bounds the work of the diff: texts that differ by more lines are
+// shown as entirely replaced
+const maxEdits = 1000
+
+type opKind byte
+
+const (
+	opEqual  opKind = ' '
+	opDelete opKind = '-'
+	opInsert opKind = '+'
+)
+
+type op struct {
+	kind opKind
+	line string
+}
+
+// Unified returns the unified diff from a to b, labelled fromName and
+// toName, or "" when the texts are equal
+func Unified(fromName, toName, a, b string) string {
+	if a == b {
+		return ""
+	}
+	ops := diffLines(splitLines(a), splitLines(b))
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromName, toName)
+	// Line numbers (1-based) in a and b of each op
+	aLine, bLine := make([]int, len(ops)), make([]int, len(ops))
+	for i, x, y := 0, 1, 1; i < len(ops); i++ {
+		aLine[i], bLine[i] = x, y
+		if ops[i].kind != opInsert {
+			x++
+		}
+		if ops[i].kind != opDelete {
+			y++
+		}
+	}
+
+	for i := 0; i < len(ops); {
+		if ops[i].kind == opEqual {
+			i++
+			continue
+		}
+		// A hunk runs from the context before this change to the context
+		// after the last change closer than two contexts
+		start := max(i-contextLines, 0)
+		end := i
+		for j := i; j < len(ops); j++ {
+			if ops[j].kind != opEqual {
+				end = j + 1
+			} else if j-end >= 2*contextLines {
+				break
+			}
+		}
+		end = min(end+contextLines, len(ops))
+
+		aCount, bCount := 0, 0
+		for _, o := range ops[start:end] {
+			if o.kind != opInsert {
+				aCount++
+			}
+			if o.kind != opDelete {
+				bCount++
+			}
+		}
+		fmt.Fprintf(&out, "@@ -%s +%s @@\n", hunkRange(aLine[start], aCount), hunkRange(bLine[start], bCount))
+		for _, o := range ops[start:end] {
+			out.WriteByte(byte(o.kind))
+			out.WriteString(o.line)
+			out.WriteByte('\n')
+		}
+		i = end
+	}
+	return out.String()
+}
+
+// hunkRange formats the start,count of a hunk side; an empty side starts at
+// the line before it
+func hunkRange(start, count int) string {
+	if count == 0 {
+		start--
+	}
+	if count == 1 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(strings.ReplaceAll(s, "\r\n", "\n"), "\n"), "\n")
+}
+
+// diffLines computes a shortest edit script with Myers' algorithm
+func diffLines(a, b []string) []op {
+	// Common prefix and suffix are kept out of the search
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	ops := make([]op, 0, len(a)+len(b))
+	for _, line := range a[:prefix] {
+		ops = append(ops, op{opEqual, line})
+	}
+	ops = append(ops, myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, op{opEqual, line})
+	}
+	return ops
+}
+
+func myers(a, b []string) []op {
+	n, m := len(a), len(b)
+	limit := min(n+m, maxEdits)
+	offset := limit + 1
+	v := make([]int, 2*offset+1)
+	// trace[d] holds v[-d..d] as it was before round d
+	var trace [][]int
+	for d := 0; d <= limit; d++ {
+		trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				return backtrack(trace, a, b)
+			}
+		}
+	}
+
+	// Too many differences: replace everything
+	ops := make([]op, 0, n+m)
+	for _, line := range a {
+		ops = append(ops, op{opDelete, line})
+	}
+	for _, line := range b {
+		ops = append(ops, op{opInsert, line})
+	}
+	return ops
+}
+
+func backtrack(trace [][]int, a, b []string) []op {
+	var ops []op
+	x, y := len(a), len(b)
+	for d := len(trace) - 1; d >= 0; d-- {
+		at := func(k int) int { return trace[d][k+d] }
+		k := x - y
+		prevK := k - 1
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		}
+		prevX := 0
+		if d > 0 {
+			prevX = at(prevK)
+		}
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			ops = append(ops, op{opEqual, a[x-1]})
+			x--
+			y--
+		}
+		if d == 0 {
+			break
+		}
+		if x == prevX {
+			ops = append(ops, op{opInsert, b[y-1]})
+			y--
+		} else {
+			ops = append(ops, op{opDelete, a[x-1]})
+			x--
+		}
+	}
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
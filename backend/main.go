@@ -1,15 +1,24 @@
 package main
 
 import (
+	"context"
 	"log"
+	"net/http"
+	"os"
 	"strings"
+	"time"
 
 	"backend/api"
 	"backend/api/handlers"
 	"backend/config"
+	"backend/internal/encryption"
+	"backend/internal/geoip"
+	"backend/internal/media"
 	"backend/internal/middleware"
+	"backend/internal/models"
 	"backend/internal/repository"
 	"backend/internal/services"
+	"backend/internal/site"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -28,8 +37,39 @@ func main() {
 	}
 	defer pool.Close()
 
+	// Encryption of submissions at rest, enabled when keys are configured
+	keyring, err := encryption.LoadKeyring(encryption.Options{
+		Keys:          cfg.EncryptionKeys,
+		KeysFile:      cfg.EncryptionKeysFile,
+		ActiveVersion: cfg.EncryptionActiveKey,
+		IndexKey:      cfg.EncryptionIndexKey,
+		IndexKeyFile:  cfg.EncryptionIndexKeyFile,
+	})
+	if err != nil {
+		log.Fatalf("Error loading encryption keys: %v", err)
+	}
+	var submissionOpts []repository.Option
+	if keyring != nil {
+		submissionOpts = append(submissionOpts, repository.WithKeyring(keyring))
+		log.Printf("Submissions are encrypted with key version %d", keyring.ActiveVersion())
+	}
+
+	// "reencrypt" rewrites stored submissions with the active key, then exits
+	if len(os.Args) > 1 && os.Args[1] == "reencrypt" {
+		code := runReencrypt(pool, keyring, os.Args[2:])
+		pool.Close()
+		os.Exit(code)
+	}
+
 	// Initialize repositories and services
-	contactRepo := repository.NewContactRepository(pool)
+	contactRepo := repository.NewContactRepository(pool, submissionOpts...)
+	idempotencyRepo := repository.NewIdempotencyRepository(pool)
+	webhookRepo := repository.NewWebhookRepository(pool)
+	inboxRepo := repository.NewInboxRepository(pool, submissionOpts...)
+	conversationRepo := repository.NewConversationRepository(pool, submissionOpts...)
+	privacyRepo := repository.NewPrivacyRepository(pool, submissionOpts...)
+	blocklistRepo := repository.NewBlocklistRepository(pool)
+	projectRepo := repository.NewProjectRepository(pool)
 
 	emailService := services.NewSMTPService(
 		cfg.SmtpHost,
@@ -39,15 +79,247 @@ func main() {
 		cfg.AdminEmail,
 	)
 
+	notifiers, err := buildNotifiers(cfg, emailService)
+	if err != nil {
+		log.Fatalf("Error configuring notification channels: %v", err)
+	}
+	dispatcher := services.NewNotificationDispatcher(notifiers...)
+	log.Printf("Notification channels: %s", strings.Join(dispatcher.Channels(), ", "))
+
+	// Routing rules come from ROUTING_RULES_FILE when set, from the database otherwise
+	var ruleSource services.RoutingRuleSource = repository.NewRoutingRuleRepository(pool)
+	if cfg.RoutingRulesFile != "" {
+		ruleSource = services.NewFileRoutingRuleSource(cfg.RoutingRulesFile)
+	}
+	routingEngine := services.NewRoutingEngine(ruleSource)
+	if err := routingEngine.Refresh(context.Background()); err != nil {
+		log.Printf("Error loading routing rules, using default routing: %v", err)
+	}
+
+	webhookService, err := services.NewWebhookService(webhookRepo, inboxRepo, services.WebhookOptions{
+		Endpoints:   cfg.WebhookURLs,
+		Secret:      cfg.WebhookSecret,
+		MaxAttempts: cfg.WebhookMaxAttempts,
+		Timeout:     cfg.WebhookTimeout,
+		BaseBackoff: cfg.WebhookBackoff,
+	})
+	if err != nil {
+		log.Fatalf("Error configuring webhooks: %v", err)
+	}
+
+	ipAnonymizer, err := services.NewIPAnonymizer(cfg.ClientIPMode, cfg.ClientIPSalt)
+	if err != nil {
+		log.Fatalf("Error configuring client metadata: %v", err)
+	}
+	var geoLocator services.GeoLocator
+	if len(cfg.GeoIPDatabases) > 0 {
+		locator, err := geoip.Open(cfg.GeoIPDatabases...)
+		if err != nil {
+			log.Fatalf("Error loading GeoIP databases: %v", err)
+		}
+		geoLocator = locator
+		go services.RunPeriodic(context.Background(), "geoip-reload", cfg.GeoIPReloadInterval, locator.Reload)
+	}
+	blocklistService := services.NewBlocklistService(blocklistRepo, services.BanOptions{
+		Strikes:  cfg.BanStrikes,
+		Window:   cfg.BanWindow,
+		Duration: cfg.BanDuration,
+	})
+	if err := blocklistService.Refresh(context.Background()); err != nil {
+		log.Printf("Error loading block rules: %v", err)
+	}
+	rateLimiter := services.NewRateLimiter(geoLocator, services.RateLimitOptions{
+		Default:         services.RateLimitPolicy{Limit: cfg.RateLimit, Window: cfg.RateLimitWindow},
+		Strict:          services.RateLimitPolicy{Limit: cfg.RateLimitStrict, Window: cfg.RateLimitWindow},
+		StrictASNs:      cfg.RateLimitStrictASNs,
+		StrictCountries: cfg.RateLimitStrictCountries,
+		OnLimited: func(ip string) {
+			blocklistService.Strike(context.Background(), ip, "rate limited")
+		},
+	})
+	go services.RunPeriodic(context.Background(), "rate-limit-prune", cfg.RateLimitWindow, rateLimiter.Prune)
+
+	emailVerifier, err := services.NewEmailVerifier(services.EmailVerifierOptions{
+		Mode:           cfg.EmailCheckMode,
+		DisposableFile: cfg.EmailDisposableFile,
+		CheckDNS:       cfg.EmailCheckDNS,
+		DNSServer:      cfg.EmailDNSServer,
+		DNSTimeout:     cfg.EmailDNSTimeout,
+		DNSCacheTTL:    cfg.EmailDNSCacheTTL,
+	})
+	if err != nil {
+		log.Fatalf("Error configuring email checks: %v", err)
+	}
+	go services.RunPeriodic(context.Background(), "disposable-domains-reload", cfg.EmailDisposableReload, emailVerifier.Reload)
+
 	// Initialize handlers
-	contactService := services.NewContactService(contactRepo, emailService)
+	contactService := services.NewContactService(contactRepo, dispatcher,
+		services.WithDedupeWindow(cfg.ContactDedupeWindow),
+		services.WithWebhooks(webhookService),
+		services.WithRouting(routingEngine),
+		services.WithClientIP(ipAnonymizer),
+		services.WithGeoIP(geoLocator),
+		services.WithBlocklist(blocklistService),
+		services.WithEmailVerifier(emailVerifier),
+	)
 	contactHandler := handlers.NewContactHandler(contactService)
+	webhookHandler := handlers.NewWebhookHandler(webhookService)
+	inboxService := services.NewInboxService(inboxRepo, cfg.ContactPurgeAfter)
+	inboxHandler := handlers.NewInboxHandler(inboxService)
+	replyFrom := cfg.ReplyFrom
+	if replyFrom == "" {
+		replyFrom = cfg.SmtpUser
+	}
+	conversationService, err := services.NewConversationService(inboxRepo, conversationRepo, emailService, services.ReplyOptions{
+		From:        replyFrom,
+		Domain:      cfg.ReplyMessageIDDomain,
+		Template:    cfg.ReplyTemplate,
+		TokenSecret: cfg.ReplyTokenSecret,
+	})
+	if err != nil {
+		log.Fatalf("Error configuring replies: %v", err)
+	}
+	conversationHandler := handlers.NewConversationHandler(conversationService)
+	privacyLink := cfg.PrivacyLinkURL
+	if privacyLink == "" {
+		privacyLink = cfg.SiteURL + "/privacy"
+	}
+	privacyService, err := services.NewPrivacyService(privacyRepo, inboxRepo, conversationRepo, emailService, services.PrivacyOptions{
+		LinkURL:         privacyLink,
+		TokenTTL:        cfg.PrivacyTokenTTL,
+		From:            replyFrom,
+		ErasureMode:     cfg.ErasureMode,
+		RetentionPeriod: cfg.RetentionPeriod,
+		RetentionMode:   cfg.RetentionMode,
+		AuditKey:        []byte(cfg.PrivacyAuditKey),
+	})
+	if err != nil {
+		log.Fatalf("Error configuring privacy: %v", err)
+	}
+	privacyHandler := handlers.NewPrivacyHandler(privacyService)
+	blocklistHandler := handlers.NewBlocklistHandler(blocklistService)
+	siteInfo := site.Info{
+		Name:        cfg.SiteName,
+		Tagline:     cfg.SiteTagline,
+		Description: cfg.SiteDescription,
+		URL:         cfg.SiteURL,
+		Email:       cfg.SiteEmail,
+		GitHubURL:   cfg.SiteGitHubURL,
+		LinkedInURL: cfg.SiteLinkedInURL,
+	}
+	// The sitemap and feeds are rebuilt after every content change
+	var feedService services.IFeedService
+	contentChanged := func() { feedService.Invalidate() }
+	revisionService := services.NewRevisionService(repository.NewRevisionRepository(pool))
+	projectService := services.NewProjectService(projectRepo,
+		services.WithProjectChanges(contentChanged),
+		services.WithProjectRevisions(revisionService),
+	)
+	// Articles come from ARTICLES_DIR when set, from the database otherwise
+	var articleStore services.ArticleStore = repository.NewArticleRepository(pool)
+	if cfg.ArticlesDir != "" {
+		articleStore = services.NewDirArticleStore(cfg.ArticlesDir)
+	}
+	articleService := services.NewArticleService(articleStore,
+		services.WithArticleChanges(contentChanged),
+		services.WithArticleRevisions(revisionService),
+	)
+	feedService = services.NewFeedService([]services.ContentSource{
+		services.ProjectEntries(projectService),
+		articleService,
+	}, services.FeedOptions{
+		Site:     siteInfo,
+		Pages:    append([]string{"/", "/projects", "/articles", "/about", "/contact"}, cfg.SitemapPages...),
+		Robots:   site.RobotsOptions{Disallow: cfg.RobotsDisallow, NoIndex: cfg.SiteNoIndex},
+		CacheTTL: cfg.FeedCacheTTL,
+		FeedSize: cfg.FeedSize,
+	})
+	if err := articleService.Refresh(context.Background()); err != nil {
+		log.Printf("Error loading articles: %v", err)
+	}
+	projectHandler := handlers.NewProjectHandler(projectService)
+	articleHandler := handlers.NewArticleHandler(articleService)
+	renderer, err := site.NewRenderer(site.Options{Dir: cfg.SiteTemplatesDir, Site: siteInfo})
+	if err != nil {
+		log.Fatalf("Error loading page templates: %v", err)
+	}
+	// content/profile.json is served until the profile is first saved
+	defaultResume, err := site.LoadResume(cfg.SiteTemplatesDir)
+	if err != nil {
+		log.Fatalf("Error loading default profile: %v", err)
+	}
+	profileService := services.NewProfileService(repository.NewProfileRepository(pool), defaultResume,
+		services.WithProfileRevisions(revisionService),
+	)
+	profileHandler := handlers.NewProfileHandler(profileService)
+	previews := services.NewPreviewSigner(cfg.PreviewSecret, cfg.PreviewTTL)
+	pageHandler := handlers.NewPageHandler(renderer, projectService, articleService, profileService, contactService, privacyService, previews)
+	scheduleService := services.NewScheduleService(repository.NewScheduleRepository(pool), map[string]services.ContentPublisher{
+		models.ContentProject: services.ProjectPublisher(projectService),
+		models.ContentArticle: services.ArticlePublisher(articleService),
+	})
+	contentHandler := handlers.NewContentHandler(revisionService, map[string]services.ContentRestorer{
+		models.ContentProject: services.ProjectRestorer(projectService),
+		models.ContentArticle: articleService,
+		models.ContentProfile: profileService,
+	}, scheduleService, previews, cfg.SiteURL)
+	feedHandler := handlers.NewFeedHandler(feedService)
+	mediaStore, err := services.NewDirMediaStore(cfg.MediaDir)
+	if err != nil {
+		log.Fatalf("Error opening media directory: %v", err)
+	}
+	mediaHandler := handlers.NewMediaHandler(services.NewMediaService(repository.NewMediaRepository(pool), mediaStore, services.MediaOptions{
+		Limits: media.Limits{MaxBytes: cfg.MediaMaxBytes, MaxPixels: cfg.MediaMaxPixels},
+		Widths: cfg.MediaVariantWidths,
+	}))
+	shareService := services.NewShareService(repository.NewShareLinkRepository(pool), services.ShareOptions{
+		BaseURL:      cfg.SiteURL,
+		Geo:          geoLocator,
+		HitRetention: cfg.ShareHitRetention,
+	})
+	shareHandler := handlers.NewShareHandler(shareService, renderer)
+
+	// Background jobs
+	go services.RunPeriodic(context.Background(), "webhook-deliveries", cfg.WebhookPollInterval, webhookService.RunDue)
+	go services.RunPeriodic(context.Background(), "routing-rules-refresh", cfg.RoutingRulesRefresh, routingEngine.Refresh)
+	go services.RunPeriodic(context.Background(), "articles-refresh", cfg.ArticlesRefresh, articleService.Refresh)
+	go services.RunPeriodic(context.Background(), "content-scheduler", cfg.SchedulerInterval, scheduleService.RunDue)
+	go services.RunPeriodic(context.Background(), "blocklist-refresh", cfg.BlocklistRefresh, blocklistService.Refresh)
+	go services.RunPeriodic(context.Background(), "blocklist-purge", time.Hour, blocklistService.PurgeExpired)
+	go services.RunPeriodic(context.Background(), "idempotency-purge", time.Hour, func(ctx context.Context) error {
+		_, err := idempotencyRepo.DeleteExpired(ctx, time.Now().Add(-cfg.IdempotencyTTL))
+		return err
+	})
+	go services.RunPeriodic(context.Background(), "contact-purge", time.Hour, func(ctx context.Context) error {
+		_, err := inboxService.PurgeTrashed(ctx)
+		return err
+	})
+	go services.RunPeriodic(context.Background(), "privacy-retention", 24*time.Hour, func(ctx context.Context) error {
+		_, err := privacyService.ApplyRetention(ctx)
+		return err
+	})
+	go services.RunPeriodic(context.Background(), "share-hits-purge", 24*time.Hour, shareService.PurgeHits)
+	if cfg.InboundMaildir != "" {
+		inboundService := services.NewInboundService(inboxRepo, conversationRepo, services.InboundOptions{
+			Domain:      services.MessageIDDomain(replyFrom, cfg.ReplyMessageIDDomain),
+			TokenSecret: cfg.ReplyTokenSecret,
+		})
+		maildir := services.NewMaildirWatcher(cfg.InboundMaildir, inboundService)
+		go services.RunPeriodic(context.Background(), "inbound-maildir", cfg.InboundPollInterval, maildir.Scan)
+	}
 
 	// Ensure Gin runs in release mode in production; set mode before creating the router
 	gin.SetMode(gin.ReleaseMode)
 	router := gin.Default()
 	// Apply security headers middleware to all responses
 	router.Use(middleware.SecurityHeaders())
+	// Reject oversized request bodies before they are decoded. Uploads get
+	// room for the file plus the multipart envelope.
+	router.Use(middleware.BodyLimit(cfg.MaxBodyBytes, middleware.RouteLimit{
+		Method:   http.MethodPost,
+		Path:     "/api/v1/admin/media",
+		MaxBytes: cfg.MediaMaxBytes + cfg.MaxBodyBytes,
+	}))
 
 	// Use trusted proxies from configuration (set via TRUSTED_PROXIES env var).
 	// The config loader provides a default of "127.0.0.1" when unset.
@@ -61,8 +333,9 @@ func main() {
 
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{cfg.FrontendURL, cfg.FrontendURL_Dev, "http://localhost", "http://127.0.0.1"},
-		AllowMethods:     []string{"GET", "POST", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
+		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "If-None-Match", middleware.IdempotencyKeyHeader},
+		ExposeHeaders:    []string{"ETag"},
 		AllowCredentials: true,
 		AllowOriginFunc: func(origin string) bool {
 			// Allow configured origins
@@ -77,10 +350,98 @@ func main() {
 		},
 	}))
 
-	api.RegisterRoutes(router, contactHandler)
+	api.RegisterRoutes(router, api.Handlers{
+		Contact:      contactHandler,
+		Webhook:      webhookHandler,
+		Inbox:        inboxHandler,
+		Conversation: conversationHandler,
+		Privacy:      privacyHandler,
+		Blocklist:    blocklistHandler,
+		Project:      projectHandler,
+		Pages:        pageHandler,
+		Feed:         feedHandler,
+		Article:      articleHandler,
+		Content:      contentHandler,
+		Media:        mediaHandler,
+		Profile:      profileHandler,
+		Share:        shareHandler,
+	}, api.Middlewares{
+		Idempotency:   middleware.Idempotency(idempotencyRepo, cfg.IdempotencyTTL, cfg.IdempotencyLease),
+		AdminAuth:     middleware.AdminAuth(cfg.AdminAPIToken),
+		RateLimit:     middleware.RateLimit(rateLimiter),
+		Blocklist:     middleware.Blocklist(blocklistService, contactHandler.HandleBlocked),
+		PageBlocklist: middleware.Blocklist(blocklistService, pageHandler.HandleContactBlocked),
+	})
 
 	log.Printf("Starting server on port %s...", cfg.Port)
 	if err := router.Run(":" + cfg.Port); err != nil {
 		log.Fatalf("Error starting server: %v", err)
 	}
 }
+
+// buildNotifiers returns the email channel plus every chat/push channel that is configured
+func buildNotifiers(cfg *config.Config, emailService services.IEmailService) ([]services.Notifier, error) {
+	notifiers := []services.Notifier{emailService}
+
+	add := func(n services.Notifier, err error) error {
+		if err != nil {
+			return err
+		}
+		notifiers = append(notifiers, n)
+		return nil
+	}
+
+	if cfg.MatrixHomeserverURL != "" {
+		if err := add(services.NewMatrixNotifier(services.MatrixOptions{
+			HomeserverURL: cfg.MatrixHomeserverURL,
+			AccessToken:   cfg.MatrixAccessToken,
+			RoomID:        cfg.MatrixRoomID,
+			Template:      cfg.MatrixTemplate,
+			Timeout:       cfg.NotifyTimeout,
+		})); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.NtfyURL != "" {
+		if err := add(services.NewNtfyNotifier(services.NtfyOptions{
+			ServerURL: cfg.NtfyURL,
+			Topic:     cfg.NtfyTopic,
+			Token:     cfg.NtfyToken,
+			Template:  cfg.NtfyTemplate,
+			Timeout:   cfg.NotifyTimeout,
+		})); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.GotifyURL != "" {
+		if err := add(services.NewGotifyNotifier(services.GotifyOptions{
+			ServerURL: cfg.GotifyURL,
+			AppToken:  cfg.GotifyToken,
+			Template:  cfg.GotifyTemplate,
+			Timeout:   cfg.NotifyTimeout,
+		})); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.DiscordWebhookURL != "" {
+		if err := add(services.NewDiscordNotifier(services.DiscordOptions{
+			WebhookURL: cfg.DiscordWebhookURL,
+			Template:   cfg.DiscordTemplate,
+			Timeout:    cfg.NotifyTimeout,
+		})); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.TelegramBotToken != "" {
+		if err := add(services.NewTelegramNotifier(services.TelegramOptions{
+			APIURL:   cfg.TelegramAPIURL,
+			BotToken: cfg.TelegramBotToken,
+			ChatID:   cfg.TelegramChatID,
+			Template: cfg.TelegramTemplate,
+			Timeout:  cfg.NotifyTimeout,
+		})); err != nil {
+			return nil, err
+		}
+	}
+	return notifiers, nil
+}
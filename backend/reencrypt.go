@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+
+	"backend/internal/encryption"
+	"backend/internal/repository"
+)
+
+// runReencrypt implements "app reencrypt [-all] [-batch n]": after a new key
+// version is made active, it rewrites the personal data stored in clear or
+// under an older version. Returns the process exit code.
+func runReencrypt(db repository.DBExecutor, keyring *encryption.Keyring, args []string) int {
+	flags := flag.NewFlagSet("reencrypt", flag.ContinueOnError)
+	all := flags.Bool("all", false, "rewrite every row, e.g. after changing the index key")
+	batch := flags.Int("batch", 100, "rows processed per query")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if keyring == nil {
+		log.Print("Re-encryption needs ENCRYPTION_KEYS (or ENCRYPTION_KEYS_FILE) and an index key")
+		return 1
+	}
+
+	count, err := repository.NewKeyRotationRepository(db, keyring).Reencrypt(context.Background(), *all, *batch)
+	log.Printf("Re-encrypted %d rows with key version %d", count, keyring.ActiveVersion())
+	if err != nil {
+		log.Printf("Error re-encrypting personal data: %v", err)
+		return 1
+	}
+	return 0
+}
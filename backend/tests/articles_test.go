@@ -0,0 +1,231 @@
+package tests_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	handlers "backend/api/handlers"
+	"backend/internal/markdown"
+	"backend/internal/models"
+	"backend/internal/repository"
+	"backend/internal/services"
+	"backend/internal/site"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pashagolub/pgxmock/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryArticleStore serves fixed article sources
+type memoryArticleStore []models.ArticleSource
+
+func (m memoryArticleStore) ListArticleSources(ctx context.Context) ([]models.ArticleSource, error) {
+	return m, nil
+}
+
+func TestSplitFrontMatter(t *testing.T) {
+	fm, body, err := markdown.SplitFrontMatter("\ufeff---\r\ntitle: Proxmox\r\ntags: [Homelab, ceph]\r\ndate: 2024-03-01T10:00:00Z\r\n---\r\n# Hello\r\n")
+	assert.NoError(t, err)
+	assert.Equal(t, "Proxmox", fm.Title)
+	assert.Equal(t, []string{"Homelab", "ceph"}, fm.Tags)
+	assert.Equal(t, time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC), fm.Date.UTC())
+	assert.Equal(t, "# Hello\n", body)
+
+	fm, body, err = markdown.SplitFrontMatter("# No front matter\n")
+	assert.NoError(t, err)
+	assert.Empty(t, fm.Title)
+	assert.Equal(t, "# No front matter\n", body)
+
+	_, _, err = markdown.SplitFrontMatter("---\ntitle: x\n# never closed\n")
+	assert.Error(t, err)
+	_, _, err = markdown.SplitFrontMatter("---\ntitle: [\n---\nbody")
+	assert.Error(t, err)
+}
+
+func TestMarkdownRender(t *testing.T) {
+	doc, err := markdown.Render("# Hello world\n\n<script>alert(1)</script>\n\n[x](javascript:alert(1))\n\n## Setup *Ceph*\n\n```go\nfunc main() {}\n```\n")
+	assert.NoError(t, err)
+	assert.NotContains(t, doc.HTML, "<script>", "raw HTML is dropped")
+	assert.NotContains(t, doc.HTML, "javascript:")
+	assert.Contains(t, doc.HTML, `<h1 id="hello-world">Hello world<a href="#hello-world" class="heading-anchor">#</a></h1>`)
+	assert.Contains(t, doc.HTML, `<pre style=`, "code blocks are highlighted")
+	assert.Equal(t, []models.Heading{
+		{Level: 1, ID: "hello-world", Text: "Hello world"},
+		{Level: 2, ID: "setup-ceph", Text: "Setup Ceph"},
+	}, doc.Headings)
+
+	assert.Equal(t, 1, markdown.ReadingMinutes("a few words"))
+	words := make([]byte, 0, 401*2)
+	for range 401 {
+		words = append(words, "w "...)
+	}
+	assert.Equal(t, 3, markdown.ReadingMinutes(string(words)))
+}
+
+func newArticleStore(now time.Time) memoryArticleStore {
+	return memoryArticleStore{
+		{Slug: "ceph", UpdatedAt: now.Add(-48 * time.Hour), Markdown: "---\ntitle: Ceph\ntags: [Homelab, Storage]\ndate: " +
+			now.Add(-24*time.Hour).Format(time.RFC3339) + "\n---\n## Pools\n\nText.\n"},
+		{Slug: "proxmox", UpdatedAt: now.Add(-48 * time.Hour), Markdown: "---\ntitle: Proxmox\nslug: proxmox-cluster\ntags: [homelab]\ndate: " +
+			now.Add(-72*time.Hour).Format(time.RFC3339) + "\n---\nBody.\n"},
+		{Slug: "scheduled", Markdown: "---\ntitle: Soon\ntags: [homelab]\ndate: " + now.Add(time.Hour).Format(time.RFC3339) + "\n---\nSoon.\n"},
+		{Slug: "draft", Markdown: "---\ntitle: Draft\ndraft: true\ndate: 2020-01-01T00:00:00Z\n---\nWIP.\n"},
+		{Slug: "untitled", Markdown: "No front matter.\n"},
+		{Slug: "Bad_Slug", Markdown: "---\ntitle: Bad\ndate: 2020-01-01T00:00:00Z\n---\n"},
+	}
+}
+
+func TestArticleService(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	clock := now
+	changes := 0
+	svc := services.NewArticleService(newArticleStore(now),
+		services.WithArticleClock(func() time.Time { return clock }),
+		services.WithArticleChanges(func() { changes++ }))
+	assert.NoError(t, svc.Refresh(ctx))
+	assert.Equal(t, 1, changes)
+
+	articles, total, err := svc.List(ctx, models.ArticleFilter{})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, total, "drafts, scheduled and invalid articles are hidden")
+	assert.Equal(t, "ceph", articles[0].Slug, "newest first")
+	assert.Equal(t, "proxmox-cluster", articles[1].Slug, "the front matter slug wins")
+	assert.Equal(t, []string{"homelab", "storage"}, articles[0].Tags)
+	assert.Empty(t, articles[0].HTML, "lists carry no body")
+
+	_, total, err = svc.List(ctx, models.ArticleFilter{Tag: "STORAGE"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, total)
+
+	article, err := svc.Get(ctx, "ceph")
+	assert.NoError(t, err)
+	assert.Contains(t, article.HTML, `<h2 id="pools">`)
+	assert.Equal(t, 1, article.ReadingMinutes)
+	assert.Equal(t, now.Add(-24*time.Hour), article.UpdatedAt.UTC(), "a publication after the last edit is the update time")
+	_, err = svc.Get(ctx, "draft")
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+	_, err = svc.Get(ctx, "scheduled")
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	tags, err := svc.Tags(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.ArticleTag{{Tag: "homelab", Articles: 2}, {Tag: "storage", Articles: 1}}, tags)
+
+	// Scheduled articles show up once their date is reached, without a refresh
+	clock = now.Add(2 * time.Hour)
+	_, err = svc.Get(ctx, "scheduled")
+	assert.NoError(t, err)
+	entries, err := svc.PublishedEntries(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 3)
+	assert.Equal(t, "/articles/scheduled", entries[0].Path)
+
+	// Unchanged sources do not report a change
+	assert.NoError(t, svc.Refresh(ctx))
+	assert.Equal(t, 1, changes)
+}
+
+func TestDirArticleStore(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "k3s.md"), []byte("---\ntitle: K3s\n---\nHi\n"), 0o644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("ignored"), 0o644))
+
+	sources, err := services.NewDirArticleStore(dir).ListArticleSources(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, sources, 1)
+	assert.Equal(t, "k3s", sources[0].Slug)
+	assert.False(t, sources[0].UpdatedAt.IsZero())
+}
+
+func TestArticleRepository_ListArticleSources(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	updated := time.Now()
+	mock.ExpectQuery(`SELECT slug, markdown, updated_at FROM articles`).
+		WillReturnRows(pgxmock.NewRows([]string{"slug", "markdown", "updated_at"}).AddRow("ceph", "# Ceph", updated))
+
+	sources, err := repository.NewArticleRepository(mock).ListArticleSources(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []models.ArticleSource{{Slug: "ceph", Markdown: "# Ceph", UpdatedAt: updated}}, sources)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestArticleHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	now := time.Now()
+	svc := services.NewArticleService(newArticleStore(now))
+	assert.NoError(t, svc.Refresh(context.Background()))
+	h := handlers.NewArticleHandler(svc)
+	router := gin.New()
+	router.GET("/articles", h.HandleList)
+	router.GET("/articles/:slug", h.HandleGet)
+	router.GET("/tags", h.HandleTags)
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	w := get("/articles?tag=storage")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotEmpty(t, w.Header().Get("ETag"))
+	var list struct {
+		Articles []models.Article `json:"articles"`
+		Total    int              `json:"total"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	assert.Equal(t, 1, list.Total)
+	assert.Equal(t, "ceph", list.Articles[0].Slug)
+
+	w = get("/articles/ceph")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var one struct {
+		Article models.Article `json:"article"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &one))
+	assert.Equal(t, []models.Heading{{Level: 2, ID: "pools", Text: "Pools"}}, one.Article.Headings)
+	assert.Contains(t, one.Article.HTML, "<p>Text.</p>")
+
+	assert.Equal(t, http.StatusNotFound, get("/articles/draft").Code)
+	assert.Contains(t, get("/tags").Body.String(), `{"tag":"homelab","articles":2}`)
+}
+
+func TestPageHandler_Articles(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	renderer, err := site.NewRenderer(site.Options{Site: site.Info{Name: "Enzo Gaggiotti", URL: "https://example.com"}})
+	assert.NoError(t, err)
+	articles := services.NewArticleService(newArticleStore(time.Now()))
+	assert.NoError(t, articles.Refresh(context.Background()))
+	h := handlers.NewPageHandler(renderer, services.NewProjectService(newMemoryProjectRepository()), articles, newTestProfileService(t), &mockContactService{}, nil, services.NewPreviewSigner("", 0))
+	router := gin.New()
+	router.GET("/articles", h.HandleArticles)
+	router.GET("/articles/:slug", h.HandleArticle)
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	w := get("/articles")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `<a href="/articles/proxmox-cluster">Proxmox</a>`)
+	assert.Contains(t, w.Body.String(), `aria-current="page">Articles</a>`)
+	assert.NotContains(t, w.Body.String(), "Draft")
+
+	w = get("/articles/ceph")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `<h2 id="pools">Pools<a href="#pools" class="heading-anchor">#</a></h2>`, "the rendered body is not escaped again")
+	assert.Contains(t, w.Body.String(), `<link rel="canonical" href="https://example.com/articles/ceph" />`)
+
+	assert.Equal(t, http.StatusNotFound, get("/articles/scheduled").Code)
+	assert.Equal(t, http.StatusNotFound, get("/articles?tag=unknown").Code)
+}
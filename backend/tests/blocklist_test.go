@@ -0,0 +1,376 @@
+package tests_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	handlers "backend/api/handlers"
+	"backend/internal/middleware"
+	"backend/internal/models"
+	"backend/internal/repository"
+	"backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pashagolub/pgxmock/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// in-memory IBlocklistRepository following the upsert rules of the SQL one
+type memoryBlocklistRepository struct {
+	mu     sync.Mutex
+	rules  []models.BlockRule
+	nextID int64
+}
+
+func (r *memoryBlocklistRepository) ListRules(ctx context.Context, includeExpired bool) ([]models.BlockRule, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rules := []models.BlockRule{}
+	for _, rule := range r.rules {
+		if includeExpired || rule.Active(time.Now()) {
+			rules = append(rules, rule)
+		}
+	}
+	return rules, nil
+}
+
+func (r *memoryBlocklistRepository) SaveRule(ctx context.Context, rule *models.BlockRule) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.rules {
+		existing := &r.rules[i]
+		if existing.Kind != rule.Kind || existing.Value != rule.Value {
+			continue
+		}
+		if rule.Source == models.BlockSourceAuto {
+			if existing.ExpiresAt != nil && rule.ExpiresAt.After(*existing.ExpiresAt) {
+				existing.ExpiresAt = rule.ExpiresAt
+			}
+		} else {
+			existing.Reason, existing.Source, existing.ExpiresAt = rule.Reason, rule.Source, rule.ExpiresAt
+		}
+		*rule = *existing
+		return nil
+	}
+	r.nextID++
+	rule.ID, rule.CreatedAt = r.nextID, time.Now()
+	r.rules = append(r.rules, *rule)
+	return nil
+}
+
+func (r *memoryBlocklistRepository) DeleteRule(ctx context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, rule := range r.rules {
+		if rule.ID == id {
+			r.rules = append(r.rules[:i], r.rules[i+1:]...)
+			return nil
+		}
+	}
+	return repository.ErrNotFound
+}
+
+func (r *memoryBlocklistRepository) RecordHit(ctx context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.rules {
+		if r.rules[i].ID == id {
+			r.rules[i].Hits++
+		}
+	}
+	return nil
+}
+
+func (r *memoryBlocklistRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	return 0, nil
+}
+
+func newTestBlocklist(t *testing.T, bans services.BanOptions, inputs ...models.BlockRuleInput) (services.IBlocklistService, *memoryBlocklistRepository) {
+	t.Helper()
+	repo := &memoryBlocklistRepository{}
+	svc := services.NewBlocklistService(repo, bans)
+	for _, input := range inputs {
+		_, err := svc.Create(context.Background(), input)
+		assert.NoError(t, err)
+	}
+	return svc, repo
+}
+
+func TestBlocklistService_Matching(t *testing.T) {
+	past := time.Now().Add(-time.Minute)
+	svc, repo := newTestBlocklist(t, services.BanOptions{},
+		models.BlockRuleInput{Kind: models.BlockIP, Value: "203.0.113.0/24"},
+		models.BlockRuleInput{Kind: models.BlockIP, Value: "2001:db8:1::1"},
+		models.BlockRuleInput{Kind: models.BlockEmail, Value: "Spammer@Example.com"},
+		models.BlockRuleInput{Kind: models.BlockDomain, Value: "@spam.test"},
+		models.BlockRuleInput{Kind: models.BlockKeyword, Value: "Casino"},
+		models.BlockRuleInput{Kind: models.BlockRegex, Value: `(?i)\bseo\s+services?\b`},
+	)
+	ctx := context.Background()
+	form := func(email, message string) models.ContactForm {
+		return models.ContactForm{Name: "Jane", Email: email, Subject: "question", Message: message}
+	}
+
+	assert.NotNil(t, svc.BlockedIP(ctx, "203.0.113.7"))
+	assert.NotNil(t, svc.BlockedIP(ctx, "::ffff:203.0.113.7"), "IPv4-mapped addresses")
+	assert.NotNil(t, svc.BlockedIP(ctx, "2001:db8:1::1"))
+	assert.Nil(t, svc.BlockedIP(ctx, "2001:db8:1::2"))
+	assert.Nil(t, svc.BlockedIP(ctx, "198.51.100.1"))
+	assert.Nil(t, svc.BlockedIP(ctx, "garbage"))
+
+	ok := form("jane@example.com", "Hello")
+	assert.Nil(t, svc.CheckSubmission(ctx, ok, "198.51.100.1"))
+	assert.Equal(t, models.BlockIP, svc.CheckSubmission(ctx, ok, "203.0.113.7").Kind)
+	assert.Equal(t, models.BlockEmail, svc.CheckSubmission(ctx, form("spammer@example.com", "Hello"), "").Kind)
+	assert.Equal(t, models.BlockDomain, svc.CheckSubmission(ctx, form("a@mx.spam.test", "Hello"), "").Kind, "subdomains")
+	assert.Nil(t, svc.CheckSubmission(ctx, form("a@notspam.test", "Hello"), ""))
+	assert.Equal(t, models.BlockKeyword, svc.CheckSubmission(ctx, form("jane@example.com", "Best CASINO bonus"), "").Kind)
+	assert.Equal(t, models.BlockRegex, svc.CheckSubmission(ctx, form("jane@example.com", "We offer SEO  services"), "").Kind)
+
+	// hits are recorded
+	rules, _ := repo.ListRules(ctx, true)
+	assert.Equal(t, int64(3), rules[0].Hits)
+
+	// expired rules no longer apply
+	repo.rules[0].ExpiresAt = &past
+	assert.NoError(t, svc.Refresh(ctx))
+	assert.Nil(t, svc.BlockedIP(ctx, "203.0.113.7"))
+	listed, err := svc.List(ctx, false)
+	assert.NoError(t, err)
+	assert.Len(t, listed, 5)
+
+	assert.NoError(t, svc.Delete(ctx, repo.rules[1].ID))
+	assert.Nil(t, svc.BlockedIP(ctx, "2001:db8:1::1"))
+	assert.ErrorIs(t, svc.Delete(ctx, 999), repository.ErrNotFound)
+}
+
+func TestBlocklistService_Validation(t *testing.T) {
+	svc, _ := newTestBlocklist(t, services.BanOptions{})
+	ctx := context.Background()
+	past := time.Now().Add(-time.Hour)
+
+	for _, input := range []models.BlockRuleInput{
+		{Kind: "country", Value: "FR"},
+		{Kind: models.BlockIP, Value: "not an ip"},
+		{Kind: models.BlockEmail, Value: "nobody"},
+		{Kind: models.BlockDomain, Value: "localhost"},
+		{Kind: models.BlockRegex, Value: "(unclosed"},
+		{Kind: models.BlockKeyword, Value: "  "},
+		{Kind: models.BlockKeyword, Value: "x", ExpiresIn: "soon"},
+		{Kind: models.BlockKeyword, Value: "x", ExpiresAt: &past},
+	} {
+		_, err := svc.Create(ctx, input)
+		assert.ErrorIs(t, err, services.ErrInvalidBlockRule, input)
+	}
+
+	rule, err := svc.Create(ctx, models.BlockRuleInput{Kind: models.BlockIP, Value: "198.51.100.77/24", ExpiresIn: "2h"})
+	assert.NoError(t, err)
+	assert.Equal(t, "198.51.100.0/24", rule.Value)
+	assert.Equal(t, models.BlockSourceAdmin, rule.Source)
+	assert.WithinDuration(t, time.Now().Add(2*time.Hour), *rule.ExpiresAt, time.Minute)
+
+	rule, err = svc.Create(ctx, models.BlockRuleInput{Kind: models.BlockIP, Value: "198.51.100.77"})
+	assert.NoError(t, err)
+	assert.Equal(t, "198.51.100.77/32", rule.Value)
+
+	// IDN domains are stored like submission emails, in Unicode
+	rule, err = svc.Create(ctx, models.BlockRuleInput{Kind: models.BlockDomain, Value: "XN--BCHER-KVA.example"})
+	assert.NoError(t, err)
+	assert.Equal(t, "bücher.example", rule.Value)
+	form := models.ContactForm{Name: "Jane", Email: "jane@Bücher.example", Subject: "question", Message: "Hallo"}
+	form.Normalize()
+	assert.NotNil(t, svc.CheckSubmission(ctx, form, ""))
+}
+
+func TestBlocklistService_AutoBan(t *testing.T) {
+	svc, repo := newTestBlocklist(t, services.BanOptions{Strikes: 3, Window: time.Hour, Duration: time.Hour})
+	ctx := context.Background()
+
+	svc.Strike(ctx, "203.0.113.7", "rate limited")
+	svc.Strike(ctx, "203.0.113.8", "rate limited")
+	svc.Strike(ctx, "203.0.113.7", "rate limited")
+	assert.Nil(t, svc.BlockedIP(ctx, "203.0.113.7"))
+	svc.Strike(ctx, "203.0.113.7", "rate limited")
+
+	rule := svc.BlockedIP(ctx, "203.0.113.7")
+	if assert.NotNil(t, rule) {
+		assert.Equal(t, models.BlockSourceAuto, rule.Source)
+		assert.Equal(t, "203.0.113.7/32", rule.Value)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), *rule.ExpiresAt, time.Minute)
+	}
+	assert.Nil(t, svc.BlockedIP(ctx, "203.0.113.8"))
+
+	// IPv6 clients are banned per /64
+	for i := 0; i < 3; i++ {
+		svc.Strike(ctx, "2001:db8:1:2::1", "blocked keyword")
+	}
+	assert.NotNil(t, svc.BlockedIP(ctx, "2001:db8:1:2::ffff"))
+	assert.Len(t, repo.rules, 2)
+
+	// a rate limiter reports its rejections as strikes
+	limiter := services.NewRateLimiter(nil, services.RateLimitOptions{
+		Default:   services.RateLimitPolicy{Limit: 1, Window: time.Hour},
+		OnLimited: func(ip string) { svc.Strike(ctx, ip, "rate limited") },
+	})
+	for i := 0; i < 4; i++ {
+		limiter.Allow("198.51.100.9")
+	}
+	assert.NotNil(t, svc.BlockedIP(ctx, "198.51.100.9"))
+
+	disabled, _ := newTestBlocklist(t, services.BanOptions{})
+	for i := 0; i < 10; i++ {
+		disabled.Strike(ctx, "203.0.113.7", "rate limited")
+	}
+	assert.Nil(t, disabled.BlockedIP(ctx, "203.0.113.7"))
+}
+
+func TestContactService_DropsBlockedSubmissions(t *testing.T) {
+	blocklist, _ := newTestBlocklist(t, services.BanOptions{Strikes: 2, Window: time.Hour, Duration: time.Hour},
+		models.BlockRuleInput{Kind: models.BlockKeyword, Value: "casino"})
+	repo := new(mockContactRepository)
+	notifier := new(mockNotifier)
+	svc := services.NewContactService(repo, notifier, services.WithBlocklist(blocklist))
+
+	ctx := services.WithClientMetadata(context.Background(), models.ClientMetadata{IP: "203.0.113.7"})
+	spam := models.ContactForm{Name: "Bob", Email: "bob@example.com", Subject: "other", Message: "Online casino"}
+	assert.NoError(t, svc.SubmitContactForm(ctx, spam), "blocked submissions look successful")
+	repo.AssertNotCalled(t, "SaveContactForm", mock.Anything, mock.Anything)
+
+	// the second strike bans the client, whatever it sends
+	assert.NoError(t, svc.SubmitContactForm(ctx, spam))
+	assert.NotNil(t, blocklist.BlockedIP(context.Background(), "203.0.113.7"))
+	form := models.ContactForm{Name: "Bob", Email: "bob@example.com", Subject: "question", Message: "Hello"}
+	assert.NoError(t, svc.SubmitContactForm(ctx, form))
+	repo.AssertNotCalled(t, "SaveContactForm", mock.Anything, mock.Anything)
+}
+
+func TestBlocklistMiddleware_FakesSuccess(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	blocklist, _ := newTestBlocklist(t, services.BanOptions{},
+		models.BlockRuleInput{Kind: models.BlockIP, Value: "198.51.100.4"})
+	svc := &metadataContactService{}
+	handler := handlers.NewContactHandler(svc)
+	router := gin.New()
+	// the real handler is slower than the fake reply, which must not show
+	router.POST("/contact", middleware.Blocklist(blocklist, handler.HandleBlocked), func(c *gin.Context) {
+		time.Sleep(50 * time.Millisecond)
+		handler.HandleSendContactForm(c)
+	})
+
+	send := func(ip, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/contact", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.RemoteAddr = ip + ":1234"
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	valid := `{"name":"Jane","email":"jane@example.com","subject":"question","message":"Hi"}`
+
+	allowed := send("198.51.100.5", valid)
+	start := time.Now()
+	banned := send("198.51.100.4", valid)
+	assert.GreaterOrEqual(t, time.Since(start), 40*time.Millisecond, "the fake success takes as long as a real one")
+	assert.Equal(t, http.StatusOK, banned.Code)
+	assert.Equal(t, allowed.Body.String(), banned.Body.String())
+	assert.Equal(t, allowed.Header().Get("Cache-Control"), banned.Header().Get("Cache-Control"))
+	assert.Equal(t, "198.51.100.5", svc.client.IP, "only the allowed client reached the service")
+
+	// invalid forms fail the same way for everyone
+	start = time.Now()
+	assert.Equal(t, http.StatusBadRequest, send("198.51.100.4", `{"name":"Jane"}`).Code)
+	assert.Less(t, time.Since(start), 40*time.Millisecond, "like the real handler, validation errors are immediate")
+}
+
+func TestBlocklistMiddleware_PadsContentRuleDrops(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	blocklist, _ := newTestBlocklist(t, services.BanOptions{},
+		models.BlockRuleInput{Kind: models.BlockKeyword, Value: "casino"})
+	repo := new(mockContactRepository)
+	notifier := new(mockNotifier)
+	notifier.On("Notify", mock.Anything, mock.Anything).Return(nil).Maybe()
+	// storing is the slow part, which a dropped submission skips
+	repo.On("SaveContactForm", mock.Anything, mock.Anything).Return(nil).After(50 * time.Millisecond)
+	handler := handlers.NewContactHandler(services.NewContactService(repo, notifier, services.WithBlocklist(blocklist)))
+	router := gin.New()
+	router.POST("/contact", middleware.Blocklist(blocklist, handler.HandleBlocked), handler.HandleSendContactForm)
+
+	send := func(message string) (*httptest.ResponseRecorder, time.Duration) {
+		body := `{"name":"Jane","email":"jane@example.com","subject":"question","message":"` + message + `"}`
+		req := httptest.NewRequest(http.MethodPost, "/contact", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.RemoteAddr = "198.51.100.5:1234"
+		w := httptest.NewRecorder()
+		start := time.Now()
+		router.ServeHTTP(w, req)
+		return w, time.Since(start)
+	}
+
+	stored, _ := send("Hello")
+	dropped, elapsed := send("Online casino")
+	assert.Equal(t, http.StatusOK, dropped.Code)
+	assert.Equal(t, stored.Body.String(), dropped.Body.String())
+	assert.GreaterOrEqual(t, elapsed, 40*time.Millisecond, "the drop takes as long as a stored submission")
+	repo.AssertNumberOfCalls(t, "SaveContactForm", 1)
+}
+
+func TestBlocklistHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	blocklist, _ := newTestBlocklist(t, services.BanOptions{})
+	h := handlers.NewBlocklistHandler(blocklist)
+	router := gin.New()
+	router.GET("/blocklist", h.HandleList)
+	router.POST("/blocklist", h.HandleCreate)
+	router.DELETE("/blocklist/:id", h.HandleDelete)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodPost, "/blocklist", `{"kind":"domain","value":"Spam.test","reason":"bulk mailer"}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), `"value":"spam.test"`)
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, "/blocklist", `{"kind":"ip","value":"nope"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, "/blocklist", `not json`).Code)
+
+	w = do(http.MethodGet, "/blocklist?include_expired=true", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"source":"admin"`)
+
+	assert.Equal(t, http.StatusNoContent, do(http.MethodDelete, "/blocklist/1", "").Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodDelete, "/blocklist/1", "").Code)
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodDelete, "/blocklist/x", "").Code)
+}
+
+func TestBlocklistRepository_SaveRule(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	expires := time.Now().Add(time.Hour)
+	now := time.Now()
+	mock.ExpectQuery(`INSERT INTO block_rules(.|\s)*ON CONFLICT \(kind, value\) DO UPDATE`).
+		WithArgs(models.BlockIP, "203.0.113.7/32", "strikes", models.BlockSourceAuto, &expires).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "kind", "value", "reason", "source", "expires_at", "hits", "last_hit_at", "created_at"}).
+			AddRow(int64(4), models.BlockIP, "203.0.113.7/32", "manual", models.BlockSourceAdmin, &expires, int64(2), (*time.Time)(nil), now))
+
+	rule := &models.BlockRule{Kind: models.BlockIP, Value: "203.0.113.7/32", Reason: "strikes", Source: models.BlockSourceAuto, ExpiresAt: &expires}
+	assert.NoError(t, repository.NewBlocklistRepository(mock).SaveRule(context.Background(), rule))
+	assert.Equal(t, int64(4), rule.ID)
+	assert.Equal(t, "manual", rule.Reason, "an auto ban keeps the reason of an existing rule")
+
+	mock.ExpectExec(`DELETE FROM block_rules WHERE id = \$1`).WithArgs(int64(9)).
+		WillReturnResult(pgxmock.NewResult("DELETE", 0))
+	assert.ErrorIs(t, repository.NewBlocklistRepository(mock).DeleteRule(context.Background(), 9), repository.ErrNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,224 @@
+package tests_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	handlers "backend/api/handlers"
+	"backend/internal/models"
+	"backend/internal/repository"
+	"backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pashagolub/pgxmock/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// contact service recording the metadata of the request context
+type metadataContactService struct {
+	client models.ClientMetadata
+}
+
+func (s *metadataContactService) SubmitContactForm(ctx context.Context, form models.ContactForm) error {
+	s.client = services.ClientMetadataFromContext(ctx)
+	return nil
+}
+
+func TestIPAnonymizer(t *testing.T) {
+	hash, err := services.NewIPAnonymizer(services.ClientIPHash, "salt")
+	assert.NoError(t, err)
+	m := models.ClientMetadata{IP: "203.0.113.7"}
+	hash.Apply(&m)
+	assert.Empty(t, m.IP)
+	assert.Len(t, m.IPHash, 64)
+	assert.Empty(t, m.IPPrefix)
+
+	// IPv4-mapped addresses hash like their IPv4 form, other salts differ
+	mapped := models.ClientMetadata{IP: "::ffff:203.0.113.7"}
+	hash.Apply(&mapped)
+	assert.Equal(t, m.IPHash, mapped.IPHash)
+	other, _ := services.NewIPAnonymizer(services.ClientIPHash, "pepper")
+	salted := models.ClientMetadata{IP: "203.0.113.7"}
+	other.Apply(&salted)
+	assert.NotEqual(t, m.IPHash, salted.IPHash)
+
+	truncate, _ := services.NewIPAnonymizer(services.ClientIPTruncate, "")
+	for ip, prefix := range map[string]string{
+		"203.0.113.7":         "203.0.113.0/24",
+		"2001:db8:1:2:3::4":   "2001:db8:1::/48",
+		"fe80::1%eth0":        "fe80::/48",
+		"not an ip":           "",
+		"2001:db8:ffff::dead": "2001:db8:ffff::/48",
+	} {
+		m := models.ClientMetadata{IP: ip}
+		truncate.Apply(&m)
+		assert.Equal(t, prefix, m.IPPrefix, ip)
+		assert.Empty(t, m.IPHash)
+		assert.Empty(t, m.IP)
+	}
+
+	none, _ := services.NewIPAnonymizer(services.ClientIPNone, "")
+	m = models.ClientMetadata{IP: "203.0.113.7"}
+	none.Apply(&m)
+	assert.Equal(t, models.ClientMetadata{}, m)
+
+	_, err = services.NewIPAnonymizer("raw", "")
+	assert.Error(t, err)
+}
+
+func TestClientMetadata_Normalize(t *testing.T) {
+	m := models.ClientMetadata{
+		UserAgent: "Mozilla/5.0\r\nX-Injected: 1" + strings.Repeat("a", 600),
+		Language:  " fr-FR,fr;q=0.9 ",
+		Referrer:  "https://Example.com/contact?email=jane@example.com#form",
+		UTM:       models.UTMParams{Source: "newsletter", Campaign: strings.Repeat("é", 150)},
+	}
+	m.Normalize()
+
+	assert.NotContains(t, m.UserAgent, "\n")
+	assert.Len(t, []rune(m.UserAgent), models.MaxUserAgentLength)
+	assert.Equal(t, "fr-FR,fr;q=0.9", m.Language)
+	assert.Equal(t, "https://example.com/contact", m.Referrer)
+	assert.Equal(t, "newsletter", m.UTM.Source)
+	assert.Len(t, []rune(m.UTM.Campaign), models.MaxUTMLength)
+
+	for _, referrer := range []string{"javascript:alert(1)", "/contact", "ftp://example.com/x"} {
+		m := models.ClientMetadata{Referrer: referrer}
+		m.Normalize()
+		assert.Empty(t, m.Referrer, referrer)
+	}
+}
+
+func TestHandleSendContactForm_CapturesMetadata(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	svc := &metadataContactService{}
+	router := gin.New()
+	router.POST("/contact", handlers.NewContactHandler(svc).HandleSendContactForm)
+
+	send := func(body string, headers map[string]string) {
+		req := httptest.NewRequest(http.MethodPost, "/contact", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		req.RemoteAddr = "198.51.100.4:51234"
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+
+	send(`{"name":"Jane","email":"jane@example.com","subject":"question","message":"Hi",
+		"referrer":"https://example.com/projects","utm":{"source":"linkedin","campaign":"launch"}}`,
+		map[string]string{"User-Agent": "TestAgent/1.0", "Accept-Language": "fr", "Referer": "https://example.com/"})
+	assert.Equal(t, models.ClientMetadata{
+		IP:        "198.51.100.4",
+		UserAgent: "TestAgent/1.0",
+		Language:  "fr",
+		Referrer:  "https://example.com/projects",
+		UTM:       models.UTMParams{Source: "linkedin", Campaign: "launch"},
+	}, svc.client)
+
+	// without a referrer in the body the Referer header is used
+	send(`{"name":"Jane","email":"jane@example.com","subject":"question","message":"Hi"}`,
+		map[string]string{"Referer": "https://example.com/contact"})
+	assert.Equal(t, "https://example.com/contact", svc.client.Referrer)
+}
+
+func TestContactService_StoresAnonymizedMetadata(t *testing.T) {
+	repo := new(mockContactRepository)
+	notifier := new(mockNotifier)
+	notifier.On("Notify", mock.Anything, mock.Anything).Return(nil).Maybe()
+
+	var stored models.ClientMetadata
+	repo.On("SaveContactForm", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		stored = args.Get(1).(*models.ContactSubmission).Client
+	}).Return(nil)
+
+	anonymizer, err := services.NewIPAnonymizer(services.ClientIPTruncate, "")
+	assert.NoError(t, err)
+	svc := services.NewContactService(repo, notifier, services.WithClientIP(anonymizer))
+
+	ctx := services.WithClientMetadata(context.Background(), models.ClientMetadata{
+		IP:       "203.0.113.7",
+		Referrer: "https://example.com/contact?ref=x",
+	})
+	form := models.ContactForm{Name: "Jane", Email: "jane@example.com", Subject: "question", Message: "Hello"}
+	assert.NoError(t, svc.SubmitContactForm(ctx, form))
+
+	assert.Empty(t, stored.IP)
+	assert.Equal(t, "203.0.113.0/24", stored.IPPrefix)
+	assert.Equal(t, "https://example.com/contact", stored.Referrer)
+
+	// without an anonymizer the IP is dropped
+	svc = services.NewContactService(repo, notifier)
+	assert.NoError(t, svc.SubmitContactForm(ctx, form))
+	assert.Empty(t, stored.IP)
+	assert.Empty(t, stored.IPPrefix)
+	assert.Empty(t, stored.IPHash)
+}
+
+func TestContactRepository_SaveContactForm_Metadata(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	form := models.ContactForm{Name: "Jane", Email: "jane@example.com", Subject: "question", Message: "Hello"}
+	client := models.ClientMetadata{IPHash: "abc", GeoLocation: models.GeoLocation{Country: "FR", ASN: 16276, ASOrg: "OVH SAS"}, UserAgent: "UA", Language: "fr", Referrer: "https://example.com/",
+		UTM: models.UTMParams{Source: "s", Medium: "m", Campaign: "c", Term: "t", Content: "x"}}
+	mock.ExpectQuery(`INSERT INTO contact_submissions`).
+		WithArgs(form.Name, form.Email, form.Subject, form.Message, form.DedupeHash(), models.PriorityNormal, []string{},
+			(*int16)(nil), []byte(nil), (*string)(nil), "abc", "", "UA", "fr", "https://example.com/", "s", "m", "c", "t", "x",
+			"FR", int64(16276), "OVH SAS").
+		WillReturnRows(pgxmock.NewRows([]string{"id", "created_at"}).AddRow(int64(1), time.Now()))
+
+	err = repository.NewContactRepository(mock).SaveContactForm(context.Background(), &models.ContactSubmission{ContactForm: form, Client: client})
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHandleSources(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	a, b, c := inboxSubmission(1, models.ContactStatusNew), inboxSubmission(2, models.ContactStatusNew), inboxSubmission(3, models.ContactStatusNew)
+	a.Client.Referrer, b.Client.Referrer = "https://example.com/projects", "https://example.com/projects"
+	repo := newMemoryInboxRepository(a, b, c)
+
+	router := gin.New()
+	router.GET("/contacts/sources", handlers.NewInboxHandler(services.NewInboxService(repo, time.Hour)).HandleSources)
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	w := get("/contacts/sources?by=referrer")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"by":"referrer","sources":[{"value":"https://example.com/projects","count":2,"replied":0,"spam":0}]}`, w.Body.String())
+
+	assert.Equal(t, http.StatusBadRequest, get("/contacts/sources").Code)
+	assert.Equal(t, http.StatusBadRequest, get("/contacts/sources?by=email").Code)
+	assert.Equal(t, http.StatusBadRequest, get("/contacts/sources?by=referrer&from=2025-06-01&to=2025-03-01").Code)
+}
+
+func TestInboxRepository_CountSources(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	mock.ExpectQuery(`SELECT utm_campaign, COUNT\(\*\)(.|\s)*GROUP BY 1`).
+		WithArgs((*time.Time)(nil), (*time.Time)(nil), 50).
+		WillReturnRows(pgxmock.NewRows([]string{"value", "count", "replied", "spam"}).AddRow("launch", int64(4), int64(1), int64(0)))
+
+	counts, err := repository.NewInboxRepository(mock).CountSources(context.Background(), models.SourceFilter{By: models.SourceUTMCampaign, Limit: 50})
+	assert.NoError(t, err)
+	assert.Equal(t, []models.SourceCount{{Value: "launch", Count: 4, Replied: 1}}, counts)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	_, err = repository.NewInboxRepository(mock).CountSources(context.Background(), models.SourceFilter{By: "email"})
+	assert.Error(t, err)
+}
@@ -50,6 +50,18 @@ func TestLoadConfig(t *testing.T) {
 		assert.Equal(t, "587", cfg.SmtpPort)
 	})
 
+	t.Run("static pages in the sitemap", func(t *testing.T) {
+		os.Unsetenv("SITEMAP_STATIC_PAGES")
+		cfg, err := config.LoadConfig()
+		assert.NoError(t, err)
+		assert.Contains(t, cfg.SitemapPages, "/proxmox-project.html")
+
+		t.Setenv("SITEMAP_STATIC_PAGES", "")
+		cfg, err = config.LoadConfig()
+		assert.NoError(t, err)
+		assert.Empty(t, cfg.SitemapPages, "an empty list removes them")
+	})
+
 	t.Run("handles missing .env gracefully", func(t *testing.T) {
 		cfg, err := config.LoadConfig()
 
@@ -0,0 +1,129 @@
+package tests_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	handlers "backend/api/handlers"
+	"backend/internal/middleware"
+	"backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContactForm_Normalize(t *testing.T) {
+	form := models.ContactForm{
+		Name:    "  José\x00 Doe\n",
+		Email:   " jose@example.com\r\n",
+		Subject: " Question ",
+		Message: "Line 1\r\nLine 2\x07\tend  ",
+	}
+
+	form.Normalize()
+
+	assert.Equal(t, "José Doe", form.Name)
+	assert.Equal(t, "jose@example.com", form.Email)
+	assert.Equal(t, "question", form.Subject)
+	assert.Equal(t, "Line 1\nLine 2\tend", form.Message)
+}
+
+func TestContactForm_Validate(t *testing.T) {
+	valid := models.ContactForm{Name: "John", Email: "john@example.com", Subject: "stage", Message: "Hi"}
+
+	testCases := []struct {
+		name    string
+		mutate  func(f *models.ContactForm)
+		wantErr string
+	}{
+		{name: "valid form", mutate: func(f *models.ContactForm) {}},
+		{name: "name too long", mutate: func(f *models.ContactForm) { f.Name = strings.Repeat("é", models.MaxNameLength+1) }, wantErr: "name must be at most 200"},
+		{name: "name at limit counts runes", mutate: func(f *models.ContactForm) { f.Name = strings.Repeat("é", models.MaxNameLength) }},
+		{name: "email too long", mutate: func(f *models.ContactForm) { f.Email = strings.Repeat("a", 250) + "@example.com" }, wantErr: "email"},
+		{name: "invalid email", mutate: func(f *models.ContactForm) { f.Email = "nope" }, wantErr: "email is not a valid address"},
+		{name: "unknown subject", mutate: func(f *models.ContactForm) { f.Subject = "spam" }, wantErr: "subject must be one of"},
+		{name: "message too long", mutate: func(f *models.ContactForm) { f.Message = strings.Repeat("x", models.MaxMessageLength+1) }, wantErr: "message must be at most 10000"},
+		{name: "empty message", mutate: func(f *models.ContactForm) { f.Message = "" }, wantErr: "message is required"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			form := valid
+			tc.mutate(&form)
+
+			err := form.Validate()
+
+			if tc.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.Error(t, err)
+			assert.True(t, errors.Is(err, models.ErrInvalidContactForm))
+			assert.Contains(t, err.Error(), tc.wantErr)
+		})
+	}
+}
+
+func TestHandleSendContactForm_ControlCharsOnlyName(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	svc := &mockContactService{}
+	h := handlers.NewContactHandler(svc)
+
+	router := gin.New()
+	router.POST("/contact", h.HandleSendContactForm)
+
+	// Name is not empty for the binding step but becomes empty once control characters are stripped
+	payload := models.ContactForm{Name: "\x01\x02", Email: "john@example.com", Subject: "question", Message: "Hi"}
+	body, _ := json.Marshal(payload)
+	req := httptest.NewRequest("POST", "/contact", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.False(t, svc.called, "service should not be called with an invalid form")
+}
+
+func TestBodyLimit_RejectsLargeBody(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	svc := &mockContactService{}
+	h := handlers.NewContactHandler(svc)
+
+	router := gin.New()
+	router.Use(middleware.BodyLimit(1024))
+	router.POST("/contact", h.HandleSendContactForm)
+
+	payload := models.ContactForm{Name: "John", Email: "john@example.com", Subject: "question", Message: strings.Repeat("x", 2048)}
+	body, _ := json.Marshal(payload)
+
+	t.Run("declared length", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/contact", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	})
+
+	t.Run("chunked body", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/contact", bytes.NewReader(body))
+		req.ContentLength = -1
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	})
+
+	assert.False(t, svc.called, "service should not be called with an oversized body")
+}
@@ -0,0 +1,250 @@
+package tests_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	handlers "backend/api/handlers"
+	"backend/internal/models"
+	"backend/internal/repository"
+	"backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pashagolub/pgxmock/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// in-memory implementation of the conversation storage
+type memoryConversationRepository struct {
+	mu       sync.Mutex
+	messages []models.ContactMessage
+}
+
+func (r *memoryConversationRepository) AddMessage(ctx context.Context, m *models.ContactMessage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.messages {
+		if existing.MessageID == m.MessageID {
+			return repository.ErrDuplicateMessage
+		}
+	}
+	if utf8.RuneCountInString(m.From) > 320 || utf8.RuneCountInString(m.To) > 320 {
+		return errors.New("value too long for type character varying(320)")
+	}
+	m.ID = int64(len(r.messages) + 1)
+	m.CreatedAt = time.Now()
+	r.messages = append(r.messages, *m)
+	return nil
+}
+
+func (r *memoryConversationRepository) ListMessages(ctx context.Context, submissionID int64) ([]models.ContactMessage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	list := []models.ContactMessage{}
+	for _, m := range r.messages {
+		if m.SubmissionID == submissionID {
+			list = append(list, m)
+		}
+	}
+	return list, nil
+}
+
+func (r *memoryConversationRepository) FindSubmissionByMessageID(ctx context.Context, messageIDs []string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := len(r.messages) - 1; i >= 0; i-- {
+		for _, id := range messageIDs {
+			if r.messages[i].MessageID == id {
+				return r.messages[i].SubmissionID, nil
+			}
+		}
+	}
+	return 0, repository.ErrNotFound
+}
+
+// records outgoing emails instead of sending them
+type recordingTransport struct {
+	mu    sync.Mutex
+	sent  []models.OutgoingEmail
+	err   error
+	block chan struct{} // when set, sends wait until it is closed
+}
+
+func (t *recordingTransport) SendEmail(ctx context.Context, msg models.OutgoingEmail) error {
+	if t.block != nil {
+		<-t.block
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.err != nil {
+		return t.err
+	}
+	t.sent = append(t.sent, msg)
+	return nil
+}
+
+// waitForSent waits until n emails were sent, for services sending in the
+// background, and returns them
+func (t *recordingTransport) waitForSent(tb testing.TB, n int) []models.OutgoingEmail {
+	tb.Helper()
+	assert.Eventually(tb, func() bool {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		return len(t.sent) >= n
+	}, time.Second, time.Millisecond)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]models.OutgoingEmail{}, t.sent...)
+}
+
+func newTestConversationService(t *testing.T, submissions ...models.ContactSubmission) (services.IConversationService, *memoryInboxRepository, *recordingTransport) {
+	t.Helper()
+	inbox := newMemoryInboxRepository(submissions...)
+	transport := &recordingTransport{}
+	svc, err := services.NewConversationService(inbox, &memoryConversationRepository{}, transport, services.ReplyOptions{
+		From: "contact@enzo.dev",
+	})
+	assert.NoError(t, err)
+	return svc, inbox, transport
+}
+
+func TestConversationService_ReplyThreads(t *testing.T) {
+	svc, inbox, transport := newTestConversationService(t, inboxSubmission(7, models.ContactStatusRead))
+
+	first, err := svc.Reply(context.Background(), 7, models.ReplyRequest{Body: "Thanks for reaching out"})
+	assert.NoError(t, err)
+	assert.Equal(t, "<contact-7@enzo.dev>", first.InReplyTo)
+	assert.Equal(t, []string{"<contact-7@enzo.dev>"}, first.References)
+	assert.True(t, strings.HasSuffix(first.MessageID, "@enzo.dev>"))
+	assert.Equal(t, "Re: Votre message via le portfolio (question)", first.Subject)
+	assert.Contains(t, first.Body, "Bonjour Jane,")
+	assert.Contains(t, first.Body, "> Hello")
+	assert.Equal(t, "admin", first.Author)
+
+	second, err := svc.Reply(context.Background(), 7, models.ReplyRequest{Body: "One more thing", Author: "enzo"})
+	assert.NoError(t, err)
+	assert.Equal(t, first.MessageID, second.InReplyTo)
+	assert.Equal(t, []string{"<contact-7@enzo.dev>", first.MessageID}, second.References)
+	assert.Equal(t, first.Subject, second.Subject)
+
+	assert.Len(t, transport.sent, 2)
+	assert.Equal(t, []string{`"Jane" <jane@example.com>`}, transport.sent[0].To)
+	assert.Equal(t, first.MessageID, transport.sent[0].MessageID)
+
+	s, _ := inbox.GetSubmission(context.Background(), 7)
+	assert.Equal(t, models.ContactStatusReplied, s.Status)
+
+	history, err := svc.Messages(context.Background(), 7)
+	assert.NoError(t, err)
+	assert.Len(t, history, 2)
+}
+
+func TestConversationService_ReplyErrors(t *testing.T) {
+	svc, _, transport := newTestConversationService(t,
+		inboxSubmission(1, models.ContactStatusNew),
+		inboxSubmission(2, models.ContactStatusSpam),
+	)
+
+	_, err := svc.Reply(context.Background(), 1, models.ReplyRequest{Body: "   "})
+	assert.ErrorIs(t, err, services.ErrInvalidUpdate)
+
+	_, err = svc.Reply(context.Background(), 2, models.ReplyRequest{Body: "Hi"})
+	assert.ErrorIs(t, err, services.ErrInvalidTransition)
+
+	_, err = svc.Reply(context.Background(), 3, models.ReplyRequest{Body: "Hi"})
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	// checked before sending, since a longer author could not be recorded
+	_, err = svc.Reply(context.Background(), 1, models.ReplyRequest{Body: "Hi", Author: strings.Repeat("a", 101)})
+	assert.ErrorIs(t, err, services.ErrInvalidUpdate)
+	assert.Empty(t, transport.sent)
+
+	transport.err = errors.New("connection refused")
+	_, err = svc.Reply(context.Background(), 1, models.ReplyRequest{Body: "Hi"})
+	assert.ErrorIs(t, err, services.ErrReplyNotSent)
+
+	history, _ := svc.Messages(context.Background(), 1)
+	assert.Empty(t, history)
+}
+
+func TestNewConversationService_InvalidTemplate(t *testing.T) {
+	_, err := services.NewConversationService(newMemoryInboxRepository(), &memoryConversationRepository{}, &recordingTransport{}, services.ReplyOptions{
+		Template: "{{.Body",
+	})
+	assert.Error(t, err)
+}
+
+func TestConversationHandler_Routes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	svc, _, transport := newTestConversationService(t, inboxSubmission(1, models.ContactStatusNew))
+	h := handlers.NewConversationHandler(svc)
+
+	router := gin.New()
+	router.POST("/contacts/:id/replies", h.HandleReply)
+	router.GET("/contacts/:id/messages", h.HandleListMessages)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusBadRequest, do("POST", "/contacts/1/replies", `{}`).Code)
+	assert.Equal(t, http.StatusNotFound, do("POST", "/contacts/9/replies", `{"body":"Hi"}`).Code)
+
+	w := do("POST", "/contacts/1/replies", `{"body":"Hi"}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), `"direction":"outbound"`)
+
+	w = do("GET", "/contacts/1/messages", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var body struct {
+		Messages []models.ContactMessage `json:"messages"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Len(t, body.Messages, 1)
+	assert.Equal(t, "<contact-1@enzo.dev>", body.Messages[0].InReplyTo)
+
+	transport.err = errors.New("smtp down")
+	assert.Equal(t, http.StatusBadGateway, do("POST", "/contacts/1/replies", `{"body":"Again"}`).Code)
+}
+
+func TestConversationRepository_AddMessage(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	now := time.Now()
+	message := &models.ContactMessage{
+		SubmissionID: 3,
+		Direction:    models.MessageOutbound,
+		MessageID:    "<a@b>",
+		InReplyTo:    "<contact-3@b>",
+		From:         "contact@b",
+		To:           "jane@example.com",
+		Subject:      "Re: hi",
+		Body:         "Hello",
+		Author:       "admin",
+	}
+	mock.ExpectQuery(`INSERT INTO contact_messages`).
+		WithArgs(int64(3), "outbound", "<a@b>", "<contact-3@b>", []string{}, "contact@b", "jane@example.com", "Re: hi", "Hello", "admin",
+			(*int16)(nil), []byte(nil)).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "created_at"}).AddRow(int64(11), now))
+
+	err = repository.NewConversationRepository(mock).AddMessage(context.Background(), message)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(11), message.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
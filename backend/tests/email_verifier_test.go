@@ -0,0 +1,208 @@
+package tests_test
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"backend/internal/models"
+	"backend/internal/services"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// testZone holds the records a testDNSServer answers for one name
+type testZone struct {
+	mx []string
+	a  [][4]byte
+}
+
+// testDNSServer is a local stand-in for a DNS server: names missing from
+// zones get NXDOMAIN, names in silent get no answer at all
+type testDNSServer struct {
+	addr    string
+	zones   map[string]testZone
+	silent  map[string]bool
+	queries atomic.Int64
+}
+
+func newTestDNSServer(t *testing.T, zones map[string]testZone, silent ...string) *testDNSServer {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	s := &testDNSServer{addr: conn.LocalAddr().String(), zones: zones, silent: map[string]bool{}}
+	for _, name := range silent {
+		s.silent[name] = true
+	}
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, from, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if answer := s.answer(buf[:n]); answer != nil {
+				_, _ = conn.WriteTo(answer, from)
+			}
+		}
+	}()
+	return s
+}
+
+func (s *testDNSServer) answer(query []byte) []byte {
+	var p dnsmessage.Parser
+	header, err := p.Start(query)
+	if err != nil {
+		return nil
+	}
+	q, err := p.Question()
+	if err != nil {
+		return nil
+	}
+	name := q.Name.String()
+	if s.silent[name] {
+		return nil
+	}
+	s.queries.Add(1)
+
+	zone, found := s.zones[name]
+	rcode := dnsmessage.RCodeSuccess
+	if !found {
+		rcode = dnsmessage.RCodeNameError
+	}
+	b := dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: header.ID, Response: true, Authoritative: true,
+		RecursionDesired: header.RecursionDesired, RecursionAvailable: true, RCode: rcode})
+	b.EnableCompression()
+	_ = b.StartQuestions()
+	_ = b.Question(q)
+	_ = b.StartAnswers()
+	rh := dnsmessage.ResourceHeader{Name: q.Name, Class: dnsmessage.ClassINET, TTL: 60}
+	switch q.Type {
+	case dnsmessage.TypeMX:
+		for _, host := range zone.mx {
+			_ = b.MXResource(rh, dnsmessage.MXResource{Pref: 10, MX: dnsmessage.MustNewName(host)})
+		}
+	case dnsmessage.TypeA:
+		for _, a := range zone.a {
+			_ = b.AResource(rh, dnsmessage.AResource{A: a})
+		}
+	}
+	msg, _ := b.Finish()
+	return msg
+}
+
+func TestEmailVerifier_Disposable(t *testing.T) {
+	list := filepath.Join(t.TempDir(), "disposable.txt")
+	assert.NoError(t, os.WriteFile(list, []byte("# throwaway providers\nmailinator.com\nYOPmail.com  # trailing comment\nbücher.example\n"), 0o644))
+
+	v, err := services.NewEmailVerifier(services.EmailVerifierOptions{Mode: services.EmailCheckReject, DisposableFile: list})
+	assert.NoError(t, err)
+	ctx := context.Background()
+
+	for _, email := range []string{"a@mailinator.com", "a@MAILINATOR.com", "a@eu.mailinator.com", "a@yopmail.com",
+		"a@BÜCHER.example", "a@xn--bcher-kva.example"} {
+		_, err := v.Check(ctx, email)
+		assert.ErrorIs(t, err, models.ErrInvalidContactForm, email)
+	}
+	flags, err := v.Check(ctx, "jane@example.com")
+	assert.NoError(t, err)
+	assert.Empty(t, flags)
+	_, err = v.Check(ctx, "jane@exa mple.com")
+	assert.ErrorIs(t, err, models.ErrInvalidContactForm, "invalid domain")
+
+	// the list is reloaded when the file changes
+	assert.NoError(t, os.WriteFile(list, []byte("guerrillamail.com\n"), 0o644))
+	future := time.Now().Add(time.Minute)
+	assert.NoError(t, os.Chtimes(list, future, future))
+	assert.NoError(t, v.Reload(ctx))
+	_, err = v.Check(ctx, "a@mailinator.com")
+	assert.NoError(t, err)
+	_, err = v.Check(ctx, "a@guerrillamail.com")
+	assert.Error(t, err)
+
+	// flag mode reports instead of rejecting
+	flagging, err := services.NewEmailVerifier(services.EmailVerifierOptions{DisposableFile: list})
+	assert.NoError(t, err)
+	flags, err = flagging.Check(ctx, "a@guerrillamail.com")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{services.EmailFlagDisposable}, flags)
+
+	_, err = services.NewEmailVerifier(services.EmailVerifierOptions{Mode: "drop"})
+	assert.Error(t, err)
+	_, err = services.NewEmailVerifier(services.EmailVerifierOptions{DisposableFile: filepath.Join(t.TempDir(), "missing")})
+	assert.Error(t, err)
+}
+
+func TestEmailVerifier_DNS(t *testing.T) {
+	dns := newTestDNSServer(t, map[string]testZone{
+		"example.com.":         {mx: []string{"mx.example.com."}},
+		"mx.example.com.":      {a: [][4]byte{{192, 0, 2, 25}}},
+		"a-only.example.":      {a: [][4]byte{{192, 0, 2, 26}}},
+		"null-mx.example.":     {mx: []string{"."}},
+		"no-records.example.":  {},
+		"xn--exmple-cva.test.": {mx: []string{"mx.example.com."}},
+	}, "slow.example.")
+	v, err := services.NewEmailVerifier(services.EmailVerifierOptions{
+		CheckDNS:    true,
+		DNSServer:   dns.addr,
+		DNSTimeout:  300 * time.Millisecond,
+		DNSCacheTTL: time.Hour,
+	})
+	assert.NoError(t, err)
+	ctx := context.Background()
+	check := func(email string) []string {
+		flags, err := v.Check(ctx, email)
+		assert.NoError(t, err)
+		return flags
+	}
+
+	assert.Empty(t, check("jane@example.com"))
+	assert.Empty(t, check("jane@a-only.example"), "A record without MX")
+	assert.Empty(t, check("jane@exémple.test"), "IDN domains are looked up in punycode")
+	assert.Equal(t, []string{services.EmailFlagNoMailServer}, check("jane@null-mx.example"))
+	assert.Equal(t, []string{services.EmailFlagNoMailServer}, check("jane@no-records.example"))
+	assert.Equal(t, []string{services.EmailFlagNoMailServer}, check("jane@missing.example"))
+	assert.Empty(t, check("jane@slow.example"), "timeouts do not fail the check")
+
+	// results are cached
+	queries := dns.queries.Load()
+	assert.Empty(t, check("jane@EXAMPLE.com"))
+	assert.Equal(t, []string{services.EmailFlagNoMailServer}, check("jane@missing.example"))
+	assert.Equal(t, queries, dns.queries.Load())
+}
+
+func TestContactService_EmailChecks(t *testing.T) {
+	list := filepath.Join(t.TempDir(), "disposable.txt")
+	assert.NoError(t, os.WriteFile(list, []byte("mailinator.com\n"), 0o644))
+	form := models.ContactForm{Name: "Jane", Email: "jane@mailinator.com", Subject: "question", Message: "Hello"}
+
+	repo := new(mockContactRepository)
+	notifier := new(mockNotifier)
+	notifier.On("Notify", mock.Anything, mock.Anything).Return(nil).Maybe()
+	var stored *models.ContactSubmission
+	repo.On("SaveContactForm", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		stored = args.Get(1).(*models.ContactSubmission)
+	}).Return(nil)
+
+	flagging, err := services.NewEmailVerifier(services.EmailVerifierOptions{DisposableFile: list})
+	assert.NoError(t, err)
+	engine := newTestRoutingEngine(t, models.RoutingRule{Name: "questions", Enabled: true, Subjects: []string{"question"}, Tags: []string{"faq"}})
+	svc := services.NewContactService(repo, notifier, services.WithEmailVerifier(flagging), services.WithRouting(engine))
+	assert.NoError(t, svc.SubmitContactForm(context.Background(), form))
+	assert.Equal(t, []string{"faq", services.EmailFlagDisposable}, stored.Tags)
+
+	rejecting, err := services.NewEmailVerifier(services.EmailVerifierOptions{Mode: services.EmailCheckReject, DisposableFile: list})
+	assert.NoError(t, err)
+	stored = nil
+	svc = services.NewContactService(repo, notifier, services.WithEmailVerifier(rejecting))
+	assert.ErrorIs(t, svc.SubmitContactForm(context.Background(), form), models.ErrInvalidContactForm)
+	assert.Nil(t, stored)
+}
@@ -0,0 +1,458 @@
+package tests_test
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/internal/encryption"
+	"backend/internal/models"
+	"backend/internal/repository"
+
+	"github.com/pashagolub/pgxmock/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func testKey(b byte) []byte {
+	return []byte(strings.Repeat(string(rune('A'+b%26)), encryption.KeySize))
+}
+
+func newTestKeyring(t *testing.T, active int, versions ...int) *encryption.Keyring {
+	t.Helper()
+	keys := map[int][]byte{}
+	for _, v := range versions {
+		keys[v] = testKey(byte(v))
+	}
+	keyring, err := encryption.NewKeyring(keys, active, testKey(25))
+	assert.NoError(t, err)
+	return keyring
+}
+
+// captures the value of a query argument
+type captureArg struct {
+	value interface{}
+}
+
+func (c *captureArg) Match(v interface{}) bool {
+	c.value = v
+	return true
+}
+
+func TestKeyring_SealOpenAndRotate(t *testing.T) {
+	v1 := newTestKeyring(t, 0, 1)
+	dek, err := v1.NewDataKey()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, dek.Version)
+
+	sealed, err := dek.Seal("email", "jane@example.com")
+	assert.NoError(t, err)
+	assert.NotContains(t, sealed, "jane")
+
+	// a keyring with a newer active key still opens data wrapped with v1
+	v2 := newTestKeyring(t, 0, 1, 2)
+	assert.Equal(t, 2, v2.ActiveVersion())
+	reopened, err := v2.OpenDataKey(dek.Version, dek.Wrapped)
+	assert.NoError(t, err)
+	plain, err := reopened.Open("email", sealed)
+	assert.NoError(t, err)
+	assert.Equal(t, "jane@example.com", plain)
+
+	// values are bound to their field
+	_, err = reopened.Open("name", sealed)
+	assert.Error(t, err)
+
+	// wrapped keys are bound to their version
+	_, err = v2.OpenDataKey(2, dek.Wrapped)
+	assert.Error(t, err)
+	_, err = newTestKeyring(t, 0, 2).OpenDataKey(1, dek.Wrapped)
+	assert.ErrorIs(t, err, encryption.ErrUnknownKey)
+}
+
+func TestKeyring_BlindIndex(t *testing.T) {
+	k := newTestKeyring(t, 0, 1)
+	assert.Equal(t, k.BlindIndex("email", "jane@example.com"), k.BlindIndex("email", "jane@example.com"))
+	assert.NotEqual(t, k.BlindIndex("email", "jane@example.com"), k.BlindIndex("dedupe", "jane@example.com"))
+	assert.Len(t, k.BlindIndex("email", "x"), 64)
+}
+
+func TestLoadKeyring(t *testing.T) {
+	keyring, err := encryption.LoadKeyring(encryption.Options{})
+	assert.NoError(t, err)
+	assert.Nil(t, keyring)
+
+	enc := func(b byte) string { return base64.StdEncoding.EncodeToString(testKey(b)) }
+	dir := t.TempDir()
+	keysFile := filepath.Join(dir, "keys")
+	indexFile := filepath.Join(dir, "index")
+	assert.NoError(t, os.WriteFile(keysFile, []byte("# rotated 2026-01\n2:"+enc(2)+"\n"), 0o600))
+	assert.NoError(t, os.WriteFile(indexFile, []byte(enc(9)+"\n"), 0o600))
+
+	keyring, err = encryption.LoadKeyring(encryption.Options{Keys: "1:" + enc(1), KeysFile: keysFile, IndexKeyFile: indexFile})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, keyring.ActiveVersion())
+
+	keyring, err = encryption.LoadKeyring(encryption.Options{Keys: "1:" + enc(1) + ", 2:" + enc(2), ActiveVersion: 1, IndexKey: enc(9)})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, keyring.ActiveVersion())
+
+	for _, opts := range []encryption.Options{
+		{Keys: "1:" + enc(1)},                                    // no index key
+		{Keys: "1:c2hvcnQ=", IndexKey: enc(9)},                   // 5-byte key
+		{Keys: "1:" + enc(1) + ",1:" + enc(2), IndexKey: enc(9)}, // duplicate version
+		{Keys: enc(1), IndexKey: enc(9)},                         // no version
+		{Keys: "1:" + enc(1), ActiveVersion: 3, IndexKey: enc(9)},
+	} {
+		_, err := encryption.LoadKeyring(opts)
+		assert.Error(t, err)
+	}
+}
+
+func TestContactRepository_EncryptsAtRest(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	keyring := newTestKeyring(t, 0, 1)
+	form := models.ContactForm{Name: "Jane", Email: "Jane@Example.com", Subject: "question", Message: "Hello there"}
+	name, email, message, dedupe, version, dataKey, index := &captureArg{}, &captureArg{}, &captureArg{}, &captureArg{}, &captureArg{}, &captureArg{}, &captureArg{}
+
+	mock.ExpectQuery(`INSERT INTO contact_submissions`).
+		WithArgs(append([]interface{}{name, email, "question", message, dedupe, models.PriorityNormal, []string{}, version, dataKey, index},
+			emptyClientRow...)...).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "created_at"}).AddRow(int64(7), time.Now()))
+
+	submission := &models.ContactSubmission{ContactForm: form}
+	assert.NoError(t, repository.NewContactRepository(mock, repository.WithKeyring(keyring)).SaveContactForm(context.Background(), submission))
+	assert.Equal(t, form, submission.ContactForm)
+
+	for _, arg := range []*captureArg{name, email, message} {
+		assert.NotContains(t, arg.value, "Jane")
+		assert.NotContains(t, arg.value, "Hello")
+	}
+	assert.NotEqual(t, form.DedupeHash(), dedupe.value)
+	assert.Equal(t, keyring.BlindIndex("email", "jane@example.com"), *index.value.(*string))
+
+	// what was written reads back in clear
+	now := time.Now()
+	mock.ExpectQuery(`SELECT .* FROM contact_submissions WHERE id = \$1`).
+		WithArgs(int64(7)).
+		WillReturnRows(pgxmock.NewRows(submissionRowColumns).
+			AddRow(int64(7), name.value, email.value, "question", message.value, "normal", []string{}, "new", (*string)(nil),
+				now, now, (*time.Time)(nil), (*time.Time)(nil), (*time.Time)(nil), (*time.Time)(nil),
+				"", "", "", "", "", "", "", "", "", "", "", int64(0), "", version.value, dataKey.value))
+
+	stored, err := repository.NewInboxRepository(mock, repository.WithKeyring(keyring)).GetSubmission(context.Background(), 7)
+	assert.NoError(t, err)
+	assert.Equal(t, "Jane", stored.Name)
+	assert.Equal(t, "Jane@Example.com", stored.Email)
+	assert.Equal(t, "Hello there", stored.Message)
+
+	// without the keys the row cannot be read
+	mock.ExpectQuery(`SELECT .* FROM contact_submissions WHERE id = \$1`).
+		WithArgs(int64(7)).
+		WillReturnRows(pgxmock.NewRows(submissionRowColumns).
+			AddRow(int64(7), name.value, email.value, "question", message.value, "normal", []string{}, "new", (*string)(nil),
+				now, now, (*time.Time)(nil), (*time.Time)(nil), (*time.Time)(nil), (*time.Time)(nil),
+				"", "", "", "", "", "", "", "", "", "", "", int64(0), "", version.value, dataKey.value))
+	_, err = repository.NewInboxRepository(mock).GetSubmission(context.Background(), 7)
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestConversationRepository_EncryptsAtRest(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	keyring := newTestKeyring(t, 0, 1)
+	message := &models.ContactMessage{
+		SubmissionID: 3,
+		Direction:    models.MessageInbound,
+		MessageID:    "<a@b>",
+		From:         "jane@example.com",
+		To:           "contact@enzo.dev",
+		Subject:      "Re: Question technique",
+		Body:         "Merci Jane\n> Hello there",
+	}
+	from, to, subject, body, version, dataKey := &captureArg{}, &captureArg{}, &captureArg{}, &captureArg{}, &captureArg{}, &captureArg{}
+	mock.ExpectQuery(`INSERT INTO contact_messages`).
+		WithArgs(int64(3), "inbound", "<a@b>", "", []string{}, from, to, subject, body, "", version, dataKey).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "created_at"}).AddRow(int64(11), time.Now()))
+
+	repo := repository.NewConversationRepository(mock, repository.WithKeyring(keyring))
+	assert.NoError(t, repo.AddMessage(context.Background(), message))
+	assert.Equal(t, "jane@example.com", message.From, "the caller's message is left in clear")
+	for _, arg := range []*captureArg{from, to, subject, body} {
+		assert.NotContains(t, arg.value, "Jane")
+		assert.NotContains(t, arg.value, "jane@")
+		assert.NotContains(t, arg.value, "Question")
+	}
+
+	// what was written reads back in clear, next to a row stored in clear
+	columns := []string{"id", "submission_id", "direction", "message_id", "in_reply_to", "refs",
+		"from_address", "to_address", "subject", "body", "author", "created_at", "key_version", "data_key"}
+	now := time.Now()
+	mock.ExpectQuery(`SELECT .* FROM contact_messages`).
+		WithArgs(int64(3)).
+		WillReturnRows(pgxmock.NewRows(columns).
+			AddRow(int64(10), int64(3), "outbound", "<z@b>", "", []string{}, "contact@enzo.dev", "jane@example.com", "Re: hi", "Old", "admin",
+				now, (*int16)(nil), []byte(nil)).
+			AddRow(int64(11), int64(3), "inbound", "<a@b>", "", []string{}, from.value, to.value, subject.value, body.value, "",
+				now, version.value, dataKey.value))
+
+	messages, err := repo.ListMessages(context.Background(), 3)
+	assert.NoError(t, err)
+	if assert.Len(t, messages, 2) {
+		assert.Equal(t, "Old", messages[0].Body)
+		assert.Equal(t, "jane@example.com", messages[1].From)
+		assert.Equal(t, "contact@enzo.dev", messages[1].To)
+		assert.Equal(t, "Re: Question technique", messages[1].Subject)
+		assert.Equal(t, "Merci Jane\n> Hello there", messages[1].Body)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPrivacyRepository_EncryptsRequests(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	keyring := newTestKeyring(t, 0, 1)
+	index := keyring.BlindIndex("email", "jane@example.com")
+	expires := time.Now().Add(time.Hour)
+	email, version, dataKey := &captureArg{}, &captureArg{}, &captureArg{}
+	mock.ExpectQuery(`INSERT INTO privacy_requests`).
+		WithArgs(email, index, "hash", expires, version, dataKey).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "created_at"}).AddRow(int64(4), time.Now()))
+
+	repo := repository.NewPrivacyRepository(mock, repository.WithKeyring(keyring))
+	req := &models.PrivacyRequest{Email: "Jane@example.com", TokenHash: "hash", ExpiresAt: expires}
+	assert.NoError(t, repo.CreateRequest(context.Background(), req))
+	assert.NotContains(t, email.value, "example")
+
+	mock.ExpectQuery(`SELECT .* FROM privacy_requests\s+WHERE token_hash = \$1`).
+		WithArgs("hash").
+		WillReturnRows(pgxmock.NewRows([]string{"id", "email", "token_hash", "expires_at", "used_at", "created_at", "key_version", "data_key"}).
+			AddRow(int64(4), email.value, "hash", expires, (*time.Time)(nil), time.Now(), version.value, dataKey.value))
+	found, err := repo.FindRequest(context.Background(), "hash")
+	assert.NoError(t, err)
+	assert.Equal(t, "Jane@example.com", found.Email)
+
+	// the cooldown looks the address up by its blind index
+	since := time.Now().Add(-10 * time.Minute)
+	mock.ExpectQuery(`FROM privacy_requests WHERE \(email_index = \$2 OR \(data_key IS NULL AND lower\(email\) = lower\(\$1\)\)\) AND created_at >= \$3`).
+		WithArgs("JANE@example.com", index, since).
+		WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(true))
+	recent, err := repo.HasRecentRequest(context.Background(), "JANE@example.com", since)
+	assert.NoError(t, err)
+	assert.True(t, recent)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// encryptedRow returns the submission columns of s encrypted with keyring
+func encryptedRow(t *testing.T, keyring *encryption.Keyring, s models.ContactSubmission) []interface{} {
+	t.Helper()
+	dek, err := keyring.NewDataKey()
+	assert.NoError(t, err)
+	seal := func(field, value string) string {
+		sealed, err := dek.Seal(field, value)
+		assert.NoError(t, err)
+		return sealed
+	}
+	version := int16(dek.Version)
+	return []interface{}{s.ID, seal("name", s.Name), seal("email", s.Email), s.Subject, seal("message", s.Message),
+		"normal", []string{}, s.Status, (*string)(nil), s.CreatedAt, s.CreatedAt,
+		(*time.Time)(nil), (*time.Time)(nil), (*time.Time)(nil), (*time.Time)(nil),
+		"", "", "", "", "", "", "", "", "", "", "", int64(0), "", &version, dek.Wrapped}
+}
+
+var submissionRowColumns = append([]string{"id", "name", "email", "subject", "message", "priority", "tags", "status", "assignee",
+	"created_at", "updated_at", "read_at", "replied_at", "archived_at", "deleted_at"}, append(clientMetadataColumns, "key_version", "data_key")...)
+
+// clientMetadataColumns are the request metadata columns of a submission row
+var clientMetadataColumns = []string{"ip_hash", "ip_prefix", "user_agent", "accept_language", "referrer",
+	"utm_source", "utm_medium", "utm_campaign", "utm_term", "utm_content", "country", "asn", "as_org"}
+
+// emptyClientRow holds the values of clientMetadataColumns for a submission without metadata
+var emptyClientRow = []interface{}{"", "", "", "", "", "", "", "", "", "", "", int64(0), ""}
+
+func TestInboxRepository_SearchEncrypted(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	keyring := newTestKeyring(t, 0, 1)
+	submission := func(id int64, name, message string) models.ContactSubmission {
+		s := inboxSubmission(id, models.ContactStatusNew)
+		s.Name, s.Message, s.CreatedAt = name, message, time.Now().Add(-time.Duration(id)*time.Hour)
+		return s
+	}
+	rows := pgxmock.NewRows(submissionRowColumns).
+		AddRow(encryptedRow(t, keyring, submission(1, "Jane", "Mon serveur Proxmox est tombé"))...).
+		AddRow(encryptedRow(t, keyring, submission(2, "Proxmox Fan", "Une question sur Proxmox et Docker"))...).
+		AddRow(encryptedRow(t, keyring, submission(3, "Bob", "Rien à voir"))...)
+
+	// candidates are listed with the other filters, then matched in memory
+	mock.ExpectQuery(`SELECT .* FROM contact_submissions\s+WHERE`).
+		WithArgs("", "", "", (*time.Time)(nil), (*time.Time)(nil), 500, 0, "").
+		WillReturnRows(rows)
+
+	repo := repository.NewInboxRepository(mock, repository.WithKeyring(keyring))
+	results, err := repo.SearchSubmissions(context.Background(), models.ContactFilter{Query: `proxmox -docker or "a voir"`, Limit: 50})
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	ids := []int64{}
+	for _, r := range results {
+		ids = append(ids, r.ID)
+	}
+	assert.Equal(t, []int64{1, 3}, ids)
+	assert.Equal(t, "Mon serveur "+repository.HeadlineStart+"Proxmox"+repository.HeadlineStop+" est tombé", results[0].Snippet)
+	assert.Equal(t, "Rien "+repository.HeadlineStart+"à voir"+repository.HeadlineStop, results[1].Snippet)
+}
+
+func TestInboxRepository_SearchEncryptedLongUnbrokenMessage(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	keyring := newTestKeyring(t, 0, 1)
+	s := inboxSubmission(1, models.ContactStatusNew)
+	s.Message = strings.Repeat("x", 300) + "proxmox" + strings.Repeat("y", 300)
+	mock.ExpectQuery(`SELECT .* FROM contact_submissions\s+WHERE`).
+		WithArgs("", "", "", (*time.Time)(nil), (*time.Time)(nil), 500, 0, "").
+		WillReturnRows(pgxmock.NewRows(submissionRowColumns).AddRow(encryptedRow(t, keyring, s)...))
+
+	repo := repository.NewInboxRepository(mock, repository.WithKeyring(keyring))
+	results, err := repo.SearchSubmissions(context.Background(), models.ContactFilter{Query: "proxmox", Limit: 50})
+	assert.NoError(t, err)
+	assert.Len(t, results, 1)
+
+	// without a space to cut at, the excerpt is cut at the raw offsets
+	want := "… " + strings.Repeat("x", 80) + repository.HeadlineStart + "proxmox" + repository.HeadlineStop + strings.Repeat("y", 153) + " …"
+	assert.Equal(t, want, results[0].Snippet)
+}
+
+func TestInboxRepository_SearchEncryptedReadsEveryBatch(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	keyring := newTestKeyring(t, 0, 1)
+	first := pgxmock.NewRows(submissionRowColumns)
+	for id := int64(1); id <= 500; id++ {
+		s := inboxSubmission(id, models.ContactStatusNew)
+		s.Message = "Rien à voir"
+		first.AddRow(encryptedRow(t, keyring, s)...)
+	}
+	old := inboxSubmission(501, models.ContactStatusNew)
+	old.Message = "Un vieux message sur Proxmox"
+
+	// a full batch means there may be more candidates: the next one is read too
+	mock.ExpectQuery(`SELECT .* FROM contact_submissions\s+WHERE`).
+		WithArgs("", "", "", (*time.Time)(nil), (*time.Time)(nil), 500, 0, "").
+		WillReturnRows(first)
+	mock.ExpectQuery(`SELECT .* FROM contact_submissions\s+WHERE`).
+		WithArgs("", "", "", (*time.Time)(nil), (*time.Time)(nil), 500, 500, "").
+		WillReturnRows(pgxmock.NewRows(submissionRowColumns).AddRow(encryptedRow(t, keyring, old)...))
+
+	repo := repository.NewInboxRepository(mock, repository.WithKeyring(keyring))
+	results, err := repo.SearchSubmissions(context.Background(), models.ContactFilter{Query: "proxmox", Limit: 50})
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	if assert.Len(t, results, 1) {
+		assert.Equal(t, int64(501), results[0].ID)
+	}
+}
+
+func TestKeyRotationRepository_Reencrypt(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	old := newTestKeyring(t, 0, 1)
+	rotated := newTestKeyring(t, 0, 1, 2)
+	columns := []string{"id", "name", "email", "subject", "message", "key_version", "data_key"}
+
+	dek, err := old.NewDataKey()
+	assert.NoError(t, err)
+	sealedName, _ := dek.Seal("name", "Jane")
+	sealedEmail, _ := dek.Seal("email", "jane@example.com")
+	sealedMessage, _ := dek.Seal("message", "Hello")
+	v1 := int16(1)
+
+	mock.ExpectQuery(`SELECT id, name, email, subject, message, key_version, data_key`).
+		WithArgs(int64(0), false, int16(2), 100).
+		WillReturnRows(pgxmock.NewRows(columns).
+			AddRow(int64(4), "Bob", "bob@example.com", "stage", "In clear", (*int16)(nil), []byte(nil)).
+			AddRow(int64(9), sealedName, sealedEmail, "question", sealedMessage, &v1, dek.Wrapped))
+
+	name, version := &captureArg{}, &captureArg{}
+	mock.ExpectExec(`UPDATE contact_submissions SET`).
+		WithArgs(int64(4), name, pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), version, pgxmock.AnyArg(),
+			pgxmock.AnyArg(), (*int16)(nil)).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectExec(`UPDATE contact_submissions SET`).
+		WithArgs(int64(9), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(),
+			pgxmock.AnyArg(), &v1).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectQuery(`SELECT id, name, email, subject, message, key_version, data_key`).
+		WithArgs(int64(9), false, int16(2), 100).
+		WillReturnRows(pgxmock.NewRows(columns))
+
+	// then the conversation messages
+	messageColumns := []string{"id", "from_address", "to_address", "subject", "body", "key_version", "data_key"}
+	mock.ExpectQuery(`SELECT id, from_address, to_address, subject, body, key_version, data_key`).
+		WithArgs(int64(0), false, int16(2), 100).
+		WillReturnRows(pgxmock.NewRows(messageColumns).
+			AddRow(int64(5), "jane@example.com", "contact@enzo.dev", "Re: hi", "In clear", (*int16)(nil), []byte(nil)))
+	body := &captureArg{}
+	mock.ExpectExec(`UPDATE contact_messages SET`).
+		WithArgs(int64(5), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), body, pgxmock.AnyArg(), pgxmock.AnyArg(), (*int16)(nil)).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectQuery(`SELECT id, from_address, to_address, subject, body, key_version, data_key`).
+		WithArgs(int64(5), false, int16(2), 100).
+		WillReturnRows(pgxmock.NewRows(messageColumns))
+
+	// and the emails of privacy requests
+	requestColumns := []string{"id", "email", "key_version", "data_key"}
+	mock.ExpectQuery(`SELECT id, email, key_version, data_key\s+FROM privacy_requests`).
+		WithArgs(int64(0), false, int16(2), 100).
+		WillReturnRows(pgxmock.NewRows(requestColumns).AddRow(int64(2), "Jane@Example.com", (*int16)(nil), []byte(nil)))
+	mock.ExpectExec(`UPDATE privacy_requests SET`).
+		WithArgs(int64(2), pgxmock.AnyArg(), rotated.BlindIndex("email", "jane@example.com"), pgxmock.AnyArg(), pgxmock.AnyArg(), (*int16)(nil)).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectQuery(`SELECT id, email, key_version, data_key\s+FROM privacy_requests`).
+		WithArgs(int64(2), false, int16(2), 100).
+		WillReturnRows(pgxmock.NewRows(requestColumns))
+
+	count, err := repository.NewKeyRotationRepository(mock, rotated).Reencrypt(context.Background(), false, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), count)
+	assert.NotEqual(t, "In clear", body.value)
+	assert.NotEqual(t, "Bob", name.value)
+	assert.Equal(t, int16(2), *version.value.(*int16))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPrivacyRepository_LooksUpByBlindIndex(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	keyring := newTestKeyring(t, 0, 1)
+	mock.ExpectQuery(`WHERE \(email_index = \$2 OR \(data_key IS NULL AND lower\(email\) = lower\(\$1\)\)\)`).
+		WithArgs(" JANE@example.com", keyring.BlindIndex("email", "jane@example.com")).
+		WillReturnRows(pgxmock.NewRows(submissionRowColumns).
+			AddRow(encryptedRow(t, keyring, inboxSubmission(1, models.ContactStatusNew))...))
+
+	list, err := repository.NewPrivacyRepository(mock, repository.WithKeyring(keyring)).ListSubmissionsByEmail(context.Background(), " JANE@example.com")
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+	assert.Equal(t, "jane@example.com", list[0].Email)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
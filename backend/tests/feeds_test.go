@@ -0,0 +1,89 @@
+package tests_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	handlers "backend/api/handlers"
+	"backend/internal/models"
+	"backend/internal/services"
+	"backend/internal/site"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFeedService_SitemapAndFeeds(t *testing.T) {
+	ctx := context.Background()
+	var feeds services.IFeedService
+	projects := services.NewProjectService(newMemoryProjectRepository(), services.WithProjectChanges(func() { feeds.Invalidate() }))
+	feeds = services.NewFeedService([]services.ContentSource{services.ProjectEntries(projects)}, services.FeedOptions{
+		Site:  site.Info{Name: "Enzo Gaggiotti", Description: "Portfolio", URL: "https://example.com"},
+		Pages: []string{"/", "/projects", "/about"},
+	})
+
+	sitemap, err := feeds.Sitemap(ctx)
+	assert.NoError(t, err)
+	assert.Contains(t, string(sitemap), `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`)
+	assert.Contains(t, string(sitemap), "<loc>https://example.com/about</loc>")
+	assert.NotContains(t, string(sitemap), "<lastmod>")
+
+	created, err := projects.Create(ctx, models.ProjectInput{Title: "Proxmox & Ceph", Summary: "Cluster", Category: models.ProjectInfrastructure, Technologies: []string{"Proxmox"}})
+	assert.NoError(t, err)
+	sitemap, err = feeds.Sitemap(ctx)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(sitemap), "/projects/proxmox-ceph", "drafts are not listed")
+
+	published := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+	_, err = projects.Publish(ctx, created.ID, models.ProjectPublication{At: &published})
+	assert.NoError(t, err)
+	sitemap, err = feeds.Sitemap(ctx)
+	assert.NoError(t, err)
+	body := string(sitemap)
+	assert.Contains(t, body, "<loc>https://example.com/projects/proxmox-ceph</loc>", "publishing invalidates the cache")
+	assert.Contains(t, body, "<loc>https://example.com/projects</loc>\n    <lastmod>")
+	assert.Contains(t, body, "<loc>https://example.com/about</loc>\n  </url>", "pages without content have no lastmod")
+
+	atom, err := feeds.Atom(ctx)
+	assert.NoError(t, err)
+	assert.Contains(t, string(atom), `<feed xmlns="http://www.w3.org/2005/Atom">`)
+	assert.Contains(t, string(atom), "<title>Proxmox &amp; Ceph</title>")
+	assert.Contains(t, string(atom), "<published>2025-05-01T12:00:00Z</published>")
+	assert.Contains(t, string(atom), `<category term="Proxmox"></category>`)
+
+	rss, err := feeds.RSS(ctx)
+	assert.NoError(t, err)
+	assert.Contains(t, string(rss), `<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom">`)
+	assert.Contains(t, string(rss), "<pubDate>Thu, 01 May 2025 12:00:00 +0000</pubDate>")
+	assert.Contains(t, string(rss), "<guid>https://example.com/projects/proxmox-ceph</guid>")
+
+	assert.NoError(t, projects.Delete(ctx, created.ID))
+	rss, err = feeds.RSS(ctx)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(rss), "<item>")
+}
+
+func TestFeedHandler_Robots(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	info := site.Info{URL: "https://example.com"}
+	do := func(robots site.RobotsOptions) *httptest.ResponseRecorder {
+		h := handlers.NewFeedHandler(services.NewFeedService(nil, services.FeedOptions{Site: info, Robots: robots}))
+		router := gin.New()
+		router.GET("/robots.txt", h.HandleRobots)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/robots.txt", nil))
+		return w
+	}
+
+	w := do(site.RobotsOptions{Disallow: []string{"/api/"}})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/plain; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.NotEmpty(t, w.Header().Get("ETag"))
+	assert.Equal(t, "User-agent: *\nAllow: /\nDisallow: /api/\n\nSitemap: https://example.com/sitemap.xml\n", w.Body.String())
+
+	w = do(site.RobotsOptions{NoIndex: true})
+	assert.Equal(t, "User-agent: *\nDisallow: /\n", w.Body.String())
+}
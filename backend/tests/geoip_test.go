@@ -0,0 +1,292 @@
+package tests_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"backend/internal/geoip"
+	"backend/internal/middleware"
+	"backend/internal/models"
+	"backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// mmdbValue encodes v in the MaxMind DB data format. Supports the types
+// found in country and ASN databases: strings, unsigned integers, maps and
+// string arrays.
+func mmdbValue(buf *bytes.Buffer, v interface{}) {
+	control := func(typ, size int) {
+		sizeBits, extra := size, -1
+		if size >= 29 {
+			sizeBits, extra = 29, size-29 // sizes up to 284
+		}
+		if typ <= 7 {
+			buf.WriteByte(byte(typ<<5 | sizeBits))
+		} else {
+			buf.WriteByte(byte(sizeBits))
+			buf.WriteByte(byte(typ - 7))
+		}
+		if extra >= 0 {
+			buf.WriteByte(byte(extra))
+		}
+	}
+	switch v := v.(type) {
+	case string:
+		control(2, len(v))
+		buf.WriteString(v)
+	case uint32:
+		var b [4]byte
+		binary.BigEndian.PutUint32(b[:], v)
+		control(6, 4)
+		buf.Write(b[:])
+	case map[string]interface{}:
+		control(7, len(v))
+		for key, value := range v {
+			mmdbValue(buf, key)
+			mmdbValue(buf, value)
+		}
+	case []string:
+		control(11, len(v))
+		for _, s := range v {
+			mmdbValue(buf, s)
+		}
+	default:
+		panic("unsupported mmdb value")
+	}
+}
+
+// writeMMDB writes an IPv4 database (24-bit records) mapping each prefix to its record
+func writeMMDB(t *testing.T, path, dbType string, records map[string]map[string]interface{}) {
+	t.Helper()
+	const empty = -1
+	nodes := [][2]int{{empty, empty}}
+	var data bytes.Buffer
+	type leaf struct{ node, bit, offset int }
+	var leaves []leaf
+
+	for prefix, record := range records {
+		p := netip.MustParsePrefix(prefix)
+		offset := data.Len()
+		mmdbValue(&data, record)
+
+		ip := p.Addr().As4()
+		node := 0
+		for i := 0; i < p.Bits(); i++ {
+			bit := int(ip[i/8]>>(7-i%8)) & 1
+			if i == p.Bits()-1 {
+				leaves = append(leaves, leaf{node, bit, offset})
+				break
+			}
+			if nodes[node][bit] == empty {
+				nodes = append(nodes, [2]int{empty, empty})
+				nodes[node][bit] = len(nodes) - 1
+			}
+			node = nodes[node][bit]
+		}
+	}
+
+	count := len(nodes)
+	for i := range nodes {
+		for bit := range nodes[i] {
+			if nodes[i][bit] == empty {
+				nodes[i][bit] = count
+			}
+		}
+	}
+	for _, l := range leaves {
+		nodes[l.node][l.bit] = count + 16 + l.offset
+	}
+
+	var file bytes.Buffer
+	for _, n := range nodes {
+		for _, record := range n {
+			file.Write([]byte{byte(record >> 16), byte(record >> 8), byte(record)})
+		}
+	}
+	file.Write(make([]byte, 16))
+	file.Write(data.Bytes())
+	file.WriteString("\xab\xcd\xefMaxMind.com")
+	mmdbValue(&file, map[string]interface{}{
+		"node_count":                  uint32(count),
+		"record_size":                 uint32(24),
+		"ip_version":                  uint32(4),
+		"database_type":               dbType,
+		"languages":                   []string{"en"},
+		"binary_format_major_version": uint32(2),
+		"binary_format_minor_version": uint32(0),
+		"build_epoch":                 uint32(time.Now().Unix()),
+		"description":                 map[string]interface{}{"en": "test"},
+	})
+	assert.NoError(t, os.WriteFile(path, file.Bytes(), 0o644))
+}
+
+// newTestGeoDatabases writes a country and an ASN database to a temp dir
+func newTestGeoDatabases(t *testing.T) (country, asn string) {
+	t.Helper()
+	dir := t.TempDir()
+	country, asn = filepath.Join(dir, "country.mmdb"), filepath.Join(dir, "asn.mmdb")
+	writeMMDB(t, country, "GeoLite2-Country", map[string]map[string]interface{}{
+		"203.0.113.0/24":  {"country": map[string]interface{}{"iso_code": "FR"}},
+		"198.51.100.0/24": {"registered_country": map[string]interface{}{"iso_code": "DE"}},
+	})
+	writeMMDB(t, asn, "GeoLite2-ASN", map[string]map[string]interface{}{
+		"203.0.113.0/25": {"autonomous_system_number": uint32(16276), "autonomous_system_organization": "OVH SAS"},
+	})
+	return country, asn
+}
+
+func TestGeoIP_Lookup(t *testing.T) {
+	country, asn := newTestGeoDatabases(t)
+	locator, err := geoip.Open(country, asn)
+	assert.NoError(t, err)
+
+	assert.Equal(t, models.GeoLocation{Country: "FR", ASN: 16276, ASOrg: "OVH SAS"}, locator.Lookup("203.0.113.7"))
+	assert.Equal(t, models.GeoLocation{Country: "FR"}, locator.Lookup("203.0.113.200"))
+	assert.Equal(t, models.GeoLocation{Country: "DE"}, locator.Lookup("::ffff:198.51.100.1"), "registered country is the fallback")
+	assert.Equal(t, models.GeoLocation{}, locator.Lookup("192.0.2.1"))
+	assert.Equal(t, models.GeoLocation{}, locator.Lookup("2001:db8::1"), "IPv6 in an IPv4 database")
+	assert.Equal(t, models.GeoLocation{}, locator.Lookup("not an ip"))
+
+	var none *geoip.Locator
+	assert.Equal(t, models.GeoLocation{}, none.Lookup("203.0.113.7"))
+
+	_, err = geoip.Open(filepath.Join(t.TempDir(), "missing.mmdb"))
+	assert.Error(t, err)
+	bad := filepath.Join(t.TempDir(), "bad.mmdb")
+	assert.NoError(t, os.WriteFile(bad, []byte("not a database"), 0o644))
+	_, err = geoip.Open(bad)
+	assert.Error(t, err)
+}
+
+func TestGeoIP_ReloadSwapsChangedFiles(t *testing.T) {
+	country, _ := newTestGeoDatabases(t)
+	locator, err := geoip.Open(country)
+	assert.NoError(t, err)
+	assert.NoError(t, locator.Reload(context.Background()), "unchanged file")
+
+	writeMMDB(t, country, "GeoLite2-Country", map[string]map[string]interface{}{
+		"203.0.113.0/24": {"country": map[string]interface{}{"iso_code": "BE"}},
+	})
+	future := time.Now().Add(time.Minute)
+	assert.NoError(t, os.Chtimes(country, future, future))
+	assert.NoError(t, locator.Reload(context.Background()))
+	assert.Equal(t, "BE", locator.Lookup("203.0.113.7").Country)
+
+	// a broken update keeps the previous version
+	assert.NoError(t, os.WriteFile(country, []byte("partial"), 0o644))
+	assert.Error(t, locator.Reload(context.Background()))
+	assert.Equal(t, "BE", locator.Lookup("203.0.113.7").Country)
+}
+
+func TestContactService_StoresGeoLocation(t *testing.T) {
+	country, asn := newTestGeoDatabases(t)
+	locator, err := geoip.Open(country, asn)
+	assert.NoError(t, err)
+
+	repo := new(mockContactRepository)
+	notifier := new(mockNotifier)
+	notifier.On("Notify", mock.Anything, mock.Anything).Return(nil).Maybe()
+	var stored *models.ContactSubmission
+	repo.On("SaveContactForm", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		stored = args.Get(1).(*models.ContactSubmission)
+	}).Return(nil)
+	anonymizer, _ := services.NewIPAnonymizer(services.ClientIPHash, "salt")
+	engine := newTestRoutingEngine(t, models.RoutingRule{Name: "hosting", Enabled: true, ASNs: []int64{16276}, Tags: []string{"hosting"}},
+		models.RoutingRule{Name: "france", Enabled: true, Countries: []string{"fr"}, Priority: models.PriorityHigh})
+	svc := services.NewContactService(repo, notifier, services.WithClientIP(anonymizer), services.WithGeoIP(locator), services.WithRouting(engine))
+
+	form := models.ContactForm{Name: "Jane", Email: "jane@example.com", Subject: "question", Message: "Hello"}
+	submit := func(ip string) {
+		ctx := services.WithClientMetadata(context.Background(), models.ClientMetadata{IP: ip})
+		assert.NoError(t, svc.SubmitContactForm(ctx, form))
+	}
+
+	submit("203.0.113.7")
+	assert.Equal(t, models.GeoLocation{Country: "FR", ASN: 16276, ASOrg: "OVH SAS"}, stored.Client.GeoLocation)
+	assert.Equal(t, []string{"hosting"}, stored.Tags)
+	assert.Empty(t, stored.Client.IP)
+	assert.NotEmpty(t, stored.Client.IPHash)
+
+	submit("203.0.113.200")
+	assert.Equal(t, models.PriorityHigh, stored.Priority, "country rule (codes are case-insensitive)")
+
+	submit("192.0.2.1")
+	assert.Equal(t, models.PriorityNormal, stored.Priority)
+	assert.Equal(t, models.GeoLocation{}, stored.Client.GeoLocation)
+}
+
+func TestRateLimiter(t *testing.T) {
+	country, asn := newTestGeoDatabases(t)
+	locator, err := geoip.Open(country, asn)
+	assert.NoError(t, err)
+
+	limiter := services.NewRateLimiter(locator, services.RateLimitOptions{
+		Default:    services.RateLimitPolicy{Limit: 3, Window: time.Hour},
+		Strict:     services.RateLimitPolicy{Limit: 1, Window: time.Hour},
+		StrictASNs: []int64{16276},
+	})
+	allowed := func(ip string) bool {
+		ok, _ := limiter.Allow(ip)
+		return ok
+	}
+
+	// hosting ASN gets the strict policy
+	assert.True(t, allowed("203.0.113.7"))
+	ok, retryAfter := limiter.Allow("203.0.113.7")
+	assert.False(t, ok)
+	assert.InDelta(t, time.Hour.Seconds(), retryAfter.Seconds(), 5)
+
+	// same country, other network: default policy
+	for i := 0; i < 3; i++ {
+		assert.True(t, allowed("203.0.113.200"))
+	}
+	assert.False(t, allowed("203.0.113.200"))
+
+	// IPv6 clients are counted per /64
+	for i := 0; i < 3; i++ {
+		assert.True(t, allowed("2001:db8:1:2::1"))
+	}
+	assert.False(t, allowed("2001:db8:1:2::ffff"))
+	assert.True(t, allowed("2001:db8:1:3::1"))
+
+	assert.True(t, allowed("garbage"), "unparsable addresses are not limited")
+
+	assert.NoError(t, limiter.Prune(context.Background()))
+	assert.False(t, allowed("203.0.113.200"), "windows in progress are kept")
+
+	disabled := services.NewRateLimiter(nil, services.RateLimitOptions{})
+	for i := 0; i < 100; i++ {
+		ok, _ := disabled.Allow("198.51.100.1")
+		assert.True(t, ok)
+	}
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	limiter := services.NewRateLimiter(nil, services.RateLimitOptions{Default: services.RateLimitPolicy{Limit: 1, Window: time.Minute}})
+	router := gin.New()
+	router.POST("/contact", middleware.RateLimit(limiter), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	send := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/contact", nil)
+		req.RemoteAddr = "198.51.100.4:1234"
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	assert.Equal(t, http.StatusOK, send().Code)
+	w := send()
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "60", w.Header().Get("Retry-After"))
+}
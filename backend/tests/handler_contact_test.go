@@ -35,7 +35,7 @@ func TestHandleSendContactForm_Success(t *testing.T) {
 	router := gin.New()
 	router.POST("/contact", h.HandleSendContactForm)
 
-	payload := models.ContactForm{Name: "John", Email: "john@example.com", Subject: "Hello", Message: "Hi"}
+	payload := models.ContactForm{Name: "John", Email: "john@example.com", Subject: "question", Message: "Hi"}
 	body, _ := json.Marshal(payload)
 	req := httptest.NewRequest("POST", "/contact", bytes.NewReader(body))
 	req.Header.Set("Content-Type", "application/json")
@@ -57,7 +57,7 @@ func TestHandleSendContactForm_ValidationFail(t *testing.T) {
 	router.POST("/contact", h.HandleSendContactForm)
 
 	// Missing email field
-	payload := map[string]string{"name": "John", "subject": "Hello", "message": "Hi"}
+	payload := map[string]string{"name": "John", "subject": "question", "message": "Hi"}
 	body, _ := json.Marshal(payload)
 	req := httptest.NewRequest("POST", "/contact", bytes.NewReader(body))
 	req.Header.Set("Content-Type", "application/json")
@@ -97,7 +97,7 @@ func TestHandleSendContactForm_ServiceError(t *testing.T) {
 	router := gin.New()
 	router.POST("/contact", h.HandleSendContactForm)
 
-	payload := models.ContactForm{Name: "John", Email: "john@example.com", Subject: "Hello", Message: "Hi"}
+	payload := models.ContactForm{Name: "John", Email: "john@example.com", Subject: "question", Message: "Hi"}
 	body, _ := json.Marshal(payload)
 	req := httptest.NewRequest("POST", "/contact", bytes.NewReader(body))
 	req.Header.Set("Content-Type", "application/json")
@@ -119,7 +119,7 @@ func TestHandleSendContactForm_InvalidEmail(t *testing.T) {
 	router.POST("/contact", h.HandleSendContactForm)
 
 	// Invalid email format
-	payload := models.ContactForm{Name: "John", Email: "not-an-email", Subject: "Hello", Message: "Hi"}
+	payload := models.ContactForm{Name: "John", Email: "not-an-email", Subject: "question", Message: "Hi"}
 	body, _ := json.Marshal(payload)
 	req := httptest.NewRequest("POST", "/contact", bytes.NewReader(body))
 	req.Header.Set("Content-Type", "application/json")
@@ -0,0 +1,218 @@
+package tests_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"backend/internal/middleware"
+	"backend/internal/models"
+	"backend/internal/repository"
+	"backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pashagolub/pgxmock/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// in-memory implementation of the idempotency store
+type memoryIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[string]*models.IdempotencyRecord
+	leases  map[string]time.Time
+}
+
+func newMemoryIdempotencyStore() *memoryIdempotencyStore {
+	return &memoryIdempotencyStore{records: map[string]*models.IdempotencyRecord{}, leases: map[string]time.Time{}}
+}
+
+func (s *memoryIdempotencyStore) Reserve(ctx context.Context, key, requestHash string, expiredBefore, lockedUntil time.Time) (*models.IdempotencyRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if rec, ok := s.records[key]; ok && !rec.CreatedAt.Before(expiredBefore) &&
+		(rec.Completed() || !s.leases[key].Before(time.Now())) {
+		copied := *rec
+		return &copied, false, nil
+	}
+	s.records[key] = &models.IdempotencyRecord{Key: key, RequestHash: requestHash, CreatedAt: time.Now()}
+	s.leases[key] = lockedUntil
+	return nil, true, nil
+}
+
+func (s *memoryIdempotencyStore) Complete(ctx context.Context, key string, statusCode int, body []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[key].StatusCode = &statusCode
+	s.records[key].ResponseBody = append([]byte(nil), body...)
+	return nil
+}
+
+func (s *memoryIdempotencyStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, key)
+	return nil
+}
+
+func (s *memoryIdempotencyStore) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	return 0, nil
+}
+
+func newIdempotentRouter(store repository.IIdempotencyRepository, status *int, calls *int, sawKey *string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/contact", middleware.Idempotency(store, time.Hour, time.Minute), func(c *gin.Context) {
+		*calls++
+		*sawKey = services.IdempotencyKeyFromContext(c.Request.Context())
+		c.JSON(*status, gin.H{"call": *calls})
+	})
+	return router
+}
+
+func postWithKey(router *gin.Engine, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", "/contact", bytes.NewReader([]byte(body)))
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set(middleware.IdempotencyKeyHeader, key)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotency_ReplaysFirstResponse(t *testing.T) {
+	status, calls, sawKey := http.StatusOK, 0, ""
+	router := newIdempotentRouter(newMemoryIdempotencyStore(), &status, &calls, &sawKey)
+
+	first := postWithKey(router, "key-1", `{"a":1}`)
+	second := postWithKey(router, "key-1", `{"a":1}`)
+
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, http.StatusOK, second.Code)
+	assert.Equal(t, 1, calls, "handler should only run once")
+	assert.Equal(t, "key-1", sawKey)
+	assert.JSONEq(t, first.Body.String(), second.Body.String())
+	assert.Equal(t, "true", second.Header().Get("Idempotent-Replayed"))
+}
+
+func TestIdempotency_DifferentPayloadRejected(t *testing.T) {
+	status, calls, sawKey := http.StatusOK, 0, ""
+	router := newIdempotentRouter(newMemoryIdempotencyStore(), &status, &calls, &sawKey)
+
+	postWithKey(router, "key-1", `{"a":1}`)
+	w := postWithKey(router, "key-1", `{"a":2}`)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Equal(t, 1, calls)
+}
+
+func TestIdempotency_ServerErrorNotStored(t *testing.T) {
+	status, calls, sawKey := http.StatusInternalServerError, 0, ""
+	router := newIdempotentRouter(newMemoryIdempotencyStore(), &status, &calls, &sawKey)
+
+	first := postWithKey(router, "key-1", `{"a":1}`)
+	status = http.StatusOK
+	second := postWithKey(router, "key-1", `{"a":1}`)
+
+	assert.Equal(t, http.StatusInternalServerError, first.Code)
+	assert.Equal(t, http.StatusOK, second.Code)
+	assert.Equal(t, 2, calls, "a failed request must be retryable with the same key")
+}
+
+func TestIdempotency_InProgressConflict(t *testing.T) {
+	store := newMemoryIdempotencyStore()
+	emptyHash := sha256.Sum256(nil)
+	_, _, _ = store.Reserve(context.Background(), "POST /contact key-1", hex.EncodeToString(emptyHash[:]), time.Now(), time.Now().Add(time.Minute))
+	status, calls, sawKey := http.StatusOK, 0, ""
+	router := newIdempotentRouter(store, &status, &calls, &sawKey)
+
+	w := postWithKey(router, "key-1", "")
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, 0, calls)
+}
+
+func TestIdempotency_ExpiredLeaseTakenOver(t *testing.T) {
+	store := newMemoryIdempotencyStore()
+	emptyHash := sha256.Sum256(nil)
+	// a request that crashed before storing its response
+	_, _, _ = store.Reserve(context.Background(), "POST /contact key-1", hex.EncodeToString(emptyHash[:]), time.Now(), time.Now().Add(-time.Second))
+	status, calls, sawKey := http.StatusOK, 0, ""
+	router := newIdempotentRouter(store, &status, &calls, &sawKey)
+
+	w := postWithKey(router, "key-1", "")
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 1, calls, "the retry runs once the lease ran out")
+	assert.Equal(t, http.StatusOK, postWithKey(router, "key-1", "").Code)
+	assert.Equal(t, 1, calls, "and its response is replayed")
+}
+
+func TestIdempotency_WithoutKey(t *testing.T) {
+	status, calls, sawKey := http.StatusOK, 0, ""
+	router := newIdempotentRouter(newMemoryIdempotencyStore(), &status, &calls, &sawKey)
+
+	postWithKey(router, "", `{"a":1}`)
+	postWithKey(router, "", `{"a":1}`)
+
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, "", sawKey)
+}
+
+func TestIdempotency_InvalidKey(t *testing.T) {
+	status, calls, sawKey := http.StatusOK, 0, ""
+	router := newIdempotentRouter(newMemoryIdempotencyStore(), &status, &calls, &sawKey)
+
+	w := postWithKey(router, "bad key with spaces", `{"a":1}`)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, 0, calls)
+}
+
+func TestIdempotencyRepository_Reserve(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	expiredBefore := time.Now().Add(-time.Hour)
+	lockedUntil := time.Now().Add(time.Minute)
+	repo := repository.NewIdempotencyRepository(mock)
+
+	t.Run("new key", func(t *testing.T) {
+		mock.ExpectExec(`INSERT INTO idempotency_keys .* OR \(idempotency_keys.status_code IS NULL AND idempotency_keys.locked_until < NOW\(\)\)`).
+			WithArgs("k", "h", expiredBefore, lockedUntil).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+		rec, reserved, err := repo.Reserve(context.Background(), "k", "h", expiredBefore, lockedUntil)
+
+		assert.NoError(t, err)
+		assert.True(t, reserved)
+		assert.Nil(t, rec)
+	})
+
+	t.Run("existing key", func(t *testing.T) {
+		code := http.StatusOK
+		mock.ExpectExec(`INSERT INTO idempotency_keys`).
+			WithArgs("k", "h", expiredBefore, lockedUntil).
+			WillReturnResult(pgxmock.NewResult("INSERT", 0))
+		mock.ExpectQuery(`SELECT key, request_hash, status_code, response_body, created_at`).
+			WithArgs("k").
+			WillReturnRows(pgxmock.NewRows([]string{"key", "request_hash", "status_code", "response_body", "created_at"}).
+				AddRow("k", "h", &code, []byte(`{}`), time.Now()))
+
+		rec, reserved, err := repo.Reserve(context.Background(), "k", "h", expiredBefore, lockedUntil)
+
+		assert.NoError(t, err)
+		assert.False(t, reserved)
+		assert.True(t, rec.Completed())
+		assert.Equal(t, http.StatusOK, *rec.StatusCode)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
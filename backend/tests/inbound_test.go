@@ -0,0 +1,198 @@
+package tests_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"backend/internal/models"
+	"backend/internal/repository"
+	"backend/internal/services"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const inboundSecret = "inbound-secret"
+
+func newTestInboundService(submissions ...models.ContactSubmission) (services.IInboundService, *memoryConversationRepository) {
+	conversations := &memoryConversationRepository{}
+	svc := services.NewInboundService(newMemoryInboxRepository(submissions...), conversations, services.InboundOptions{
+		Domain:      "enzo.dev",
+		TokenSecret: inboundSecret,
+	})
+	return svc, conversations
+}
+
+func rawEmail(headers map[string]string, body string) string {
+	var b strings.Builder
+	for k, v := range headers {
+		b.WriteString(k + ": " + v + "\r\n")
+	}
+	b.WriteString("\r\n" + body)
+	return b.String()
+}
+
+func TestInboundService_MatchesInReplyTo(t *testing.T) {
+	svc, conversations := newTestInboundService(inboxSubmission(4, models.ContactStatusReplied))
+	conversations.messages = append(conversations.messages, models.ContactMessage{
+		ID: 1, SubmissionID: 4, Direction: models.MessageOutbound, MessageID: "<out-1@enzo.dev>",
+	})
+
+	email := rawEmail(map[string]string{
+		"From":        "Jane <jane@example.com>",
+		"To":          "contact@enzo.dev",
+		"Subject":     "=?UTF-8?Q?Re:_Votre_message_=C3=A9t=C3=A9?=",
+		"Message-ID":  "<in-1@example.com>",
+		"In-Reply-To": "<out-1@enzo.dev>",
+		"References":  "<contact-4@enzo.dev> <out-1@enzo.dev>",
+	}, "Thanks!\r\n\r\n> quoted")
+
+	message, err := svc.Ingest(context.Background(), strings.NewReader(email))
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), message.SubmissionID)
+	assert.Equal(t, models.MessageInbound, message.Direction)
+	assert.Equal(t, "Re: Votre message été", message.Subject)
+	assert.Equal(t, "Thanks!\n\n> quoted", message.Body)
+	assert.Equal(t, []string{"<contact-4@enzo.dev>", "<out-1@enzo.dev>"}, message.References)
+
+	// The same email delivered twice is stored once
+	_, err = svc.Ingest(context.Background(), strings.NewReader(email))
+	assert.ErrorIs(t, err, repository.ErrDuplicateMessage)
+}
+
+func TestInboundService_MatchesSubmissionRootOnlyFromVisitor(t *testing.T) {
+	svc, _ := newTestInboundService(inboxSubmission(4, models.ContactStatusReplied))
+
+	headers := map[string]string{
+		"From":        "jane@example.com",
+		"Message-ID":  "<in-2@example.com>",
+		"In-Reply-To": "<contact-4@enzo.dev>",
+	}
+	message, err := svc.Ingest(context.Background(), strings.NewReader(rawEmail(headers, "Hello")))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), message.SubmissionID)
+
+	headers["From"] = "mallory@example.com"
+	headers["Message-ID"] = "<in-3@example.com>"
+	_, err = svc.Ingest(context.Background(), strings.NewReader(rawEmail(headers, "Hello")))
+	assert.ErrorIs(t, err, services.ErrUnmatchedMessage)
+}
+
+func TestInboundService_MatchesPlusAddressToken(t *testing.T) {
+	svc, _ := newTestInboundService(inboxSubmission(9, models.ContactStatusReplied))
+
+	to := services.PlusAddress("Portfolio <contact@enzo.dev>", services.ReplyToken(inboundSecret, 9))
+	assert.True(t, strings.HasPrefix(to, "contact+c9."))
+
+	email := "From: someone@else.org\r\n" +
+		"To: " + to + "\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: multipart/alternative; boundary=\"b1\"\r\n\r\n" +
+		"--b1\r\nContent-Type: text/html; charset=utf-8\r\n\r\n<p>HTML version</p>\r\n" +
+		"--b1\r\nContent-Type: text/plain; charset=iso-8859-1\r\nContent-Transfer-Encoding: quoted-printable\r\n\r\nD=E9j=E0 r=E9pondu\r\n" +
+		"--b1--\r\n"
+
+	message, err := svc.Ingest(context.Background(), strings.NewReader(email))
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(9), message.SubmissionID)
+	assert.Equal(t, "Déjà répondu", message.Body)
+	assert.True(t, strings.HasPrefix(message.MessageID, "<inbound-"))
+
+	// A forged token does not match
+	forged := strings.Replace(email, "+c9.", "+c8.", 1)
+	_, err = svc.Ingest(context.Background(), strings.NewReader(forged))
+	assert.ErrorIs(t, err, services.ErrUnmatchedMessage)
+}
+
+func TestInboundService_HTMLOnlyAndBase64(t *testing.T) {
+	svc, conversations := newTestInboundService(inboxSubmission(2, models.ContactStatusReplied))
+	conversations.messages = append(conversations.messages, models.ContactMessage{ID: 1, SubmissionID: 2, MessageID: "<out@enzo.dev>"})
+
+	email := "From: jane@example.com\r\nIn-Reply-To: <out@enzo.dev>\r\n" +
+		"Content-Type: text/html; charset=utf-8\r\nContent-Transfer-Encoding: base64\r\n\r\n" +
+		"PHA+SGVsbG8gPGI+dGhlcmU8L2I+PC9wPjxwPlNlZSB5b3U8YnI+c29vbiAmYW1wOyBieWU8L3A+\r\n"
+
+	message, err := svc.Ingest(context.Background(), strings.NewReader(email))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Hello there\nSee you\nsoon & bye", message.Body)
+}
+
+func TestInboundService_LongDisplayName(t *testing.T) {
+	svc, conversations := newTestInboundService(inboxSubmission(2, models.ContactStatusReplied))
+	conversations.messages = append(conversations.messages, models.ContactMessage{ID: 1, SubmissionID: 2, MessageID: "<out@enzo.dev>"})
+
+	email := "From: \"" + strings.Repeat("Jane ", 100) + "\" <jane@example.com>\r\nIn-Reply-To: <out@enzo.dev>\r\n\r\nHello"
+	message, err := svc.Ingest(context.Background(), strings.NewReader(email))
+
+	assert.NoError(t, err)
+	assert.Len(t, []rune(message.From), 320)
+}
+
+// failingIngester fails to store the emails containing "fail" and passes
+// the others to the wrapped service
+type failingIngester struct {
+	services.IInboundService
+}
+
+func (f failingIngester) Ingest(ctx context.Context, r io.Reader) (*models.ContactMessage, error) {
+	data, _ := io.ReadAll(r)
+	if strings.Contains(string(data), "fail") {
+		return nil, errors.New("connection refused")
+	}
+	return f.IInboundService.Ingest(ctx, strings.NewReader(string(data)))
+}
+
+func TestMaildirWatcher_ScanContinuesAfterStorageError(t *testing.T) {
+	dir := t.TempDir()
+	for _, sub := range []string{"new", "cur", "tmp"} {
+		assert.NoError(t, os.Mkdir(filepath.Join(dir, sub), 0o755))
+	}
+
+	svc, conversations := newTestInboundService(inboxSubmission(4, models.ContactStatusReplied))
+	conversations.messages = append(conversations.messages, models.ContactMessage{ID: 1, SubmissionID: 4, MessageID: "<out-1@enzo.dev>"})
+
+	broken := "From: jane@example.com\r\nMessage-ID: <a@example.com>\r\nIn-Reply-To: <out-1@enzo.dev>\r\n\r\nThis one will fail"
+	matched := "From: jane@example.com\r\nMessage-ID: <b@example.com>\r\nIn-Reply-To: <out-1@enzo.dev>\r\n\r\nSure!"
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "new", "1.host"), []byte(broken), 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "new", "2.host"), []byte(matched), 0o600))
+
+	watcher := services.NewMaildirWatcher(dir, failingIngester{svc})
+	assert.NoError(t, watcher.Scan(context.Background()))
+
+	assert.FileExists(t, filepath.Join(dir, "new", "1.host"), "kept for the next scan")
+	assert.FileExists(t, filepath.Join(dir, "cur", "2.host:2,S"), "later messages are still ingested")
+}
+
+func TestMaildirWatcher_Scan(t *testing.T) {
+	dir := t.TempDir()
+	for _, sub := range []string{"new", "cur", "tmp"} {
+		assert.NoError(t, os.Mkdir(filepath.Join(dir, sub), 0o755))
+	}
+
+	svc, conversations := newTestInboundService(inboxSubmission(4, models.ContactStatusReplied))
+	conversations.messages = append(conversations.messages, models.ContactMessage{ID: 1, SubmissionID: 4, MessageID: "<out-1@enzo.dev>"})
+
+	matched := "From: jane@example.com\r\nMessage-ID: <in@example.com>\r\nIn-Reply-To: <out-1@enzo.dev>\r\n\r\nSure!"
+	unmatched := "From: spam@example.com\r\nMessage-ID: <spam@example.com>\r\n\r\nBuy now"
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "new", "1.host"), []byte(matched), 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "new", "2.host"), []byte(unmatched), 0o600))
+
+	watcher := services.NewMaildirWatcher(dir, svc)
+	assert.NoError(t, watcher.Scan(context.Background()))
+
+	remaining, _ := os.ReadDir(filepath.Join(dir, "new"))
+	assert.Empty(t, remaining)
+	assert.FileExists(t, filepath.Join(dir, "cur", "1.host:2,S"))
+	assert.FileExists(t, filepath.Join(dir, "cur", "2.host:2,"))
+
+	history, _ := conversations.ListMessages(context.Background(), 4)
+	assert.Len(t, history, 2)
+	assert.Equal(t, "Sure!", history[1].Body)
+}
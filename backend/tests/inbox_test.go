@@ -0,0 +1,421 @@
+package tests_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	handlers "backend/api/handlers"
+	"backend/internal/models"
+	"backend/internal/repository"
+	"backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// in-memory implementation of the admin inbox storage
+type memoryInboxRepository struct {
+	mu          sync.Mutex
+	submissions map[int64]*models.ContactSubmission
+	notes       []models.ContactNote
+}
+
+func newMemoryInboxRepository(submissions ...models.ContactSubmission) *memoryInboxRepository {
+	r := &memoryInboxRepository{submissions: map[int64]*models.ContactSubmission{}}
+	for i := range submissions {
+		s := submissions[i]
+		r.submissions[s.ID] = &s
+	}
+	return r
+}
+
+func (r *memoryInboxRepository) ListSubmissions(ctx context.Context, filter models.ContactFilter) ([]models.ContactSubmission, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	list := []models.ContactSubmission{}
+	for _, s := range r.submissions {
+		if (filter.Status == "" && s.Status != models.ContactStatusTrashed) || s.Status == filter.Status {
+			list = append(list, *s)
+		}
+	}
+	return list, nil
+}
+
+// SearchSubmissions matches the query as a plain substring of the message
+func (r *memoryInboxRepository) SearchSubmissions(ctx context.Context, filter models.ContactFilter) ([]models.ContactSearchResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	results := []models.ContactSearchResult{}
+	for _, s := range r.submissions {
+		if i := strings.Index(s.Message, filter.Query); i >= 0 {
+			snippet := s.Message[:i] + repository.HeadlineStart + filter.Query + repository.HeadlineStop + s.Message[i+len(filter.Query):]
+			results = append(results, models.ContactSearchResult{ContactSubmission: *s, Rank: 1, Snippet: snippet})
+		}
+	}
+	return results, nil
+}
+
+func (r *memoryInboxRepository) GetSubmission(ctx context.Context, id int64) (*models.ContactSubmission, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s, ok := r.submissions[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	copied := *s
+	return &copied, nil
+}
+
+func (r *memoryInboxRepository) UpdateSubmission(ctx context.Context, id int64, from string, changes models.ContactChanges, note *models.ContactNote) (*models.ContactSubmission, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s, ok := r.submissions[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	if from != "" && s.Status != from {
+		return nil, repository.ErrVersionConflict
+	}
+	if note != nil {
+		note.ID = int64(len(r.notes) + 1)
+		r.notes = append(r.notes, *note)
+	}
+	if changes.Status != nil {
+		s.Status = *changes.Status
+	}
+	if changes.Tags != nil {
+		s.Tags = *changes.Tags
+	}
+	if changes.Assignee != nil {
+		s.Assignee = changes.Assignee
+	}
+	copied := *s
+	return &copied, nil
+}
+
+func (r *memoryInboxRepository) AddNote(ctx context.Context, note *models.ContactNote) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	note.ID = int64(len(r.notes) + 1)
+	r.notes = append(r.notes, *note)
+	return nil
+}
+
+func (r *memoryInboxRepository) ListNotes(ctx context.Context, submissionID int64) ([]models.ContactNote, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	notes := []models.ContactNote{}
+	for _, n := range r.notes {
+		if n.SubmissionID == submissionID {
+			notes = append(notes, n)
+		}
+	}
+	return notes, nil
+}
+
+func (r *memoryInboxRepository) PurgeTrashed(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	return 0, nil
+}
+
+// CountSources groups submissions by referrer only
+func (r *memoryInboxRepository) CountSources(ctx context.Context, filter models.SourceFilter) ([]models.SourceCount, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	counts := map[string]int64{}
+	for _, s := range r.submissions {
+		if s.Client.Referrer != "" {
+			counts[s.Client.Referrer]++
+		}
+	}
+	list := []models.SourceCount{}
+	for value, count := range counts {
+		list = append(list, models.SourceCount{Value: value, Count: count})
+	}
+	return list, nil
+}
+
+func inboxSubmission(id int64, status string, tags ...string) models.ContactSubmission {
+	return models.ContactSubmission{
+		ID:          id,
+		ContactForm: models.ContactForm{Name: "Jane", Email: "jane@example.com", Subject: "question", Message: "Hello"},
+		Priority:    models.PriorityNormal,
+		Tags:        tags,
+		Status:      status,
+	}
+}
+
+func TestCanTransition(t *testing.T) {
+	assert.True(t, models.CanTransition(models.ContactStatusNew, models.ContactStatusRead))
+	assert.True(t, models.CanTransition(models.ContactStatusTrashed, models.ContactStatusNew))
+	assert.True(t, models.CanTransition(models.ContactStatusRead, models.ContactStatusRead))
+	assert.False(t, models.CanTransition(models.ContactStatusReplied, models.ContactStatusNew))
+	assert.False(t, models.CanTransition(models.ContactStatusSpam, models.ContactStatusReplied))
+	assert.False(t, models.CanTransition(models.ContactStatusNew, "deleted"))
+}
+
+func TestInboxService_Update(t *testing.T) {
+	repo := newMemoryInboxRepository(inboxSubmission(1, models.ContactStatusNew, "job"))
+	svc := services.NewInboxService(repo, time.Hour)
+
+	status := models.ContactStatusRead
+	assignee := "  enzo "
+	detail, err := svc.Update(context.Background(), 1, models.ContactUpdate{
+		ContactChanges: models.ContactChanges{Status: &status, Assignee: &assignee},
+		AddTags:        []string{" Urgent", "job"},
+		RemoveTags:     []string{"JOB"},
+		Note:           "Called back",
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.ContactStatusRead, detail.Status)
+	assert.Equal(t, []string{"urgent"}, detail.Tags)
+	assert.Equal(t, "enzo", *detail.Assignee)
+	assert.Len(t, detail.Notes, 1)
+	assert.Equal(t, "admin", detail.Notes[0].Author)
+}
+
+func TestInboxService_Update_InvalidTransition(t *testing.T) {
+	repo := newMemoryInboxRepository(inboxSubmission(1, models.ContactStatusReplied))
+	svc := services.NewInboxService(repo, time.Hour)
+
+	status := models.ContactStatusNew
+	_, err := svc.Update(context.Background(), 1, models.ContactUpdate{ContactChanges: models.ContactChanges{Status: &status}})
+	assert.ErrorIs(t, err, services.ErrInvalidTransition)
+
+	status = "deleted"
+	_, err = svc.Update(context.Background(), 1, models.ContactUpdate{ContactChanges: models.ContactChanges{Status: &status}})
+	assert.ErrorIs(t, err, services.ErrInvalidUpdate)
+}
+
+// staleInboxRepository returns submissions as they were before someone else
+// changed them
+type staleInboxRepository struct {
+	*memoryInboxRepository
+	stale models.ContactSubmission
+}
+
+func (r *staleInboxRepository) GetSubmission(ctx context.Context, id int64) (*models.ContactSubmission, error) {
+	copied := r.stale
+	return &copied, nil
+}
+
+func TestInboxService_Update_StatusChangedMeanwhile(t *testing.T) {
+	// read as new, but marked as spam since
+	memory := newMemoryInboxRepository(inboxSubmission(1, models.ContactStatusSpam))
+	repo := &staleInboxRepository{memoryInboxRepository: memory, stale: inboxSubmission(1, models.ContactStatusNew)}
+	svc := services.NewInboxService(repo, time.Hour)
+
+	status := models.ContactStatusReplied
+	_, err := svc.Update(context.Background(), 1, models.ContactUpdate{
+		ContactChanges: models.ContactChanges{Status: &status},
+		Note:           "Answered by phone",
+	})
+	assert.ErrorIs(t, err, services.ErrInvalidTransition)
+
+	s, _ := memory.GetSubmission(context.Background(), 1)
+	assert.Equal(t, models.ContactStatusSpam, s.Status)
+	notes, _ := memory.ListNotes(context.Background(), 1)
+	assert.Empty(t, notes, "the note goes with the refused change")
+
+	// an invalid note author is refused before anything is changed
+	memory = newMemoryInboxRepository(inboxSubmission(2, models.ContactStatusNew))
+	svc = services.NewInboxService(memory, time.Hour)
+	_, err = svc.Update(context.Background(), 2, models.ContactUpdate{
+		ContactChanges: models.ContactChanges{Status: &status},
+		Note:           "Answered",
+		Author:         strings.Repeat("a", 101),
+	})
+	assert.ErrorIs(t, err, services.ErrInvalidUpdate)
+	s, _ = memory.GetSubmission(context.Background(), 2)
+	assert.Equal(t, models.ContactStatusNew, s.Status)
+}
+
+func TestInboxService_BulkUpdate(t *testing.T) {
+	repo := newMemoryInboxRepository(
+		inboxSubmission(1, models.ContactStatusNew),
+		inboxSubmission(2, models.ContactStatusReplied),
+	)
+	svc := services.NewInboxService(repo, time.Hour)
+
+	status := models.ContactStatusNew
+	result, err := svc.BulkUpdate(context.Background(), models.ContactBulkUpdate{IDs: []int64{1, 2, 3}, Status: &status})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1}, result.Updated)
+	assert.Len(t, result.Failed, 2)
+	assert.Contains(t, result.Failed[3], "not found")
+}
+
+func TestInboxHandler_Routes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	repo := newMemoryInboxRepository(inboxSubmission(1, models.ContactStatusNew), inboxSubmission(2, models.ContactStatusReplied))
+	h := handlers.NewInboxHandler(services.NewInboxService(repo, time.Hour))
+
+	router := gin.New()
+	router.GET("/contacts", h.HandleList)
+	router.POST("/contacts/bulk", h.HandleBulkUpdate)
+	router.GET("/contacts/:id", h.HandleGet)
+	router.PATCH("/contacts/:id", h.HandleUpdate)
+	router.DELETE("/contacts/:id", h.HandleDelete)
+	router.POST("/contacts/:id/notes", h.HandleAddNote)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusBadRequest, do("GET", "/contacts?status=unknown", "").Code)
+	assert.Equal(t, http.StatusNotFound, do("GET", "/contacts/9", "").Code)
+	assert.Equal(t, http.StatusConflict, do("PATCH", "/contacts/2", `{"status":"new"}`).Code)
+
+	w := do("PATCH", "/contacts/1", `{"status":"archived","tags":["a"]}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":"archived"`)
+
+	assert.Equal(t, http.StatusCreated, do("POST", "/contacts/1/notes", `{"body":"Looks legit"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, do("POST", "/contacts/1/notes", `{}`).Code)
+
+	w = do("DELETE", "/contacts/1", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":"trashed"`)
+
+	w = do("GET", "/contacts", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), `"id":1,`)
+
+	assert.Equal(t, http.StatusBadRequest, do("POST", "/contacts/bulk", `{"ids":[]}`).Code)
+	w = do("POST", "/contacts/bulk", `{"ids":[1,2],"status":"read"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"updated":[1,2],"failed":{}}`, w.Body.String())
+}
+
+func TestInboxRepository_PurgeTrashed(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	before := time.Now()
+	mock.ExpectExec(`DELETE FROM contact_submissions WHERE status = 'trashed'`).
+		WithArgs(before).
+		WillReturnResult(pgxmock.NewResult("DELETE", 3))
+
+	n, err := repository.NewInboxRepository(mock).PurgeTrashed(context.Background(), before)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), n)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInboxRepository_UpdateSubmission_StatusChanged(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	now := time.Now()
+	status := models.ContactStatusRead
+	note := &models.ContactNote{Author: "admin", Body: "Called back"}
+	mock.ExpectQuery(`WITH updated AS \(\s*UPDATE contact_submissions SET(.|\s)*WHERE id = \$1 AND \(\$6::text = '' OR status = \$6::text\)(.|\s)*INSERT INTO contact_notes(.|\s)*FROM updated`).
+		WithArgs(int64(4), &status, (*[]string)(nil), false, "", "new", "admin", "Called back", true).
+		WillReturnError(pgx.ErrNoRows)
+	mock.ExpectQuery(`SELECT .* FROM contact_submissions WHERE id = \$1`).
+		WithArgs(int64(4)).
+		WillReturnRows(pgxmock.NewRows(submissionRowColumns).AddRow(append(append([]interface{}{int64(4), "Jane", "jane@example.com", "question", "Hello",
+			"normal", []string{}, "spam", (*string)(nil), now, now, (*time.Time)(nil), (*time.Time)(nil), (*time.Time)(nil), (*time.Time)(nil)},
+			emptyClientRow...), (*int16)(nil), []byte(nil))...))
+
+	_, err = repository.NewInboxRepository(mock).UpdateSubmission(context.Background(), 4, models.ContactStatusNew, models.ContactChanges{Status: &status}, note)
+
+	assert.ErrorIs(t, err, repository.ErrVersionConflict)
+	assert.Zero(t, note.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInboxRepository_GetSubmission_NotFound(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	mock.ExpectQuery(`SELECT .* FROM contact_submissions WHERE id = \$1`).
+		WithArgs(int64(4)).
+		WillReturnError(pgx.ErrNoRows)
+
+	_, err = repository.NewInboxRepository(mock).GetSubmission(context.Background(), 4)
+
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInboxService_SearchHighlightsEscapedSnippet(t *testing.T) {
+	submission := inboxSubmission(1, models.ContactStatusNew)
+	submission.Message = "<script>x</script> my Proxmox cluster"
+	svc := services.NewInboxService(newMemoryInboxRepository(submission), time.Hour)
+
+	results, err := svc.Search(context.Background(), models.ContactFilter{Query: " Proxmox "})
+
+	assert.NoError(t, err)
+	assert.Len(t, results, 1)
+	assert.Equal(t, "&lt;script&gt;x&lt;/script&gt; my <mark>Proxmox</mark> cluster", results[0].Snippet)
+
+	_, err = svc.Search(context.Background(), models.ContactFilter{Query: strings.Repeat("a", models.MaxSearchQueryLength+1)})
+	assert.ErrorIs(t, err, services.ErrInvalidUpdate)
+}
+
+func TestInboxHandler_SearchAndDates(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	submission := inboxSubmission(1, models.ContactStatusNew)
+	submission.Message = "About Proxmox"
+	h := handlers.NewInboxHandler(services.NewInboxService(newMemoryInboxRepository(submission), time.Hour))
+	router := gin.New()
+	router.GET("/contacts", h.HandleList)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w
+	}
+
+	w := get("/contacts?q=Proxmox&from=2025-03-01&to=2025-06-01T00:00:00Z")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"rank":1`)
+	assert.Contains(t, w.Body.String(), `"snippet":"About \u003cmark\u003eProxmox\u003c/mark\u003e"`)
+
+	assert.Equal(t, http.StatusBadRequest, get("/contacts?from=spring").Code)
+	assert.Equal(t, http.StatusBadRequest, get("/contacts?from=2025-06-01&to=2025-03-01").Code)
+}
+
+func TestInboxRepository_SearchSubmissions(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	now := time.Now()
+	columns := []string{"id", "name", "email", "subject", "message", "priority", "tags", "status", "assignee",
+		"created_at", "updated_at", "read_at", "replied_at", "archived_at", "deleted_at",
+		"ip_hash", "ip_prefix", "user_agent", "accept_language", "referrer",
+		"utm_source", "utm_medium", "utm_campaign", "utm_term", "utm_content", "country", "asn", "as_org", "key_version", "data_key", "rank", "snippet"}
+	mock.ExpectQuery(`websearch_to_tsquery\('french', \$1\)(.|\s)*ts_headline(.|\s)*search_vector @@ q.query(.|\s)*ORDER BY rank DESC`).
+		WithArgs("proxmox", pgxmock.AnyArg(), "", "", "", (*time.Time)(nil), (*time.Time)(nil), 50, 0, "").
+		WillReturnRows(pgxmock.NewRows(columns).AddRow(int64(3), "Jane", "jane@example.com", "question", "My Proxmox setup",
+			"normal", []string{}, "new", (*string)(nil), now, now, (*time.Time)(nil), (*time.Time)(nil), (*time.Time)(nil), (*time.Time)(nil),
+			"", "", "", "", "", "", "", "", "", "", "", int64(0), "", (*int16)(nil), []byte(nil), float32(0.4), "My \ue000Proxmox\ue001 setup"))
+
+	results, err := repository.NewInboxRepository(mock).SearchSubmissions(context.Background(), models.ContactFilter{Query: "proxmox", Limit: 50})
+
+	assert.NoError(t, err)
+	assert.Len(t, results, 1)
+	assert.Equal(t, int64(3), results[0].ID)
+	assert.Equal(t, float32(0.4), results[0].Rank)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -63,8 +63,8 @@ func (suite *IntegrationTestSuite) SetupSuite() {
 
 	// Create real services (but mock SMTP to avoid sending real emails)
 	contactRepo := repository.NewContactRepository(suite.db)
-	emailService := &mockEmailServiceIntegration{}
-	contactService := services.NewContactService(contactRepo, emailService)
+	notifier := &mockNotifierIntegration{}
+	contactService := services.NewContactService(contactRepo, notifier)
 	contactHandler := handlers.NewContactHandler(contactService)
 
 	suite.router.POST("/api/v1/contact", contactHandler.HandleSendContactForm)
@@ -84,10 +84,14 @@ func (suite *IntegrationTestSuite) SetupTest() {
 	}
 }
 
-// Mock email service for integration tests
-type mockEmailServiceIntegration struct{}
+// Mock notifier for integration tests
+type mockNotifierIntegration struct{}
 
-func (m *mockEmailServiceIntegration) SendContactEmail(contact models.ContactForm) error {
+func (m *mockNotifierIntegration) Name() string {
+	return "mock"
+}
+
+func (m *mockNotifierIntegration) Notify(ctx context.Context, n services.Notification) error {
 	// Don't send real emails in tests
 	return nil
 }
@@ -96,7 +100,7 @@ func (suite *IntegrationTestSuite) TestContactSubmission_EndToEnd() {
 	form := models.ContactForm{
 		Name:    "Integration Test",
 		Email:   "integration@test.com",
-		Subject: "Test Subject",
+		Subject: "question",
 		Message: "This is an integration test message",
 	}
 
@@ -0,0 +1,311 @@
+package tests_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	handlers "backend/api/handlers"
+	"backend/internal/media"
+	"backend/internal/middleware"
+	"backend/internal/models"
+	"backend/internal/repository"
+	"backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pashagolub/pgxmock/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// in-memory implementation of the media storage
+type memoryMediaRepository struct {
+	mu    sync.Mutex
+	media []models.Media
+}
+
+func (r *memoryMediaRepository) CreateMedia(ctx context.Context, m *models.Media) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.media {
+		if existing.SHA256 == m.SHA256 {
+			*m = existing
+			return false, nil
+		}
+	}
+	m.ID = int64(len(r.media) + 1)
+	m.CreatedAt = time.Now()
+	r.media = append(r.media, *m)
+	return true, nil
+}
+
+func (r *memoryMediaRepository) GetMedia(ctx context.Context, id int64) (*models.Media, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, m := range r.media {
+		if m.ID == id {
+			return &m, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *memoryMediaRepository) GetMediaBySHA256(ctx context.Context, sha256 string) (*models.Media, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, m := range r.media {
+		if m.SHA256 == sha256 {
+			return &m, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *memoryMediaRepository) ListMedia(ctx context.Context, limit, offset int) ([]models.Media, int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]models.Media{}, r.media...), len(r.media), nil
+}
+
+func (r *memoryMediaRepository) DeleteMedia(ctx context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, m := range r.media {
+		if m.ID == id {
+			r.media = append(r.media[:i], r.media[i+1:]...)
+			return nil
+		}
+	}
+	return repository.ErrNotFound
+}
+
+// twoColorImage is red on its left half and blue on its right half
+func twoColorImage(w, h int) image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := color.NRGBA{R: 255, A: 255}
+			if x >= w/2 {
+				c = color.NRGBA{B: 255, A: 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+// jpegWithEXIF encodes img and inserts an EXIF segment with the given
+// orientation and a GPS-like marker string
+func jpegWithEXIF(t *testing.T, img image.Image, orientation uint16) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	assert.NoError(t, jpeg.Encode(&buf, img, &jpeg.Options{Quality: 95}))
+
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08\x00\x01\x01\x12\x00\x03\x00\x00\x00\x01")
+	tiff = binary.BigEndian.AppendUint16(tiff, orientation)
+	tiff = append(tiff, "\x00\x00\x00\x00\x00\x00secret-gps-48.85"...)
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	segment := []byte{0xFF, 0xE1}
+	segment = binary.BigEndian.AppendUint16(segment, uint16(len(payload)+2))
+	segment = append(segment, payload...)
+
+	data := buf.Bytes()
+	return append(append(append([]byte{}, data[:2]...), segment...), data[2:]...)
+}
+
+// pngWithText encodes img and inserts a tEXt chunk after the header
+func pngWithText(t *testing.T, img image.Image, text string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, img))
+	data := buf.Bytes()
+
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(text)))
+	chunk = append(chunk, "tEXt"+text...)
+	chunk = binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+	const headerEnd = 8 + 25 // signature and IHDR
+	return append(append(append([]byte{}, data[:headerEnd]...), chunk...), data[headerEnd:]...)
+}
+
+func TestMediaPrepare(t *testing.T) {
+	photo := jpegWithEXIF(t, twoColorImage(40, 20), 6)
+	assert.True(t, bytes.Contains(photo, []byte("secret-gps")))
+
+	file, err := media.Prepare(photo, media.Limits{})
+	assert.NoError(t, err)
+	assert.Equal(t, "image/jpeg", file.ContentType)
+	assert.False(t, bytes.Contains(file.Data, []byte("secret-gps")), "EXIF is removed")
+	assert.False(t, bytes.Contains(file.Data, []byte("Exif")))
+	assert.Equal(t, 20, file.Width, "the orientation is applied")
+	assert.Equal(t, 40, file.Height)
+	img, err := jpeg.Decode(bytes.NewReader(file.Data))
+	assert.NoError(t, err)
+	r, _, b, _ := img.At(10, 5).RGBA()
+	assert.Greater(t, r, b, "rotated clockwise: the left (red) half is on top")
+
+	upright := jpegWithEXIF(t, twoColorImage(40, 20), 1)
+	file, err = media.Prepare(upright, media.Limits{})
+	assert.NoError(t, err)
+	assert.Equal(t, 40, file.Width)
+	var plain bytes.Buffer
+	assert.NoError(t, jpeg.Encode(&plain, twoColorImage(40, 20), &jpeg.Options{Quality: 95}))
+	assert.Equal(t, plain.Bytes(), file.Data, "upright photos are not re-encoded, only the segment goes")
+
+	graphic := pngWithText(t, twoColorImage(16, 16), "Author\x00Enzo")
+	file, err = media.Prepare(graphic, media.Limits{})
+	assert.NoError(t, err)
+	assert.Equal(t, "image/png", file.ContentType)
+	assert.False(t, bytes.Contains(file.Data, []byte("tEXt")))
+	_, err = png.Decode(bytes.NewReader(file.Data))
+	assert.NoError(t, err, "the remaining chunks are intact")
+
+	_, err = media.Prepare([]byte(`<svg xmlns="http://www.w3.org/2000/svg"><script>alert(1)</script></svg>`), media.Limits{})
+	assert.ErrorIs(t, err, media.ErrUnsupportedType)
+	_, err = media.Prepare(photo, media.Limits{MaxBytes: 100})
+	assert.ErrorIs(t, err, media.ErrTooLarge)
+	_, err = media.Prepare(photo, media.Limits{MaxPixels: 799})
+	assert.ErrorIs(t, err, media.ErrTooLarge, "the pixel count is checked")
+	_, err = media.Prepare(photo[:len(photo)/3], media.Limits{})
+	assert.ErrorIs(t, err, media.ErrInvalidFile)
+}
+
+func TestMediaService(t *testing.T) {
+	ctx := context.Background()
+	repo := &memoryMediaRepository{}
+	store, err := services.NewDirMediaStore(t.TempDir())
+	assert.NoError(t, err)
+	svc := services.NewMediaService(repo, store, services.MediaOptions{Widths: []int{8, 16, 64}})
+
+	photo := jpegWithEXIF(t, twoColorImage(40, 20), 1)
+	m, created, err := svc.Upload(ctx, `C:\Users\enzo\rack.jpg`, photo)
+	assert.NoError(t, err)
+	assert.True(t, created)
+	assert.Equal(t, "rack.jpg", m.Filename)
+	assert.Len(t, m.SHA256, 64)
+	assert.Equal(t, "/media/"+m.SHA256+".jpg", m.URL)
+	assert.Len(t, m.Variants, 2, "only widths smaller than the image")
+	assert.Equal(t, 8, m.Variants[0].Width)
+	assert.Equal(t, 4, m.Variants[0].Height)
+	assert.Equal(t, "/media/"+m.SHA256+"-16w.jpg", m.Variants[1].URL)
+	assert.Equal(t, "/media/"+m.SHA256+"-8w.jpg 8w, /media/"+m.SHA256+"-16w.jpg 16w, /media/"+m.SHA256+".jpg 40w", m.SrcSet)
+
+	again, created, err := svc.Upload(ctx, "copy.jpg", photo)
+	assert.NoError(t, err)
+	assert.False(t, created, "identical content is stored once")
+	assert.Equal(t, m.ID, again.ID)
+	assert.Equal(t, "rack.jpg", again.Filename)
+
+	f, contentType, err := svc.Open(m.Variants[0].Name)
+	assert.NoError(t, err)
+	assert.Equal(t, "image/jpeg", contentType)
+	data, _ := io.ReadAll(f)
+	f.Close()
+	variant, err := jpeg.Decode(bytes.NewReader(data))
+	assert.NoError(t, err)
+	assert.Equal(t, 8, variant.Bounds().Dx())
+
+	for _, name := range []string{"../secret", m.SHA256 + ".svg", m.SHA256[:10] + ".jpg", m.SHA256 + "-0w.jpg"} {
+		_, _, err = svc.Open(name)
+		assert.ErrorIs(t, err, repository.ErrNotFound, name)
+	}
+
+	assert.NoError(t, svc.Delete(ctx, m.ID))
+	_, _, err = svc.Open(m.Name)
+	assert.ErrorIs(t, err, repository.ErrNotFound, "files are removed with the row")
+	_, _, err = svc.Open(m.Variants[1].Name)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+}
+
+func TestMediaHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store, err := services.NewDirMediaStore(t.TempDir())
+	assert.NoError(t, err)
+	svc := services.NewMediaService(&memoryMediaRepository{}, store, services.MediaOptions{
+		Limits: media.Limits{MaxBytes: 64 << 10},
+		Widths: []int{16},
+	})
+	h := handlers.NewMediaHandler(svc)
+	router := gin.New()
+	router.Use(middleware.BodyLimit(256, middleware.RouteLimit{Method: http.MethodPost, Path: "/admin/media", MaxBytes: 128 << 10}))
+	router.POST("/admin/media", h.HandleUpload)
+	router.POST("/other", func(c *gin.Context) {
+		_, err := io.ReadAll(c.Request.Body)
+		assert.Error(t, err)
+		c.Status(http.StatusRequestEntityTooLarge)
+	})
+	router.GET("/media/:name", h.HandleServe)
+
+	upload := func(data []byte) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		part, _ := form.CreateFormFile("file", "photo.jpg")
+		_, _ = part.Write(data)
+		_ = form.Close()
+		req := httptest.NewRequest(http.MethodPost, "/admin/media", &body)
+		req.Header.Set("Content-Type", form.FormDataContentType())
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	photo := jpegWithEXIF(t, twoColorImage(40, 20), 1)
+	assert.Greater(t, len(photo), 256, "larger than the default limit")
+	w := upload(photo)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), `"srcset":"/media/`)
+	assert.Equal(t, http.StatusOK, upload(photo).Code, "duplicates return the stored file")
+	assert.Equal(t, http.StatusUnsupportedMediaType, upload([]byte("just some text")).Code)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, upload(bytes.Repeat([]byte{0xFF}, 100<<10)).Code)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, upload(bytes.Repeat([]byte{0xFF}, 200<<10)).Code, "over the route limit")
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/other", bytes.NewReader(photo)))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code, "other routes keep the default limit")
+
+	list, _, err := svc.List(context.Background(), 10, 0)
+	assert.NoError(t, err)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, list[0].URL, nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "image/jpeg", w.Header().Get("Content-Type"))
+	assert.Equal(t, "public, max-age=31536000, immutable", w.Header().Get("Cache-Control"))
+	assert.Equal(t, list[0].Size, int64(w.Body.Len()))
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/media/unknown.jpg", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Empty(t, w.Header().Get("Cache-Control"), "missing files are not cached")
+}
+
+func TestMediaRepository_CreateMedia(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	created := time.Now()
+	columns := []string{"id", "sha256", "content_type", "size", "width", "height", "filename", "name", "variants", "created_at", "inserted"}
+	mock.ExpectQuery(`INSERT INTO media .* ON CONFLICT \(sha256\)`).
+		WithArgs("abc", "image/png", int64(10), 4, 2, "new.png", "abc.png", []byte("[]")).
+		WillReturnRows(pgxmock.NewRows(columns).
+			AddRow(int64(3), "abc", "image/png", int64(10), 4, 2, "first.png", "abc.png", []byte(`[{"name":"abc-2w.png","width":2}]`), created, false))
+
+	m := &models.Media{SHA256: "abc", ContentType: "image/png", Size: 10, Width: 4, Height: 2, Filename: "new.png", Name: "abc.png", Variants: []models.MediaVariant{}}
+	inserted, err := repository.NewMediaRepository(mock).CreateMedia(context.Background(), m)
+	assert.NoError(t, err)
+	assert.False(t, inserted)
+	assert.Equal(t, int64(3), m.ID)
+	assert.Equal(t, "first.png", m.Filename, "the stored row is returned")
+	assert.Len(t, m.Variants, 1)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
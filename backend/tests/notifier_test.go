@@ -0,0 +1,204 @@
+package tests_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"backend/internal/models"
+	"backend/internal/services"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type capturedRequest struct {
+	method string
+	path   string
+	header http.Header
+	body   map[string]interface{}
+}
+
+// newStandIn starts a local HTTP server recording the last request and answering with status
+func newStandIn(t *testing.T, status int) (*httptest.Server, *capturedRequest) {
+	t.Helper()
+	captured := &capturedRequest{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, _ := io.ReadAll(r.Body)
+		captured.method = r.Method
+		captured.path = r.URL.EscapedPath()
+		captured.header = r.Header.Clone()
+		captured.body = map[string]interface{}{}
+		_ = json.Unmarshal(raw, &captured.body)
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server, captured
+}
+
+func sampleNotification() services.Notification {
+	return services.Notification{
+		Submission: models.ContactSubmission{
+			ID: 7,
+			ContactForm: models.ContactForm{
+				Name:    "José",
+				Email:   "jose@example.com",
+				Subject: "question",
+				Message: "Hello @everyone",
+			},
+		},
+		Priority: models.PriorityHigh,
+		Tags:     []string{"proxmox"},
+	}
+}
+
+func TestMatrixNotifier(t *testing.T) {
+	server, got := newStandIn(t, http.StatusOK)
+	n, err := services.NewMatrixNotifier(services.MatrixOptions{HomeserverURL: server.URL, AccessToken: "tok", RoomID: "!room:example.org"})
+	assert.NoError(t, err)
+
+	err = n.Notify(context.Background(), sampleNotification())
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.MethodPut, got.method)
+	assert.True(t, strings.HasPrefix(got.path, "/_matrix/client/v3/rooms/%21room:example.org/send/m.room.message/"), got.path)
+	assert.Equal(t, "Bearer tok", got.header.Get("Authorization"))
+	assert.Equal(t, "m.text", got.body["msgtype"])
+	assert.Contains(t, got.body["body"], "José <jose@example.com>")
+}
+
+func TestNtfyNotifier(t *testing.T) {
+	server, got := newStandIn(t, http.StatusOK)
+	n, err := services.NewNtfyNotifier(services.NtfyOptions{ServerURL: server.URL, Topic: "portfolio", Token: "tk"})
+	assert.NoError(t, err)
+
+	err = n.Notify(context.Background(), sampleNotification())
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.MethodPost, got.method)
+	assert.Equal(t, "Bearer tk", got.header.Get("Authorization"))
+	assert.Equal(t, "portfolio", got.body["topic"])
+	assert.Equal(t, float64(4), got.body["priority"])
+	assert.Equal(t, []interface{}{"proxmox"}, got.body["tags"])
+	assert.Equal(t, services.DefaultNotificationTitle, got.body["title"])
+}
+
+func TestGotifyNotifier(t *testing.T) {
+	server, got := newStandIn(t, http.StatusOK)
+	n, err := services.NewGotifyNotifier(services.GotifyOptions{ServerURL: server.URL, AppToken: "app"})
+	assert.NoError(t, err)
+
+	err = n.Notify(context.Background(), sampleNotification())
+
+	assert.NoError(t, err)
+	assert.Equal(t, "/message", got.path)
+	assert.Equal(t, "app", got.header.Get("X-Gotify-Key"))
+	assert.Equal(t, float64(7), got.body["priority"])
+}
+
+func TestDiscordNotifier(t *testing.T) {
+	server, got := newStandIn(t, http.StatusNoContent)
+	n, err := services.NewDiscordNotifier(services.DiscordOptions{WebhookURL: server.URL + "/api/webhooks/1/abc"})
+	assert.NoError(t, err)
+
+	err = n.Notify(context.Background(), sampleNotification())
+
+	assert.NoError(t, err)
+	assert.Contains(t, got.body["content"], "Hello @everyone")
+	assert.Equal(t, map[string]interface{}{"parse": []interface{}{}}, got.body["allowed_mentions"])
+}
+
+func TestTelegramNotifier(t *testing.T) {
+	server, got := newStandIn(t, http.StatusOK)
+	n, err := services.NewTelegramNotifier(services.TelegramOptions{
+		APIURL:   server.URL,
+		BotToken: "123:secret",
+		ChatID:   "42",
+		Template: "#{{.Submission.ID}} {{.Submission.Subject}} ({{.Priority}})",
+	})
+	assert.NoError(t, err)
+
+	err = n.Notify(context.Background(), sampleNotification())
+
+	assert.NoError(t, err)
+	assert.Equal(t, "/bot123:secret/sendMessage", got.path)
+	assert.Equal(t, "42", got.body["chat_id"])
+	assert.Equal(t, "#7 question (high)", got.body["text"])
+	_, hasParseMode := got.body["parse_mode"]
+	assert.False(t, hasParseMode)
+}
+
+func TestNotifier_ErrorStatus(t *testing.T) {
+	server, _ := newStandIn(t, http.StatusUnauthorized)
+	n, err := services.NewGotifyNotifier(services.GotifyOptions{ServerURL: server.URL, AppToken: "bad"})
+	assert.NoError(t, err)
+
+	err = n.Notify(context.Background(), sampleNotification())
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "401")
+}
+
+func TestNotifier_InvalidConfig(t *testing.T) {
+	_, err := services.NewTelegramNotifier(services.TelegramOptions{BotToken: "x"})
+	assert.Error(t, err)
+
+	_, err = services.NewDiscordNotifier(services.DiscordOptions{WebhookURL: "http://x", Template: "{{.Broken"})
+	assert.Error(t, err)
+}
+
+// fake channel recording the notifications it receives
+type recordingNotifier struct {
+	name string
+	err  error
+	got  chan services.Notification
+}
+
+func newRecordingNotifier(name string, err error) *recordingNotifier {
+	return &recordingNotifier{name: name, err: err, got: make(chan services.Notification, 1)}
+}
+
+func (r *recordingNotifier) Name() string { return r.name }
+
+func (r *recordingNotifier) Notify(ctx context.Context, n services.Notification) error {
+	r.got <- n
+	return r.err
+}
+
+func TestNotificationDispatcher(t *testing.T) {
+	email := newRecordingNotifier("email", nil)
+	ntfy := newRecordingNotifier("ntfy", errors.New("boom"))
+	discord := newRecordingNotifier("discord", nil)
+	d := services.NewNotificationDispatcher(email, ntfy, discord)
+
+	assert.Equal(t, []string{"email", "ntfy", "discord"}, d.Channels())
+
+	t.Run("fans out to all channels", func(t *testing.T) {
+		err := d.Notify(context.Background(), sampleNotification())
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "ntfy: boom")
+		assert.Len(t, email.got, 1)
+		assert.Len(t, ntfy.got, 1)
+		assert.Len(t, discord.got, 1)
+		<-email.got
+		<-ntfy.got
+		<-discord.got
+	})
+
+	t.Run("restricted to selected channels", func(t *testing.T) {
+		n := sampleNotification()
+		n.Channels = []string{"discord"}
+
+		err := d.Notify(context.Background(), n)
+
+		assert.NoError(t, err)
+		assert.Len(t, email.got, 0)
+		assert.Len(t, ntfy.got, 0)
+		assert.Len(t, discord.got, 1)
+	})
+}
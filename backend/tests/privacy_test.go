@@ -0,0 +1,448 @@
+package tests_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	handlers "backend/api/handlers"
+	"backend/internal/models"
+	"backend/internal/repository"
+	"backend/internal/services"
+	"backend/internal/site"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pashagolub/pgxmock/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// in-memory implementation of the privacy storage, backed by an inbox repository
+type memoryPrivacyRepository struct {
+	mu       sync.Mutex
+	inbox    *memoryInboxRepository
+	requests []*models.PrivacyRequest
+	audit    []models.PrivacyAuditEntry
+}
+
+func (r *memoryPrivacyRepository) CreateRequest(ctx context.Context, req *models.PrivacyRequest) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	req.ID = int64(len(r.requests) + 1)
+	req.CreatedAt = time.Now()
+	stored := *req
+	r.requests = append(r.requests, &stored)
+	return nil
+}
+
+func (r *memoryPrivacyRepository) FindRequest(ctx context.Context, tokenHash string) (*models.PrivacyRequest, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, req := range r.requests {
+		if req.TokenHash == tokenHash {
+			found := *req
+			return &found, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *memoryPrivacyRepository) MarkRequestUsed(ctx context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	for _, req := range r.requests {
+		if req.ID == id {
+			req.UsedAt = &now
+		}
+	}
+	return nil
+}
+
+func (r *memoryPrivacyRepository) HasRecentRequest(ctx context.Context, email string, since time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, req := range r.requests {
+		if strings.EqualFold(req.Email, email) && !req.CreatedAt.Before(since) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *memoryPrivacyRepository) DeleteExpiredRequests(ctx context.Context, before time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (r *memoryPrivacyRepository) ListSubmissionsByEmail(ctx context.Context, email string) ([]models.ContactSubmission, error) {
+	r.inbox.mu.Lock()
+	defer r.inbox.mu.Unlock()
+	list := []models.ContactSubmission{}
+	for _, s := range r.inbox.submissions {
+		if strings.EqualFold(s.Email, email) {
+			list = append(list, *s)
+		}
+	}
+	return list, nil
+}
+
+func (r *memoryPrivacyRepository) EraseByEmail(ctx context.Context, email, mode string) (int64, error) {
+	r.inbox.mu.Lock()
+	defer r.inbox.mu.Unlock()
+	var count int64
+	for id, s := range r.inbox.submissions {
+		if strings.EqualFold(s.Email, email) {
+			delete(r.inbox.submissions, id)
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *memoryPrivacyRepository) ApplyRetention(ctx context.Context, createdBefore time.Time, mode string) (int64, error) {
+	r.inbox.mu.Lock()
+	defer r.inbox.mu.Unlock()
+	var count int64
+	for _, s := range r.inbox.submissions {
+		if s.CreatedAt.Before(createdBefore) {
+			s.Name, s.Email, s.Message = models.AnonymizedValue, "anonymized@invalid", models.AnonymizedValue
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *memoryPrivacyRepository) AddAuditEntry(ctx context.Context, entry *models.PrivacyAuditEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entry.ID = int64(len(r.audit) + 1)
+	r.audit = append(r.audit, *entry)
+	return nil
+}
+
+func (r *memoryPrivacyRepository) ListAuditEntries(ctx context.Context, limit, offset int) ([]models.PrivacyAuditEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]models.PrivacyAuditEntry{}, r.audit...), nil
+}
+
+func (r *memoryPrivacyRepository) actions() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	actions := []string{}
+	for _, e := range r.audit {
+		actions = append(actions, e.Action)
+	}
+	return actions
+}
+
+var privacyTokenPattern = regexp.MustCompile(`token=([A-Za-z0-9_-]+)`)
+
+func newTestPrivacyService(t *testing.T, opts services.PrivacyOptions, submissions ...models.ContactSubmission) (services.IPrivacyService, *memoryPrivacyRepository, *recordingTransport) {
+	t.Helper()
+	inbox := newMemoryInboxRepository(submissions...)
+	repo := &memoryPrivacyRepository{inbox: inbox}
+	transport := &recordingTransport{}
+	if opts.LinkURL == "" {
+		opts.LinkURL = "https://enzo.dev/privacy"
+	}
+	if opts.AuditKey == nil {
+		opts.AuditKey = []byte("audit-key")
+	}
+	svc, err := services.NewPrivacyService(repo, inbox, &memoryConversationRepository{}, transport, opts)
+	assert.NoError(t, err)
+	return svc, repo, transport
+}
+
+// requestToken asks for a verification link and returns the token it carries
+func requestToken(t *testing.T, svc services.IPrivacyService, transport *recordingTransport, email string) string {
+	t.Helper()
+	before := len(transport.waitForSent(t, 0))
+	assert.NoError(t, svc.RequestAccess(context.Background(), email))
+	sent := transport.waitForSent(t, before+1)
+	if !assert.Len(t, sent, before+1) {
+		return ""
+	}
+	m := privacyTokenPattern.FindStringSubmatch(sent[len(sent)-1].Text)
+	if !assert.Len(t, m, 2) {
+		return ""
+	}
+	return m[1]
+}
+
+func TestPrivacyService_RequestAndExport(t *testing.T) {
+	svc, repo, transport := newTestPrivacyService(t, services.PrivacyOptions{From: "contact@enzo.dev", AuditKey: []byte("audit-key")},
+		inboxSubmission(1, models.ContactStatusNew),
+		inboxSubmission(2, models.ContactStatusReplied),
+	)
+
+	token := requestToken(t, svc, transport, "Jane <JANE@example.com>")
+	assert.Equal(t, []string{"JANE@example.com"}, transport.sent[0].To)
+	assert.Equal(t, "contact@enzo.dev", transport.sent[0].From)
+	assert.Contains(t, transport.sent[0].Text, "https://enzo.dev/privacy?token=")
+	assert.Contains(t, transport.sent[0].Text, "1 h")
+
+	// only the hash of the token is stored
+	assert.NotEqual(t, token, repo.requests[0].TokenHash)
+
+	export, err := svc.ExportByToken(context.Background(), token)
+	assert.NoError(t, err)
+	assert.Len(t, export.Submissions, 2)
+
+	// the link can be reused for export until it expires
+	_, err = svc.ExportByToken(context.Background(), token)
+	assert.NoError(t, err)
+
+	_, err = svc.ExportByToken(context.Background(), "forged")
+	assert.ErrorIs(t, err, services.ErrInvalidPrivacyToken)
+
+	assert.Equal(t, []string{models.PrivacyActionRequest, models.PrivacyActionExport, models.PrivacyActionExport}, repo.actions())
+	assert.Equal(t, services.PrivacySubjectHash([]byte("audit-key"), "jane@example.com"), repo.audit[0].SubjectHash)
+	assert.NotEqual(t, services.PrivacySubjectHash([]byte("other-key"), "jane@example.com"), repo.audit[0].SubjectHash)
+}
+
+func TestPrivacyService_RequestDoesNotLeak(t *testing.T) {
+	svc, _, transport := newTestPrivacyService(t, services.PrivacyOptions{}, inboxSubmission(1, models.ContactStatusNew))
+
+	// unknown address: same outcome, no email
+	assert.NoError(t, svc.RequestAccess(context.Background(), "nobody@example.com"))
+
+	// repeated requests within the cooldown send a single email
+	assert.NoError(t, svc.RequestAccess(context.Background(), "jane@example.com"))
+	assert.NoError(t, svc.RequestAccess(context.Background(), "jane@example.com"))
+	sent := transport.waitForSent(t, 1)
+	time.Sleep(20 * time.Millisecond)
+	assert.Len(t, transport.waitForSent(t, 1), 1)
+	assert.Equal(t, []string{"jane@example.com"}, sent[0].To)
+
+	err := svc.RequestAccess(context.Background(), "not an email")
+	assert.ErrorIs(t, err, services.ErrInvalidPrivacyRequest)
+}
+
+func TestPrivacyService_RequestDoesNotWaitForTheEmail(t *testing.T) {
+	svc, _, transport := newTestPrivacyService(t, services.PrivacyOptions{}, inboxSubmission(1, models.ContactStatusNew))
+	transport.block = make(chan struct{})
+
+	// known and unknown addresses both return while the mail transport is stuck
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		assert.NoError(t, svc.RequestAccess(context.Background(), "jane@example.com"))
+		assert.NoError(t, svc.RequestAccess(context.Background(), "nobody@example.com"))
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("RequestAccess waited for the mail transport")
+	}
+
+	close(transport.block)
+	assert.Len(t, transport.waitForSent(t, 1), 1)
+}
+
+func TestPrivacyService_EraseConsumesToken(t *testing.T) {
+	svc, repo, transport := newTestPrivacyService(t, services.PrivacyOptions{}, inboxSubmission(1, models.ContactStatusNew))
+
+	token := requestToken(t, svc, transport, "jane@example.com")
+	count, err := svc.EraseByToken(context.Background(), token)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+	assert.Empty(t, repo.inbox.submissions)
+
+	_, err = svc.EraseByToken(context.Background(), token)
+	assert.ErrorIs(t, err, services.ErrInvalidPrivacyToken)
+	_, err = svc.ExportByToken(context.Background(), token)
+	assert.ErrorIs(t, err, services.ErrInvalidPrivacyToken)
+
+	entries, err := svc.AuditLog(context.Background(), 10, 0)
+	assert.NoError(t, err)
+	last := entries[len(entries)-1]
+	assert.Equal(t, models.PrivacyActionErase, last.Action)
+	assert.Equal(t, "visitor", last.Actor)
+	assert.Equal(t, models.RetentionAnonymize, last.Detail)
+}
+
+func TestPrivacyService_ExpiredToken(t *testing.T) {
+	svc, repo, transport := newTestPrivacyService(t, services.PrivacyOptions{}, inboxSubmission(1, models.ContactStatusNew))
+
+	token := requestToken(t, svc, transport, "jane@example.com")
+	repo.requests[0].ExpiresAt = time.Now().Add(-time.Minute)
+
+	_, err := svc.ExportByToken(context.Background(), token)
+	assert.ErrorIs(t, err, services.ErrInvalidPrivacyToken)
+}
+
+func TestPrivacyService_ApplyRetention(t *testing.T) {
+	old := inboxSubmission(1, models.ContactStatusArchived)
+	old.CreatedAt = time.Now().Add(-3 * 365 * 24 * time.Hour)
+	recent := inboxSubmission(2, models.ContactStatusNew)
+	recent.CreatedAt = time.Now()
+
+	svc, repo, _ := newTestPrivacyService(t, services.PrivacyOptions{RetentionPeriod: 2 * 365 * 24 * time.Hour}, old, recent)
+	count, err := svc.ApplyRetention(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+	assert.Equal(t, models.AnonymizedValue, repo.inbox.submissions[1].Name)
+	assert.Equal(t, "Jane", repo.inbox.submissions[2].Name)
+	assert.Equal(t, []string{models.PrivacyActionRetention}, repo.actions())
+
+	// a zero period keeps everything
+	svc, _, _ = newTestPrivacyService(t, services.PrivacyOptions{}, old)
+	count, err = svc.ApplyRetention(context.Background())
+	assert.NoError(t, err)
+	assert.Zero(t, count)
+}
+
+func TestNewPrivacyService_InvalidMode(t *testing.T) {
+	_, err := services.NewPrivacyService(&memoryPrivacyRepository{}, newMemoryInboxRepository(), &memoryConversationRepository{}, &recordingTransport{},
+		services.PrivacyOptions{RetentionMode: "shred", AuditKey: []byte("audit-key")})
+	assert.Error(t, err)
+
+	_, err = services.NewPrivacyService(&memoryPrivacyRepository{}, newMemoryInboxRepository(), &memoryConversationRepository{}, &recordingTransport{},
+		services.PrivacyOptions{})
+	assert.Error(t, err, "the audit key is required so that hashes stay stable across restarts")
+}
+
+func TestPrivacyHandler_Routes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	svc, _, transport := newTestPrivacyService(t, services.PrivacyOptions{}, inboxSubmission(1, models.ContactStatusNew))
+	h := handlers.NewPrivacyHandler(svc)
+
+	router := gin.New()
+	router.POST("/privacy/requests", h.HandleRequest)
+	router.GET("/privacy/export", h.HandleExport)
+	router.POST("/privacy/erase", h.HandleErase)
+	router.GET("/admin/privacy/export", h.HandleAdminExport)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusBadRequest, do("POST", "/privacy/requests", `{"email":"nope"}`).Code)
+	assert.Equal(t, http.StatusAccepted, do("POST", "/privacy/requests", `{"email":"other@example.com"}`).Code)
+	assert.Equal(t, http.StatusAccepted, do("POST", "/privacy/requests", `{"email":"jane@example.com"}`).Code)
+	token := privacyTokenPattern.FindStringSubmatch(transport.waitForSent(t, 1)[0].Text)[1]
+
+	assert.Equal(t, http.StatusGone, do("GET", "/privacy/export?token=bogus", "").Code)
+	assert.Equal(t, http.StatusBadRequest, do("GET", "/privacy/export?format=pdf&token="+token, "").Code)
+
+	w := do("GET", "/privacy/export?token="+url.QueryEscape(token), "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Disposition"), ".json")
+	var export models.PrivacyExport
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &export))
+	assert.Len(t, export.Submissions, 1)
+
+	w = do("GET", "/privacy/export?format=zip&token="+token, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/zip", w.Header().Get("Content-Type"))
+	archive, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	assert.NoError(t, err)
+	names := []string{}
+	for _, f := range archive.File {
+		names = append(names, f.Name)
+	}
+	assert.Equal(t, []string{"data.json", "submission-1.txt"}, names)
+
+	w = do("GET", "/admin/privacy/export?email=jane@example.com", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = do("POST", "/privacy/erase", `{"token":"`+token+`"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"erased":1}`, w.Body.String())
+	assert.Equal(t, http.StatusGone, do("POST", "/privacy/erase", `{"token":"`+token+`"}`).Code)
+}
+
+func TestPageHandler_Privacy(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	renderer, err := site.NewRenderer(site.Options{Site: site.Info{Name: "Enzo Gaggiotti", URL: "https://example.com"}})
+	assert.NoError(t, err)
+	svc, repo, transport := newTestPrivacyService(t, services.PrivacyOptions{}, inboxSubmission(1, models.ContactStatusNew))
+	h := handlers.NewPageHandler(renderer, services.NewProjectService(newMemoryProjectRepository()), services.NewArticleService(memoryArticleStore{}),
+		newTestProfileService(t), &mockContactService{}, svc, services.NewPreviewSigner("", 0))
+	router := gin.New()
+	router.GET("/privacy", h.HandlePrivacy)
+	router.POST("/privacy", h.HandlePrivacyErase)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		return w
+	}
+	post := func(form url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/privacy", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	token := requestToken(t, svc, transport, "jane@example.com")
+	assert.Contains(t, transport.sent[0].Text, "demandez leur suppression depuis cette page")
+
+	w := get("/privacy?token=" + url.QueryEscape(token))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "private, no-store", w.Header().Get("Cache-Control"))
+	assert.Equal(t, "noindex, nofollow", w.Header().Get("X-Robots-Tag"))
+	assert.Contains(t, w.Body.String(), `href="/api/v1/privacy/export?format=zip&amp;token=`+url.QueryEscape(token)+`"`)
+	assert.Contains(t, w.Body.String(), `name="token" value="`+token+`"`)
+	assert.Equal(t, http.StatusGone, get("/privacy?token=bogus").Code)
+	assert.Equal(t, http.StatusGone, get("/privacy").Code)
+
+	// the erasure must be confirmed
+	w = post(url.Values{"token": {token}})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Len(t, repo.inbox.submissions, 1)
+
+	w = post(url.Values{"token": {token}, "confirm": {"1"}})
+	assert.Equal(t, http.StatusSeeOther, w.Code)
+	assert.Equal(t, "/privacy?erased=1", w.Header().Get("Location"))
+	assert.Empty(t, repo.inbox.submissions)
+	assert.Contains(t, get("/privacy?erased=1").Body.String(), "Vos données ont été supprimées")
+
+	// the link is used up
+	assert.Equal(t, http.StatusGone, post(url.Values{"token": {token}, "confirm": {"1"}}).Code)
+	assert.Equal(t, http.StatusGone, get("/privacy?token="+url.QueryEscape(token)).Code)
+}
+
+func TestPrivacyRepository_EraseByEmail(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := repository.NewPrivacyRepository(mock)
+
+	mock.ExpectQuery(`DELETE FROM webhook_deliveries(.|\n)*UPDATE contact_submissions SET(.|\n)*user_agent = ''(.|\n)*accept_language = ''(.|\n)*country = ''(.|\n)*asn = 0(.|\n)*as_org = ''(.|\n)*utm_source = ''(.|\n)*utm_content = ''(.|\n)*anonymized_at = NOW\(\)`).
+		WithArgs("jane@example.com", "").
+		WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(int64(2)))
+	mock.ExpectExec(`DELETE FROM privacy_requests WHERE \(email_index = \$2 OR \(data_key IS NULL AND lower\(email\) = lower\(\$1\)\)\)`).
+		WithArgs("jane@example.com", "").
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+
+	count, err := repo.EraseByEmail(context.Background(), "jane@example.com", models.RetentionAnonymize)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	cutoff := time.Now()
+	mock.ExpectQuery(`DELETE FROM contact_submissions WHERE id IN \(SELECT id FROM target\)`).
+		WithArgs(cutoff).
+		WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(int64(5)))
+
+	count, err = repo.ApplyRetention(context.Background(), cutoff, models.RetentionDelete)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), count)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,315 @@
+package tests_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	handlers "backend/api/handlers"
+	"backend/internal/models"
+	"backend/internal/repository"
+	"backend/internal/services"
+	"backend/internal/site"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pashagolub/pgxmock/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// in-memory implementation of the profile storage
+type memoryProfileRepository struct {
+	mu      sync.Mutex
+	profile *models.Profile
+}
+
+func (r *memoryProfileRepository) GetProfile(ctx context.Context) (*models.Profile, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.profile == nil {
+		return nil, repository.ErrNotFound
+	}
+	p := *r.profile
+	return &p, nil
+}
+
+func (r *memoryProfileRepository) SaveProfile(ctx context.Context, resume models.Resume, version int) (*models.Profile, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	current := 0
+	if r.profile != nil {
+		current = r.profile.Version
+	}
+	if version != current {
+		return nil, repository.ErrVersionConflict
+	}
+	r.profile = &models.Profile{Resume: resume, Version: current + 1, UpdatedAt: time.Now()}
+	p := *r.profile
+	return &p, nil
+}
+
+// newTestProfileService serves the embedded default profile
+func newTestProfileService(t *testing.T, opts ...services.ProfileServiceOption) services.IProfileService {
+	t.Helper()
+	resume, err := site.LoadResume("")
+	assert.NoError(t, err)
+	return services.NewProfileService(&memoryProfileRepository{}, resume, opts...)
+}
+
+func TestLoadResume_Default(t *testing.T) {
+	resume, err := site.LoadResume("")
+	assert.NoError(t, err)
+	assert.Equal(t, "Enzo Gaggiotti", resume.Basics.Name)
+	assert.NotEmpty(t, resume.Skills)
+	assert.NotEmpty(t, resume.Education)
+}
+
+func TestPeriod(t *testing.T) {
+	assert.Equal(t, "2021 - 2023", site.Period("2021", "2023"))
+	assert.Equal(t, "09/2023 - Présent", site.Period("2023-09", ""))
+	assert.Equal(t, "01/09/2023", site.Period("2023-09-01", "2023-09-01"))
+	assert.Equal(t, "2024", site.Period("", "2024"))
+}
+
+func TestProfileService(t *testing.T) {
+	ctx := context.Background()
+	revisions := services.NewRevisionService(&memoryRevisionRepository{})
+	svc := newTestProfileService(t, services.WithProfileRevisions(revisions))
+
+	profile, err := svc.Get(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, profile.Version, "the default profile is served before the first save")
+	assert.Equal(t, "Enzo Gaggiotti", profile.Resume.Basics.Name)
+
+	resume := profile.Resume
+	resume.Basics.Label = "Ingénieur systèmes"
+	saved, err := svc.Update(ctx, models.ProfileInput{Resume: resume, Version: 0})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, saved.Version)
+
+	_, err = svc.Update(ctx, models.ProfileInput{Resume: resume, Version: 0})
+	assert.ErrorIs(t, err, repository.ErrVersionConflict)
+
+	list, err := revisions.List(ctx, models.ContentProfile, models.ProfileKey)
+	assert.NoError(t, err)
+	assert.Len(t, list, 1)
+
+	// Restoring the revision after another change brings the label back
+	resume.Basics.Label = "Autre"
+	_, err = svc.Update(ctx, models.ProfileInput{Resume: resume, Version: 1})
+	assert.NoError(t, err)
+	first, err := revisions.Get(ctx, list[0].ID)
+	assert.NoError(t, err)
+	assert.NoError(t, svc.RestoreRevision(ctx, first, 0))
+	profile, err = svc.Get(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "Ingénieur systèmes", profile.Resume.Basics.Label)
+	assert.Equal(t, 3, profile.Version)
+
+	wrong := &models.Revision{ID: 9, ContentType: models.ContentArticle, Body: "{}"}
+	assert.ErrorIs(t, svc.RestoreRevision(ctx, wrong, 0), services.ErrInvalidRevision)
+}
+
+func TestProfileService_Validation(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestProfileService(t)
+	valid := func() models.Resume {
+		return models.Resume{Basics: models.ResumeBasics{Name: "Enzo", Email: "enzo@example.com", Image: "/assets/images/avatar.png"}}
+	}
+
+	cases := map[string]func(r *models.Resume){
+		"missing name": func(r *models.Resume) { r.Basics.Name = "  " },
+		"bad email":    func(r *models.Resume) { r.Basics.Email = "Enzo <enzo@example.com>" },
+		"bad date":     func(r *models.Resume) { r.Work = []models.ResumeWork{{Name: "A", StartDate: "09/2023"}} },
+		"bad month":    func(r *models.Resume) { r.Education = []models.ResumeEducation{{Institution: "A", EndDate: "2023-13"}} },
+		"script url": func(r *models.Resume) {
+			r.Basics.Profiles = []models.ResumeProfile{{Network: "X", URL: "javascript:alert(1)"}}
+		},
+		"relative url":  func(r *models.Resume) { r.Projects = []models.ResumeProject{{Name: "P", URL: "/projects/p"}} },
+		"unnamed skill": func(r *models.Resume) { r.Skills = []models.ResumeSkill{{Name: ""}} },
+		"bad icon":      func(r *models.Resume) { r.Skills = []models.ResumeSkill{{Name: "Go", Icon: `x" onclick="y`}} },
+	}
+	for name, mutate := range cases {
+		t.Run(name, func(t *testing.T) {
+			resume := valid()
+			mutate(&resume)
+			_, err := svc.Update(ctx, models.ProfileInput{Resume: resume})
+			assert.ErrorIs(t, err, services.ErrInvalidProfile)
+		})
+	}
+
+	resume := valid()
+	resume.Work = []models.ResumeWork{{Name: "A", URL: "https://a.example", StartDate: "2023-09", EndDate: "2024-02-29"}}
+	_, err := svc.Update(ctx, models.ProfileInput{Resume: resume})
+	assert.NoError(t, err)
+}
+
+func TestProfileService_PDF(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestProfileService(t)
+
+	pdf, name, err := svc.PDF(ctx)
+	assert.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(pdf, []byte("%PDF-")))
+	assert.Equal(t, "CV_Enzo_Gaggiotti.pdf", name)
+
+	again, _, err := svc.PDF(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, pdf, again)
+
+	profile, err := svc.Get(ctx)
+	assert.NoError(t, err)
+	profile.Resume.Basics.Label = "Nouveau titre"
+	_, err = svc.Update(ctx, models.ProfileInput{Resume: profile.Resume})
+	assert.NoError(t, err)
+	changed, _, err := svc.PDF(ctx)
+	assert.NoError(t, err)
+	assert.NotEqual(t, pdf, changed, "the PDF follows the profile")
+}
+
+func TestResumePDF_Deterministic(t *testing.T) {
+	resume, err := site.LoadResume("")
+	assert.NoError(t, err)
+	modified := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	a, err := site.ResumePDF(resume, modified)
+	assert.NoError(t, err)
+	b, err := site.ResumePDF(resume, modified)
+	assert.NoError(t, err)
+	assert.Equal(t, a, b)
+}
+
+func TestProfileHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := handlers.NewProfileHandler(newTestProfileService(t))
+	router := gin.New()
+	router.GET("/profile", h.HandleGet)
+	router.GET("/cv.pdf", h.HandleCV)
+	router.GET("/admin/profile", h.HandleAdminGet)
+	router.PUT("/admin/profile", h.HandleUpdate)
+	do := func(method, path, body string, header ...string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodGet, "/profile", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resume models.Resume
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resume))
+	assert.Equal(t, "Enzo Gaggiotti", resume.Basics.Name)
+	etag := w.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+	assert.Equal(t, http.StatusNotModified, do(http.MethodGet, "/profile", "", "If-None-Match", etag).Code)
+
+	w = do(http.MethodGet, "/cv.pdf", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/pdf", w.Header().Get("Content-Type"))
+	assert.Equal(t, `inline; filename="CV_Enzo_Gaggiotti.pdf"`, w.Header().Get("Content-Disposition"))
+
+	w = do(http.MethodGet, "/admin/profile", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"version":0`)
+
+	body := `{"resume":{"basics":{"name":"Jane Doe","label":"SRE"}},"version":0}`
+	w = do(http.MethodPut, "/admin/profile", body)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"version":1`)
+	assert.Equal(t, http.StatusConflict, do(http.MethodPut, "/admin/profile", body).Code)
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPut, "/admin/profile", `{"resume":{"basics":{"name":""}},"version":1}`).Code)
+
+	w = do(http.MethodGet, "/profile", "")
+	assert.Contains(t, w.Body.String(), `"name":"Jane Doe"`)
+	assert.NotEqual(t, etag, w.Header().Get("ETag"))
+	assert.Equal(t, `inline; filename="CV_Jane_Doe.pdf"`, do(http.MethodGet, "/cv.pdf", "").Header().Get("Content-Disposition"))
+}
+
+func TestPageHandler_AboutFromProfile(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	renderer, err := site.NewRenderer(site.Options{Site: site.Info{Name: "Enzo Gaggiotti", URL: "https://example.com"}})
+	assert.NoError(t, err)
+	profiles := newTestProfileService(t)
+	_, err = profiles.Update(context.Background(), models.ProfileInput{Resume: models.Resume{
+		Basics:    models.ResumeBasics{Name: "Enzo Gaggiotti", Label: "Admin <sys>", Summary: "Premier.\n\nSecond."},
+		Work:      []models.ResumeWork{{Name: "Abomicro", Position: "Stagiaire", StartDate: "2024-03", EndDate: "2024-08"}},
+		Skills:    []models.ResumeSkill{{Name: "Proxmox", Icon: "fas fa-server", Keywords: []string{"Ceph"}}},
+		Languages: []models.ResumeLanguage{{Language: "Anglais", Fluency: "B2"}},
+	}})
+	assert.NoError(t, err)
+
+	h := handlers.NewPageHandler(renderer, services.NewProjectService(newMemoryProjectRepository()), services.NewArticleService(memoryArticleStore{}),
+		profiles, &mockContactService{}, nil, services.NewPreviewSigner("", 0))
+	router := gin.New()
+	router.GET("/", h.HandleIndex)
+	router.GET("/about", h.HandleAbout)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/about", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	body := w.Body.String()
+	assert.Contains(t, body, "Admin &lt;sys&gt;")
+	assert.Contains(t, body, "<p>Second.</p>")
+	assert.Contains(t, body, "03/2024 - 08/2024")
+	assert.Contains(t, body, "Stagiaire - Abomicro")
+	assert.Contains(t, body, "Ceph")
+	assert.Contains(t, body, "Anglais : B2")
+	assert.Contains(t, body, `href="/cv.pdf"`)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Contains(t, w.Body.String(), "Proxmox")
+}
+
+// failing profile storage
+type failingProfileRepository struct{}
+
+func (failingProfileRepository) GetProfile(ctx context.Context) (*models.Profile, error) {
+	return nil, errors.New("database down")
+}
+
+func (failingProfileRepository) SaveProfile(ctx context.Context, resume models.Resume, version int) (*models.Profile, error) {
+	return nil, errors.New("database down")
+}
+
+func TestProfileService_StorageError(t *testing.T) {
+	svc := services.NewProfileService(failingProfileRepository{}, models.Resume{})
+	_, err := svc.Get(context.Background())
+	assert.Error(t, err, "only a missing profile falls back to the default")
+}
+
+func TestProfileRepository_SaveProfile(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	resume := models.Resume{Basics: models.ResumeBasics{Name: "Enzo"}}
+	data, err := json.Marshal(resume)
+	assert.NoError(t, err)
+	updated := time.Now()
+	mock.ExpectQuery(`INSERT INTO profile`).
+		WithArgs(data, 2).
+		WillReturnRows(pgxmock.NewRows([]string{"resume", "version", "updated_at"}).AddRow(data, 3, updated))
+	mock.ExpectQuery(`INSERT INTO profile`).
+		WithArgs(data, 2).
+		WillReturnRows(pgxmock.NewRows([]string{"resume", "version", "updated_at"}))
+
+	repo := repository.NewProfileRepository(mock)
+	profile, err := repo.SaveProfile(context.Background(), resume, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, profile.Version)
+	assert.Equal(t, "Enzo", profile.Resume.Basics.Name)
+
+	_, err = repo.SaveProfile(context.Background(), resume, 2)
+	assert.ErrorIs(t, err, repository.ErrVersionConflict)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
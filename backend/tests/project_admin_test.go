@@ -0,0 +1,323 @@
+package tests_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	handlers "backend/api/handlers"
+	"backend/internal/models"
+	"backend/internal/repository"
+	"backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pashagolub/pgxmock/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// in-memory implementation of the project storage
+type memoryProjectRepository struct {
+	mu       sync.Mutex
+	nextID   int64
+	projects map[int64]*models.Project
+}
+
+func newMemoryProjectRepository() *memoryProjectRepository {
+	return &memoryProjectRepository{projects: map[int64]*models.Project{}}
+}
+
+func (r *memoryProjectRepository) ListProjects(ctx context.Context, filter models.ProjectFilter) ([]models.Project, int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	list := []models.Project{}
+	for _, p := range r.projects {
+		published := p.PublishedAt != nil && !p.PublishedAt.After(time.Now())
+		if filter.Drafts || published {
+			list = append(list, *p)
+		}
+	}
+	slices.SortFunc(list, func(a, b models.Project) int { return a.Position - b.Position })
+	return list, len(list), nil
+}
+
+func (r *memoryProjectRepository) GetProjectBySlug(ctx context.Context, slug string) (*models.Project, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, p := range r.projects {
+		if p.Slug == slug && p.PublishedAt != nil && !p.PublishedAt.After(time.Now()) {
+			copy := *p
+			return &copy, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *memoryProjectRepository) ListTechnologies(ctx context.Context) ([]models.Technology, error) {
+	return []models.Technology{}, nil
+}
+
+func (r *memoryProjectRepository) GetProject(ctx context.Context, id int64) (*models.Project, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p, ok := r.projects[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	copy := *p
+	return &copy, nil
+}
+
+func (r *memoryProjectRepository) SlugTaken(ctx context.Context, slug string, exceptID int64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, p := range r.projects {
+		if p.Slug == slug && p.ID != exceptID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *memoryProjectRepository) CreateProject(ctx context.Context, p *models.Project) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextID++
+	p.ID, p.Version, p.Position = r.nextID, 1, len(r.projects)+1
+	p.CreatedAt, p.UpdatedAt = time.Now(), time.Now()
+	copy := *p
+	r.projects[p.ID] = &copy
+	return nil
+}
+
+func (r *memoryProjectRepository) UpdateProject(ctx context.Context, p *models.Project) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	current, ok := r.projects[p.ID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if current.Version != p.Version {
+		return repository.ErrVersionConflict
+	}
+	p.Version, p.Position, p.PublishedAt, p.CreatedAt = current.Version+1, current.Position, current.PublishedAt, current.CreatedAt
+	copy := *p
+	r.projects[p.ID] = &copy
+	return nil
+}
+
+func (r *memoryProjectRepository) SetPublication(ctx context.Context, id int64, publishedAt *time.Time, version int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	p, ok := r.projects[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if version != 0 && p.Version != version {
+		return repository.ErrVersionConflict
+	}
+	p.PublishedAt = publishedAt
+	p.Version++
+	return nil
+}
+
+func (r *memoryProjectRepository) ReorderProjects(ctx context.Context, ids []int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, id := range ids {
+		if _, ok := r.projects[id]; !ok {
+			return repository.ErrNotFound
+		}
+	}
+	for i, id := range ids {
+		r.projects[id].Position = i + 1
+	}
+	return nil
+}
+
+func (r *memoryProjectRepository) DeleteProject(ctx context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.projects[id]; !ok {
+		return repository.ErrNotFound
+	}
+	delete(r.projects, id)
+	return nil
+}
+
+func TestSlugify(t *testing.T) {
+	assert.Equal(t, "infrastructure-reseau-homelab", models.Slugify("Infrastructure Réseau HomeLab"))
+	assert.Equal(t, "gitops-automation-hub", models.Slugify("  GitOps & Automation Hub! "))
+	assert.Equal(t, "aquarium-connecte-v2", models.Slugify("Aquarium connecté — v2"))
+	assert.Equal(t, "", models.Slugify("日本語"))
+	assert.Len(t, models.Slugify(strings.Repeat("abcd ", 30)), models.MaxProjectSlugLength-1, "no trailing dash after truncation")
+}
+
+func TestProjectService_Validation(t *testing.T) {
+	svc := services.NewProjectService(newMemoryProjectRepository())
+	ctx := context.Background()
+	valid := models.ProjectInput{Title: "Cluster Proxmox", Category: models.ProjectInfrastructure}
+
+	for name, mutate := range map[string]func(*models.ProjectInput){
+		"missing title":    func(in *models.ProjectInput) { in.Title = " " },
+		"unknown category": func(in *models.ProjectInput) { in.Category = "games" },
+		"invalid slug":     func(in *models.ProjectInput) { in.Slug = "Not a slug" },
+		"script icon":      func(in *models.ProjectInput) { in.Icon = `fas" onmouseover="alert(1)` },
+		"javascript link": func(in *models.ProjectInput) {
+			in.Links = []models.ProjectLink{{Label: "Go", URL: "javascript:alert(1)"}}
+		},
+		"protocol-relative":   func(in *models.ProjectInput) { in.Links = []models.ProjectLink{{Label: "Go", URL: "//evil.example/x"}} },
+		"image without alt":   func(in *models.ProjectInput) { in.Images = []models.ProjectImage{{URL: "/assets/images/rack.webp"}} },
+		"blank technology":    func(in *models.ProjectInput) { in.Technologies = []string{"--"} },
+		"too many technology": func(in *models.ProjectInput) { in.Technologies = make([]string, models.MaxProjectTechnologies+1) },
+	} {
+		input := valid
+		mutate(&input)
+		_, err := svc.Create(ctx, input)
+		assert.ErrorIs(t, err, services.ErrInvalidProject, name)
+	}
+
+	input := valid
+	input.Technologies = []string{"Proxmox VE", "proxmox-ve", "Linux"}
+	input.Links = []models.ProjectLink{{Label: "Détails", URL: "gitops-project.html#stack"}, {Label: "Code", URL: "https://git.example.com/x"}}
+	p, err := svc.Create(ctx, input)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Technology{{Slug: "proxmox-ve", Name: "Proxmox VE"}, {Slug: "linux", Name: "Linux"}}, p.Technologies,
+		"technologies are deduplicated by slug")
+	assert.Len(t, p.Links, 2)
+}
+
+func TestProjectService_SlugsAndVersions(t *testing.T) {
+	repo := newMemoryProjectRepository()
+	svc := services.NewProjectService(repo)
+	ctx := context.Background()
+	input := models.ProjectInput{Title: "Aquarium connecté", Category: models.ProjectIoT}
+
+	first, err := svc.Create(ctx, input)
+	assert.NoError(t, err)
+	assert.Equal(t, "aquarium-connecte", first.Slug)
+	assert.Equal(t, 1, first.Version)
+	assert.Nil(t, first.PublishedAt, "new projects are drafts")
+	second, err := svc.Create(ctx, input)
+	assert.NoError(t, err)
+	assert.Equal(t, "aquarium-connecte-2", second.Slug)
+
+	input.Slug = "aquarium-connecte"
+	_, err = svc.Create(ctx, input)
+	assert.ErrorIs(t, err, repository.ErrSlugTaken)
+
+	// updates must carry the version they were made from
+	update := models.ProjectInput{Title: "Aquarium v2", Category: models.ProjectIoT}
+	_, err = svc.Update(ctx, first.ID, update)
+	assert.ErrorIs(t, err, services.ErrInvalidProject)
+	update.Version = 1
+	updated, err := svc.Update(ctx, first.ID, update)
+	assert.NoError(t, err)
+	assert.Equal(t, "aquarium-connecte", updated.Slug, "the slug is kept when none is given")
+	assert.Equal(t, 2, updated.Version)
+	_, err = svc.Update(ctx, first.ID, update)
+	assert.ErrorIs(t, err, repository.ErrVersionConflict, "a stale version is rejected")
+
+	published, err := svc.Publish(ctx, first.ID, models.ProjectPublication{Version: 2})
+	assert.NoError(t, err)
+	assert.NotNil(t, published.PublishedAt)
+	_, err = svc.Unpublish(ctx, first.ID, 2)
+	assert.ErrorIs(t, err, repository.ErrVersionConflict)
+
+	assert.ErrorIs(t, svc.Reorder(ctx, []int64{second.ID, second.ID}), services.ErrInvalidProject)
+	assert.ErrorIs(t, svc.Reorder(ctx, []int64{second.ID, 99}), repository.ErrNotFound)
+	assert.NoError(t, svc.Reorder(ctx, []int64{second.ID, first.ID}))
+	list, _, err := svc.List(ctx, models.ProjectFilter{Drafts: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{second.ID, first.ID}, []int64{list[0].ID, list[1].ID})
+}
+
+func TestProjectHandler_Admin(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := handlers.NewProjectHandler(services.NewProjectService(newMemoryProjectRepository()))
+	router := gin.New()
+	router.GET("/projects/:slug", h.HandleGet)
+	router.GET("/admin/projects", h.HandleAdminList)
+	router.POST("/admin/projects", h.HandleCreate)
+	router.PUT("/admin/projects/order", h.HandleReorder)
+	router.PUT("/admin/projects/:id", h.HandleUpdate)
+	router.DELETE("/admin/projects/:id", h.HandleDelete)
+	router.POST("/admin/projects/:id/publish", h.HandlePublish)
+	router.POST("/admin/projects/:id/unpublish", h.HandleUnpublish)
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodPost, "/admin/projects", `{"title":"GitOps & Automation Hub","category":"system","technologies":["Jenkins","Ansible"],
+		"images":[{"url":"/media/pipeline.webp","alt":"Pipeline Jenkins"}]}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), `"slug":"gitops-automation-hub"`)
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, "/admin/projects", `{"title":"x","category":"games"}`).Code)
+	assert.Equal(t, http.StatusConflict, do(http.MethodPost, "/admin/projects", `{"slug":"gitops-automation-hub","title":"x","category":"web"}`).Code)
+
+	assert.Equal(t, http.StatusNotFound, do(http.MethodGet, "/projects/gitops-automation-hub", "").Code, "drafts are not public")
+	assert.Equal(t, http.StatusOK, do(http.MethodPost, "/admin/projects/1/publish", "").Code)
+	assert.Equal(t, http.StatusOK, do(http.MethodGet, "/projects/gitops-automation-hub", "").Code)
+
+	assert.Equal(t, http.StatusConflict, do(http.MethodPut, "/admin/projects/1", `{"title":"GitOps","category":"system","version":1}`).Code)
+	w = do(http.MethodPut, "/admin/projects/1", `{"title":"GitOps","category":"system","version":2}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"version":3`)
+
+	assert.Equal(t, http.StatusOK, do(http.MethodPost, "/admin/projects/1/unpublish", `{"version":3}`).Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodGet, "/projects/gitops-automation-hub", "").Code)
+
+	assert.Equal(t, http.StatusNoContent, do(http.MethodPut, "/admin/projects/order", `{"ids":[1]}`).Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodPut, "/admin/projects/order", `{"ids":[1,5]}`).Code)
+	assert.Contains(t, do(http.MethodGet, "/admin/projects", "").Body.String(), `"total":1`)
+
+	assert.Equal(t, http.StatusNoContent, do(http.MethodDelete, "/admin/projects/1", "").Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodDelete, "/admin/projects/1", "").Code)
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPut, "/admin/projects/x", `{}`).Code)
+}
+
+func TestProjectRepository_Versioning(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+	repo := repository.NewProjectRepository(mock)
+	ctx := context.Background()
+	now := time.Now()
+
+	p := &models.Project{ID: 3, Slug: "nas", Title: "NAS", Category: models.ProjectInfrastructure, Version: 4,
+		Technologies: []models.Technology{{Slug: "synology", Name: "Synology"}},
+		Links:        []models.ProjectLink{},
+		Images:       []models.ProjectImage{{URL: "/media/nas.webp", Alt: "NAS"}}}
+	args := []any{int64(3), "nas", "NAS", "", "", models.ProjectInfrastructure, "", "", 4,
+		`[{"slug":"synology","name":"Synology","position":0}]`, `[]`, `[{"url":"/media/nas.webp","alt":"NAS","position":0}]`}
+	mock.ExpectQuery(`UPDATE projects SET(.|\s)*WHERE id = \$1 AND version = \$9(.|\s)*INSERT INTO technologies`).
+		WithArgs(args...).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "version", "position", "created_at", "updated_at"}).AddRow(int64(3), 5, 2, now, now))
+	assert.NoError(t, repo.UpdateProject(ctx, p))
+	assert.Equal(t, 5, p.Version)
+
+	// no row written: the project exists, so the version was stale
+	args[8] = 5
+	mock.ExpectQuery(`UPDATE projects SET`).WithArgs(args...).WillReturnRows(pgxmock.NewRows([]string{"id", "version", "position", "created_at", "updated_at"}))
+	mock.ExpectQuery(`SELECT EXISTS \(SELECT 1 FROM projects WHERE id = \$1\)`).WithArgs(int64(3)).
+		WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(true))
+	assert.ErrorIs(t, repo.UpdateProject(ctx, p), repository.ErrVersionConflict)
+
+	mock.ExpectExec(`UPDATE projects SET published_at = \$2`).WithArgs(int64(8), (*time.Time)(nil), 0).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+	mock.ExpectQuery(`SELECT EXISTS`).WithArgs(int64(8)).WillReturnRows(pgxmock.NewRows([]string{"exists"}).AddRow(false))
+	assert.ErrorIs(t, repo.SetPublication(ctx, 8, nil, 0), repository.ErrNotFound)
+
+	mock.ExpectQuery(`WITH listed AS(.|\s)*UPDATE projects p SET position`).WithArgs([]int64{2, 9}).
+		WillReturnRows(pgxmock.NewRows([]string{"n"}).AddRow(1))
+	assert.ErrorIs(t, repo.ReorderProjects(ctx, []int64{2, 9}), repository.ErrNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
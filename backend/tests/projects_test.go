@@ -0,0 +1,132 @@
+package tests_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	handlers "backend/api/handlers"
+	"backend/internal/models"
+	"backend/internal/repository"
+	"backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+var projectRowColumns = []string{"id", "slug", "title", "summary", "body", "category", "badge", "icon", "position", "version",
+	"published_at", "created_at", "updated_at", "technologies", "links", "images"}
+
+func projectRow(rows *pgxmock.Rows, id int64, slug string, extra ...any) *pgxmock.Rows {
+	published := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	values := []any{id, slug, "Cluster " + slug, "Summary", "Body", models.ProjectInfrastructure, "Infrastructure", "fas fa-server", int(id), 1,
+		&published, published, published,
+		[]byte(`[{"slug":"proxmox","name":"Proxmox"},{"slug":"linux","name":"Linux"}]`),
+		[]byte(`[{"label":"Détails","url":"/projects/` + slug + `","icon":"fas fa-external-link-alt"}]`),
+		[]byte(`[]`)}
+	return rows.AddRow(append(values, extra...)...)
+}
+
+func TestProjectRepository_ListProjects(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	rows := pgxmock.NewRows(append(projectRowColumns, "count"))
+	projectRow(rows, 1, "proxmox", 3)
+	projectRow(rows, 2, "network", 3)
+	mock.ExpectQuery(`FROM projects p(.|\s)*published_at <= NOW\(\)(.|\s)*ORDER BY lower\(p.title\), p.id(.|\s)*LIMIT \$4 OFFSET \$5`).
+		WithArgs("proxmox", "", false, 2, 0).
+		WillReturnRows(rows)
+
+	repo := repository.NewProjectRepository(mock)
+	projects, total, err := repo.ListProjects(context.Background(), models.ProjectFilter{Technology: "proxmox", Sort: models.ProjectSortTitle, Limit: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, total)
+	assert.Len(t, projects, 2)
+	assert.Equal(t, []models.Technology{{Slug: "proxmox", Name: "Proxmox"}, {Slug: "linux", Name: "Linux"}}, projects[0].Technologies)
+	assert.Equal(t, "/projects/network", projects[1].Links[0].URL)
+
+	// past the last page, the total is counted separately
+	mock.ExpectQuery(`LIMIT \$4 OFFSET \$5`).
+		WithArgs("proxmox", "", false, 2, 4).
+		WillReturnRows(pgxmock.NewRows(append(projectRowColumns, "count")))
+	mock.ExpectQuery(`SELECT COUNT\(\*\)\s+FROM projects p`).
+		WithArgs("proxmox", "", false).
+		WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(3))
+	projects, total, err = repo.ListProjects(context.Background(), models.ProjectFilter{Technology: "proxmox", Sort: models.ProjectSortTitle, Limit: 2, Offset: 4})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, total)
+	assert.Empty(t, projects)
+
+	mock.ExpectQuery(`FROM projects p WHERE p.slug = \$1 AND p.published_at IS NOT NULL`).
+		WithArgs("draft").
+		WillReturnError(pgx.ErrNoRows)
+	_, err = repo.GetProjectBySlug(context.Background(), "draft")
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestProjectService_InvalidFilter(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+	svc := services.NewProjectService(repository.NewProjectRepository(mock))
+
+	_, _, err = svc.List(context.Background(), models.ProjectFilter{Sort: "popular"})
+	assert.ErrorIs(t, err, services.ErrInvalidProjectFilter)
+	_, _, err = svc.List(context.Background(), models.ProjectFilter{Category: "games"})
+	assert.ErrorIs(t, err, services.ErrInvalidProjectFilter)
+	assert.NoError(t, mock.ExpectationsWereMet(), "invalid filters never reach the database")
+}
+
+func TestProjectHandler_ETag(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+	mock.MatchExpectationsInOrder(false)
+
+	h := handlers.NewProjectHandler(services.NewProjectService(repository.NewProjectRepository(mock)))
+	router := gin.New()
+	router.GET("/projects", h.HandleList)
+	router.GET("/projects/:slug", h.HandleGet)
+	do := func(path, ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	expectList := func() {
+		mock.ExpectQuery(`FROM projects p`).WithArgs("proxmox", models.ProjectInfrastructure, false, 50, 0).
+			WillReturnRows(projectRow(pgxmock.NewRows(append(projectRowColumns, "count")), 1, "proxmox", 1))
+	}
+
+	expectList()
+	w := do("/projects?technology=Proxmox&category=infrastructure", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"total":1`)
+	assert.Contains(t, w.Body.String(), `"slug":"proxmox"`)
+	etag := w.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+	assert.Equal(t, "public, no-cache", w.Header().Get("Cache-Control"))
+
+	expectList()
+	w = do("/projects?technology=proxmox&category=infrastructure", `"other", W/`+etag)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.String())
+	assert.Equal(t, etag, w.Header().Get("ETag"))
+
+	assert.Equal(t, http.StatusBadRequest, do("/projects?sort=popular", "").Code)
+
+	mock.ExpectQuery(`WHERE p.slug = \$1`).WithArgs("missing").WillReturnError(pgx.ErrNoRows)
+	assert.Equal(t, http.StatusNotFound, do("/projects/missing", "").Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"backend/internal/models"
 	"backend/internal/repository"
@@ -25,17 +26,22 @@ func TestContactRepository_SaveContactForm_Success(t *testing.T) {
 		Message: "Test Message",
 	}
 
-	mock.ExpectExec(`INSERT INTO contact_submissions`).
-		WithArgs(form.Name, form.Email, form.Subject, form.Message).
-		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	createdAt := time.Now()
+	mock.ExpectQuery(`INSERT INTO contact_submissions`).
+		WithArgs(form.Name, form.Email, form.Subject, form.Message, form.DedupeHash(), models.PriorityHigh, []string{"vip"},
+			(*int16)(nil), []byte(nil), (*string)(nil), "", "", "", "", "", "", "", "", "", "", "", int64(0), "").
+		WillReturnRows(pgxmock.NewRows([]string{"id", "created_at"}).AddRow(int64(42), createdAt))
 
 	repo := repository.NewContactRepository(mock)
+	submission := &models.ContactSubmission{ContactForm: form, Priority: models.PriorityHigh, Tags: []string{"vip"}}
 
 	// Act
-	err = repo.SaveContactForm(context.Background(), form)
+	err = repo.SaveContactForm(context.Background(), submission)
 
 	// Assert
 	assert.NoError(t, err)
+	assert.Equal(t, int64(42), submission.ID)
+	assert.Equal(t, createdAt, submission.CreatedAt)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
@@ -53,17 +59,59 @@ func TestContactRepository_SaveContactForm_DatabaseError(t *testing.T) {
 	}
 
 	expectedErr := errors.New("connection timeout")
-	mock.ExpectExec(`INSERT INTO contact_submissions`).
-		WithArgs(form.Name, form.Email, form.Subject, form.Message).
+	mock.ExpectQuery(`INSERT INTO contact_submissions`).
+		WithArgs(form.Name, form.Email, form.Subject, form.Message, form.DedupeHash(), models.PriorityNormal, []string{},
+			(*int16)(nil), []byte(nil), (*string)(nil), "", "", "", "", "", "", "", "", "", "", "", int64(0), "").
 		WillReturnError(expectedErr)
 
 	repo := repository.NewContactRepository(mock)
 
 	// Act
-	err = repo.SaveContactForm(context.Background(), form)
+	err = repo.SaveContactForm(context.Background(), &models.ContactSubmission{ContactForm: form})
 
 	// Assert
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "unable to insert contact in database")
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
+
+func TestContactRepository_SaveContactFormOnce(t *testing.T) {
+	// Arrange
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	form := models.ContactForm{Name: "John Doe", Email: "john@example.com", Subject: "question", Message: "Hello"}
+	created := time.Now()
+	mock.ExpectQuery(`WITH purge AS \(\s*DELETE FROM contact_dedupe .* ON CONFLICT \(dedupe_hash\) DO UPDATE .* INSERT INTO contact_submissions .* FROM claim`).
+		WithArgs(append(anyArgs(23), 600.0)...).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "created_at"}).AddRow(int64(7), created))
+	mock.ExpectQuery(`WITH purge AS`).
+		WithArgs(append(anyArgs(23), 600.0)...).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "created_at"}))
+
+	repo := repository.NewContactRepository(mock)
+
+	// Act
+	first := &models.ContactSubmission{ContactForm: form}
+	saved, err := repo.SaveContactFormOnce(context.Background(), first, 10*time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, saved)
+	assert.Equal(t, int64(7), first.ID)
+
+	saved, err = repo.SaveContactFormOnce(context.Background(), &models.ContactSubmission{ContactForm: form}, 10*time.Minute)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.False(t, saved, "no row: the hash is claimed by a recent submission")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// anyArgs matches n query arguments of any value
+func anyArgs(n int) []interface{} {
+	args := make([]interface{}, n)
+	for i := range args {
+		args[i] = pgxmock.AnyArg()
+	}
+	return args
+}
@@ -0,0 +1,487 @@
+package tests_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	handlers "backend/api/handlers"
+	"backend/internal/markdown"
+	"backend/internal/models"
+	"backend/internal/repository"
+	"backend/internal/services"
+	"backend/internal/site"
+	"backend/internal/textdiff"
+
+	"github.com/gin-gonic/gin"
+	"github.com/pashagolub/pgxmock/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// in-memory implementation of the revision storage
+type memoryRevisionRepository struct {
+	mu        sync.Mutex
+	revisions []models.Revision
+}
+
+func (r *memoryRevisionRepository) CreateRevision(ctx context.Context, revision *models.Revision) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	revision.Number = 1
+	for _, existing := range r.revisions {
+		if existing.ContentType == revision.ContentType && existing.ContentKey == revision.ContentKey {
+			revision.Number = existing.Number + 1
+		}
+	}
+	revision.ID = int64(len(r.revisions) + 1)
+	revision.CreatedAt = time.Now()
+	r.revisions = append(r.revisions, *revision)
+	return nil
+}
+
+func (r *memoryRevisionRepository) ListRevisions(ctx context.Context, contentType, contentKey string) ([]models.Revision, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	list := []models.Revision{}
+	for i := len(r.revisions) - 1; i >= 0; i-- {
+		if rev := r.revisions[i]; rev.ContentType == contentType && rev.ContentKey == contentKey {
+			rev.Body = ""
+			list = append(list, rev)
+		}
+	}
+	return list, nil
+}
+
+func (r *memoryRevisionRepository) GetRevision(ctx context.Context, id int64) (*models.Revision, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if id <= 0 || int(id) > len(r.revisions) {
+		return nil, repository.ErrNotFound
+	}
+	rev := r.revisions[id-1]
+	return &rev, nil
+}
+
+// in-memory, writable article storage
+type writableArticleStore struct {
+	mu      sync.Mutex
+	sources map[string]models.ArticleSource
+}
+
+func newWritableArticleStore() *writableArticleStore {
+	return &writableArticleStore{sources: map[string]models.ArticleSource{}}
+}
+
+func (w *writableArticleStore) ListArticleSources(ctx context.Context) ([]models.ArticleSource, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var list []models.ArticleSource
+	for _, source := range w.sources {
+		list = append(list, source)
+	}
+	return list, nil
+}
+
+func (w *writableArticleStore) GetArticleSource(ctx context.Context, slug string) (*models.ArticleSource, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	source, ok := w.sources[slug]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &source, nil
+}
+
+func (w *writableArticleStore) SaveArticleSource(ctx context.Context, source *models.ArticleSource) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	source.UpdatedAt = time.Now()
+	w.sources[source.Slug] = *source
+	return nil
+}
+
+func (w *writableArticleStore) DeleteArticleSource(ctx context.Context, slug string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, ok := w.sources[slug]; !ok {
+		return repository.ErrNotFound
+	}
+	delete(w.sources, slug)
+	return nil
+}
+
+// in-memory implementation of the schedule storage
+type memoryScheduleRepository struct {
+	mu           sync.Mutex
+	schedules    []models.Schedule
+	claimedUntil map[int64]time.Time
+}
+
+func (r *memoryScheduleRepository) CreateSchedule(ctx context.Context, schedule *models.Schedule) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	schedule.ID = int64(len(r.schedules) + 1)
+	schedule.CreatedAt = time.Now()
+	r.schedules = append(r.schedules, *schedule)
+	return nil
+}
+
+func (r *memoryScheduleRepository) ListSchedules(ctx context.Context, filter models.ScheduleFilter) ([]models.Schedule, int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	list := []models.Schedule{}
+	for _, s := range r.schedules {
+		if !filter.Pending || (s.DoneAt == nil && s.FailedAt == nil) {
+			list = append(list, s)
+		}
+	}
+	return list, len(list), nil
+}
+
+func (r *memoryScheduleRepository) DeleteSchedule(ctx context.Context, id int64) error {
+	return errors.New("not implemented")
+}
+
+func (r *memoryScheduleRepository) ClaimDueSchedules(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]models.Schedule, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.claimedUntil == nil {
+		r.claimedUntil = map[int64]time.Time{}
+	}
+	var due []models.Schedule
+	for _, s := range r.schedules {
+		if s.DoneAt == nil && s.FailedAt == nil && !s.RunAt.After(now) && !r.claimedUntil[s.ID].After(now) && len(due) < limit {
+			r.claimedUntil[s.ID] = now.Add(lease)
+			due = append(due, s)
+		}
+	}
+	return due, nil
+}
+
+func (r *memoryScheduleRepository) RecordScheduleAttempt(ctx context.Context, id int64, attemptErr string, retryAt *time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	s := &r.schedules[id-1]
+	s.Attempts++
+	s.Error = attemptErr
+	delete(r.claimedUntil, id)
+	switch {
+	case attemptErr == "":
+		s.DoneAt = &now
+	case retryAt == nil:
+		s.FailedAt = &now
+	default:
+		r.claimedUntil[id] = *retryAt
+	}
+	return nil
+}
+
+func TestTextDiff_Unified(t *testing.T) {
+	assert.Empty(t, textdiff.Unified("a", "b", "same\n", "same\n"))
+	diff := textdiff.Unified("project/1 #1", "project/1 #2", "1\n2\n3\n4\n5\n6\n7\n8\n", "1\n2\n3\nfour\n5\n6\n7\n8\n9\n")
+	assert.Equal(t, "--- project/1 #1\n+++ project/1 #2\n@@ -1,8 +1,9 @@\n 1\n 2\n 3\n-4\n+four\n 5\n 6\n 7\n 8\n+9\n", diff)
+	assert.Equal(t, "--- a\n+++ b\n@@ -0,0 +1 @@\n+new\n", textdiff.Unified("a", "b", "", "new"))
+}
+
+func TestSetFrontMatter(t *testing.T) {
+	source := "---\ntitle: Ceph # the title\ndraft: true\n---\nBody\n"
+	out := markdown.SetFrontMatter(source, markdown.Field{Key: "draft", Value: "false"}, markdown.Field{Key: "date", Value: "2024-05-01T10:00:00Z"})
+	assert.Equal(t, "---\ntitle: Ceph # the title\ndraft: false\ndate: 2024-05-01T10:00:00Z\n---\nBody\n", out)
+	assert.Equal(t, "---\ndraft: true\n---\nBody\n", markdown.SetFrontMatter("Body\n", markdown.Field{Key: "draft", Value: "true"}))
+}
+
+func TestProjectRevisions(t *testing.T) {
+	ctx := context.Background()
+	revisions := services.NewRevisionService(&memoryRevisionRepository{})
+	projects := services.NewProjectService(newMemoryProjectRepository(), services.WithProjectRevisions(revisions))
+
+	created, err := projects.Create(ctx, models.ProjectInput{
+		Title: "Proxmox", Summary: "Cluster", Body: "First paragraph.\n\nSecond paragraph.",
+		Category: models.ProjectInfrastructure, Technologies: []string{"Proxmox"},
+	})
+	assert.NoError(t, err)
+	_, err = projects.Update(ctx, created.ID, models.ProjectInput{
+		Title: "Proxmox VE", Summary: "Cluster", Body: "First paragraph.\n\nSecond paragraph, edited.",
+		Category: models.ProjectInfrastructure, Technologies: []string{"Proxmox"}, Version: created.Version,
+	})
+	assert.NoError(t, err)
+
+	list, err := revisions.List(ctx, models.ContentProject, "1")
+	assert.NoError(t, err)
+	assert.Len(t, list, 2)
+	assert.Equal(t, 2, list[0].Number, "newest first")
+	assert.Empty(t, list[0].Body)
+
+	diff, err := revisions.Diff(ctx, list[1].ID, list[0].ID)
+	assert.NoError(t, err)
+	assert.Contains(t, diff.Diff, "-title: Proxmox\n+title: Proxmox VE\n")
+	assert.Contains(t, diff.Diff, "-    Second paragraph.\n+    Second paragraph, edited.\n", "body lines are diffed one by one")
+
+	first, err := revisions.Get(ctx, list[1].ID)
+	assert.NoError(t, err)
+	assert.NoError(t, services.ProjectRestorer(projects).RestoreRevision(ctx, first, 0))
+	restored, err := projects.GetByID(ctx, created.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Proxmox", restored.Title)
+	assert.Equal(t, "First paragraph.\n\nSecond paragraph.", restored.Body)
+	list, _ = revisions.List(ctx, models.ContentProject, "1")
+	assert.Len(t, list, 3, "restoring records a new revision")
+
+	err = services.ProjectRestorer(projects).RestoreRevision(ctx, first, 1)
+	assert.ErrorIs(t, err, repository.ErrVersionConflict)
+
+	_, err = revisions.List(ctx, "page", "1")
+	assert.ErrorIs(t, err, services.ErrInvalidRevision)
+}
+
+func TestArticleAdmin(t *testing.T) {
+	ctx := context.Background()
+	revisions := services.NewRevisionService(&memoryRevisionRepository{})
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	articles := services.NewArticleService(newWritableArticleStore(),
+		services.WithArticleRevisions(revisions),
+		services.WithArticleClock(func() time.Time { return now }))
+
+	article, err := articles.Save(ctx, "ceph", "---\ntitle: Ceph\ndraft: true\n---\nDraft body\n")
+	assert.NoError(t, err)
+	assert.True(t, article.Draft)
+	_, err = articles.Get(ctx, "ceph")
+	assert.ErrorIs(t, err, repository.ErrNotFound, "drafts are not public")
+
+	article, err = articles.Publish(ctx, "ceph", models.ArticlePublication{})
+	assert.NoError(t, err)
+	assert.False(t, article.Draft)
+	assert.Equal(t, now, article.PublishedAt.UTC())
+	_, err = articles.Get(ctx, "ceph")
+	assert.NoError(t, err)
+
+	_, err = articles.Unpublish(ctx, "ceph")
+	assert.NoError(t, err)
+	_, err = articles.Get(ctx, "ceph")
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	list, err := revisions.List(ctx, models.ContentArticle, "ceph")
+	assert.NoError(t, err)
+	assert.Len(t, list, 3, "publishing edits the front matter, which is a save")
+	published, err := revisions.Get(ctx, list[1].ID)
+	assert.NoError(t, err)
+	assert.NoError(t, articles.RestoreRevision(ctx, published, 0))
+	_, err = articles.Get(ctx, "ceph")
+	assert.NoError(t, err, "the published revision is live again")
+
+	_, err = articles.Save(ctx, "ceph", "---\ntitle: Ceph\nslug: other\n---\n")
+	assert.ErrorIs(t, err, services.ErrInvalidArticle)
+	_, err = articles.Save(ctx, "ceph", "---\nsummary: no title\n---\n")
+	assert.ErrorIs(t, err, services.ErrInvalidArticle)
+
+	readOnly := services.NewArticleService(memoryArticleStore{})
+	_, err = readOnly.Save(ctx, "ceph", "---\ntitle: Ceph\n---\n")
+	assert.ErrorIs(t, err, services.ErrReadOnlyArticles)
+}
+
+// recordingPublisher records the actions run by the scheduler
+type recordingPublisher struct {
+	actions []string
+	err     error
+}
+
+func (p *recordingPublisher) PublishContent(ctx context.Context, key string) error {
+	p.actions = append(p.actions, "publish "+key)
+	return p.err
+}
+
+func (p *recordingPublisher) UnpublishContent(ctx context.Context, key string) error {
+	p.actions = append(p.actions, "unpublish "+key)
+	return p.err
+}
+
+func TestScheduleService(t *testing.T) {
+	ctx := context.Background()
+	repo := &memoryScheduleRepository{}
+	projects := &recordingPublisher{}
+	articles := &recordingPublisher{err: errors.New("boom")}
+	svc := services.NewScheduleService(repo, map[string]services.ContentPublisher{
+		models.ContentProject: projects,
+		models.ContentArticle: articles,
+	})
+
+	_, err := svc.Schedule(ctx, models.Schedule{ContentType: models.ContentProject, ContentKey: "1", Action: "archive", RunAt: time.Now().Add(time.Hour)})
+	assert.ErrorIs(t, err, services.ErrInvalidSchedule)
+	_, err = svc.Schedule(ctx, models.Schedule{ContentType: models.ContentProject, ContentKey: "1", Action: models.SchedulePublish, RunAt: time.Now().Add(-time.Hour)})
+	assert.ErrorIs(t, err, services.ErrInvalidSchedule, "run_at must be in the future")
+	_, err = svc.Schedule(ctx, models.Schedule{ContentType: "page", ContentKey: "1", Action: models.SchedulePublish, RunAt: time.Now().Add(time.Hour)})
+	assert.ErrorIs(t, err, services.ErrInvalidSchedule)
+
+	for _, s := range []models.Schedule{
+		{ContentType: models.ContentProject, ContentKey: "1", Action: models.SchedulePublish, RunAt: time.Now().Add(time.Millisecond)},
+		{ContentType: models.ContentProject, ContentKey: "1", Action: models.ScheduleUnpublish, RunAt: time.Now().Add(time.Hour)},
+		{ContentType: models.ContentArticle, ContentKey: "ceph", Action: models.ScheduleUnpublish, RunAt: time.Now().Add(time.Millisecond)},
+	} {
+		_, err := svc.Schedule(ctx, s)
+		assert.NoError(t, err)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	assert.NoError(t, svc.RunDue(ctx))
+	assert.Equal(t, []string{"publish 1"}, projects.actions, "only due schedules run")
+	assert.Equal(t, []string{"unpublish ceph"}, articles.actions)
+	assert.Equal(t, "boom", repo.schedules[2].Error, "failures are recorded")
+	assert.NotNil(t, repo.schedules[0].DoneAt)
+	assert.Nil(t, repo.schedules[2].DoneAt, "a failed schedule is not done")
+	assert.Equal(t, 1, repo.schedules[2].Attempts)
+
+	pending, total, err := svc.List(ctx, models.ScheduleFilter{Pending: true})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, total, "the failed schedule waits for its retry")
+	assert.Equal(t, models.ScheduleUnpublish, pending[1].Action)
+
+	assert.NoError(t, svc.RunDue(ctx))
+	assert.Len(t, projects.actions, 1, "schedules run once")
+	assert.Len(t, articles.actions, 1, "retries wait for their delay")
+
+	// the retry time has come for the last allowed attempt
+	repo.schedules[2].Attempts = 4
+	repo.claimedUntil[3] = time.Now().Add(-time.Second)
+	assert.NoError(t, svc.RunDue(ctx))
+	assert.Len(t, articles.actions, 2)
+	assert.NotNil(t, repo.schedules[2].FailedAt, "the schedule fails after the last attempt")
+	assert.Equal(t, 5, repo.schedules[2].Attempts)
+	_, total, err = svc.List(ctx, models.ScheduleFilter{Pending: true})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, total)
+
+	assert.NoError(t, svc.RunDue(ctx))
+	assert.Len(t, articles.actions, 2, "failed schedules are not retried")
+}
+
+func TestPreviewSigner(t *testing.T) {
+	now := time.Now()
+	signer := services.NewPreviewSigner("secret", time.Hour)
+	path, expires, err := signer.Path(models.ContentArticle, "ceph", now)
+	assert.NoError(t, err)
+	assert.WithinDuration(t, now.Add(time.Hour), expires, time.Second)
+
+	u, err := url.Parse(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "/preview/article/ceph", u.Path)
+	q := u.Query()
+	assert.True(t, signer.Verify(models.ContentArticle, "ceph", q.Get("expires"), q.Get("sig"), now))
+	assert.False(t, signer.Verify(models.ContentArticle, "k3s", q.Get("expires"), q.Get("sig"), now), "a link only opens its content")
+	assert.False(t, signer.Verify(models.ContentArticle, "ceph", q.Get("expires"), q.Get("sig"), now.Add(2*time.Hour)), "links expire")
+	assert.False(t, services.NewPreviewSigner("other", time.Hour).Verify(models.ContentArticle, "ceph", q.Get("expires"), q.Get("sig"), now))
+
+	_, _, err = services.NewPreviewSigner("", time.Hour).Path(models.ContentArticle, "ceph", now)
+	assert.ErrorIs(t, err, services.ErrPreviewDisabled)
+}
+
+func TestPageHandler_Preview(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx := context.Background()
+	renderer, err := site.NewRenderer(site.Options{Site: site.Info{Name: "Enzo Gaggiotti", URL: "https://example.com"}})
+	assert.NoError(t, err)
+	projects := services.NewProjectService(newMemoryProjectRepository())
+	draft, err := projects.Create(ctx, models.ProjectInput{Title: "Secret lab", Summary: "Soon", Category: models.ProjectWeb})
+	assert.NoError(t, err)
+	signer := services.NewPreviewSigner("secret", time.Hour)
+	h := handlers.NewPageHandler(renderer, projects, services.NewArticleService(memoryArticleStore{}), newTestProfileService(t), &mockContactService{}, nil, signer)
+	router := gin.New()
+	router.GET("/preview/:type/:key", h.HandlePreview)
+
+	path, _, err := signer.Path(models.ContentProject, "1", time.Now())
+	assert.NoError(t, err)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), draft.Title)
+	assert.Contains(t, w.Body.String(), `<meta name="robots" content="noindex, nofollow" />`)
+	assert.Equal(t, "private, no-store", w.Header().Get("Cache-Control"))
+	assert.Empty(t, w.Header().Get("ETag"))
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, strings.Replace(path, "sig=", "sig=0", 1), nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestContentHandler_Revisions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx := context.Background()
+	revisions := services.NewRevisionService(&memoryRevisionRepository{})
+	projects := services.NewProjectService(newMemoryProjectRepository(), services.WithProjectRevisions(revisions))
+	created, err := projects.Create(ctx, models.ProjectInput{Title: "One", Summary: "S", Category: models.ProjectWeb})
+	assert.NoError(t, err)
+	_, err = projects.Update(ctx, created.ID, models.ProjectInput{Title: "Two", Summary: "S", Category: models.ProjectWeb, Version: created.Version})
+	assert.NoError(t, err)
+
+	h := handlers.NewContentHandler(revisions, map[string]services.ContentRestorer{
+		models.ContentProject: services.ProjectRestorer(projects),
+	}, services.NewScheduleService(&memoryScheduleRepository{}, nil), services.NewPreviewSigner("", time.Hour), "https://example.com")
+	router := gin.New()
+	router.GET("/revisions", h.HandleListRevisions)
+	router.GET("/revisions/diff", h.HandleDiffRevisions)
+	router.POST("/revisions/:id/restore", h.HandleRestoreRevision)
+	router.POST("/previews", h.HandleCreatePreview)
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodGet, "/revisions?content_type=project&content_key=1", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"number":2`)
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodGet, "/revisions?content_type=page&content_key=1", "").Code)
+
+	w = do(http.MethodGet, "/revisions/diff?from=1&to=2", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `-title: One\n+title: Two`)
+
+	assert.Equal(t, http.StatusConflict, do(http.MethodPost, "/revisions/1/restore", `{"version": 1}`).Code, "stale versions are rejected")
+	assert.Equal(t, http.StatusNoContent, do(http.MethodPost, "/revisions/1/restore", "").Code)
+	project, err := projects.GetByID(ctx, created.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "One", project.Title)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodPost, "/revisions/99/restore", "").Code)
+
+	assert.Equal(t, http.StatusServiceUnavailable, do(http.MethodPost, "/previews", `{"content_type":"project","content_key":"1"}`).Code)
+}
+
+func TestRevisionRepository(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	created := time.Now()
+	mock.ExpectQuery(`INSERT INTO content_revisions`).
+		WithArgs(models.ContentArticle, "ceph", "# Ceph").
+		WillReturnRows(pgxmock.NewRows([]string{"id", "number", "created_at"}).AddRow(int64(7), 3, created))
+	mock.ExpectQuery(`UPDATE content_schedules SET claimed_until = NOW\(\) \+ \$3(.|\s)*claimed_until IS NULL OR claimed_until <= NOW\(\)(.|\s)*FOR UPDATE SKIP LOCKED`).
+		WithArgs(created, 50, float64(300)).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "content_type", "content_key", "action", "run_at", "created_at", "done_at", "failed_at", "attempts", "error"}).
+			AddRow(int64(1), models.ContentProject, "4", models.SchedulePublish, created, created, (*time.Time)(nil), (*time.Time)(nil), 1, "boom"))
+	retry := created.Add(time.Minute)
+	mock.ExpectExec(`UPDATE content_schedules(.|\s)*attempts = attempts \+ 1(.|\s)*done_at = CASE WHEN \$2 = ''`).
+		WithArgs(int64(1), "boom", &retry).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+	revision := &models.Revision{ContentType: models.ContentArticle, ContentKey: "ceph", Body: "# Ceph"}
+	assert.NoError(t, repository.NewRevisionRepository(mock).CreateRevision(context.Background(), revision))
+	assert.Equal(t, 3, revision.Number)
+
+	schedules := repository.NewScheduleRepository(mock)
+	due, err := schedules.ClaimDueSchedules(context.Background(), created, 50, 5*time.Minute)
+	assert.NoError(t, err)
+	assert.Len(t, due, 1)
+	assert.Equal(t, "4", due[0].ContentKey)
+	assert.Equal(t, 1, due[0].Attempts)
+	assert.NoError(t, schedules.RecordScheduleAttempt(context.Background(), 1, "boom", &retry))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,198 @@
+package tests_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"backend/internal/models"
+	"backend/internal/repository"
+	"backend/internal/services"
+
+	"github.com/pashagolub/pgxmock/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// static rule source for tests
+type staticRuleSource []models.RoutingRule
+
+func (s staticRuleSource) ListRoutingRules(ctx context.Context) ([]models.RoutingRule, error) {
+	return s, nil
+}
+
+func newTestRoutingEngine(t *testing.T, rules ...models.RoutingRule) *services.RoutingEngine {
+	t.Helper()
+	engine := services.NewRoutingEngine(staticRuleSource(rules))
+	assert.NoError(t, engine.Refresh(context.Background()))
+	return engine
+}
+
+func TestRoutingEngine_Route(t *testing.T) {
+	engine := newTestRoutingEngine(t,
+		models.RoutingRule{Name: "spam-words", Position: 0, Enabled: true, Keywords: []string{"CASINO"}, Suppress: true},
+		models.RoutingRule{Name: "disabled", Position: 1, Enabled: false, Subjects: []string{"question"}, Priority: models.PriorityUrgent},
+		models.RoutingRule{Name: "school", Position: 2, Enabled: true, Subjects: []string{"stage"}, SenderDomains: []string{"univ.fr"},
+			Recipients: []string{"jobs@example.com"}, Priority: models.PriorityHigh, Tags: []string{"internship"}, Channels: []string{"email", "ntfy"}},
+		models.RoutingRule{Name: "proxmox", Position: 3, Enabled: true, Keywords: []string{"proxmox"}, Tags: []string{"homelab"}},
+	)
+
+	testCases := []struct {
+		name     string
+		form     models.ContactForm
+		wantRule string
+		check    func(t *testing.T, d models.RoutingDecision)
+	}{
+		{
+			name:     "keyword match suppresses",
+			form:     models.ContactForm{Email: "a@b.com", Subject: "other", Message: "Best casino bonus"},
+			wantRule: "spam-words",
+			check:    func(t *testing.T, d models.RoutingDecision) { assert.True(t, d.Suppress) },
+		},
+		{
+			name:     "subject and subdomain match",
+			form:     models.ContactForm{Email: "student@etu.univ.fr", Subject: "stage", Message: "Bonjour"},
+			wantRule: "school",
+			check: func(t *testing.T, d models.RoutingDecision) {
+				assert.Equal(t, []string{"jobs@example.com"}, d.Recipients)
+				assert.Equal(t, models.PriorityHigh, d.Priority)
+				assert.Equal(t, []string{"internship"}, d.Tags)
+				assert.Equal(t, []string{"email", "ntfy"}, d.Channels)
+			},
+		},
+		{
+			name:     "subject without domain does not match",
+			form:     models.ContactForm{Email: "student@gmail.com", Subject: "stage", Message: "Bonjour"},
+			wantRule: "",
+		},
+		{
+			name:     "lookalike domain does not match",
+			form:     models.ContactForm{Email: "x@notuniv.fr", Subject: "stage", Message: "Bonjour"},
+			wantRule: "",
+		},
+		{
+			name:     "disabled rule is skipped",
+			form:     models.ContactForm{Email: "a@b.com", Subject: "question", Message: "About Proxmox"},
+			wantRule: "proxmox",
+			check:    func(t *testing.T, d models.RoutingDecision) { assert.Equal(t, models.PriorityNormal, d.Priority) },
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			d := engine.Route(tc.form, models.ClientMetadata{})
+
+			assert.Equal(t, tc.wantRule, d.Rule)
+			if tc.check != nil {
+				tc.check(t, d)
+			}
+		})
+	}
+}
+
+func TestRoutingEngine_InvalidRulesKeepPrevious(t *testing.T) {
+	source := &mutableRuleSource{rules: []models.RoutingRule{{Name: "ok", Enabled: true, Tags: []string{"a"}}}}
+	engine := services.NewRoutingEngine(source)
+	assert.NoError(t, engine.Refresh(context.Background()))
+
+	source.rules = []models.RoutingRule{{Name: "bad", Enabled: true, Priority: "whenever"}}
+	err := engine.Refresh(context.Background())
+
+	assert.Error(t, err)
+	assert.Len(t, engine.Rules(), 1)
+	assert.Equal(t, "ok", engine.Rules()[0].Name)
+}
+
+type mutableRuleSource struct {
+	rules []models.RoutingRule
+}
+
+func (m *mutableRuleSource) ListRoutingRules(ctx context.Context) ([]models.RoutingRule, error) {
+	return m.rules, nil
+}
+
+func TestFileRoutingRuleSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.json")
+	content := `[
+		{"name": "first", "subjects": ["stage"], "priority": "high"},
+		{"name": "off", "enabled": false},
+		{"name": "last", "position": -1, "keywords": ["urgent"]}
+	]`
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	engine := services.NewRoutingEngine(services.NewFileRoutingRuleSource(path))
+	assert.NoError(t, engine.Refresh(context.Background()))
+
+	rules := engine.Rules()
+	assert.Len(t, rules, 2)
+	assert.Equal(t, "last", rules[0].Name, "explicit position -1 sorts first")
+	assert.Equal(t, "first", rules[1].Name)
+}
+
+func TestRoutingRuleRepository_ListRoutingRules(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	mock.ExpectQuery(`SELECT id, name, position, enabled`).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "name", "position", "enabled", "subjects", "keywords", "sender_domains",
+			"countries", "asns", "recipients", "priority", "tags", "channels", "suppress"}).
+			AddRow(int64(1), "school", 0, true, []string{"stage"}, []string{}, []string{"univ.fr"}, []string{"FR"}, []int64{16276},
+				[]string{"jobs@example.com"}, "high", []string{"internship"}, []string{}, false))
+
+	repo := repository.NewRoutingRuleRepository(mock)
+	rules, err := repo.ListRoutingRules(context.Background())
+
+	assert.NoError(t, err)
+	assert.Len(t, rules, 1)
+	assert.Equal(t, []string{"univ.fr"}, rules[0].SenderDomains)
+	assert.Equal(t, []int64{16276}, rules[0].ASNs)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestContactService_AppliesRouting(t *testing.T) {
+	form := models.ContactForm{Name: "Jane", Email: "jane@univ.fr", Subject: "stage", Message: "Hello"}
+
+	t.Run("recipients, priority and tags", func(t *testing.T) {
+		mockRepo := new(mockContactRepository)
+		notifier := newRecordingNotifier("email", nil)
+		engine := newTestRoutingEngine(t, models.RoutingRule{Name: "school", Enabled: true, SenderDomains: []string{"univ.fr"},
+			Recipients: []string{"jobs@example.com"}, Priority: models.PriorityHigh, Tags: []string{"internship"}})
+
+		mockRepo.On("SaveContactForm", mock.Anything, mock.MatchedBy(func(s *models.ContactSubmission) bool {
+			return s.Priority == models.PriorityHigh && len(s.Tags) == 1 && s.Tags[0] == "internship"
+		})).Return(nil)
+
+		service := services.NewContactService(mockRepo, notifier, services.WithRouting(engine))
+		assert.NoError(t, service.SubmitContactForm(context.Background(), form))
+
+		select {
+		case n := <-notifier.got:
+			assert.Equal(t, []string{"jobs@example.com"}, n.Recipients)
+			assert.Equal(t, models.PriorityHigh, n.Priority)
+		case <-time.After(time.Second):
+			t.Fatal("notification was not sent")
+		}
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("suppressed", func(t *testing.T) {
+		mockRepo := new(mockContactRepository)
+		notifier := newRecordingNotifier("email", nil)
+		engine := newTestRoutingEngine(t, models.RoutingRule{Name: "mute", Enabled: true, Suppress: true})
+
+		mockRepo.On("SaveContactForm", mock.Anything, submissionOf(form)).Return(nil)
+
+		service := services.NewContactService(mockRepo, notifier, services.WithRouting(engine))
+		assert.NoError(t, service.SubmitContactForm(context.Background(), form))
+
+		select {
+		case <-notifier.got:
+			t.Fatal("notification should be suppressed")
+		case <-time.After(50 * time.Millisecond):
+		}
+		mockRepo.AssertExpectations(t)
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"backend/internal/models"
 	"backend/internal/services"
@@ -17,37 +18,59 @@ type mockContactRepository struct {
 	mock.Mock
 }
 
-func (m *mockContactRepository) SaveContactForm(ctx context.Context, form models.ContactForm) error {
-	args := m.Called(ctx, form)
+func (m *mockContactRepository) SaveContactForm(ctx context.Context, submission *models.ContactSubmission) error {
+	args := m.Called(ctx, submission)
+	if args.Error(0) == nil {
+		submission.ID = 1
+	}
 	return args.Error(0)
 }
 
-// Mock email service
-type mockEmailService struct {
+// submissionOf matches the *models.ContactSubmission built from form
+func submissionOf(form models.ContactForm) interface{} {
+	return mock.MatchedBy(func(s *models.ContactSubmission) bool {
+		return s.ContactForm == form
+	})
+}
+
+func (m *mockContactRepository) SaveContactFormOnce(ctx context.Context, submission *models.ContactSubmission, window time.Duration) (bool, error) {
+	args := m.Called(ctx, submission, window)
+	if args.Bool(0) {
+		submission.ID = 1
+	}
+	return args.Bool(0), args.Error(1)
+}
+
+// Mock notifier
+type mockNotifier struct {
 	mock.Mock
 }
 
-func (m *mockEmailService) SendContactEmail(contact models.ContactForm) error {
-	args := m.Called(contact)
+func (m *mockNotifier) Name() string {
+	return "mock"
+}
+
+func (m *mockNotifier) Notify(ctx context.Context, n services.Notification) error {
+	args := m.Called(ctx, n)
 	return args.Error(0)
 }
 
 func TestContactService_SubmitContactForm_Success(t *testing.T) {
 	// Arrange
 	mockRepo := new(mockContactRepository)
-	mockEmail := new(mockEmailService)
+	mockNotifier := new(mockNotifier)
 
 	form := models.ContactForm{
 		Name:    "John Doe",
 		Email:   "john@example.com",
-		Subject: "Test Subject",
+		Subject: "question",
 		Message: "Test Message",
 	}
 
-	mockRepo.On("SaveContactForm", mock.Anything, form).Return(nil)
-	mockEmail.On("SendContactEmail", form).Return(nil)
+	mockRepo.On("SaveContactForm", mock.Anything, submissionOf(form)).Return(nil)
+	mockNotifier.On("Notify", mock.Anything, mock.Anything).Return(nil).Maybe()
 
-	service := services.NewContactService(mockRepo, mockEmail)
+	service := services.NewContactService(mockRepo, mockNotifier)
 
 	// Act
 	err := service.SubmitContactForm(context.Background(), form)
@@ -55,25 +78,25 @@ func TestContactService_SubmitContactForm_Success(t *testing.T) {
 	// Assert
 	assert.NoError(t, err)
 	mockRepo.AssertExpectations(t)
-	// Note: email is sent asynchronously, so we can't assert it here reliably
+	// Note: notifications are sent asynchronously, so we can't assert it here reliably
 }
 
 func TestContactService_SubmitContactForm_RepositoryError(t *testing.T) {
 	// Arrange
 	mockRepo := new(mockContactRepository)
-	mockEmail := new(mockEmailService)
+	mockNotifier := new(mockNotifier)
 
 	form := models.ContactForm{
 		Name:    "John Doe",
 		Email:   "john@example.com",
-		Subject: "Test Subject",
+		Subject: "question",
 		Message: "Test Message",
 	}
 
 	expectedErr := errors.New("database connection error")
-	mockRepo.On("SaveContactForm", mock.Anything, form).Return(expectedErr)
+	mockRepo.On("SaveContactForm", mock.Anything, submissionOf(form)).Return(expectedErr)
 
-	service := services.NewContactService(mockRepo, mockEmail)
+	service := services.NewContactService(mockRepo, mockNotifier)
 
 	// Act
 	err := service.SubmitContactForm(context.Background(), form)
@@ -83,3 +106,96 @@ func TestContactService_SubmitContactForm_RepositoryError(t *testing.T) {
 	assert.Equal(t, expectedErr, err)
 	mockRepo.AssertExpectations(t)
 }
+
+func TestContactService_SubmitContactForm_DuplicateIgnored(t *testing.T) {
+	// Arrange
+	mockRepo := new(mockContactRepository)
+	mockNotifier := new(mockNotifier)
+
+	form := models.ContactForm{
+		Name:    "John Doe",
+		Email:   "john@example.com",
+		Subject: "question",
+		Message: "Test Message",
+	}
+
+	mockRepo.On("SaveContactFormOnce", mock.Anything, submissionOf(form), 10*time.Minute).Return(false, nil)
+
+	service := services.NewContactService(mockRepo, mockNotifier, services.WithDedupeWindow(10*time.Minute))
+
+	// Act
+	err := service.SubmitContactForm(context.Background(), form)
+
+	// Assert
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "SaveContactForm", mock.Anything, mock.Anything)
+}
+
+func TestContactService_SubmitContactForm_DedupeSkippedWithIdempotencyKey(t *testing.T) {
+	// Arrange
+	mockRepo := new(mockContactRepository)
+	mockNotifier := new(mockNotifier)
+
+	form := models.ContactForm{
+		Name:    "John Doe",
+		Email:   "john@example.com",
+		Subject: "question",
+		Message: "Test Message",
+	}
+
+	mockRepo.On("SaveContactForm", mock.Anything, submissionOf(form)).Return(nil)
+	mockNotifier.On("Notify", mock.Anything, mock.Anything).Return(nil).Maybe()
+
+	service := services.NewContactService(mockRepo, mockNotifier, services.WithDedupeWindow(10*time.Minute))
+	ctx := services.WithIdempotencyKey(context.Background(), "abc")
+
+	// Act
+	err := service.SubmitContactForm(ctx, form)
+
+	// Assert
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "SaveContactFormOnce", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// Mock webhook publisher
+type mockWebhookPublisher struct {
+	events chan string
+}
+
+func (m *mockWebhookPublisher) Publish(ctx context.Context, eventType string, submissionID int64) error {
+	m.events <- eventType
+	return nil
+}
+
+func TestContactService_SubmitContactForm_PublishesWebhook(t *testing.T) {
+	// Arrange
+	mockRepo := new(mockContactRepository)
+	mockNotifier := new(mockNotifier)
+	publisher := &mockWebhookPublisher{events: make(chan string, 1)}
+
+	form := models.ContactForm{
+		Name:    "John Doe",
+		Email:   "john@example.com",
+		Subject: "question",
+		Message: "Test Message",
+	}
+
+	mockRepo.On("SaveContactForm", mock.Anything, submissionOf(form)).Return(nil)
+	mockNotifier.On("Notify", mock.Anything, mock.Anything).Return(nil).Maybe()
+
+	service := services.NewContactService(mockRepo, mockNotifier, services.WithWebhooks(publisher))
+
+	// Act
+	err := service.SubmitContactForm(context.Background(), form)
+
+	// Assert
+	assert.NoError(t, err)
+	select {
+	case eventType := <-publisher.events:
+		assert.Equal(t, models.WebhookEventContactSubmitted, eventType)
+	case <-time.After(time.Second):
+		t.Fatal("webhook event was not published")
+	}
+}
@@ -0,0 +1,457 @@
+package tests_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	handlers "backend/api/handlers"
+	"backend/internal/models"
+	"backend/internal/repository"
+	"backend/internal/services"
+	"backend/internal/site"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pashagolub/pgxmock/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// in-memory implementation of the share link storage
+type memoryShareLinkRepository struct {
+	mu       sync.Mutex
+	links    []models.ShareLink
+	hits     []models.ShareHit
+	hitError error
+}
+
+func (r *memoryShareLinkRepository) CreateShareLink(ctx context.Context, link *models.ShareLink) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.links {
+		if existing.Code == link.Code {
+			return repository.ErrCodeTaken
+		}
+	}
+	link.ID = int64(len(r.links) + 1)
+	link.CreatedAt = time.Now()
+	r.links = append(r.links, *link)
+	return nil
+}
+
+// withHits counts the hits of l like the SQL query, bots excluded
+func (r *memoryShareLinkRepository) withHits(l models.ShareLink) *models.ShareLink {
+	for _, h := range r.hits {
+		if h.LinkID == l.ID && h.Device != models.DeviceBot {
+			at := h.At
+			l.Hits++
+			l.LastHitAt = &at
+		}
+	}
+	return &l
+}
+
+func (r *memoryShareLinkRepository) GetShareLink(ctx context.Context, id int64) (*models.ShareLink, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, l := range r.links {
+		if l.ID == id {
+			return r.withHits(l), nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *memoryShareLinkRepository) GetShareLinkByCode(ctx context.Context, code string) (*models.ShareLink, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, l := range r.links {
+		if l.Code == code {
+			return &l, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *memoryShareLinkRepository) ListShareLinks(ctx context.Context, limit, offset int) ([]models.ShareLink, int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	list := []models.ShareLink{}
+	for i := len(r.links) - 1; i >= 0; i-- {
+		list = append(list, *r.withHits(r.links[i]))
+	}
+	return list, len(list), nil
+}
+
+func (r *memoryShareLinkRepository) RevokeShareLink(ctx context.Context, id int64, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, l := range r.links {
+		if l.ID == id {
+			if l.RevokedAt == nil {
+				r.links[i].RevokedAt = &at
+			}
+			return nil
+		}
+	}
+	return repository.ErrNotFound
+}
+
+func (r *memoryShareLinkRepository) DeleteShareLink(ctx context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, l := range r.links {
+		if l.ID == id {
+			r.links = append(r.links[:i], r.links[i+1:]...)
+			kept := r.hits[:0]
+			for _, h := range r.hits {
+				if h.LinkID != id {
+					kept = append(kept, h)
+				}
+			}
+			r.hits = kept
+			return nil
+		}
+	}
+	return repository.ErrNotFound
+}
+
+func (r *memoryShareLinkRepository) CreateShareHit(ctx context.Context, hit *models.ShareHit) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.hitError != nil {
+		return r.hitError
+	}
+	hit.ID = int64(len(r.hits) + 1)
+	r.hits = append(r.hits, *hit)
+	return nil
+}
+
+func (r *memoryShareLinkRepository) ListShareHits(ctx context.Context, linkID int64, limit, offset int) ([]models.ShareHit, int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	list := []models.ShareHit{}
+	for i := len(r.hits) - 1; i >= 0; i-- {
+		if r.hits[i].LinkID == linkID {
+			list = append(list, r.hits[i])
+		}
+	}
+	return list, len(list), nil
+}
+
+func (r *memoryShareLinkRepository) ShareTargetStats(ctx context.Context) ([]models.ShareTargetStats, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	byTarget := map[string]*models.ShareTargetStats{}
+	stats := []models.ShareTargetStats{}
+	for _, l := range r.links {
+		s, ok := byTarget[l.Target]
+		if !ok {
+			s = &models.ShareTargetStats{Target: l.Target}
+			byTarget[l.Target] = s
+		}
+		counted := r.withHits(l)
+		s.Links++
+		s.Hits += counted.Hits
+		if counted.LastHitAt != nil {
+			s.LastHitAt = counted.LastHitAt
+		}
+	}
+	for _, s := range byTarget {
+		stats = append(stats, *s)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Hits > stats[j].Hits })
+	return stats, nil
+}
+
+func (r *memoryShareLinkRepository) DeleteShareHitsBefore(ctx context.Context, before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	kept := r.hits[:0]
+	for _, h := range r.hits {
+		if !h.At.Before(before) {
+			kept = append(kept, h)
+		}
+	}
+	deleted := int64(len(r.hits) - len(kept))
+	r.hits = kept
+	return deleted, nil
+}
+
+// staticGeoLocator locates every IP in the same country
+type staticGeoLocator string
+
+func (g staticGeoLocator) Lookup(ip string) models.GeoLocation {
+	return models.GeoLocation{Country: string(g)}
+}
+
+const (
+	firefoxDesktopUA = "Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:128.0) Gecko/20100101 Firefox/128.0"
+	safariIPhoneUA   = "Mozilla/5.0 (iPhone; CPU iPhone OS 17_5 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.5 Mobile/15E148 Safari/604.1"
+	linkedInBotUA    = "LinkedInBot/1.0 (compatible; Mozilla/5.0; Apache-HttpClient +http://www.linkedin.com)"
+)
+
+func newTestShareService(now *time.Time) (services.IShareService, *memoryShareLinkRepository) {
+	repo := &memoryShareLinkRepository{}
+	return services.NewShareService(repo, services.ShareOptions{
+		BaseURL:      "https://example.com",
+		Geo:          staticGeoLocator("fr"),
+		HitRetention: 30 * 24 * time.Hour,
+		Now:          func() time.Time { return *now },
+	}), repo
+}
+
+func TestShareService_Create(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	svc, _ := newTestShareService(&now)
+
+	link, err := svc.Create(ctx, models.ShareLinkInput{Target: "/cv.pdf", Label: " Recruiter at Acme "})
+	assert.NoError(t, err)
+	assert.Regexp(t, `^[A-Za-z0-9]{7}$`, link.Code)
+	assert.Equal(t, "Recruiter at Acme", link.Label)
+	assert.Equal(t, "https://example.com/s/"+link.Code, link.URL)
+
+	expires := now.Add(14 * 24 * time.Hour)
+	custom, err := svc.Create(ctx, models.ShareLinkInput{Target: "/projects/proxmox", Label: "Meetup", Code: "meetup-2026", ExpiresAt: &expires})
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.com/s/meetup-2026", custom.URL)
+	assert.Equal(t, expires, *custom.ExpiresAt)
+
+	_, err = svc.Create(ctx, models.ShareLinkInput{Target: "/about", Label: "Other", Code: "meetup-2026"})
+	assert.ErrorIs(t, err, repository.ErrCodeTaken)
+
+	past := now.Add(-time.Minute)
+	for name, input := range map[string]models.ShareLinkInput{
+		"external target":   {Target: "https://evil.example/", Label: "x"},
+		"protocol relative": {Target: "//evil.example/", Label: "x"},
+		"api target":        {Target: "/api/v1/admin/inbox", Label: "x"},
+		"missing label":     {Target: "/cv.pdf"},
+		"long label":        {Target: "/cv.pdf", Label: strings.Repeat("a", 201)},
+		"invalid code":      {Target: "/cv.pdf", Label: "x", Code: "a/b"},
+		"short code":        {Target: "/cv.pdf", Label: "x", Code: "ab"},
+		"past expiry":       {Target: "/cv.pdf", Label: "x", ExpiresAt: &past},
+	} {
+		_, err := svc.Create(ctx, input)
+		assert.ErrorIs(t, err, services.ErrInvalidShareLink, name)
+	}
+}
+
+func TestShareService_Resolve(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	svc, repo := newTestShareService(&now)
+	expires := now.Add(time.Hour)
+	link, err := svc.Create(ctx, models.ShareLinkInput{Target: "/cv.pdf", Label: "Acme", ExpiresAt: &expires})
+	assert.NoError(t, err)
+
+	target, err := svc.Resolve(ctx, link.Code, models.ClientMetadata{IP: "203.0.113.7", UserAgent: firefoxDesktopUA, Referrer: "https://mail.example.com/inbox?id=42#top"})
+	assert.NoError(t, err)
+	assert.Equal(t, "/cv.pdf", target)
+	_, err = svc.Resolve(ctx, link.Code, models.ClientMetadata{IP: "203.0.113.8", UserAgent: safariIPhoneUA})
+	assert.NoError(t, err)
+	_, err = svc.Resolve(ctx, link.Code, models.ClientMetadata{IP: "203.0.113.9", UserAgent: linkedInBotUA})
+	assert.NoError(t, err)
+
+	hits, total, err := svc.Hits(ctx, link.ID, 10, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, total)
+	assert.Equal(t, models.DeviceBot, hits[0].Device, "link previews are recorded as bots")
+	assert.Equal(t, models.ShareHit{ID: 2, LinkID: link.ID, At: now, Browser: "Safari", OS: "iOS", Device: models.DeviceMobile, Country: "FR"}, hits[1])
+	assert.Equal(t, models.ShareHit{ID: 1, LinkID: link.ID, At: now, Referrer: "https://mail.example.com/inbox", Browser: "Firefox", OS: "Windows", Device: models.DeviceDesktop, Country: "FR"}, hits[2])
+
+	got, err := svc.Get(ctx, link.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, got.Hits, "bots are not counted")
+
+	repo.hitError = errors.New("disk full")
+	target, err = svc.Resolve(ctx, link.Code, models.ClientMetadata{UserAgent: firefoxDesktopUA})
+	assert.NoError(t, err, "the visit is redirected even when it cannot be recorded")
+	assert.Equal(t, "/cv.pdf", target)
+
+	_, err = svc.Resolve(ctx, "unknown", models.ClientMetadata{})
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+	_, err = svc.Resolve(ctx, "../etc", models.ClientMetadata{})
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	now = expires
+	_, err = svc.Resolve(ctx, link.Code, models.ClientMetadata{})
+	assert.ErrorIs(t, err, services.ErrShareLinkGone, "expired")
+
+	other, err := svc.Create(ctx, models.ShareLinkInput{Target: "/about", Label: "Beta"})
+	assert.NoError(t, err)
+	revoked, err := svc.Revoke(ctx, other.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, now, *revoked.RevokedAt)
+	_, err = svc.Resolve(ctx, other.Code, models.ClientMetadata{})
+	assert.ErrorIs(t, err, services.ErrShareLinkGone, "revoked")
+}
+
+func TestShareService_StatsAndPurge(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	svc, _ := newTestShareService(&now)
+	cv1, _ := svc.Create(ctx, models.ShareLinkInput{Target: "/cv.pdf", Label: "Acme"})
+	cv2, _ := svc.Create(ctx, models.ShareLinkInput{Target: "/cv.pdf", Label: "Globex"})
+	project, _ := svc.Create(ctx, models.ShareLinkInput{Target: "/projects/proxmox", Label: "Meetup"})
+
+	for _, code := range []string{cv1.Code, cv2.Code, cv2.Code, project.Code} {
+		_, err := svc.Resolve(ctx, code, models.ClientMetadata{UserAgent: firefoxDesktopUA})
+		assert.NoError(t, err)
+	}
+
+	stats, err := svc.Stats(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, stats, 2)
+	assert.Equal(t, "/cv.pdf", stats[0].Target)
+	assert.Equal(t, 2, stats[0].Links)
+	assert.Equal(t, 3, stats[0].Hits)
+
+	now = now.Add(31 * 24 * time.Hour)
+	_, err = svc.Resolve(ctx, cv1.Code, models.ClientMetadata{UserAgent: firefoxDesktopUA})
+	assert.NoError(t, err)
+	assert.NoError(t, svc.PurgeHits(ctx))
+	stats, err = svc.Stats(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, models.ShareTargetStats{Target: "/cv.pdf", Links: 2, Hits: 1, LastHitAt: &now}, stats[0], "older visits are purged")
+}
+
+func TestShareService_QRCode(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	svc, _ := newTestShareService(&now)
+	link, err := svc.Create(ctx, models.ShareLinkInput{Target: "/cv.pdf", Label: "Acme"})
+	assert.NoError(t, err)
+
+	data, err := svc.QRCode(ctx, link.ID, 0)
+	assert.NoError(t, err)
+	img, err := png.Decode(bytes.NewReader(data))
+	assert.NoError(t, err)
+	assert.Equal(t, services.DefaultQRCodeSize, img.Bounds().Dx())
+
+	_, err = svc.QRCode(ctx, link.ID, 64)
+	assert.ErrorIs(t, err, services.ErrInvalidShareLink)
+	_, err = svc.QRCode(ctx, 99, 256)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+}
+
+func TestShareHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	now := time.Now()
+	svc, _ := newTestShareService(&now)
+	renderer, err := site.NewRenderer(site.Options{Site: site.Info{Name: "Enzo Gaggiotti", URL: "https://example.com"}})
+	assert.NoError(t, err)
+	h := handlers.NewShareHandler(svc, renderer)
+	router := gin.New()
+	router.GET("/s/:code", h.HandleRedirect)
+	router.GET("/admin/share-links", h.HandleList)
+	router.POST("/admin/share-links", h.HandleCreate)
+	router.GET("/admin/share-links/stats", h.HandleStats)
+	router.GET("/admin/share-links/:id", h.HandleGet)
+	router.DELETE("/admin/share-links/:id", h.HandleDelete)
+	router.POST("/admin/share-links/:id/revoke", h.HandleRevoke)
+	router.GET("/admin/share-links/:id/hits", h.HandleHits)
+	router.GET("/admin/share-links/:id/qr.png", h.HandleQRCode)
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", firefoxDesktopUA)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodPost, "/admin/share-links", `{"target":"/cv.pdf","label":"Acme","code":"acme"}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Contains(t, w.Body.String(), `"url":"https://example.com/s/acme"`)
+	assert.Equal(t, http.StatusConflict, do(http.MethodPost, "/admin/share-links", `{"target":"/about","label":"Other","code":"acme"}`).Code)
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodPost, "/admin/share-links", `{"target":"https://evil.example","label":"x"}`).Code)
+
+	w = do(http.MethodGet, "/s/acme", "")
+	assert.Equal(t, http.StatusFound, w.Code)
+	assert.Equal(t, "/cv.pdf", w.Header().Get("Location"))
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+	assert.Empty(t, w.Result().Cookies())
+
+	w = do(http.MethodGet, "/admin/share-links/1", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"hits":1`)
+	w = do(http.MethodGet, "/admin/share-links/1/hits", "")
+	assert.Contains(t, w.Body.String(), `"browser":"Firefox"`)
+	assert.Contains(t, do(http.MethodGet, "/admin/share-links", "").Body.String(), `"total":1`)
+	assert.Contains(t, do(http.MethodGet, "/admin/share-links/stats", "").Body.String(), `"target":"/cv.pdf","links":1,"hits":1`)
+
+	w = do(http.MethodGet, "/admin/share-links/1/qr.png?size=256", "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "image/png", w.Header().Get("Content-Type"))
+	assert.True(t, bytes.HasPrefix(w.Body.Bytes(), []byte("\x89PNG")))
+	assert.Equal(t, http.StatusBadRequest, do(http.MethodGet, "/admin/share-links/1/qr.png?size=big", "").Code)
+
+	w = do(http.MethodGet, "/s/missing", "")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), "Ce lien n&#39;existe pas.")
+
+	assert.Equal(t, http.StatusOK, do(http.MethodPost, "/admin/share-links/1/revoke", "").Code)
+	w = do(http.MethodGet, "/s/acme", "")
+	assert.Equal(t, http.StatusGone, w.Code)
+	assert.Contains(t, w.Body.String(), "410")
+
+	assert.Equal(t, http.StatusNoContent, do(http.MethodDelete, "/admin/share-links/1", "").Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodGet, "/admin/share-links/1", "").Code)
+	assert.Equal(t, http.StatusNotFound, do(http.MethodGet, "/admin/share-links/1/hits", "").Code)
+}
+
+func TestShareLinkRepository_CreateShareLink(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	created := time.Now()
+	mock.ExpectQuery(`INSERT INTO share_links`).
+		WithArgs("acme", "/cv.pdf", "Acme", (*time.Time)(nil)).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "created_at"}).AddRow(int64(4), created))
+	mock.ExpectQuery(`INSERT INTO share_links`).
+		WithArgs("acme", "/about", "Other", (*time.Time)(nil)).
+		WillReturnError(&pgconn.PgError{Code: "23505"})
+
+	repo := repository.NewShareLinkRepository(mock)
+	link := &models.ShareLink{Code: "acme", Target: "/cv.pdf", Label: "Acme"}
+	assert.NoError(t, repo.CreateShareLink(context.Background(), link))
+	assert.Equal(t, int64(4), link.ID)
+	assert.Equal(t, created, link.CreatedAt)
+
+	err = repo.CreateShareLink(context.Background(), &models.ShareLink{Code: "acme", Target: "/about", Label: "Other"})
+	assert.ErrorIs(t, err, repository.ErrCodeTaken)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestShareLinkRepository_ListShareLinks(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	created := time.Now()
+	columns := []string{"id", "code", "target", "label", "expires_at", "revoked_at", "created_at", "hits", "last_hit_at", "total"}
+	mock.ExpectQuery(`SELECT l.id, .* FROM share_links l LEFT JOIN share_hits h .* GROUP BY l.id`).
+		WithArgs(10, 0).
+		WillReturnRows(pgxmock.NewRows(columns).
+			AddRow(int64(2), "globex", "/about", "Globex", nil, nil, created, 0, nil, 2).
+			AddRow(int64(1), "acme", "/cv.pdf", "Acme", nil, &created, created, 3, &created, 2))
+
+	links, total, err := repository.NewShareLinkRepository(mock).ListShareLinks(context.Background(), 10, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, total)
+	assert.Len(t, links, 2)
+	assert.Nil(t, links[0].LastHitAt)
+	assert.Equal(t, 3, links[1].Hits)
+	assert.False(t, links[1].Active(created), "revoked")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
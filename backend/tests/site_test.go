@@ -0,0 +1,135 @@
+package tests_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	handlers "backend/api/handlers"
+	"backend/internal/models"
+	"backend/internal/services"
+	"backend/internal/site"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func newPageRouter(t *testing.T, contact services.IContactService) (*gin.Engine, services.IProjectService) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	renderer, err := site.NewRenderer(site.Options{Site: site.Info{
+		Name:        "Enzo Gaggiotti",
+		Description: "Portfolio",
+		URL:         "https://example.com",
+		GitHubURL:   "https://github.com/enzogagg",
+	}})
+	assert.NoError(t, err)
+
+	projects := services.NewProjectService(newMemoryProjectRepository())
+	articles := services.NewArticleService(memoryArticleStore{})
+	h := handlers.NewPageHandler(renderer, projects, articles, newTestProfileService(t), contact, nil, services.NewPreviewSigner("", 0))
+	router := gin.New()
+	router.GET("/", h.HandleIndex)
+	router.GET("/projects", h.HandleProjects)
+	router.GET("/projects/:slug", h.HandleProject)
+	router.GET("/about", h.HandleAbout)
+	router.GET("/contact", h.HandleContact)
+	router.POST("/contact", h.HandleContactSubmit)
+	return router, projects
+}
+
+func TestPageHandler_Pages(t *testing.T) {
+	router, projects := newPageRouter(t, &mockContactService{})
+	ctx := context.Background()
+	created, err := projects.Create(ctx, models.ProjectInput{
+		Title:        "Cluster <Proxmox>",
+		Summary:      "Trois nœuds & du Ceph",
+		Body:         "Premier paragraphe.\n\nSecond <b>paragraphe</b>.",
+		Category:     models.ProjectInfrastructure,
+		Technologies: []string{"Proxmox"},
+	})
+	assert.NoError(t, err)
+	_, err = projects.Publish(ctx, created.ID, models.ProjectPublication{})
+	assert.NoError(t, err)
+	_, err = projects.Create(ctx, models.ProjectInput{Title: "Draft", Summary: "Not yet", Category: models.ProjectWeb})
+	assert.NoError(t, err)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	w := get("/")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.NotEmpty(t, w.Header().Get("ETag"))
+	body := w.Body.String()
+	assert.Contains(t, body, `<a href="/projects/cluster-proxmox">Cluster &lt;Proxmox&gt;</a>`, "titles are escaped")
+	assert.Contains(t, body, `<link rel="canonical" href="https://example.com/" />`)
+	assert.Contains(t, body, `href="https://github.com/enzogagg"`)
+	assert.NotContains(t, body, "Draft")
+	assert.NotContains(t, body, "component-loader", "header and footer are rendered, not fetched")
+
+	w = get("/projects/cluster-proxmox")
+	assert.Equal(t, http.StatusOK, w.Code)
+	body = w.Body.String()
+	assert.Contains(t, body, "<title>Cluster &lt;Proxmox&gt; - Enzo Gaggiotti</title>")
+	assert.Contains(t, body, "<p>Premier paragraphe.</p>")
+	assert.Contains(t, body, "<p>Second &lt;b&gt;paragraphe&lt;/b&gt;.</p>")
+	assert.Contains(t, body, `aria-current="page">Projets</a>`)
+
+	w = get("/projects?category=infrastructure")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "Cluster &lt;Proxmox&gt;")
+	assert.Equal(t, http.StatusNotFound, get("/projects?category=games").Code)
+	assert.Equal(t, http.StatusNotFound, get("/projects/draft").Code)
+	assert.Contains(t, get("/projects/draft").Body.String(), "404")
+
+	w = get("/about")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "Mon parcours")
+
+	req := httptest.NewRequest(http.MethodGet, "/about", nil)
+	req.Header.Set("If-None-Match", w.Header().Get("ETag"))
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+}
+
+func TestPageHandler_ContactForm(t *testing.T) {
+	svc := &mockContactService{}
+	router, _ := newPageRouter(t, svc)
+	post := func(form url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/contact", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := post(url.Values{"name": {"<John>"}, "email": {"not-an-email"}, "subject": {"question"}, "message": {"Hi"}})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.False(t, svc.called)
+	assert.Contains(t, w.Body.String(), `value="&lt;John&gt;"`, "the form is shown again, escaped")
+	assert.Contains(t, w.Body.String(), `<option value="question" selected>`)
+	assert.Contains(t, w.Body.String(), `role="alert"`)
+
+	w = post(url.Values{"name": {"John"}, "email": {"john@example.com"}, "subject": {"question"}, "message": {"Hi"}})
+	assert.Equal(t, http.StatusSeeOther, w.Code)
+	assert.Equal(t, "/contact?sent=1", w.Header().Get("Location"))
+	assert.True(t, svc.called)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/contact?sent=1", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `role="status"`)
+}
+
+func TestRenderer_TemplatesDir(t *testing.T) {
+	_, err := site.NewRenderer(site.Options{Dir: t.TempDir()})
+	assert.Error(t, err, "a templates directory without the pages is rejected")
+}
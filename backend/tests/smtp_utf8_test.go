@@ -0,0 +1,220 @@
+package tests_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"backend/internal/models"
+	"backend/internal/services"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeSMTPServer is a TLS SMTP relay recording the last transaction. It
+// rejects non-ASCII envelope addresses unless it advertises SMTPUTF8.
+type fakeSMTPServer struct {
+	host, port string
+	smtpUTF8   bool
+
+	mu    sync.Mutex
+	mail  string
+	rcpts []string
+	data  string
+}
+
+// newTestTLSConfigs returns a server config with a self-signed certificate
+// for 127.0.0.1 and a client config trusting it
+func newTestTLSConfigs(t *testing.T) (server, client *tls.Config) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	server = &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}
+	client = &tls.Config{ServerName: "127.0.0.1", RootCAs: pool}
+	return server, client
+}
+
+func newFakeSMTPServer(t *testing.T, smtpUTF8 bool) (*fakeSMTPServer, *tls.Config) {
+	t.Helper()
+	serverTLS, clientTLS := newTestTLSConfigs(t)
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", serverTLS)
+	assert.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+	host, port, _ := net.SplitHostPort(ln.Addr().String())
+
+	s := &fakeSMTPServer{host: host, port: port, smtpUTF8: smtpUTF8}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(textproto.NewConn(conn))
+		}
+	}()
+	return s, clientTLS
+}
+
+func (s *fakeSMTPServer) serve(c *textproto.Conn) {
+	defer c.Close()
+	_ = c.PrintfLine("220 test ESMTP")
+	ascii := func(line string) bool {
+		for i := 0; i < len(line); i++ {
+			if line[i] >= 0x80 {
+				return false
+			}
+		}
+		return true
+	}
+	for {
+		line, err := c.ReadLine()
+		if err != nil {
+			return
+		}
+		verb := strings.ToUpper(strings.Fields(line + " x")[0])
+		switch verb {
+		case "EHLO":
+			_ = c.PrintfLine("250-test")
+			if s.smtpUTF8 {
+				_ = c.PrintfLine("250-SMTPUTF8")
+			}
+			_ = c.PrintfLine("250 8BITMIME")
+		case "MAIL", "RCPT":
+			if !s.smtpUTF8 && !ascii(line) {
+				_ = c.PrintfLine("553 5.6.7 non-ASCII address")
+				continue
+			}
+			s.mu.Lock()
+			if verb == "MAIL" {
+				s.mail, s.rcpts = line, nil
+			} else {
+				s.rcpts = append(s.rcpts, line)
+			}
+			s.mu.Unlock()
+			_ = c.PrintfLine("250 OK")
+		case "DATA":
+			_ = c.PrintfLine("354 go ahead")
+			data, err := c.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.data = string(data)
+			s.mu.Unlock()
+			_ = c.PrintfLine("250 queued")
+		case "QUIT":
+			_ = c.PrintfLine("221 bye")
+			return
+		default:
+			_ = c.PrintfLine("250 OK")
+		}
+	}
+}
+
+func (s *fakeSMTPServer) last() (mail string, rcpts []string, data string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.mail, s.rcpts, s.data
+}
+
+func TestEmailAddresses_Internationalized(t *testing.T) {
+	for _, valid := range []string{"josé@exemple.fr", "jane@bücher.example", "jane@xn--bcher-kva.example", "用户@例子.中国", "o'brien+tag@example.com"} {
+		assert.True(t, models.IsValidEmail(valid), valid)
+	}
+	for _, invalid := range []string{"nope", "jane@", "@example.com", "Jane <jane@example.com>", "jane@localhost",
+		strings.Repeat("a", 65) + "@example.com", "jane@exa_mple..com"} {
+		assert.False(t, models.IsValidEmail(invalid), invalid)
+	}
+
+	canonical, err := models.CanonicalEmail("José@XN--EXMPLE-CVA.fr")
+	assert.NoError(t, err)
+	assert.Equal(t, "José@exémple.fr", canonical)
+	ascii, err := models.ASCIIEmail("jane@Bücher.example")
+	assert.NoError(t, err)
+	assert.Equal(t, "jane@xn--bcher-kva.example", ascii)
+	_, err = models.ASCIIEmail("josé@exemple.fr")
+	assert.ErrorIs(t, err, models.ErrNonASCIILocalPart)
+
+	// forms are stored in canonical form
+	form := models.ContactForm{Name: "José", Email: " josé@XN--EXMPLE-CVA.FR ", Subject: "question", Message: "Bonjour"}
+	form.Normalize()
+	assert.NoError(t, form.Validate())
+	assert.Equal(t, "josé@exémple.fr", form.Email)
+}
+
+func TestSMTPService_SMTPUTF8(t *testing.T) {
+	relay, clientTLS := newFakeSMTPServer(t, true)
+	svc := services.NewSMTPService(relay.host, relay.port, "contact@example.com", "", "admin@exémple.fr", services.WithSMTPTLSConfig(clientTLS))
+
+	err := svc.SendEmail(context.Background(), models.OutgoingEmail{
+		To:      []string{"josé@exémple.fr"},
+		ReplyTo: "inbox+c1@example.com",
+		Subject: "Re: question",
+		Text:    "Bonjour José",
+	})
+	assert.NoError(t, err)
+	mail, rcpts, data := relay.last()
+	assert.Equal(t, "MAIL FROM:<contact@example.com> BODY=8BITMIME SMTPUTF8", mail)
+	assert.Equal(t, []string{"RCPT TO:<josé@exémple.fr>"}, rcpts)
+	assert.Contains(t, data, "To: <josé@exémple.fr>")
+	assert.Contains(t, data, "Reply-To: <inbox+c1@example.com>")
+
+	// notifications carry the visitor address unmangled in Reply-To
+	form := models.ContactForm{Name: "José", Email: "josé@exémple.fr", Subject: "question", Message: "Bonjour"}
+	assert.NoError(t, svc.SendContactEmail(form))
+	_, rcpts, data = relay.last()
+	assert.Equal(t, []string{"RCPT TO:<admin@exémple.fr>"}, rcpts)
+	assert.Contains(t, data, "Reply-To: =?utf-8?q?Jos=C3=A9?= <josé@exémple.fr>")
+}
+
+func TestSMTPService_FallbackWithoutSMTPUTF8(t *testing.T) {
+	relay, clientTLS := newFakeSMTPServer(t, false)
+	svc := services.NewSMTPService(relay.host, relay.port, "contact@example.com", "", "admin@exémple.fr", services.WithSMTPTLSConfig(clientTLS))
+
+	// IDN domains are converted to punycode
+	form := models.ContactForm{Name: "Jane", Email: "jane@bücher.example", Subject: "question", Message: "Hallo"}
+	assert.NoError(t, svc.SendContactEmail(form))
+	mail, rcpts, data := relay.last()
+	assert.Equal(t, "MAIL FROM:<contact@example.com> BODY=8BITMIME", mail)
+	assert.Equal(t, []string{"RCPT TO:<admin@xn--exmple-cva.fr>"}, rcpts)
+	assert.Contains(t, data, "Reply-To: \"Jane\" <jane@xn--bcher-kva.example>")
+
+	// an internationalized local part cannot be converted: the notification
+	// goes out without Reply-To, a reply fails with a clear error
+	form = models.ContactForm{Name: "José", Email: "josé@exemple.fr", Subject: "question", Message: "Bonjour"}
+	assert.NoError(t, svc.SendContactEmail(form))
+	_, _, data = relay.last()
+	assert.NotContains(t, data, "Reply-To")
+	assert.Contains(t, data, "jos=C3=A9@exemple.fr", "the address is still in the body")
+
+	err := svc.SendEmail(context.Background(), models.OutgoingEmail{To: []string{"josé@exemple.fr"}, Subject: "Re: question", Text: "Bonjour"})
+	assert.ErrorIs(t, err, services.ErrSMTPUTF8Unsupported)
+}
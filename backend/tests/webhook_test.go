@@ -0,0 +1,439 @@
+package tests_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	handlers "backend/api/handlers"
+	"backend/internal/middleware"
+	"backend/internal/models"
+	"backend/internal/repository"
+	"backend/internal/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// in-memory implementation of the webhook delivery log
+type memoryWebhookRepository struct {
+	mu         sync.Mutex
+	deliveries map[int64]*models.WebhookDelivery
+}
+
+func newMemoryWebhookRepository() *memoryWebhookRepository {
+	return &memoryWebhookRepository{deliveries: map[int64]*models.WebhookDelivery{}}
+}
+
+func (r *memoryWebhookRepository) CreateDelivery(ctx context.Context, d *models.WebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	d.ID = int64(len(r.deliveries) + 1)
+	d.Status = models.WebhookDeliveryPending
+	now := time.Now()
+	d.NextAttemptAt = &now
+	d.CreatedAt = now
+	copied := *d
+	r.deliveries[d.ID] = &copied
+	return nil
+}
+
+func (r *memoryWebhookRepository) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	claimed := []models.WebhookDelivery{}
+	for _, d := range r.deliveries {
+		if len(claimed) < limit && d.Status == models.WebhookDeliveryPending && !d.NextAttemptAt.After(now) {
+			until := now.Add(lease)
+			d.NextAttemptAt = &until
+			claimed = append(claimed, *d)
+		}
+	}
+	return claimed, nil
+}
+
+func (r *memoryWebhookRepository) ClaimDelivery(ctx context.Context, id int64, lease time.Duration) (*models.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	d, ok := r.deliveries[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	now := time.Now()
+	if d.Status == models.WebhookDeliveryPending && d.NextAttemptAt.After(now) {
+		return nil, repository.ErrDeliveryLeased
+	}
+	until := now.Add(lease)
+	d.NextAttemptAt = &until
+	copied := *d
+	return &copied, nil
+}
+
+func (r *memoryWebhookRepository) RecordAttempt(ctx context.Context, id int64, status string, statusCode *int, attemptErr *string, nextAttemptAt *time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	d := r.deliveries[id]
+	d.Status = status
+	d.Attempts++
+	d.LastStatusCode = statusCode
+	d.LastError = attemptErr
+	d.NextAttemptAt = nextAttemptAt
+	return nil
+}
+
+func (r *memoryWebhookRepository) GetDelivery(ctx context.Context, id int64) (*models.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	d, ok := r.deliveries[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	copied := *d
+	return &copied, nil
+}
+
+func (r *memoryWebhookRepository) ListDeliveries(ctx context.Context, endpoint string, limit, offset int) ([]models.WebhookDelivery, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	list := []models.WebhookDelivery{}
+	for _, d := range r.deliveries {
+		if endpoint == "" || d.Endpoint == endpoint {
+			list = append(list, *d)
+		}
+	}
+	return list, nil
+}
+
+func (r *memoryWebhookRepository) waitForStatus(t *testing.T, id int64, status string) *models.WebhookDelivery {
+	t.Helper()
+	var d *models.WebhookDelivery
+	assert.Eventually(t, func() bool {
+		d, _ = r.GetDelivery(context.Background(), id)
+		return d != nil && d.Status == status
+	}, 2*time.Second, 5*time.Millisecond)
+	return d
+}
+
+func newTestWebhookService(t *testing.T, repo repository.IWebhookRepository, submissions repository.IInboxRepository, opts services.WebhookOptions) services.IWebhookService {
+	t.Helper()
+	svc, err := services.NewWebhookService(repo, submissions, opts)
+	assert.NoError(t, err)
+	return svc
+}
+
+func TestNewWebhookService_RequiresSecret(t *testing.T) {
+	_, err := services.NewWebhookService(newMemoryWebhookRepository(), newMemoryInboxRepository(),
+		services.WebhookOptions{Endpoints: []string{"https://hooks.example.com"}})
+	assert.Error(t, err, "deliveries signed with an empty key could be forged")
+
+	_, err = services.NewWebhookService(newMemoryWebhookRepository(), newMemoryInboxRepository(), services.WebhookOptions{})
+	assert.NoError(t, err, "no secret is needed without endpoints")
+}
+
+func TestWebhookService_PublishSignsPayload(t *testing.T) {
+	type received struct {
+		header http.Header
+		body   []byte
+	}
+	got := make(chan received, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got <- received{header: r.Header.Clone(), body: body}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	repo := newMemoryWebhookRepository()
+	svc := newTestWebhookService(t, repo, newMemoryInboxRepository(inboxSubmission(4, models.ContactStatusNew)), services.WebhookOptions{
+		Endpoints:   []string{server.URL},
+		Secret:      "s3cret",
+		MaxAttempts: 3,
+	})
+
+	err := svc.Publish(context.Background(), models.WebhookEventContactSubmitted, 4)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), repo.deliveries[1].SubmissionID)
+
+	select {
+	case r := <-got:
+		ts, err := strconv.ParseInt(r.header.Get(services.WebhookTimestampHeader), 10, 64)
+		assert.NoError(t, err)
+		assert.Equal(t, services.SignWebhookPayload("s3cret", ts, r.body), r.header.Get(services.WebhookSignatureHeader))
+		assert.Equal(t, models.WebhookEventContactSubmitted, r.header.Get(services.WebhookEventHeader))
+		assert.Equal(t, "1", r.header.Get(services.WebhookDeliveryHeader))
+
+		var event struct {
+			models.WebhookEvent
+			Data struct {
+				Submission models.ContactSubmission `json:"submission"`
+			} `json:"data"`
+		}
+		assert.NoError(t, json.Unmarshal(r.body, &event))
+		assert.Equal(t, models.WebhookEventContactSubmitted, event.Type)
+		assert.NotEmpty(t, event.ID)
+		// built at send time from the stored submission
+		assert.Equal(t, int64(4), event.Data.Submission.ID)
+		assert.Equal(t, "jane@example.com", event.Data.Submission.Email)
+	case <-time.After(2 * time.Second):
+		t.Fatal("webhook was not delivered")
+	}
+
+	d := repo.waitForStatus(t, 1, models.WebhookDeliverySucceeded)
+	assert.Equal(t, 1, d.Attempts)
+}
+
+func TestWebhookService_RetriesThenFails(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	repo := newMemoryWebhookRepository()
+	svc := newTestWebhookService(t, repo, newMemoryInboxRepository(inboxSubmission(1, models.ContactStatusNew)), services.WebhookOptions{
+		Endpoints:   []string{server.URL},
+		Secret:      "s3cret",
+		MaxAttempts: 3,
+		BaseBackoff: time.Millisecond,
+	})
+
+	assert.NoError(t, svc.Publish(context.Background(), models.WebhookEventContactSubmitted, 1))
+	assert.Eventually(t, func() bool { return hits.Load() == 1 }, 2*time.Second, 5*time.Millisecond)
+
+	// Retries are sent by the periodic job once due
+	var d *models.WebhookDelivery
+	assert.Eventually(t, func() bool {
+		assert.NoError(t, svc.RunDue(context.Background()))
+		d, _ = repo.GetDelivery(context.Background(), 1)
+		return d.Status == models.WebhookDeliveryFailed
+	}, 2*time.Second, 5*time.Millisecond)
+	assert.Equal(t, 3, d.Attempts)
+	assert.Nil(t, d.NextAttemptAt)
+	assert.Equal(t, int32(3), hits.Load())
+	assert.Equal(t, http.StatusServiceUnavailable, *d.LastStatusCode)
+}
+
+func TestWebhookService_RunDueResumesPendingDeliveries(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	// Deliveries left pending by a previous process
+	repo := newMemoryWebhookRepository()
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, repo.CreateDelivery(context.Background(), &models.WebhookDelivery{Endpoint: server.URL, EventType: models.WebhookEventContactSubmitted, SubmissionID: 1}))
+	}
+	later := time.Now().Add(time.Hour)
+	repo.deliveries[3].NextAttemptAt = &later
+
+	svc := newTestWebhookService(t, repo, newMemoryInboxRepository(inboxSubmission(1, models.ContactStatusNew)), services.WebhookOptions{Endpoints: []string{server.URL}, Secret: "s3cret", MaxAttempts: 3})
+	assert.NoError(t, svc.RunDue(context.Background()))
+
+	assert.Equal(t, int32(2), hits.Load(), "only due deliveries are sent")
+	d, _ := repo.GetDelivery(context.Background(), 1)
+	assert.Equal(t, models.WebhookDeliverySucceeded, d.Status)
+	d, _ = repo.GetDelivery(context.Background(), 3)
+	assert.Equal(t, models.WebhookDeliveryPending, d.Status)
+}
+
+func TestWebhookService_FailsWhenSubmissionIsGone(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	// the submission was erased after the event was published
+	repo := newMemoryWebhookRepository()
+	assert.NoError(t, repo.CreateDelivery(context.Background(), &models.WebhookDelivery{Endpoint: server.URL, EventType: models.WebhookEventContactSubmitted, SubmissionID: 8}))
+
+	svc := newTestWebhookService(t, repo, newMemoryInboxRepository(), services.WebhookOptions{Endpoints: []string{server.URL}, Secret: "s3cret", MaxAttempts: 3})
+	assert.NoError(t, svc.RunDue(context.Background()))
+
+	d, _ := repo.GetDelivery(context.Background(), 1)
+	assert.Equal(t, models.WebhookDeliveryFailed, d.Status, "not retried")
+	assert.Contains(t, *d.LastError, "submission 8 no longer exists")
+	assert.Zero(t, hits.Load())
+}
+
+func TestWebhookService_Redeliver(t *testing.T) {
+	var fail atomic.Bool
+	fail.Store(true)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	repo := newMemoryWebhookRepository()
+	svc := newTestWebhookService(t, repo, newMemoryInboxRepository(inboxSubmission(1, models.ContactStatusNew)), services.WebhookOptions{Endpoints: []string{server.URL}, Secret: "s3cret", MaxAttempts: 1})
+
+	assert.NoError(t, svc.Publish(context.Background(), models.WebhookEventContactSubmitted, 1))
+	repo.waitForStatus(t, 1, models.WebhookDeliveryFailed)
+
+	fail.Store(false)
+	d, err := svc.Redeliver(context.Background(), 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.WebhookDeliverySucceeded, d.Status)
+	assert.Equal(t, 2, d.Attempts)
+
+	_, err = svc.Redeliver(context.Background(), 99)
+	assert.ErrorIs(t, err, repository.ErrNotFound)
+
+	// a pending delivery claimed by a worker is not sent a second time
+	leased := time.Now().Add(time.Minute)
+	repo.deliveries[1].Status, repo.deliveries[1].NextAttemptAt = models.WebhookDeliveryPending, &leased
+	_, err = svc.Redeliver(context.Background(), 1)
+	assert.ErrorIs(t, err, repository.ErrDeliveryLeased)
+	d, err = repo.GetDelivery(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, d.Attempts)
+}
+
+func TestWebhookHandler_AdminRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	repo := newMemoryWebhookRepository()
+	svc := newTestWebhookService(t, repo, newMemoryInboxRepository(), services.WebhookOptions{})
+	h := handlers.NewWebhookHandler(svc)
+
+	router := gin.New()
+	admin := router.Group("/admin", middleware.AdminAuth("token"))
+	admin.GET("/webhooks/deliveries", h.HandleListDeliveries)
+	admin.POST("/webhooks/deliveries/:id/redeliver", h.HandleRedeliver)
+
+	t.Run("missing token", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/admin/webhooks/deliveries", nil))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("wrong token", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/admin/webhooks/deliveries", nil)
+		req.Header.Set("Authorization", "Bearer nope")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("list", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/admin/webhooks/deliveries", nil)
+		req.Header.Set("Authorization", "Bearer token")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"deliveries":[],"limit":50,"offset":0}`, w.Body.String())
+	})
+
+	t.Run("redeliver unknown", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/admin/webhooks/deliveries/7/redeliver", nil)
+		req.Header.Set("Authorization", "Bearer token")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestAdminAuth_DisabledWithoutToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/admin", middleware.AdminAuth(""), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	req := httptest.NewRequest("GET", "/admin", nil)
+	req.Header.Set("Authorization", "Bearer ")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestWebhookRepository_CreateDelivery(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	now := time.Now()
+	delivery := &models.WebhookDelivery{Endpoint: "http://hook", EventID: "evt_1", EventType: "contact.submitted", SubmissionID: 3}
+	mock.ExpectQuery(`INSERT INTO webhook_deliveries`).
+		WithArgs("http://hook", "evt_1", "contact.submitted", int64(3), models.WebhookDeliveryPending).
+		WillReturnRows(pgxmock.NewRows([]string{"id", "next_attempt_at", "created_at", "updated_at"}).AddRow(int64(5), &now, now, now))
+
+	repo := repository.NewWebhookRepository(mock)
+	err = repo.CreateDelivery(context.Background(), delivery)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), delivery.ID)
+	assert.Equal(t, now, *delivery.NextAttemptAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWebhookRepository_ClaimDueDeliveries(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	now := time.Now()
+	columns := []string{"id", "endpoint", "event_id", "event_type", "submission_id", "status", "attempts",
+		"last_status_code", "last_error", "next_attempt_at", "created_at", "updated_at", "delivered_at"}
+	mock.ExpectQuery(`UPDATE webhook_deliveries\s+SET next_attempt_at = NOW\(\) \+ \$2 \* INTERVAL '1 second'.*FOR UPDATE SKIP LOCKED`).
+		WithArgs(20, 70.0).
+		WillReturnRows(pgxmock.NewRows(columns).
+			AddRow(int64(5), "http://hook", "evt_1", "contact.submitted", int64(3), "pending", 2, nil, nil, &now, now, now, nil))
+
+	deliveries, err := repository.NewWebhookRepository(mock).ClaimDueDeliveries(context.Background(), 20, 70*time.Second)
+
+	assert.NoError(t, err)
+	assert.Len(t, deliveries, 1)
+	assert.Equal(t, 2, deliveries[0].Attempts)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWebhookRepository_ClaimDelivery(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	now := time.Now()
+	columns := []string{"id", "endpoint", "event_id", "event_type", "submission_id", "status", "attempts",
+		"last_status_code", "last_error", "next_attempt_at", "created_at", "updated_at", "delivered_at"}
+	claim := `UPDATE webhook_deliveries\s+SET next_attempt_at = NOW\(\) \+ \$2 \* INTERVAL '1 second'\s+WHERE id = \$1 AND \(status <> 'pending' OR next_attempt_at <= NOW\(\)\)`
+	mock.ExpectQuery(claim).
+		WithArgs(int64(5), 70.0).
+		WillReturnRows(pgxmock.NewRows(columns).
+			AddRow(int64(5), "http://hook", "evt_1", "contact.submitted", int64(3), "failed", 3, nil, nil, &now, now, now, nil))
+	// a pending delivery that is not due is leased by a worker
+	mock.ExpectQuery(claim).
+		WithArgs(int64(6), 70.0).
+		WillReturnError(pgx.ErrNoRows)
+	mock.ExpectQuery(`SELECT .* FROM webhook_deliveries WHERE id = \$1`).
+		WithArgs(int64(6)).
+		WillReturnRows(pgxmock.NewRows(columns).
+			AddRow(int64(6), "http://hook", "evt_2", "contact.submitted", int64(3), "pending", 1, nil, nil, &now, now, now, nil))
+
+	repo := repository.NewWebhookRepository(mock)
+	d, err := repo.ClaimDelivery(context.Background(), 5, 70*time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, "failed", d.Status)
+	_, err = repo.ClaimDelivery(context.Background(), 6, 70*time.Second)
+	assert.ErrorIs(t, err, repository.ErrDeliveryLeased)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
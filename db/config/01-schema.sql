@@ -7,16 +7,441 @@
 -- -----------------------------------------------------
 -- Table for contact form submissions
 -- -----------------------------------------------------
+-- Field limits are enforced by the backend (internal/models/contact.go).
+-- name, email and message are TEXT because they hold base64 AES-GCM
+-- ciphertext when ENCRYPTION_KEYS is set.
 CREATE TABLE IF NOT EXISTS contact_submissions (
     id         SERIAL PRIMARY KEY,
-    name       VARCHAR(255) NOT NULL,
-    email      VARCHAR(255) NOT NULL,
-    subject    TEXT NOT NULL,
+    name       TEXT NOT NULL,
+    email      TEXT NOT NULL,
+    subject    VARCHAR(32) NOT NULL,
     message    TEXT NOT NULL,
-    
+
+    -- Envelope encryption: the row's data key, wrapped with key version
+    -- key_version. Both are NULL for rows stored in clear.
+    key_version SMALLINT,
+    data_key    BYTEA,
+
+    -- Blind index (keyed HMAC) of the lowercased email, for lookups on encrypted rows
+    email_index CHAR(64),
+
+    -- SHA-256 of email + subject + message, used to drop duplicate submissions
+    -- (keyed with the index key when encryption is enabled)
+    dedupe_hash CHAR(64),
+
+    -- Set by the routing rules (low, normal, high, urgent) and free-form labels
+    priority   VARCHAR(16) NOT NULL DEFAULT 'normal',
+    tags       TEXT[] NOT NULL DEFAULT '{}',
+
+    -- Admin inbox lifecycle; trashed rows are purged after CONTACT_PURGE_AFTER
+    status     VARCHAR(16) NOT NULL DEFAULT 'new'
+        CHECK (status IN ('new', 'read', 'replied', 'archived', 'spam', 'trashed')),
+    assignee   VARCHAR(100),
+    read_at     TIMESTAMPTZ,
+    replied_at  TIMESTAMPTZ,
+    archived_at TIMESTAMPTZ,
+    deleted_at  TIMESTAMPTZ,
+
+    -- Request metadata: the client IP is only stored salted and hashed or
+    -- truncated to its network (CLIENT_IP_MODE). Referrer is the form page,
+    -- UTM parameters come from the visitor's landing page.
+    ip_hash         VARCHAR(64) NOT NULL DEFAULT '',
+    ip_prefix       VARCHAR(43) NOT NULL DEFAULT '',
+    user_agent      VARCHAR(512) NOT NULL DEFAULT '',
+    accept_language VARCHAR(100) NOT NULL DEFAULT '',
+    referrer        VARCHAR(500) NOT NULL DEFAULT '',
+
+    -- GeoIP location of the client IP, looked up before it is anonymized
+    country         VARCHAR(2) NOT NULL DEFAULT '',
+    asn             BIGINT NOT NULL DEFAULT 0,
+    as_org          VARCHAR(200) NOT NULL DEFAULT '',
+    utm_source      VARCHAR(100) NOT NULL DEFAULT '',
+    utm_medium      VARCHAR(100) NOT NULL DEFAULT '',
+    utm_campaign    VARCHAR(100) NOT NULL DEFAULT '',
+    utm_term        VARCHAR(100) NOT NULL DEFAULT '',
+    utm_content     VARCHAR(100) NOT NULL DEFAULT '',
+
+    -- Set when personal fields were overwritten (erasure request or retention policy)
+    anonymized_at TIMESTAMPTZ,
+
     -- Creation date is automatically added
-    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    -- Full-text search document: sender (unstemmed), subject and message
+    -- stemmed in French and English so either language finds its inflections.
+    -- Ciphertext is not indexed: encrypted rows are searched by the backend.
+    search_vector tsvector GENERATED ALWAYS AS (
+        CASE WHEN data_key IS NULL THEN
+            setweight(to_tsvector('simple', name || ' ' || email), 'A') ||
+            setweight(to_tsvector('french', subject) || to_tsvector('english', subject), 'B') ||
+            setweight(to_tsvector('french', message) || to_tsvector('english', message), 'C')
+        ELSE
+            setweight(to_tsvector('french', subject) || to_tsvector('english', subject), 'B')
+        END
+    ) STORED
 );
 
 -- Creating an index on email can be useful if you want to search contacts
 CREATE INDEX IF NOT EXISTS idx_contact_email ON contact_submissions(email);
+
+CREATE INDEX IF NOT EXISTS idx_contact_email_index ON contact_submissions(email_index);
+
+CREATE INDEX IF NOT EXISTS idx_contact_ip_hash ON contact_submissions(ip_hash, created_at);
+
+CREATE INDEX IF NOT EXISTS idx_contact_status ON contact_submissions(status, created_at DESC);
+
+CREATE INDEX IF NOT EXISTS idx_contact_search ON contact_submissions USING GIN (search_vector);
+
+-- -----------------------------------------------------
+-- Duplicate submission claims: a row per dedupe_hash seen within
+-- CONTACT_DEDUPE_WINDOW, taken in the same statement as the insert so
+-- concurrent duplicates store a single submission. Expired rows are
+-- removed by later inserts.
+-- -----------------------------------------------------
+CREATE TABLE IF NOT EXISTS contact_dedupe (
+    dedupe_hash CHAR(64) PRIMARY KEY,
+    expires_at  TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_contact_dedupe_expires ON contact_dedupe(expires_at);
+
+-- -----------------------------------------------------
+-- Internal admin notes on submissions
+-- -----------------------------------------------------
+CREATE TABLE IF NOT EXISTS contact_notes (
+    id            BIGSERIAL PRIMARY KEY,
+    submission_id INTEGER NOT NULL REFERENCES contact_submissions(id) ON DELETE CASCADE,
+    author        VARCHAR(100) NOT NULL,
+    body          TEXT NOT NULL,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_contact_notes_submission ON contact_notes(submission_id, created_at);
+
+-- -----------------------------------------------------
+-- Responses stored for requests sent with an Idempotency-Key header
+-- -----------------------------------------------------
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    key           VARCHAR(255) PRIMARY KEY,
+    request_hash  CHAR(64) NOT NULL,
+
+    -- NULL while the first request is still being processed
+    status_code   INTEGER,
+    response_body BYTEA,
+
+    -- Lease of the request in progress: past it, a retry takes the key over
+    locked_until  TIMESTAMPTZ,
+
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_created_at ON idempotency_keys(created_at);
+
+-- -----------------------------------------------------
+-- Outbound webhook delivery log (one row per event and endpoint). Only the
+-- submission id is kept: the payload is built from it at each attempt.
+-- -----------------------------------------------------
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id               BIGSERIAL PRIMARY KEY,
+    endpoint         TEXT NOT NULL,
+    event_id         VARCHAR(64) NOT NULL,
+    event_type       VARCHAR(64) NOT NULL,
+    submission_id    INTEGER NOT NULL REFERENCES contact_submissions(id) ON DELETE CASCADE,
+
+    -- pending, succeeded or failed
+    status           VARCHAR(16) NOT NULL DEFAULT 'pending',
+    attempts         INTEGER NOT NULL DEFAULT 0,
+    last_status_code INTEGER,
+    last_error       TEXT,
+
+    -- Due time of the next attempt of a pending delivery; pushed forward
+    -- while an attempt is in flight, so a crashed worker's delivery is retried
+    next_attempt_at  TIMESTAMPTZ,
+
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    delivered_at     TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due ON webhook_deliveries(next_attempt_at) WHERE status = 'pending';
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_endpoint ON webhook_deliveries(endpoint, created_at DESC);
+
+-- -----------------------------------------------------
+-- Routing rules for contact notifications (used when ROUTING_RULES_FILE is not set)
+-- Every non-empty criterion must match; the enabled rule with the lowest position wins.
+-- -----------------------------------------------------
+CREATE TABLE IF NOT EXISTS routing_rules (
+    id             SERIAL PRIMARY KEY,
+    name           VARCHAR(100) NOT NULL,
+    position       INTEGER NOT NULL DEFAULT 0,
+    enabled        BOOLEAN NOT NULL DEFAULT TRUE,
+
+    -- Criteria
+    subjects       TEXT[] NOT NULL DEFAULT '{}',
+    keywords       TEXT[] NOT NULL DEFAULT '{}',
+    sender_domains TEXT[] NOT NULL DEFAULT '{}',
+    countries      TEXT[] NOT NULL DEFAULT '{}',   -- ISO codes of the client IP (GeoIP)
+    asns           BIGINT[] NOT NULL DEFAULT '{}', -- autonomous systems of the client IP (GeoIP)
+
+    -- Actions
+    recipients     TEXT[] NOT NULL DEFAULT '{}',
+    priority       VARCHAR(16),
+    tags           TEXT[] NOT NULL DEFAULT '{}',
+    channels       TEXT[] NOT NULL DEFAULT '{}',
+    suppress       BOOLEAN NOT NULL DEFAULT FALSE,
+
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at     TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- -----------------------------------------------------
+-- Emails exchanged about a submission (admin replies and visitor answers)
+-- -----------------------------------------------------
+-- Addresses, subject and body are TEXT because they hold base64 AES-GCM
+-- ciphertext when ENCRYPTION_KEYS is set.
+CREATE TABLE IF NOT EXISTS contact_messages (
+    id            BIGSERIAL PRIMARY KEY,
+    submission_id INTEGER NOT NULL REFERENCES contact_submissions(id) ON DELETE CASCADE,
+    direction     VARCHAR(8) NOT NULL CHECK (direction IN ('outbound', 'inbound')),
+
+    -- Threading headers, with the angle brackets
+    message_id    VARCHAR(255) NOT NULL UNIQUE,
+    in_reply_to   VARCHAR(255),
+    refs          TEXT[] NOT NULL DEFAULT '{}',
+
+    from_address  TEXT NOT NULL,
+    to_address    TEXT NOT NULL,
+    subject       TEXT NOT NULL,
+    body          TEXT NOT NULL,
+    author        VARCHAR(100) NOT NULL DEFAULT '',
+
+    -- Envelope encryption, as in contact_submissions
+    key_version   SMALLINT,
+    data_key      BYTEA,
+
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_contact_messages_submission ON contact_messages(submission_id, created_at);
+
+-- -----------------------------------------------------
+-- "Request my data" verifications; only the SHA-256 of the emailed token is kept
+-- -----------------------------------------------------
+-- The email is encrypted and looked up by its blind index when
+-- ENCRYPTION_KEYS is set, as in contact_submissions.
+CREATE TABLE IF NOT EXISTS privacy_requests (
+    id          BIGSERIAL PRIMARY KEY,
+    email       TEXT NOT NULL,
+    email_index CHAR(64),
+    key_version SMALLINT,
+    data_key    BYTEA,
+    token_hash  CHAR(64) NOT NULL UNIQUE,
+    expires_at  TIMESTAMPTZ NOT NULL,
+    used_at     TIMESTAMPTZ,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_privacy_requests_email ON privacy_requests(lower(email), created_at);
+
+CREATE INDEX IF NOT EXISTS idx_privacy_requests_email_index ON privacy_requests(email_index, created_at);
+
+-- -----------------------------------------------------
+-- Privacy audit log: exports, erasures and retention runs. The subject is the
+-- HMAC-SHA256 of the lowercased email, keyed with PRIVACY_AUDIT_KEY, so the log
+-- itself holds no personal data.
+-- -----------------------------------------------------
+CREATE TABLE IF NOT EXISTS privacy_audit_log (
+    id           BIGSERIAL PRIMARY KEY,
+    action       VARCHAR(16) NOT NULL,
+    subject_hash CHAR(64),
+    actor        VARCHAR(100) NOT NULL,
+    affected     BIGINT NOT NULL DEFAULT 0,
+    detail       TEXT,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_privacy_audit_created ON privacy_audit_log(created_at DESC);
+
+-- -----------------------------------------------------
+-- Blocklist: deny rules managed by admins and automatic temporary bans.
+-- Matching requests get a normal success answer and are dropped.
+-- -----------------------------------------------------
+CREATE TABLE IF NOT EXISTS block_rules (
+    id          BIGSERIAL PRIMARY KEY,
+    kind        VARCHAR(16) NOT NULL
+        CHECK (kind IN ('ip', 'email', 'domain', 'keyword', 'regex')),
+    value       VARCHAR(500) NOT NULL,
+    reason      VARCHAR(200) NOT NULL DEFAULT '',
+    source      VARCHAR(16) NOT NULL DEFAULT 'admin' CHECK (source IN ('admin', 'auto')),
+    expires_at  TIMESTAMPTZ,
+    hits        BIGINT NOT NULL DEFAULT 0,
+    last_hit_at TIMESTAMPTZ,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (kind, value)
+);
+
+CREATE INDEX IF NOT EXISTS idx_block_rules_expires ON block_rules(expires_at);
+
+-- -----------------------------------------------------
+-- Portfolio projects and their technology tags. Only projects with a
+-- published_at in the past are served by the public API.
+-- -----------------------------------------------------
+CREATE TABLE IF NOT EXISTS projects (
+    id           BIGSERIAL PRIMARY KEY,
+    slug         VARCHAR(100) NOT NULL UNIQUE,
+    title        VARCHAR(200) NOT NULL,
+    summary      VARCHAR(1000) NOT NULL DEFAULT '',
+    body         TEXT NOT NULL DEFAULT '',
+    category     VARCHAR(32) NOT NULL
+        CHECK (category IN ('infrastructure', 'web', 'system', 'iot')),
+    badge        VARCHAR(100) NOT NULL DEFAULT '',
+    icon         VARCHAR(100) NOT NULL DEFAULT '',
+    position     INTEGER NOT NULL DEFAULT 0,
+    version      INTEGER NOT NULL DEFAULT 1,
+    published_at TIMESTAMPTZ,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_projects_published ON projects(published_at);
+
+CREATE TABLE IF NOT EXISTS technologies (
+    id   BIGSERIAL PRIMARY KEY,
+    slug VARCHAR(50) NOT NULL UNIQUE,
+    name VARCHAR(100) NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS project_technologies (
+    project_id    BIGINT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    technology_id BIGINT NOT NULL REFERENCES technologies(id) ON DELETE CASCADE,
+    position      INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (project_id, technology_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_project_technologies_technology ON project_technologies(technology_id);
+
+CREATE TABLE IF NOT EXISTS project_links (
+    id         BIGSERIAL PRIMARY KEY,
+    project_id BIGINT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    label      VARCHAR(100) NOT NULL,
+    url        VARCHAR(2000) NOT NULL,
+    icon       VARCHAR(100) NOT NULL DEFAULT '',
+    position   INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_project_links_project ON project_links(project_id, position);
+
+CREATE TABLE IF NOT EXISTS project_images (
+    id         BIGSERIAL PRIMARY KEY,
+    project_id BIGINT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    url        VARCHAR(2000) NOT NULL,
+    alt        VARCHAR(300) NOT NULL,
+    caption    VARCHAR(500) NOT NULL DEFAULT '',
+    position   INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_project_images_project ON project_images(project_id, position);
+
+-- -----------------------------------------------------
+-- Articles (homelab write-ups) as Markdown with a YAML front matter
+-- (title, summary, tags, date, draft). Read when ARTICLES_DIR is unset.
+-- -----------------------------------------------------
+CREATE TABLE IF NOT EXISTS articles (
+    id         BIGSERIAL PRIMARY KEY,
+    slug       VARCHAR(100) NOT NULL UNIQUE,
+    markdown   TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- -----------------------------------------------------
+-- Content revisions: an immutable snapshot of a project (YAML) or article
+-- (Markdown) stored on every save, numbered per content item
+-- -----------------------------------------------------
+CREATE TABLE IF NOT EXISTS content_revisions (
+    id           BIGSERIAL PRIMARY KEY,
+    content_type VARCHAR(20) NOT NULL,
+    content_key  VARCHAR(100) NOT NULL, -- project id or article slug
+    number       INTEGER NOT NULL,
+    body         TEXT NOT NULL,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (content_type, content_key, number)
+);
+
+-- -----------------------------------------------------
+-- Scheduled publications and unpublications, run by the content scheduler
+-- -----------------------------------------------------
+CREATE TABLE IF NOT EXISTS content_schedules (
+    id            BIGSERIAL PRIMARY KEY,
+    content_type  VARCHAR(20) NOT NULL,
+    content_key   VARCHAR(100) NOT NULL,
+    action        VARCHAR(20) NOT NULL CHECK (action IN ('publish', 'unpublish')),
+    run_at        TIMESTAMPTZ NOT NULL,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    done_at       TIMESTAMPTZ, -- set once the action succeeded
+    failed_at     TIMESTAMPTZ, -- set once the last allowed attempt failed
+    attempts      INTEGER NOT NULL DEFAULT 0,
+    claimed_until TIMESTAMPTZ, -- lease of the running worker, or time of the next retry
+    error         TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_content_schedules_pending ON content_schedules (run_at) WHERE done_at IS NULL AND failed_at IS NULL;
+
+-- -----------------------------------------------------
+-- Media library: uploaded files, stored in MEDIA_DIR under the SHA-256 of
+-- their content (metadata removed), with resized variants for srcset
+-- -----------------------------------------------------
+CREATE TABLE IF NOT EXISTS media (
+    id           BIGSERIAL PRIMARY KEY,
+    sha256       CHAR(64) NOT NULL UNIQUE,
+    content_type VARCHAR(50) NOT NULL,
+    size         BIGINT NOT NULL,
+    width        INTEGER NOT NULL DEFAULT 0,
+    height       INTEGER NOT NULL DEFAULT 0,
+    filename     VARCHAR(255) NOT NULL DEFAULT '',
+    name         VARCHAR(100) NOT NULL,
+    variants     JSONB NOT NULL DEFAULT '[]',
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- -----------------------------------------------------
+-- Profile: the résumé as a JSON Resume document (jsonresume.org), source of
+-- the about page, GET /api/v1/profile and the generated CV PDF. A single row.
+-- -----------------------------------------------------
+CREATE TABLE IF NOT EXISTS profile (
+    id         SMALLINT PRIMARY KEY DEFAULT 1 CHECK (id = 1),
+    resume     JSONB NOT NULL,
+    version    INTEGER NOT NULL DEFAULT 1,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- -----------------------------------------------------
+-- Share links: short links (/s/<code>) given to one recipient, redirecting
+-- to the CV or a page. Hits keep coarse client information only: no IP,
+-- cookie or full user agent.
+-- -----------------------------------------------------
+CREATE TABLE IF NOT EXISTS share_links (
+    id         BIGSERIAL PRIMARY KEY,
+    code       TEXT NOT NULL UNIQUE,
+    target     TEXT NOT NULL,
+    label      TEXT NOT NULL DEFAULT '',
+    expires_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS share_hits (
+    id         BIGSERIAL PRIMARY KEY,
+    link_id    BIGINT NOT NULL REFERENCES share_links(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    referrer   TEXT NOT NULL DEFAULT '',
+    browser    TEXT NOT NULL DEFAULT '',
+    os         TEXT NOT NULL DEFAULT '',
+    device     TEXT NOT NULL DEFAULT '',
+    country    TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_share_hits_link ON share_hits(link_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_share_hits_created ON share_hits(created_at);
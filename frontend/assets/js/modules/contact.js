@@ -47,6 +47,10 @@ const contactModule = {
       const subjectInput = form.querySelector("#subject");
       const messageInput = form.querySelector("#message");
 
+      // One key per message: retries and double clicks reuse it so the
+      // backend replays the first response instead of storing a duplicate
+      let idempotencyKey = null;
+
       form.addEventListener("submit", async (e) => {
         e.preventDefault();
 
@@ -62,6 +66,10 @@ const contactModule = {
           submitBtn.classList.add("opacity-60", "cursor-not-allowed");
         }
 
+        if (!idempotencyKey) {
+          idempotencyKey = newIdempotencyKey();
+        }
+
         const payload = {
           name: nameInput.value.trim(),
           email: emailInput.value.trim(),
@@ -74,6 +82,7 @@ const contactModule = {
             method: "POST",
             headers: {
               "Content-Type": "application/json",
+              "Idempotency-Key": idempotencyKey,
             },
             body: JSON.stringify(payload),
           });
@@ -89,6 +98,7 @@ const contactModule = {
           } else {
             showToast("Message envoyé avec succès !", "success");
             form.reset();
+            idempotencyKey = null;
           }
         } catch (err) {
           console.error("Contact submit error:", err);
@@ -106,6 +116,13 @@ const contactModule = {
   },
 };
 
+function newIdempotencyKey() {
+  if (globalThis.crypto && typeof globalThis.crypto.randomUUID === "function") {
+    return globalThis.crypto.randomUUID();
+  }
+  return `${Date.now().toString(36)}-${Math.random().toString(36).slice(2)}`;
+}
+
 function showToast(message, type = "info") {
   // minimal toast: use alert() fallback for simplicity
   try {